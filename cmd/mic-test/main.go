@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"time"
+
+	"speek_to_text_linux/internal/toolpath"
+	"speek_to_text_linux/pkg/wav"
 )
 
 // AudioRecorder provides simple microphone testing
@@ -46,13 +51,13 @@ func (r *AudioRecorder) Record(duration time.Duration, outputFile string) error
 	log.Println("\n📋 Checking recording tools...")
 
 	// Try arecord (ALSA)
-	if r.isToolAvailable("arecord") {
+	if toolpath.Available("arecord") {
 		log.Println("   ✓ Using arecord (ALSA)")
 		return r.recordWithArecord(duration, outputFile)
 	}
 
 	// Try rec (sox)
-	if r.isToolAvailable("rec") {
+	if toolpath.Available("rec") {
 		log.Println("   ✓ Using rec (sox)")
 		return r.recordWithRec(duration, outputFile)
 	}
@@ -148,7 +153,7 @@ func (r *AudioRecorder) recordWithPactl(duration time.Duration, outputFile strin
 func (r *AudioRecorder) Play(file string) error {
 	log.Printf("\n🔊 Playing audio file: %s", file)
 
-	if !r.isToolAvailable("aplay") {
+	if !toolpath.Available("aplay") {
 		log.Println("   ✗ aplay not found. Install: sudo apt install alsa-utils")
 		return fmt.Errorf("aplay not available")
 	}
@@ -174,30 +179,45 @@ func (r *AudioRecorder) Info(file string) error {
 	log.Printf("   Path: %s", file)
 	log.Printf("   Size: %d bytes (%.2f KB)", info.Size(), float64(info.Size())/1024)
 
-	if r.isToolAvailable("file") {
-		cmd := exec.Command("file", file)
-		output, _ := cmd.Output()
-		log.Printf("   Type: %s", string(output))
+	f, err := os.Open(file)
+	if err != nil {
+		return err
 	}
-
-	if r.isToolAvailable("sox") {
-		cmd := exec.Command("sox", "--i", file)
-		output, _ := cmd.Output()
-		log.Printf("   Details:\n%s", string(output))
+	defer f.Close()
+	w, err := wav.Read(bufio.NewReader(f))
+	if err != nil {
+		log.Printf("   ⚠️  Not a readable PCM WAV file: %v", err)
+		return err
+	}
+	log.Printf("   Format: %d Hz, %d channel(s), %d-bit", w.SampleRate, w.Channels, w.BitsPerSample)
+	log.Printf("   Duration: %.2fs", w.Duration().Seconds())
+	if w.BitsPerSample == 16 {
+		log.Printf("   Peak level: %.0f%%", peakLevel(w.Data)*100)
 	}
 
 	return nil
 }
 
-func (r *AudioRecorder) isToolAvailable(name string) bool {
-	_, err := exec.LookPath(name)
-	return err == nil
+// peakLevel returns the loudest 16-bit sample as a fraction of full scale,
+// near 0 for a muted or disconnected microphone
+func peakLevel(pcm []byte) float64 {
+	peak := 0
+	for i := 0; i+1 < len(pcm); i += 2 {
+		sample := int(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		if sample < 0 {
+			sample = -sample
+		}
+		if sample > peak {
+			peak = sample
+		}
+	}
+	return float64(peak) / 32768
 }
 
 func (r *AudioRecorder) ListDevices() error {
 	log.Println("\n🎛️  Available audio input devices:")
 
-	if r.isToolAvailable("arecord") {
+	if toolpath.Available("arecord") {
 		cmd := exec.Command("arecord", "-l")
 		output, err := cmd.CombinedOutput()
 		if err == nil {
@@ -206,7 +226,7 @@ func (r *AudioRecorder) ListDevices() error {
 		}
 	}
 
-	if r.isToolAvailable("aplay") {
+	if toolpath.Available("aplay") {
 		cmd := exec.Command("aplay", "-l")
 		output, err := cmd.CombinedOutput()
 		if err == nil {
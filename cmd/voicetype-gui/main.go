@@ -20,15 +20,32 @@ import (
 	"io"
 	"speek_to_text_linux/internal/api"
 	"speek_to_text_linux/internal/audio"
+	"speek_to_text_linux/internal/dataset"
+	"speek_to_text_linux/internal/diagnostics"
+	"speek_to_text_linux/internal/history"
 	"speek_to_text_linux/internal/hotkey"
+	"speek_to_text_linux/internal/journal"
+	"speek_to_text_linux/internal/logger"
+	"speek_to_text_linux/internal/metrics"
+	"speek_to_text_linux/internal/notify"
+	"speek_to_text_linux/internal/pidfile"
+	"speek_to_text_linux/internal/postprocess"
+	"speek_to_text_linux/internal/retention"
+	"speek_to_text_linux/internal/sound"
+	"speek_to_text_linux/internal/terminal"
+	"speek_to_text_linux/internal/toolpath"
+	"speek_to_text_linux/internal/tts"
 	"speek_to_text_linux/internal/typing"
 	"speek_to_text_linux/internal/ui"
 	"speek_to_text_linux/pkg/config"
+	"speek_to_text_linux/pkg/errors"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -39,31 +56,60 @@ var version = "1.0.0"
 const configFile = ".voicetype.conf"
 
 type VoiceTypeApp struct {
-	a            fyne.App
-	cfg          *config.Config
-	audioSys     *audio.System
-	apiClient    *api.Client
-	typer        *typing.System
-	hotkey       *hotkey.Listener
-	ctx          context.Context
-	cancel       context.CancelFunc
-	isRecording  bool
-	mu           sync.Mutex
-	window       fyne.Window
-	pillBg       *canvas.Rectangle
-	glowLayers   []*canvas.Rectangle // Kept for logic compatibility but will be empty/ignored
-	waveBars     []*canvas.Rectangle
-	status       *canvas.Text
-	anim         *fyne.Animation
-	pulseAnim    *fyne.Animation
-	running      bool
-	lastToggle   time.Time
-	winTitle     string
-	isProcessing bool
-	statusIcon   *canvas.Image
-	smoothLevel  float64
-	winPosX      int
-	winPosY      int
+	a          fyne.App
+	cfg        *config.Config
+	audioSys   *audio.System
+	apiClient  *api.Client
+	typer      *typing.System
+	hotkey     *hotkey.Listener
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+	window     fyne.Window
+	pillBg     *canvas.Rectangle
+	glowLayers []*canvas.Rectangle // Kept for logic compatibility but will be empty/ignored
+	waveBars   []*canvas.Rectangle
+	status     *canvas.Text
+	anim       *fyne.Animation
+	pulseAnim  *fyne.Animation
+	running    bool
+	gate       *hotkey.Gate
+	winTitle   string
+	session    ui.Session
+	statusIcon *canvas.Image
+	winPosX    int
+	winPosY    int
+	fader      ui.Fader
+	metrics    *metrics.Store
+	notifier   *notify.Notifier
+	sound      *sound.Player
+	watchdog   *audio.Watchdog
+	journal    *journal.Journal
+	dataset    *dataset.Writer
+	history    *history.History
+	recording  string // history file of the recording being transcribed
+	index      *history.Store
+	actionFile string
+	pid        *pidfile.File
+	language   string // per-recording language override from the hotkey action
+	recordFor  int    // per-recording --record-seconds from the hotkey action
+	focusBack  bool   // pill already hidden and focus handed back this processing run
+	retryModel string // model for a --retry-model re-transcription in flight
+	labels     *postprocess.LabelStripper
+	emoji      *postprocess.EmojiReplacer
+	focus      ui.FocusSnapshot
+	stereo     []byte // last multi-channel recording; the API client only keeps one channel
+	upload     *pendingUpload
+	inflight   ui.InFlight // transcription/typing goroutines shutdown waits for
+	shortRetry *ui.ShortRetry
+}
+
+// pendingUpload is a transcription streaming up while recording (stream_upload)
+type pendingUpload struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	text   string
+	err    error
 }
 
 type draggableBackground struct {
@@ -102,13 +148,28 @@ func (d *draggableBackground) TappedSecondary(e *fyne.PointEvent) {
 }
 
 func main() {
-	initLogger()
 	flagHelp := flag.Bool("help", false, "Show help")
 	flagDevice := flag.String("device", "", "Audio device")
 	flagToggle := flag.Bool("toggle", false, "Toggle recording on a running instance")
 	flagStop := flag.Bool("stop", false, "Stop a running instance")
+	flagRetryModel := flag.String("retry-model", "", "Re-transcribe the running instance's last recording with this model and type it again")
+	flagToggleRaw := flag.Bool("toggle-raw", false, "Toggle verbatim (raw) transcription on a running instance")
+	flagLanguage := flag.String("language", "", "Transcription language for this launch or toggle (e.g. en, es), overriding the config")
 	flagNoReturn := flag.Bool("no-return", false, "Don't press Enter after typing")
 	flagSettings := flag.Bool("settings", false, "Show settings window")
+	flagLogs := flag.Bool("logs", false, "Show the debug log viewer")
+	flagHistory := flag.Bool("history", false, "Show past transcriptions, to search, copy or replay them")
+	flagDoctor := flag.Bool("doctor", false, "Check the environment and print a diagnostic report")
+	flagStats := flag.Bool("stats", false, "Print local success/error counters")
+	flagHotkeyDebug := flag.Bool("hotkey-debug", false, "Print every hotkey key-state change and fire event to stderr without recording")
+	flagCalibrate := flag.Bool("calibrate", false, "Record a few seconds of room noise and save its level so auto-stop adapts to it")
+	flagDumpSchema := flag.Bool("dump-schema", false, "Print the JSON schema of config.json (keys, types, defaults)")
+	flagDatasetDir := flag.String("dataset-dir", "", "Save each recording and its transcription as NNNN.wav/NNNN.txt in this directory")
+	flagTeeFIFO := flag.String("tee-fifo", "", "Also write the live capture as raw PCM to this named pipe (created if missing) for other tools")
+	flagRecordSeconds := flag.Int("record-seconds", 0, "Record for exactly N seconds, then transcribe and type; also sent to a running instance's toggle")
+	var flagSets config.Sets
+	flag.Var(&flagSets, "set", "Override a config key for this launch only, e.g. --set language=es (repeatable)")
+	flagConfigJSON := flag.String("config-json", "", "Override config keys for this launch only from a JSON object, e.g. '{\"auto_return\": true}'")
 	flag.Parse()
 
 	if *flagHelp {
@@ -117,18 +178,94 @@ func main() {
 		os.Exit(0)
 	}
 
-	cfg, _ := config.Load()
+	if *flagDumpSchema {
+		data, err := config.GenerateSchema().JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate schema: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		os.Exit(0)
+	}
 
-	if *flagSettings {
-		apiKey := cfg.GROQ_API_KEY
-		if apiKey == "" {
-			apiKey = os.Getenv("GROQ_API_KEY")
+	// Before Load, which would move a corrupt config aside unreported
+	if *flagDoctor {
+		os.Exit(runDoctor(*flagConfigJSON, flagSets))
+	}
+
+	cfg, configErr := config.Load()
+	if err := cfg.ApplyOverrides(*flagConfigJSON, flagSets); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config override: %v\n", err)
+		os.Exit(2)
+	}
+	initLogger(cfg)
+	if configErr != nil {
+		log.Printf("WARNING: %v", configErr)
+	}
+
+	runDir, err := pidfile.RuntimeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "No private runtime directory: %v\n", err)
+		os.Exit(1)
+	}
+	pidFile := filepath.Join(runDir, "voicetype-gui.pid")
+	actionFile := filepath.Join(runDir, "voicetype-gui.action")
+
+	if *flagStats {
+		os.Exit(printStats())
+	}
+
+	if *flagHotkeyDebug {
+		os.Exit(runHotkeyDebug(cfg))
+	}
+
+	if *flagCalibrate {
+		os.Exit(runCalibrate(cfg, *flagDevice))
+	}
+
+	if *flagLogs {
+		app := &VoiceTypeApp{
+			a:   app.NewWithID("com.voicetype.app"),
+			cfg: cfg,
 		}
+		app.showLogWindow(true)
+		app.a.Run()
+		os.Exit(0)
+	}
+
+	if *flagHistory {
 		app := &VoiceTypeApp{
 			a:   app.NewWithID("com.voicetype.app"),
 			cfg: cfg,
 		}
-		app.audioSys = audio.NewSystem(nil)
+		app.showHistoryWindow(true)
+		app.a.Run()
+		os.Exit(0)
+	}
+
+	if *flagSettings {
+		// A running instance opens its own settings, so changes apply at once
+		// and two processes don't write the config
+		if process, ok := pidfile.Running(pidFile); ok {
+			if err := sendAction(process, actionFile, hotkey.Action{OpenSettings: true}); err == nil {
+				fmt.Println("Opened settings in running instance.")
+				os.Exit(0)
+			} else {
+				log.Printf("Could not reach the running instance: %v", err)
+			}
+		}
+		// Otherwise a process of its own, showing the saved config rather
+		// than this launch's overrides
+		saved, err := config.Load()
+		if err != nil && !errors.Is(err, errors.ErrConfigReset) {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		app := &VoiceTypeApp{
+			a:   app.NewWithID("com.voicetype.app"),
+			cfg: saved,
+		}
+		app.audioSys = audio.NewSystem(nil, cfg.CaptureBackend)
 		app.showSettingsWindow()
 		app.a.Run()
 		os.Exit(0)
@@ -136,27 +273,51 @@ func main() {
 
 	if *flagNoReturn {
 		cfg.AutoReturn = false
+		cfg.PostTypeKey = typing.PostKeyNone
 	}
 
-	pidFile := filepath.Join(os.TempDir(), "voicetype-gui.pid")
+	// Handle --toggle-raw by signalling the existing process; it has no effect on a new one
+	if *flagToggleRaw {
+		if process, ok := pidfile.Running(pidFile); ok && process.Signal(syscall.SIGUSR2) == nil {
+			fmt.Println("Sent raw-mode toggle to running instance.")
+			os.Exit(0)
+		}
+		fmt.Println("No running instance found.")
+		os.Exit(1)
+	}
+
+	// Handle --retry-model; only a running instance still holds the recording
+	if *flagRetryModel != "" {
+		if process, ok := pidfile.Running(pidFile); ok {
+			if err := sendAction(process, actionFile, hotkey.Action{RetryModel: *flagRetryModel}); err == nil {
+				fmt.Printf("Sent retry with %s to running instance.\n", *flagRetryModel)
+				os.Exit(0)
+			} else {
+				log.Printf("Could not reach the running instance: %v", err)
+			}
+		}
+		fmt.Println("No running instance found.")
+		os.Exit(1)
+	}
 
 	// Handle --toggle or --stop by sending signals to existing process
 	if *flagToggle || *flagStop {
-		data, err := os.ReadFile(pidFile)
-		if err == nil {
-			var pid int
-			fmt.Sscanf(string(data), "%d", &pid)
-			process, err := os.FindProcess(pid)
-			if err == nil {
-				if *flagToggle {
-					_ = process.Signal(syscall.SIGUSR1)
-					fmt.Println("Sent toggle signal to running instance.")
-				} else {
-					_ = process.Signal(syscall.SIGTERM)
-					fmt.Println("Sent stop signal to running instance.")
+		// A stale pid file from a crashed instance must not swallow the toggle
+		if process, ok := pidfile.Running(pidFile); ok {
+			if *flagToggle {
+				if err := sendAction(process, actionFile, hotkey.Action{Language: *flagLanguage, RecordSeconds: *flagRecordSeconds}); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to toggle the running instance: %v\n", err)
+					os.Exit(1)
 				}
-				os.Exit(0)
+				fmt.Println("Sent toggle signal to running instance.")
+			} else {
+				if err := process.Signal(syscall.SIGTERM); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to stop the running instance: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Println("Sent stop signal to running instance.")
 			}
+			os.Exit(0)
 		}
 		if *flagToggle {
 			fmt.Println("No running instance found. Starting new instance...")
@@ -167,75 +328,272 @@ func main() {
 	}
 
 	// Single instance check for main app
-	if _, err := os.Stat(pidFile); err == nil {
-		// Verify if process really exists
-		data, _ := os.ReadFile(pidFile)
-		var oldPid int
-		fmt.Sscanf(string(data), "%d", &oldPid)
-		if p, err := os.FindProcess(oldPid); err == nil && p.Signal(syscall.Signal(0)) == nil {
-			// Instead of just exiting, toggle the already running instance
-			_ = p.Signal(syscall.SIGUSR1)
-			fmt.Println("VoiceType is already running. Sent toggle signal.")
-			os.Exit(0)
+	if p, ok := pidfile.Running(pidFile); ok {
+		// Instead of just exiting, toggle the already running instance
+		if err := sendAction(p, actionFile, hotkey.Action{Language: *flagLanguage, RecordSeconds: *flagRecordSeconds}); err != nil {
+			fmt.Fprintf(os.Stderr, "VoiceType is already running, but toggling it failed: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Println("VoiceType is already running. Sent toggle signal.")
+		os.Exit(0)
+	}
+	pid, err := pidfile.Acquire(pidFile)
+	if err != nil {
+		log.Fatalf("Failed to write pid file: %v", err)
 	}
-	os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0644)
-	defer os.Remove(pidFile)
+	defer pid.Remove()
+	defer pid.Recover()
 
 	log.Println("VoiceType v" + version + " starting...")
 
-	cfg.AudioDevice = *flagDevice
 	if *flagNoReturn {
 		cfg.AutoReturn = false
+		cfg.PostTypeKey = typing.PostKeyNone
+	}
+	if *flagDatasetDir != "" {
+		cfg.DatasetDir = *flagDatasetDir
 	}
 	log.Printf("Config loaded: AutoReturn=%v", cfg.AutoReturn)
 
-	apiKey := loadAPIKey()
-	if apiKey == "" {
-		apiKey = askAPIKey()
-		saveAPIKey(apiKey)
+	provider, err := api.ParseProvider(cfg.Provider)
+	if err != nil {
+		log.Printf("%v, using %s", err, api.ProviderGroq)
+		provider = api.ProviderGroq
+	}
+	cfg.Provider = provider
+	// Only Groq needs a key; a local server gets no credentials
+	if provider == api.ProviderGroq {
+		apiKey := loadAPIKey()
+		if apiKey == "" {
+			apiKey = askAPIKey()
+			saveAPIKey(apiKey)
+		}
+		cfg.GROQ_API_KEY = apiKey
 	}
-	cfg.GROQ_API_KEY = apiKey
 
 	app := &VoiceTypeApp{
-		a:       app.NewWithID("com.voicetype.app"),
-		cfg:     cfg,
-		running: true,
+		a:          app.NewWithID("com.voicetype.app"),
+		cfg:        cfg,
+		running:    true,
+		actionFile: actionFile,
+		pid:        pid,
 	}
 
-	app.audioSys = audio.NewSystem(nil)
-	if err := app.audioSys.Initialize(cfg.AudioDevice); err != nil {
+	app.audioSys = audio.NewSystem(nil, cfg.CaptureBackend)
+	device := audio.ResolveDevice(*flagDevice, app.audioSys.GetDevices(), cfg.DeviceHistory, cfg.AudioDevice)
+	if err := app.audioSys.Initialize(device); err != nil {
+		// log.Fatalf skips deferred calls
+		pid.Remove()
 		log.Fatalf("Audio init failed: %v", err)
 	}
+	if *flagDevice != "" {
+		rememberDevice(cfg, app.audioSys.Device())
+	}
+	if source := audio.ResolvePulseSource(cfg.PulseSource, app.audioSys.Device(), audio.DefaultSource); source != "" {
+		app.audioSys.SetPulseSource(source)
+		log.Printf("Capturing from PulseAudio source %s", source)
+	}
+	if err := app.audioSys.SetCaptureFormat(cfg.CaptureFormat); err != nil {
+		log.Printf("%v, using %s", err, app.audioSys.CaptureFormat())
+	}
+	if err := app.audioSys.Configure(cfg.SampleRate, cfg.Channels, 16); err != nil {
+		log.Printf("%v, recording %d Hz mono", err, app.audioSys.SampleRate())
+	}
+	if err := app.audioSys.SetBufferSizes(cfg.PeriodSize, cfg.BufferSize); err != nil {
+		log.Printf("%v, using ALSA defaults", err)
+	}
+	if err := app.audioSys.ValidateDevice(); err != nil {
+		log.Printf("Capture device check failed: %v", err)
+	}
+	if *flagTeeFIFO != "" {
+		if fifo, err := audio.OpenFIFO(*flagTeeFIFO); err != nil {
+			log.Printf("Audio tee disabled: %v", err)
+		} else {
+			app.audioSys.SetTee(fifo)
+			log.Printf("Teeing live audio (S16_LE, %d Hz, %d ch) to %s", app.audioSys.SampleRate(), app.audioSys.Channels(), *flagTeeFIFO)
+		}
+	}
+	app.audioSys.SetPreroll(cfg.PrerollMs)
+	app.audioSys.SetMinDuration(time.Duration(cfg.MinRecordingMs) * time.Millisecond)
+	if err := app.audioSys.StartPreroll(); err != nil {
+		log.Printf("Pre-roll capture failed: %v", err)
+	}
+	if cfg.AutoStopSilenceMs > 0 {
+		silence := time.Duration(cfg.AutoStopSilenceMs) * time.Millisecond
+		app.audioSys.EnableAutoStop(audio.SilenceThreshold(cfg.AutoStopThreshold, cfg.NoiseFloor), silence)
+		app.audioSys.OnAutoStop(func() {
+			log.Printf("Silent for %v, stopping", silence)
+			app.stopRecording()
+		})
+	}
 
-	app.apiClient = api.NewClient(cfg.GROQ_API_KEY, nil)
+	app.apiClient, err = api.NewForProvider(cfg.Provider, cfg.GROQ_API_KEY, cfg.LocalURL, nil)
+	if err != nil {
+		// log.Fatalf skips deferred calls
+		pid.Remove()
+		log.Fatalf("API client setup failed: %v", err)
+	}
+	app.apiClient.SetLanguage(cfg.Language)
+	app.apiClient.SetTranslate(cfg.Translate)
+	app.apiClient.SetPrompt(cfg.TranscriptionPrompt)
+	app.apiClient.SetPromptOverrides(cfg.PromptOverrides)
+	app.apiClient.SetGlossary(cfg.Glossary)
+	app.apiClient.SetRetryPolicy(cfg.APIRetries, time.Duration(cfg.APIRetryDelayMs)*time.Millisecond)
+	app.apiClient.SetRetryTimeouts(cfg.APIRetryTimeouts)
+	app.apiClient.SetTimeout(time.Duration(cfg.RequestTimeoutSecs) * time.Second)
+	app.apiClient.SetMaxConcurrentRequests(cfg.MaxConcurrentReqs)
+	app.apiClient.SetVerbose(cfg.Verbose)
+	// transcribeChannels uploads each channel on its own
+	app.apiClient.SetAudioFormat(app.audioSys.SampleRate(), 1, app.audioSys.BitsPerSample())
+	app.apiClient.SetRaw(cfg.RawTranscription)
+	if cfg.LocalMetrics {
+		if path, err := metrics.DefaultPath(); err == nil {
+			app.metrics = metrics.NewStore(path)
+		}
+	}
+	if cfg.DatasetDir != "" {
+		app.dataset = dataset.NewWriter(cfg.DatasetDir)
+		app.dataset.SetMinFreeBytes(retention.MinFreeBytes(cfg.MinFreeSpaceMB))
+	}
+	if cfg.SaveRecordings {
+		app.history = history.New(cfg.HistoryDir)
+		app.history.SetMaxAge(time.Duration(cfg.HistoryMaxAgeDays) * 24 * time.Hour)
+		app.history.SetMinFreeBytes(retention.MinFreeBytes(cfg.MinFreeSpaceMB))
+		if err := app.history.Prune(); err != nil {
+			log.Printf("History prune failed: %v", err)
+		}
+		app.index = history.NewStore("")
+		if err := app.index.Prune(time.Duration(cfg.HistoryMaxAgeDays) * 24 * time.Hour); err != nil {
+			log.Printf("History prune failed: %v", err)
+		}
+	}
+	if cfg.JournalDir != "" {
+		app.journal = journal.New(cfg.JournalDir)
+		app.journal.SetRetention(retention.FromConfig(cfg))
+		app.journal.SetMinFreeBytes(retention.MinFreeBytes(cfg.MinFreeSpaceMB))
+		if err := app.journal.Prune(); err != nil {
+			log.Printf("Journal prune failed: %v", err)
+		}
+	}
+	app.notifier = notify.NewNotifier(nil)
+	app.notifier.SetRespectDND(cfg.RespectDND)
+	app.notifier.SetTranscriptFormat(cfg.NotifyMaxChars, cfg.NotifyShowCount)
+	app.sound = sound.NewPlayer()
+	app.sound.SetErrorSound(cfg.ErrorSound)
+	if !cfg.DisableNotifications {
+		if err := app.notifier.Initialize(); err != nil {
+			log.Printf("Notifications unavailable: %v", err)
+		}
+	}
+	if errors.Is(configErr, errors.ErrConfigReset) {
+		if err := app.notifier.Notify("VoiceType", configResetMessage); err != nil {
+			log.Printf("Notification failed: %v", err)
+		}
+	}
+	if cfg.StripLabels {
+		labels, err := postprocess.NewLabelStripper(cfg.LabelPatterns)
+		if err != nil {
+			log.Printf("Invalid label pattern: %v, using the built-in patterns", err)
+			labels = postprocess.DefaultLabelStripper()
+		}
+		app.labels = labels
+	}
+	if cfg.EmojiShortcodes {
+		app.emoji = postprocess.NewEmojiReplacer(cfg.EmojiMap)
+	}
 	app.typer = typing.NewSystem()
+	app.typer.SetTypeDelay("xdotool", cfg.XdotoolTypeDelayMs)
+	app.typer.SetTypeDelay("ydotool", cfg.YdotoolTypeDelayMs)
+	app.typer.SetTypeDelay("wtype", cfg.WtypeTypeDelayMs)
+	if err := app.typer.SetReplacements(cfg.Replacements, cfg.AppReplacements); err != nil {
+		log.Printf("Invalid replacements, skipping them: %v", err)
+	}
+	app.typer.OnManualPaste(func() {
+		if err := app.notifier.NotifyError("VoiceType", "Couldn't type the text. It's on the clipboard: paste it manually."); err != nil {
+			log.Printf("Notification failed: %v", err)
+		}
+	})
 	app.hotkey = hotkey.NewListener(nil)
+	// The listener gets the same window, so neither drops what the other allows
+	app.gate = hotkey.NewGate(time.Duration(cfg.ToggleDebounceMs)*time.Millisecond, time.Duration(cfg.CooldownMs)*time.Millisecond)
+	mode, err := hotkey.ParseMode(cfg.HotkeyMode)
+	if err != nil {
+		log.Printf("%v, using %s", err, hotkey.ModeToggle)
+		mode = hotkey.ModeToggle
+	}
+	// In push-to-talk the key is already up, so nothing would stop a retry
+	if cfg.RetryOnShort && mode != hotkey.ModePushToTalk {
+		app.shortRetry = ui.NewShortRetry(cfg.RetryOnShortMax)
+	}
 	app.ctx, app.cancel = context.WithCancel(context.Background())
 
 	// Handle Signals for toggling and quitting
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGINT, syscall.SIGTERM)
+	actions := hotkey.ActionHandlers{
+		Toggle:     app.toggleRecording,
+		RetryModel: app.retryWithModel,
+		OpenSettings: func() {
+			fyne.Do(app.showSettingsWindow)
+		},
+	}
+	pid.Go(func() {
 		for sig := range sigChan {
 			switch sig {
 			case syscall.SIGUSR1:
-				app.toggleRecording()
+				action, err := hotkey.TakeAction(app.actionFile)
+				if err != nil {
+					log.Printf("Ignoring action file: %v", err)
+				}
+				actions.Dispatch(action)
+			case syscall.SIGUSR2:
+				if app.apiClient.ToggleRaw() {
+					log.Println("Raw transcription enabled (no prompt, no cleanup)")
+				} else {
+					log.Println("Raw transcription disabled")
+				}
 			case syscall.SIGINT, syscall.SIGTERM:
 				fyne.Do(func() {
 					app.a.Quit()
 				})
 			}
 		}
-	}()
+	})
 
 	// Restore background listener for persistent sessions
-	if err := app.hotkey.Initialize(app.cfg.Hotkey); err != nil {
+	if err := app.hotkey.SetMode(mode); err != nil {
+		log.Printf("Hotkey mode: %v", err)
+	}
+	app.hotkey.SetDebounce(time.Duration(app.cfg.ToggleDebounceMs) * time.Millisecond)
+	app.hotkey.SetIgnoreLaunchPress(app.cfg.IgnoreLaunchPress)
+	if err := app.hotkey.Initialize(app.cfg.Hotkey); errors.Is(err, errors.ErrNoHotkeyTool) {
+		log.Printf("Hotkey unavailable: %v", err)
+		for _, line := range app.hotkey.Diagnostics() {
+			log.Printf("Hotkey %s", line)
+		}
+		if err := app.notifier.Notify("VoiceType", fmt.Sprintf("Hotkey %s unavailable: %v", app.cfg.Hotkey, err)); err != nil {
+			log.Printf("Notification failed: %v", err)
+		}
+	} else if err != nil {
 		log.Printf("Hotkey init failed: %v", err)
 	}
-	app.hotkey.OnPress(func() {
-		app.toggleRecording()
-	})
+	if mode == hotkey.ModePushToTalk {
+		app.hotkey.OnPress(func() {
+			defer pid.Recover()
+			app.startHeld()
+		})
+		app.hotkey.OnRelease(func() {
+			defer pid.Recover()
+			if app.session.State() == ui.StateRecording {
+				app.stopRecording()
+			}
+		})
+	} else {
+		app.hotkey.OnPress(func() {
+			defer pid.Recover()
+			app.toggleRecording(hotkey.Action{})
+		})
+	}
 	if err := app.hotkey.Start(); err != nil {
 		log.Printf("Hotkey start failed: %v", err)
 	}
@@ -248,28 +606,46 @@ func main() {
 	})
 
 	// Debounce toggle from same hotkey that launched the app
-	app.lastToggle = time.Now()
+	app.gate.Touch()
 
 	// One-shot auto-start on launch
-	app.startRecording()
+	app.startRecording(hotkey.Action{Language: *flagLanguage, RecordSeconds: *flagRecordSeconds})
 
-	// Safety shutdown: If the app is left idle for more than 60 seconds, quit.
+	// Safety shutdown: If the app is left idle for more than 60 seconds, fade out and quit.
 	// This handles cases where --toggle was used but something hung.
 	time.AfterFunc(60*time.Second, func() {
-		app.mu.Lock()
-		recording := app.isRecording
-		app.mu.Unlock()
-		if !recording {
-			log.Println("Auto-shutting down due to inactivity")
-			app.a.Quit()
+		if state := app.session.State(); state == ui.StateRecording || state == ui.StateProcessing {
+			return
 		}
+		// A recording started during the fade cancels it; stay alive in that case
+		if !app.fadeOutWindow() {
+			return
+		}
+		log.Println("Auto-shutting down due to inactivity")
+		app.safeUIUpdate(func() {
+			app.a.Quit()
+		})
 	})
 
 	app.a.Run()
 	app.shutdown()
 }
 
+// sendAction hands a to the running instance and signals it to act on it
+func sendAction(process *os.Process, actionFile string, a hotkey.Action) error {
+	if err := hotkey.WriteAction(actionFile, a); err != nil {
+		return fmt.Errorf("failed to write action file: %w", err)
+	}
+	return process.Signal(syscall.SIGUSR1)
+}
+
 func (app *VoiceTypeApp) readStdin() {
+	// Launched from a desktop icon stdin is closed or /dev/null; reading it would spin on EOF
+	if !terminal.IsTerminal(os.Stdin) {
+		log.Println("stdin is not a terminal, Enter-to-toggle is disabled")
+		return
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		select {
@@ -284,38 +660,79 @@ func (app *VoiceTypeApp) readStdin() {
 		}
 
 		if strings.TrimSpace(line) == "" {
-			app.toggleRecording()
+			app.toggleRecording(hotkey.Action{})
 		}
 	}
 }
 
-func (app *VoiceTypeApp) toggleRecording() {
-	app.mu.Lock()
-	// Debounce and "Already Processing" check (prevents loop from xdotool CTRL+V)
-	if time.Since(app.lastToggle) < 600*time.Millisecond || app.isProcessing {
-		app.mu.Unlock()
+// toggleRecording starts or stops recording; action only applies when it starts one
+func (app *VoiceTypeApp) toggleRecording(action hotkey.Action) {
+	// "Already Processing" check, then debounce and post-typing cooldown (prevents loop from xdotool CTRL+V)
+	state := app.session.State()
+	if state == ui.StateProcessing || !app.gate.Allow() {
 		return
 	}
-	app.lastToggle = time.Now()
-	recording := app.isRecording
-	app.mu.Unlock()
 
-	if recording {
+	if state == ui.StateRecording {
 		app.stopRecording()
 	} else {
-		app.startRecording()
+		app.startRecording(action)
 	}
 }
 
-func (app *VoiceTypeApp) startRecording() {
-	if err := app.audioSys.StartRecording(); err != nil {
-		log.Printf("Recording error: %v", err)
+// startHeld starts a push-to-talk recording when the hotkey goes down; its
+// release stops it. A recording already running, e.g. the one started at
+// launch by this same key, is left alone so the release still ends it.
+func (app *VoiceTypeApp) startHeld() {
+	if state := app.session.State(); state == ui.StateRecording || state == ui.StateProcessing || !app.gate.AllowHold() {
+		return
+	}
+	app.startRecording(hotkey.Action{})
+}
+
+func (app *VoiceTypeApp) startRecording(action hotkey.Action) {
+	app.record(action, true)
+}
+
+// record starts a recording, snapshotting the focused window first unless
+// captureFocus is false, e.g. when listening again while the pill has focus
+func (app *VoiceTypeApp) record(action hotkey.Action, captureFocus bool) {
+	if err := app.session.Start(); err != nil {
+		log.Printf("Not starting: %v", err)
 		return
 	}
 
 	app.mu.Lock()
-	app.isRecording = true
+	app.language = action.Language
+	app.recordFor = action.RecordSeconds
 	app.mu.Unlock()
+	if action.Language != "" {
+		log.Printf("Transcription language for this recording: %s", action.Language)
+	}
+
+	// Snapshot the target before the pill is shown and can take focus
+	if captureFocus {
+		app.focus.Capture(app.typer.GetActiveWindowID())
+	}
+
+	// Stop any idle fade-out so it doesn't hide the pill we are about to show
+	app.fader.Cancel()
+
+	restarted, err := audio.StartFresh(app.audioSys)
+	if restarted {
+		log.Println("WARNING: Audio was already recording; discarded the stale session and started a new one")
+	}
+	if err != nil {
+		log.Printf("Recording error: %v", err)
+		app.count(metrics.AudioErrors)
+		app.failSession()
+		if err := app.notifier.NotifyError("VoiceType", "Could not start recording: "+err.Error()); err != nil {
+			log.Printf("Notification failed: %v", err)
+		}
+		return
+	}
+
+	app.startUpload()
 
 	app.safeUIUpdate(func() {
 		app.window.Show()
@@ -333,30 +750,492 @@ func (app *VoiceTypeApp) startRecording() {
 	app.startWaveAnimation()
 	app.startPulseAnimation(color.RGBA{R: 255, G: 255, B: 255, A: 255})
 
+	// Fixed-duration mode: stop exactly N seconds in, as if the hotkey was pressed
+	if action.RecordSeconds > 0 {
+		d := time.Duration(action.RecordSeconds) * time.Second
+		app.mu.Lock()
+		app.watchdog = audio.StartWatchdog(d, func() {
+			log.Printf("Fixed duration of %v reached, stopping", d)
+			app.stopRecording()
+		})
+		app.mu.Unlock()
+	}
+
 	log.Println("Recording started")
 }
 
-func (app *VoiceTypeApp) stopRecording() {
-	audioData, err := app.audioSys.StopRecording()
-	if err != nil {
-		log.Printf("Stop error: %v", err)
-		app.mu.Lock()
-		app.isRecording = false
-		app.mu.Unlock()
-		return
+// retryWithModel re-transcribes the last recording with model and delivers
+// the result like a fresh one
+func (app *VoiceTypeApp) retryWithModel(model string) {
+	audioData := app.apiClient.LastAudio()
+	if app.audioSys.Channels() > 1 {
+		app.mu.Lock()
+		audioData = app.stereo
+		app.mu.Unlock()
+	}
+	if len(audioData) == 0 {
+		log.Println("Not retrying: no recording to re-transcribe")
+		return
+	}
+	if err := app.session.Reprocess(); err != nil {
+		log.Printf("Not retrying: %v", err)
+		return
+	}
+
+	app.mu.Lock()
+	app.retryModel = model
+	app.mu.Unlock()
+	log.Printf("Re-transcribing last recording with %s", model)
+
+	app.goTranscribe(audioData)
+}
+
+// transcribeChannels transcribes each captured channel on its own, so a
+// stereo interview gets one transcript per speaker; mono audio gives one
+func (app *VoiceTypeApp) transcribeChannels(audioData []byte, o api.Overrides) ([]string, error) {
+	channels := audio.SplitChannels(audioData, app.audioSys.Channels())
+	if len(channels) == 1 {
+		if up := app.takeUpload(); up != nil {
+			<-up.done
+			up.cancel()
+			if up.err == nil {
+				return []string{up.text}, nil
+			}
+			log.Printf("Streamed upload failed, sending the recording again: %v", up.err)
+		}
+		text, err := app.apiClient.TranscribeWith(app.ctx, audioData, o)
+		return []string{text}, err
+	}
+
+	app.mu.Lock()
+	app.stereo = audioData
+	app.mu.Unlock()
+
+	texts := make([]string, len(channels))
+	errs := make([]error, len(channels))
+	var wg sync.WaitGroup
+	for i, pcm := range channels {
+		wg.Add(1)
+		go func(i int, pcm []byte) {
+			defer wg.Done()
+			texts[i], errs[i] = app.apiClient.TranscribeWith(app.ctx, pcm, o)
+		}(i, pcm)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return texts, nil
+}
+
+// startUpload streams the recording to the API while it is captured, so the
+// transcription is ready soon after stopping. Multi-channel recordings are
+// split first and always sent whole.
+func (app *VoiceTypeApp) startUpload() {
+	if !app.cfg.StreamUpload || app.audioSys.Channels() != 1 {
+		return
+	}
+	r := app.audioSys.Live()
+	if r == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(app.ctx)
+	up := &pendingUpload{cancel: cancel, done: make(chan struct{})}
+	app.mu.Lock()
+	language := app.language
+	app.upload = up
+	app.mu.Unlock()
+
+	go func() {
+		defer close(up.done)
+		up.text, up.err = app.apiClient.TranscribeReaderWith(ctx, r, api.Overrides{Language: language})
+	}()
+}
+
+// takeUpload returns the upload of the recording just stopped, if any
+func (app *VoiceTypeApp) takeUpload() *pendingUpload {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	up := app.upload
+	app.upload = nil
+	return up
+}
+
+// cancelUpload abandons the upload of a recording that won't be transcribed
+func (app *VoiceTypeApp) cancelUpload() {
+	if up := app.takeUpload(); up != nil {
+		up.cancel()
+	}
+}
+
+// cleanup applies the configured post-processing to one transcript; language
+// is the recording's language override, if any
+func (app *VoiceTypeApp) cleanup(text, language string) string {
+	text = strings.TrimSpace(text)
+	if app.apiClient.IsRaw() {
+		return text
+	}
+	if app.cfg.StripModelArtifacts {
+		text = postprocess.StripArtifacts(text)
+	}
+	if app.labels != nil {
+		text = app.labels.Strip(text)
+	}
+	if app.emoji != nil {
+		text = app.emoji.Replace(text)
+	}
+	// Unknown is taken for English, as the default prompt is
+	if language := app.apiClient.LanguageFor(language); app.cfg.FixEnglish && (language == "" || api.IsEnglish(language)) {
+		text = postprocess.FixEnglish(text)
+	}
+	if app.cfg.ForceSentenceCase {
+		text = postprocess.SentenceCase(text, app.cfg.Acronyms)
+	}
+	return text
+}
+
+// endSession returns the session to idle once processing is over
+func (app *VoiceTypeApp) endSession() {
+	if err := app.session.Done(); err != nil {
+		log.Printf("Session not ended: %v", err)
+	}
+}
+
+// failSession moves the session to the error state, from which the next
+// recording can start
+func (app *VoiceTypeApp) failSession() {
+	if err := app.session.Fail(); err != nil {
+		log.Printf("Session not failed: %v", err)
+	}
+}
+
+// rememberDevice records an explicitly chosen capture device so it is preferred next launch
+func rememberDevice(cfg *config.Config, device string) {
+	cfg.RememberDevice(audio.DeviceID(device))
+	if err := config.SaveKeys("", map[string]interface{}{"device_history": cfg.DeviceHistory}); err != nil {
+		log.Printf("Failed to save device history: %v", err)
+	}
+}
+
+func (app *VoiceTypeApp) stopRecording() {
+	// Only one of the hotkey, the fixed-duration timer and auto-stop gets to stop
+	if err := app.session.Stop(); err != nil {
+		log.Printf("Not stopping: %v", err)
+		return
+	}
+
+	app.mu.Lock()
+	app.watchdog.Stop()
+	app.watchdog = nil
+	app.mu.Unlock()
+
+	audioData, err := app.audioSys.StopRecording()
+	if err == nil && len(audioData) == 0 {
+		err = errors.ErrAudioTooShort
+	}
+	if err != nil {
+		log.Printf("Stop error: %v", err)
+		app.cancelUpload()
+		app.endSession()
+		if app.shortRetry.Retry(err) {
+			app.listenAgain()
+			return
+		}
+		app.stopWaveAnimation()
+		app.stopPulseAnimation()
+		app.safeUIUpdate(func() {
+			app.status.Text = ""
+			app.status.Refresh()
+		})
+		return
+	}
+	app.shortRetry.Reset()
+
+	app.stopWaveAnimation()
+	app.stopPulseAnimation()
+
+	app.safeUIUpdate(func() {
+		app.status.Text = ""
+		app.statusIcon.Hide()
+		app.status.Refresh()
+		app.statusIcon.Refresh()
+	})
+
+	app.saveRecording(audioData)
+	app.goTranscribe(audioData)
+}
+
+// saveRecording keeps audioData in the history when save_recordings is on,
+// for saveTranscript to add its transcription to
+func (app *VoiceTypeApp) saveRecording(audioData []byte) {
+	if app.history == nil {
+		return
+	}
+	path, err := app.history.SaveRecording(func(path string) error {
+		return app.audioSys.SaveDataToFile(path, audioData)
+	})
+	if err != nil {
+		log.Printf("History save failed: %v", err)
+		return
+	}
+	app.mu.Lock()
+	app.recording = path
+	app.mu.Unlock()
+}
+
+// saveTranscript writes text next to the recording saveRecording kept and
+// adds it to the history window's index
+func (app *VoiceTypeApp) saveTranscript(text string, audioData []byte) {
+	if app.history == nil {
+		return
+	}
+	app.mu.Lock()
+	path := app.recording
+	app.mu.Unlock()
+	if path != "" {
+		if err := app.history.SaveTranscript(path, text); err != nil {
+			log.Printf("History save failed: %v", err)
+		}
+	}
+	if !postprocess.HasContent(text) {
+		return
+	}
+	entry := history.Entry{Time: time.Now(), Duration: app.audioSys.DurationOf(audioData).Seconds(), Text: text, Audio: path}
+	if err := app.index.Append(entry); err != nil {
+		log.Printf("History save failed: %v", err)
+	}
+}
+
+// discardRecording removes the recording saveRecording kept, for one that
+// must not be kept, like a password spoken into a password prompt
+func (app *VoiceTypeApp) discardRecording() {
+	app.mu.Lock()
+	path := app.recording
+	app.recording = ""
+	app.mu.Unlock()
+	if path == "" {
+		return
+	}
+	if err := app.history.Discard(path); err != nil {
+		log.Printf("History discard failed: %v", err)
+	}
+}
+
+// listenAgainCue is shown while a too-short recording starts over
+const listenAgainCue = "Listening again"
+
+// listenAgain starts over a recording that was too short to transcribe, in
+// the same language and duration and for the window focused when it began,
+// showing a brief cue, as the user will most likely try again straight away
+func (app *VoiceTypeApp) listenAgain() {
+	log.Println("Recording too short, listening again")
+	app.mu.Lock()
+	action := hotkey.Action{Language: app.language, RecordSeconds: app.recordFor}
+	app.mu.Unlock()
+	app.record(action, false)
+	if app.session.State() != ui.StateRecording {
+		return
+	}
+
+	app.safeUIUpdate(func() {
+		app.status.Text = listenAgainCue
+		app.status.Refresh()
+	})
+	time.AfterFunc(1500*time.Millisecond, func() {
+		app.safeUIUpdate(func() {
+			if app.status.Text == listenAgainCue {
+				app.status.Text = ""
+				app.status.Refresh()
+			}
+		})
+	})
+}
+
+// goTranscribe runs transcribeAndType in the background, tracked so shutdown
+// waits for the text to be typed
+func (app *VoiceTypeApp) goTranscribe(audioData []byte) {
+	app.inflight.Go(func() {
+		defer app.pid.Recover()
+		app.transcribeAndType(audioData)
+	})
+}
+
+// transcribeAndType sends a finished recording for transcription and types the
+// result. The session must be processing; it returns to idle when done.
+func (app *VoiceTypeApp) transcribeAndType(audioData []byte) {
+	rekeying := false
+	defer func() {
+		// The re-key prompt keeps the session busy until the user answers it
+		if rekeying {
+			return
+		}
+		app.endSession()
+	}()
+
+	app.mu.Lock()
+	language := app.language
+	model := app.retryModel
+	app.retryModel = ""
+	app.mu.Unlock()
+
+	app.focusBack = false
+	placeholder := app.showPlaceholder()
+	defer func() {
+		// Nothing replaced the placeholder, so take it back out
+		if placeholder != "" {
+			app.clearPlaceholder(placeholder)
+		}
+	}()
+
+	texts := []string{""}
+	var err error
+	if app.heardSpeech(audioData) {
+		texts, err = app.transcribeChannels(audioData, api.Overrides{Model: model, Language: language})
+	} else {
+		log.Println("Nothing above the calibrated noise floor, not transcribing")
+		app.cancelUpload()
+	}
+	if err != nil {
+		log.Printf("Transcription failed: %v", err)
+		// Before any prompt window can take focus from the target app
+		app.clearPlaceholder(placeholder)
+		placeholder = ""
+		app.count(metrics.APIError(err))
+		app.playErrorSound()
+		if api.NeedsRekey(err) {
+			rekeying = true
+			app.safeUIUpdate(func() {
+				app.showRekeyWindow(audioData)
+			})
+			return
+		}
+		app.failSession()
+		app.safeUIUpdate(func() {
+			app.status.Text = "Error"
+			app.pillBg.StrokeColor = color.RGBA{R: 239, G: 68, B: 68, A: 255} // Crimson
+			app.pillBg.Refresh()
+			app.status.Refresh()
+		})
+		time.Sleep(1500 * time.Millisecond)
+		app.safeUIUpdate(func() {
+			app.a.Quit()
+		})
+		return
+	}
+
+	for i := range texts {
+		texts[i] = app.cleanup(texts[i], language)
+	}
+	text := texts[0]
+	if len(texts) > 1 {
+		text = postprocess.MergeChannels(texts, app.cfg.ChannelLabels)
+	}
+	text = postprocess.NormalizeNewlines(text, app.cfg.NewlineStyle)
+	if !postprocess.HasContent(text) {
+		log.Printf("No speech detected (got %q)", text)
+		app.saveTranscript(text, audioData)
+		if app.cfg.OnEmpty == postprocess.OnEmptyNotify {
+			if err := app.notifier.Notify("VoiceType", "No speech detected"); err != nil {
+				log.Printf("Notification failed: %v", err)
+			}
+		}
+		app.safeUIUpdate(func() {
+			app.a.Quit()
+		})
+		return
+	}
+
+	app.count(metrics.TranscriptionsOK)
+
+	if !app.focusBack {
+		app.returnFocus()
+	}
+
+	// Never auto-type into what looks like a password prompt, nor journal or
+	// log it; leave it on the clipboard
+	if typing.ShouldAvoidTyping(app.typer.DetectFieldKind(), app.cfg.AvoidPasswordFields) {
+		log.Println("WARNING: Focused window looks like a password prompt, copied transcription to clipboard instead of typing")
+		// Never quote the text here, it may well be the password
+		if err := app.notifier.Notify("VoiceType", "Password field detected: transcription copied to clipboard instead of typed"); err != nil {
+			log.Printf("Notification failed: %v", err)
+		}
+		if err := app.typer.SetPrimarySelection(app.ctx, app.typer.ApplyReplacements(text)); err != nil {
+			log.Printf("Clipboard set failed: %v", err)
+		}
+		app.discardRecording()
+		app.safeUIUpdate(func() {
+			app.a.Quit()
+		})
+		return
+	}
+	log.Printf("Transcribed: %s", text)
+
+	if app.dataset != nil {
+		if entry, err := app.dataset.Save(audioData, app.audioSys.SampleRate(), app.audioSys.Channels(), text); err != nil {
+			log.Printf("Dataset save failed: %v", err)
+		} else {
+			log.Printf("Saved dataset pair %s", entry.ID)
+		}
+	}
+
+	if app.journal != nil {
+		if err := app.journal.Append(text); err != nil {
+			log.Printf("Journal append failed: %v", err)
+		} else if app.cfg.JournalOnly {
+			app.saveTranscript(text, audioData)
+			if err := app.notifier.NotifyTranscript("VoiceType", "Saved to journal", text); err != nil {
+				log.Printf("Notification failed: %v", err)
+			}
+			app.safeUIUpdate(func() {
+				app.a.Quit()
+			})
+			return
+		}
+	}
+
+	// Replacements can depend on the app, which has focus again by now
+	text = app.typer.ApplyReplacements(text)
+	app.saveTranscript(text, audioData)
+
+	if app.cfg.ReadBack {
+		app.readBack(text)
 	}
 
-	app.mu.Lock()
-	app.isRecording = false
-	app.mu.Unlock()
+	if model == "" {
+		model = app.apiClient.GetModel()
+	}
+	text = postprocess.AddMetadata(text, app.cfg.OutputMetadataTemplate, app.cfg.OutputMetadataPosition, postprocess.Metadata{
+		Model:    model,
+		Duration: app.audioSys.DurationOf(audioData),
+		Time:     time.Now(),
+	})
 
-	app.stopWaveAnimation()
-	app.stopPulseAnimation()
+	if placeholder != "" {
+		if err := app.typer.SelectPlaceholder(app.ctx, placeholder); err != nil {
+			log.Printf("Placeholder select failed: %v", err)
+		}
+		placeholder = ""
+	}
 
-	if len(audioData) == 0 {
+	method := app.typer.DeliveryFor(app.cfg.DeliveryMethod, app.cfg.AppDelivery)
+	if app.emoji != nil && postprocess.HasEmoji(text) {
+		// Typing tools mangle emoji; the clipboard carries them intact
+		method = typing.DeliveryPaste
+	}
+	err = app.typer.DeliverText(app.ctx, text, typing.PostKeyForText(text, app.cfg.PostTypeKey, app.cfg.AutoReturn, app.cfg.SmartEnter), method)
+	app.gate.StartCooldown()
+	if errors.Is(err, typing.ErrLeftOnClipboard) {
+		// The manual paste notification has told the user; the text isn't lost
+		log.Printf("Typing failed, text left on the clipboard: %v", err)
+	} else if err != nil {
+		log.Printf("Typing failed: %v", err)
+		app.count(metrics.TypingErrors)
+		app.playErrorSound()
 		app.safeUIUpdate(func() {
-			app.status.Text = ""
-			app.status.Refresh()
+			app.a.Quit()
 		})
 		return
 	}
@@ -368,92 +1247,100 @@ func (app *VoiceTypeApp) stopRecording() {
 		app.statusIcon.Refresh()
 	})
 
-	app.mu.Lock()
-	app.isProcessing = true
-	app.mu.Unlock()
-
-	go func() {
-		defer func() {
-			app.mu.Lock()
-			app.isProcessing = false
-			app.mu.Unlock()
-		}()
-
-		text, err := app.apiClient.Transcribe(app.ctx, audioData)
-		if err != nil {
-			log.Printf("Transcription failed: %v", err)
-			app.safeUIUpdate(func() {
-				app.status.Text = "Error"
-				app.pillBg.StrokeColor = color.RGBA{R: 239, G: 68, B: 68, A: 255} // Crimson
-				app.pillBg.Refresh()
-				app.status.Refresh()
-			})
-			time.Sleep(1500 * time.Millisecond)
-			app.safeUIUpdate(func() {
-				app.a.Quit()
-			})
-			return
-		}
-
-		text = strings.TrimSpace(text)
-		if text == "" {
-			app.safeUIUpdate(func() {
-				app.a.Quit()
-			})
-			return
-		}
+	// Stay alive long enough for the target app to read a selection we serve
+	taken := app.typer.SelectionTaken()
+	if !ui.WaitBeforeExit(time.Duration(app.cfg.ExitDelayMs)*time.Millisecond, taken, ui.DefaultSelectionTimeout) && taken != nil {
+		log.Printf("Primary selection not read within %v, exiting anyway", ui.DefaultSelectionTimeout)
+	}
+	app.safeUIUpdate(func() {
+		app.a.Quit()
+	})
+}
 
-		log.Printf("Transcribed: %s", text)
+// heardSpeech reports whether audioData rises above the noise floor
+// --calibrate measured anywhere; without one everything is transcribed
+func (app *VoiceTypeApp) heardSpeech(audioData []byte) bool {
+	if app.cfg.NoiseFloor <= 0 {
+		return true
+	}
+	return audio.HasSpeech(audioData, app.audioSys.SampleRate(), app.audioSys.Channels(), audio.ThresholdFromFloor(app.cfg.NoiseFloor))
+}
 
-		app.safeUIUpdate(func() {
-			app.status.Text = ""
-			app.status.Refresh()
+// playErrorSound plays the error_sound cue. It blocks until the cue ends so
+// quitting right after doesn't cut it off.
+func (app *VoiceTypeApp) playErrorSound() {
+	if err := app.sound.Error(app.ctx); err != nil {
+		log.Printf("Error sound failed: %v", err)
+	}
+}
 
-			// Capture target window ID before hiding
-			prevWindowID := app.typer.GetActiveWindowID()
+// readBack speaks the transcription aloud, for read_back, and returns once
+// it has been heard so typing follows. Without a speech tool it only logs.
+func (app *VoiceTypeApp) readBack(text string) {
+	if err := tts.Speak(app.ctx, text); err != nil {
+		log.Printf("Read-back failed, typing anyway: %v", err)
+	}
+}
 
-			// Hide window immediately to return focus to the target software
-			app.window.Hide()
+// returnFocus hides the pill and hands focus back to the app being dictated into
+func (app *VoiceTypeApp) returnFocus() {
+	app.safeUIUpdate(func() {
+		app.status.Text = ""
+		app.status.Refresh()
 
-			// Actively restore focus to the previous window
-			if prevWindowID != "" {
-				app.typer.ActivateWindow(prevWindowID)
-			}
-		})
+		// Prefer the window focused when recording started; the user may have alt-tabbed since
+		prevWindowID := app.focus.Take(app.typer.GetActiveWindowID(), app.cfg.FocusTarget)
 
-		// Shorter delay since we actively restore focus
-		time.Sleep(500 * time.Millisecond)
+		// Hide window immediately to return focus to the target software
+		app.window.Hide()
 
-		if err := app.typer.TypeText(app.ctx, text, app.cfg.AutoReturn); err != nil {
-			log.Printf("Typing failed: %v", err)
-			app.safeUIUpdate(func() {
-				app.a.Quit()
-			})
-			return
+		// Actively restore focus to the previous window
+		if prevWindowID != "" {
+			app.typer.ActivateWindow(prevWindowID)
 		}
+	})
 
-		app.safeUIUpdate(func() {
-			app.status.Text = ""
-			app.statusIcon.Hide()
-			app.status.Refresh()
-			app.statusIcon.Refresh()
-		})
+	// Shorter delay since we actively restore focus
+	time.Sleep(500 * time.Millisecond)
+	app.focusBack = true
+}
 
-		time.Sleep(600 * time.Millisecond)
-		app.safeUIUpdate(func() {
-			app.a.Quit()
-		})
-	}()
+// showPlaceholder types the configured typing_placeholder into the target app
+// as soon as processing starts. It returns the placeholder actually typed, or
+// "" when disabled, the target looks like a password prompt or typing failed.
+// The pill is hidden early to do so.
+func (app *VoiceTypeApp) showPlaceholder() string {
+	placeholder := app.cfg.TypingPlaceholder
+	if placeholder == "" {
+		return ""
+	}
+	app.returnFocus()
+	// Nothing is typed into a password prompt, the placeholder included
+	if typing.ShouldAvoidTyping(app.typer.DetectFieldKind(), app.cfg.AvoidPasswordFields) {
+		return ""
+	}
+	if err := app.typer.ShowPlaceholder(app.ctx, placeholder); err != nil {
+		log.Printf("Placeholder typing failed: %v", err)
+		return ""
+	}
+	return placeholder
+}
+
+// clearPlaceholder removes a placeholder that no transcription will replace
+func (app *VoiceTypeApp) clearPlaceholder(placeholder string) {
+	if err := app.typer.ClearPlaceholder(app.ctx, placeholder); err != nil {
+		log.Printf("Placeholder removal failed: %v", err)
+	}
 }
 
 func (app *VoiceTypeApp) resetUI() {
-	app.mu.Lock()
-	defer app.mu.Unlock()
-	if !app.isRecording {
+	if app.session.State() != ui.StateRecording {
 		app.safeUIUpdate(func() {
 			// Smooth fade out animation
 			go func() {
-				app.fadeOutWindow()
+				if !app.fadeOutWindow() {
+					return
+				}
 				app.safeUIUpdate(func() {
 					app.status.Text = ""
 					app.status.Refresh()
@@ -483,7 +1370,14 @@ func (app *VoiceTypeApp) createWindow() {
 	pillHeight := float32(28.0)
 
 	app.winTitle = fmt.Sprintf("VoiceTypeUI_%d", time.Now().UnixNano())
-	app.window = app.a.NewWindow(app.winTitle)
+	// Splash windows are created without decorations, so the WM never gets a
+	// chance to draw a title bar before the xprop hints below apply
+	if drv, ok := app.a.Driver().(desktop.Driver); ok {
+		app.window = drv.CreateSplashWindow()
+		app.window.SetTitle(app.winTitle)
+	} else {
+		app.window = app.a.NewWindow(app.winTitle)
+	}
 	app.window.SetFixedSize(true)
 	app.window.SetPadded(false)
 	app.window.Resize(fyne.NewSize(pillWidth, pillHeight))
@@ -563,14 +1457,8 @@ func (app *VoiceTypeApp) stripDecorations(title string) {
 		}
 	}
 
-	if winID != "" {
-		exec.Command("xprop", "-id", winID, "-f", "_MOTIF_WM_HINTS", "32c", "-set", "_MOTIF_WM_HINTS", "0x2, 0x0, 0x0, 0x0, 0x0").Run()
-		exec.Command("xprop", "-id", winID, "-f", "_NET_WM_WINDOW_TYPE", "32a", "-set", "_NET_WM_WINDOW_TYPE", "_NET_WM_WINDOW_TYPE_NOTIFICATION").Run()
-		exec.Command("xprop", "-id", winID, "-f", "_NET_WM_STATE", "32a", "-set", "_NET_WM_STATE", "_NET_WM_STATE_SKIP_TASKBAR,_NET_WM_STATE_SKIP_PAGER,_NET_WM_STATE_ABOVE,_NET_WM_STATE_STAY_ON_TOP").Run()
-		exec.Command("xprop", "-id", winID, "-f", "_NET_WM_ALLOWED_ACTIONS", "32a", "-set", "_NET_WM_ALLOWED_ACTIONS", "").Run()
-	} else {
-		exec.Command("xprop", "-name", title, "-f", "_MOTIF_WM_HINTS", "32c", "-set", "_MOTIF_WM_HINTS", "0x2, 0x0, 0x0, 0x0, 0x0").Run()
-		exec.Command("xprop", "-name", title, "-f", "_NET_WM_WINDOW_TYPE", "32a", "-set", "_NET_WM_WINDOW_TYPE", "_NET_WM_WINDOW_TYPE_NOTIFICATION").Run()
+	ui.ApplyWindowHints(winID, title)
+	if winID == "" {
 		exec.Command("wmctrl", "-r", title, "-b", "add,above,skip_taskbar,skip_pager").Run()
 	}
 }
@@ -584,13 +1472,13 @@ func (app *VoiceTypeApp) fadeInWindow() {
 	}
 }
 
-func (app *VoiceTypeApp) fadeOutWindow() {
-	steps := 8
-	for i := steps; i >= 0; i-- {
-		opacity := float64(i) / float64(steps)
+// fadeOutWindow fades the pill over the configured duration.
+// It returns false if a new recording cancelled the fade.
+func (app *VoiceTypeApp) fadeOutWindow() bool {
+	duration := time.Duration(app.cfg.FadeOutMs) * time.Millisecond
+	return app.fader.FadeOut(duration, 8, func(opacity float64) {
 		exec.Command("xprop", "-name", app.winTitle, "-f", "_NET_WM_WINDOW_OPACITY", "32c", "-set", "_NET_WM_WINDOW_OPACITY", fmt.Sprintf("%d", uint32(opacity*0xFFFFFFFF))).Run()
-		time.Sleep(25 * time.Millisecond)
-	}
+	})
 }
 
 func (app *VoiceTypeApp) safeUIUpdate(f func()) {
@@ -634,10 +1522,7 @@ func (app *VoiceTypeApp) startWaveAnimation() {
 	startTime := time.Now()
 
 	app.anim = fyne.NewAnimation(time.Millisecond*16, func(f float32) {
-		app.mu.Lock()
-		level := app.audioSys.GetLevel()
-		app.smoothLevel = app.smoothLevel*0.7 + level*0.3
-		app.mu.Unlock()
+		smoothLevel := app.audioSys.SmoothedLevel()
 
 		center := len(app.waveBars) / 2
 		for i, bar := range app.waveBars {
@@ -654,7 +1539,7 @@ func (app *VoiceTypeApp) startWaveAnimation() {
 			idle := 1.0 * math.Sin(elapsed*3.5+float64(i)*0.15)
 			idle += 0.5 * math.Sin(elapsed*2.0+float64(i)*0.25)
 
-			vocal := app.smoothLevel * 40.0 * falloff
+			vocal := smoothLevel * 40.0 * falloff
 
 			h := 2.5 + math.Abs(idle) + vocal
 			if h > 18 {
@@ -676,10 +1561,7 @@ func (app *VoiceTypeApp) startWaveAnimation() {
 		ticker := time.NewTicker(time.Second)
 		defer ticker.Stop()
 		for {
-			app.mu.Lock()
-			recording := app.isRecording
-			app.mu.Unlock()
-			if !recording {
+			if app.session.State() != ui.StateRecording {
 				return
 			}
 
@@ -755,6 +1637,10 @@ func (app *VoiceTypeApp) stopWaveAnimation() {
 
 func (app *VoiceTypeApp) shutdown() {
 	log.Println("Shutting down...")
+	// Let a transcription in flight finish typing before its context is cancelled
+	if !app.inflight.Wait(ui.DefaultShutdownTimeout) {
+		log.Printf("Delivery still running after %v, exiting anyway", ui.DefaultShutdownTimeout)
+	}
 	app.cancel()
 	app.audioSys.Close()
 	log.Println("Done")
@@ -793,6 +1679,59 @@ func askAPIKey() string {
 	return ""
 }
 
+// showRekeyWindow asks for a new API key after the current one was rejected
+// mid-session, then retries the recording that failed instead of losing it
+func (app *VoiceTypeApp) showRekeyWindow(audioData []byte) {
+	app.window.Hide()
+	w := app.a.NewWindow("VoiceType - API Key Rejected")
+
+	message := widget.NewLabel("Groq rejected the API key (it may have expired or been rotated).\nEnter a new key to retry this recording.")
+	message.Wrapping = fyne.TextWrapWord
+
+	keyEntry := widget.NewPasswordEntry()
+	keyEntry.SetPlaceHolder("gsk_...")
+
+	retried := false
+	retryBtn := widget.NewButton("Save & Retry", func() {
+		key := strings.TrimSpace(keyEntry.Text)
+		if key == "" {
+			return
+		}
+		retried = true
+		app.cfg.GROQ_API_KEY = key
+		app.apiClient.SetAPIKey(key)
+		saveAPIKey(key)
+		log.Println("API key updated, retrying transcription")
+
+		w.Close()
+		app.window.Show()
+		app.goTranscribe(audioData)
+	})
+	retryBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton("Cancel", func() {
+		w.Close()
+	})
+
+	w.SetOnClosed(func() {
+		if !retried {
+			app.a.Quit()
+		}
+	})
+
+	w.SetContent(container.NewPadded(container.NewVBox(
+		message,
+		widget.NewForm(widget.NewFormItem("GROQ API Key", keyEntry)),
+		container.NewHBox(layout.NewSpacer(), cancelBtn, retryBtn),
+	)))
+	w.Resize(fyne.NewSize(420, 180))
+	w.CenterOnScreen()
+	w.Show()
+	w.Canvas().Focus(keyEntry)
+}
+
+// showSettingsWindow shows the settings. Saving writes only the edited keys;
+// the running instance applies them at once and keeps going, while a
+// --settings process of its own quits.
 func (app *VoiceTypeApp) showSettingsWindow() {
 	w := app.a.NewWindow("VoiceType Settings")
 
@@ -821,30 +1760,74 @@ func (app *VoiceTypeApp) showSettingsWindow() {
 		modelSelect.SetSelected("whisper-large-v3")
 	}
 
+	promptEntry := widget.NewMultiLineEntry()
+	promptEntry.SetText(app.cfg.TranscriptionPrompt)
+	promptEntry.SetPlaceHolder("No prompt: transcribe as spoken")
+	promptEntry.Wrapping = fyne.TextWrapWord
+	promptEntry.SetMinRowsVisible(4)
+
 	form := widget.NewForm(
 		widget.NewFormItem("GROQ API Key", keyEntry),
 		widget.NewFormItem("Hotkey", hotkeyEntry),
 		widget.NewFormItem("Audio Device", deviceSelect),
 		widget.NewFormItem("Model", modelSelect),
+		widget.NewFormItem("Dictation Style", promptEntry),
 		widget.NewFormItem("", autoReturnCheck),
 	)
 
-	saveBtn := widget.NewButton("Save & Exit", func() {
-		app.cfg.GROQ_API_KEY = keyEntry.Text
-		app.cfg.Hotkey = hotkeyEntry.Text
-		app.cfg.AudioDevice = deviceSelect.Selected
-		app.cfg.AutoReturn = autoReturnCheck.Checked
-		app.cfg.Model = modelSelect.Selected
-
-		if err := app.cfg.Save(""); err != nil {
-			log.Printf("Failed to save config: %v", err)
-		} else {
+	values := func() map[string]interface{} {
+		return map[string]interface{}{
+			"groq_api_key":         keyEntry.Text,
+			"hotkey":               hotkeyEntry.Text,
+			"audio_device":         deviceSelect.Selected,
+			"model":                modelSelect.Selected,
+			"transcription_prompt": promptEntry.Text,
+			"auto_return":          autoReturnCheck.Checked,
+		}
+	}
+	shown := values()
+	saveLabel := "Save & Exit"
+	if app.running {
+		saveLabel = "Save"
+	}
+	saveBtn := widget.NewButton(saveLabel, func() {
+		// Only what was edited, so e.g. a GROQ_API_KEY from the environment
+		// isn't written into the file
+		edited := make(map[string]interface{})
+		for key, value := range values() {
+			if value != shown[key] {
+				edited[key] = value
+			}
+		}
+		if device, ok := edited["audio_device"].(string); ok && device != "" {
+			// A device picked here should win over the remembered ones
+			app.cfg.RememberDevice(audio.DeviceID(device))
+			edited["device_history"] = app.cfg.DeviceHistory
+		}
+		if len(edited) > 0 {
+			if err := config.SaveKeys("", edited); err != nil {
+				log.Printf("Failed to save config: %v", err)
+				dialog.ShowError(err, w)
+				return
+			}
 			log.Println("Config saved successfully")
 		}
-		app.a.Quit()
+		if !app.running {
+			app.a.Quit()
+			return
+		}
+		app.applySettings(edited)
+		w.Close()
 	})
 	saveBtn.Importance = widget.HighImportance
 
+	logsBtn := widget.NewButton("View Logs", func() {
+		app.showLogWindow(false)
+	})
+	historyBtn := widget.NewButton("View History", func() {
+		app.showHistoryWindow(false)
+	})
+
 	title := widget.NewLabelWithStyle("VoiceType Settings", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 
 	content := container.NewVBox(
@@ -852,13 +1835,14 @@ func (app *VoiceTypeApp) showSettingsWindow() {
 		widget.NewSeparator(),
 		form,
 		layout.NewSpacer(),
+		container.NewGridWithColumns(2, logsBtn, historyBtn),
 		saveBtn,
 	)
 
 	bg := canvas.NewRectangle(color.RGBA{R: 25, G: 25, B: 30, A: 255})
 	w.SetContent(container.NewStack(bg, container.NewPadded(content)))
 
-	w.Resize(fyne.NewSize(420, 320))
+	w.Resize(fyne.NewSize(420, 460))
 	w.SetFixedSize(true)
 	w.CenterOnScreen()
 
@@ -872,12 +1856,357 @@ func (app *VoiceTypeApp) showSettingsWindow() {
 	w.Show()
 }
 
-func initLogger() {
-	path, err := config.GetConfigPath()
+// applySettings applies keys just saved from the settings window to the
+// running instance
+func (app *VoiceTypeApp) applySettings(edited map[string]interface{}) {
+	if apiKey, ok := edited["groq_api_key"].(string); ok {
+		app.cfg.GROQ_API_KEY = apiKey
+		app.apiClient.SetAPIKey(apiKey)
+	}
+	if model, ok := edited["model"].(string); ok {
+		app.cfg.Model = model
+		app.apiClient.SetModel(model)
+	}
+	if prompt, ok := edited["transcription_prompt"].(string); ok {
+		app.cfg.TranscriptionPrompt = prompt
+		app.apiClient.SetPrompt(prompt)
+	}
+	if autoReturn, ok := edited["auto_return"].(bool); ok {
+		app.cfg.AutoReturn = autoReturn
+	}
+	if device, ok := edited["audio_device"].(string); ok {
+		app.cfg.AudioDevice = device
+		// Takes effect from the next recording
+		if err := app.audioSys.Initialize(device); err != nil {
+			log.Printf("Audio device %s unavailable: %v", device, err)
+		}
+	}
+	if hotkeyName, ok := edited["hotkey"].(string); ok {
+		log.Printf("Hotkey changed to %s; restart VoiceType for it to take effect", hotkeyName)
+	}
+}
+
+// logTailBytes caps how much of debug.log the viewer shows
+const logTailBytes = 256 * 1024
+
+// showLogWindow opens a window tailing debug.log. quitOnClose ends the app
+// when it is the only window, as with --logs.
+func (app *VoiceTypeApp) showLogWindow(quitOnClose bool) {
+	w := app.a.NewWindow("VoiceType Logs")
+
+	logPath, err := logger.LogPath()
+	if err != nil {
+		log.Printf("Failed to locate log file: %v", err)
+	}
+
+	text := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	text.Wrapping = fyne.TextWrapBreak
+	scroll := container.NewVScroll(text)
+
+	refresh := func() {
+		content, err := logger.Tail(logPath, logTailBytes)
+		if err != nil {
+			content = fmt.Sprintf("Failed to read %s: %v", logPath, err)
+		}
+		if content == text.Text {
+			return
+		}
+		text.SetText(content)
+		scroll.ScrollToBottom()
+	}
+
+	copyBtn := widget.NewButton("Copy all", func() {
+		app.a.Clipboard().SetContent(text.Text)
+	})
+	clearBtn := widget.NewButton("Clear", func() {
+		if err := logger.Clear(logPath); err != nil {
+			log.Printf("Failed to clear log: %v", err)
+		}
+		refresh()
+	})
+
+	header := widget.NewLabel(logPath)
+	buttons := container.NewHBox(layout.NewSpacer(), copyBtn, clearBtn)
+	w.SetContent(container.NewBorder(header, buttons, nil, nil, scroll))
+	w.Resize(fyne.NewSize(720, 480))
+	w.CenterOnScreen()
+
+	stop := make(chan struct{})
+	w.SetOnClosed(func() {
+		close(stop)
+		if quitOnClose {
+			app.a.Quit()
+		}
+	})
+
+	refresh()
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				app.safeUIUpdate(refresh)
+			}
+		}
+	}()
+
+	w.Show()
+}
+
+// showHistoryWindow opens a searchable list of past transcriptions, newest
+// first. Selecting one enables copying its text again and, when its
+// recording was kept, replaying it. quitOnClose ends the app when it is the
+// only window, as with --history.
+func (app *VoiceTypeApp) showHistoryWindow(quitOnClose bool) {
+	w := app.a.NewWindow("VoiceType History")
+	store := history.NewStore("")
+
+	var entries []history.Entry
+	selected := -1
+
+	status := widget.NewLabel("")
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			e := entries[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s  %5.1fs  %s",
+				e.Time.Local().Format("2006-01-02 15:04"), e.Duration, postprocess.Truncate(strings.Join(strings.Fields(e.Text), " "), 80)))
+		},
+	)
+
+	copyBtn := widget.NewButton("Copy text", func() {
+		if selected < 0 {
+			return
+		}
+		app.a.Clipboard().SetContent(entries[selected].Text)
+		status.SetText("Copied to the clipboard")
+	})
+	playBtn := widget.NewButton("Play audio", func() {
+		if selected < 0 {
+			return
+		}
+		path := entries[selected].Audio
+		if !toolpath.Available("aplay") {
+			status.SetText("aplay not found; install alsa-utils")
+			return
+		}
+		go func() {
+			if err := exec.Command("aplay", "-q", path).Run(); err != nil {
+				log.Printf("History playback failed: %v", err)
+			}
+		}()
+		status.SetText("Playing " + filepath.Base(path))
+	})
+	copyBtn.Disable()
+	playBtn.Disable()
+
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		copyBtn.Enable()
+		if _, err := os.Stat(entries[id].Audio); entries[id].Audio != "" && err == nil {
+			playBtn.Enable()
+		} else {
+			playBtn.Disable()
+		}
+		status.SetText("")
+	}
+
+	load := func(term string) {
+		var err error
+		entries, err = store.Query(term)
+		switch {
+		case err != nil:
+			status.SetText(fmt.Sprintf("Failed to read %s: %v", store.Path(), err))
+		case len(entries) == 0 && term == "":
+			status.SetText(`No transcriptions yet. Set "save_recordings": true to keep them.`)
+		case len(entries) == 0:
+			status.SetText("No matches")
+		default:
+			status.SetText("")
+		}
+		selected = -1
+		copyBtn.Disable()
+		playBtn.Disable()
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search transcriptions")
+	search.OnChanged = load
+
+	buttons := container.NewHBox(status, layout.NewSpacer(), copyBtn, playBtn)
+	w.SetContent(container.NewBorder(search, buttons, nil, nil, list))
+	w.Resize(fyne.NewSize(720, 480))
+	w.CenterOnScreen()
+	w.SetOnClosed(func() {
+		if quitOnClose {
+			app.a.Quit()
+		}
+	})
+
+	load("")
+	w.Canvas().Focus(search)
+	w.Show()
+}
+
+// configResetMessage tells the user their unreadable config was replaced;
+// the log names the backup
+const configResetMessage = "Config file was unreadable and has been reset to defaults. The old file was backed up next to it."
+
+// runDoctor prints the environment checklist and returns the process exit code
+func runDoctor(configJSON string, sets config.Sets) int {
+	// A config file that doesn't parse is one of the doctor's own failures
+	cfg, err := config.Inspect()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if err := cfg.ApplyOverrides(configJSON, sets); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config override: %v\n", err)
+		return 2
+	}
+
+	var healthCheck func(ctx context.Context) error
+	if provider, err := api.ParseProvider(cfg.Provider); err == nil {
+		switch {
+		case provider == api.ProviderLocal:
+			healthCheck = api.NewLocalWhisperClient(cfg.LocalURL, nil).HealthCheck
+		case cfg.GROQ_API_KEY != "":
+			healthCheck = api.NewClient(cfg.GROQ_API_KEY, nil).HealthCheck
+		}
+	}
+
+	report := diagnostics.NewDoctor(healthCheck).Run(context.Background())
+	fmt.Println("VoiceType v" + version + " diagnostics")
+	fmt.Println()
+	report.Print(os.Stdout)
+
+	if report.Worst() == diagnostics.StatusFail {
+		return 1
+	}
+	return 0
+}
+
+// runHotkeyDebug starts only the hotkey listener and prints what it sees
+// until interrupted, for diagnosing a hotkey that never fires. Nothing is
+// recorded or typed.
+func runHotkeyDebug(cfg *config.Config) int {
+	listener := hotkey.NewListener(nil)
+	if err := listener.SetMode(cfg.HotkeyMode); err != nil {
+		fmt.Fprintf(os.Stderr, "%v, using %s\n", err, hotkey.ModeToggle)
+	}
+	listener.SetDebounce(time.Duration(cfg.ToggleDebounceMs) * time.Millisecond)
+	listener.SetIgnoreLaunchPress(cfg.IgnoreLaunchPress)
+	listener.OnDebug(func(e hotkey.Event) {
+		fmt.Fprintf(os.Stderr, "%s %s\n", time.Now().Format("15:04:05.000"), e)
+	})
+	err := listener.Initialize(cfg.Hotkey)
+	if err != nil && !errors.Is(err, errors.ErrNoHotkeyTool) {
+		fmt.Fprintf(os.Stderr, "Failed to initialize hotkey listener: %v\n", err)
+		return 1
+	}
+	defer listener.Close()
+	for _, line := range listener.Diagnostics() {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Watching hotkey %q; press it to see events, Ctrl+C to quit\n", cfg.Hotkey)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	return 0
+}
+
+// runCalibrate measures the room's noise floor on the capture device and saves
+// it to the config, returning the process exit code
+func runCalibrate(cfg *config.Config, deviceFlag string) int {
+	audioSys := audio.NewSystem(nil, cfg.CaptureBackend)
+	device := audio.ResolveDevice(deviceFlag, audioSys.GetDevices(), cfg.DeviceHistory, cfg.AudioDevice)
+	if err := audioSys.Initialize(device); err != nil {
+		fmt.Fprintf(os.Stderr, "Audio init failed: %v\n", err)
+		return 1
+	}
+	defer audioSys.Close()
+	if source := audio.ResolvePulseSource(cfg.PulseSource, audioSys.Device(), audio.DefaultSource); source != "" {
+		audioSys.SetPulseSource(source)
+	}
+	if err := audioSys.SetCaptureFormat(cfg.CaptureFormat); err != nil {
+		log.Printf("%v, using %s", err, audioSys.CaptureFormat())
+	}
+	if err := audioSys.Configure(cfg.SampleRate, cfg.Channels, 16); err != nil {
+		log.Printf("%v, recording %d Hz mono", err, audioSys.SampleRate())
+	}
+
+	fmt.Fprintf(os.Stderr, "Stay quiet for %v while the room noise is measured...\n", audio.DefaultCalibrationTime)
+	floor, err := audioSys.Calibrate(audio.DefaultCalibrationTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Calibration failed: %v\n", err)
+		return 1
+	}
+
+	// Only the noise floor is saved, not any --set overrides or environment
+	if err := config.SaveKeys("", map[string]interface{}{"noise_floor": floor}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save the noise floor: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Noise floor: %.4f\nAuto-stop threshold: %.4f\nSpeech threshold: %.4f\n", floor, audio.SilenceThreshold(cfg.AutoStopThreshold, floor), audio.ThresholdFromFloor(floor))
+	return 0
+}
+
+// count increments a local metrics counter when metrics are enabled
+func (app *VoiceTypeApp) count(name string) {
+	if app.metrics == nil {
+		return
+	}
+	if err := app.metrics.Increment(name); err != nil {
+		log.Printf("Failed to update metrics: %v", err)
+	}
+}
+
+// printStats prints the local metrics counters and returns the process exit code
+func printStats() int {
+	path, err := metrics.DefaultPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to locate metrics file: %v\n", err)
+		return 1
+	}
+
+	counters, err := metrics.NewStore(path).Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read metrics: %v\n", err)
+		return 1
+	}
+
+	if len(counters) == 0 {
+		fmt.Println("No metrics recorded. Enable with \"local_metrics\": true in config.json")
+		return 0
+	}
+	for _, name := range metrics.Names(counters) {
+		fmt.Printf("%-28s %d\n", name, counters[name])
+	}
+	return 0
+}
+
+// initLogger also writes the log to the log file, unless the disk is short
+// of cfg's min_free_space_mb
+func initLogger(cfg *config.Config) {
+	logPath, err := logger.LogPath()
 	if err != nil {
 		return
 	}
-	logPath := filepath.Join(filepath.Dir(path), "debug.log")
+	if err := retention.CheckSpace(filepath.Dir(logPath), retention.MinFreeBytes(cfg.MinFreeSpaceMB)); err != nil {
+		fmt.Printf("Warning: Not writing the log file: %v\n", err)
+		return
+	}
 
 	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
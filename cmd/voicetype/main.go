@@ -8,14 +8,27 @@ import (
 	"image/color"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"speek_to_text_linux/internal/api"
 	"speek_to_text_linux/internal/audio"
+	"speek_to_text_linux/internal/diagnostics"
+	"speek_to_text_linux/internal/history"
+	"speek_to_text_linux/internal/hotkey"
+	"speek_to_text_linux/internal/journal"
+	"speek_to_text_linux/internal/notify"
+	"speek_to_text_linux/internal/postprocess"
+	"speek_to_text_linux/internal/retention"
+	"speek_to_text_linux/internal/sound"
+	"speek_to_text_linux/internal/terminal"
+	"speek_to_text_linux/internal/tts"
 	"speek_to_text_linux/internal/typing"
+	"speek_to_text_linux/internal/ui"
 	"speek_to_text_linux/pkg/config"
+	"speek_to_text_linux/pkg/errors"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -43,12 +56,28 @@ type VoiceTypeApp struct {
 	statusLabel *widget.Label
 	icon        *canvas.Text
 	running     bool
+	journal     *journal.Journal
+	history     *history.History
+	recording   string // history file of the last recording
+	index       *history.Store
+	labels      *postprocess.LabelStripper
+	emoji       *postprocess.EmojiReplacer
+	inflight    ui.InFlight      // transcription goroutines shutdown waits for
+	warmer      *api.Warmer      // nil unless keep_warm_interval is set
+	undoHotkey  *hotkey.Listener // nil unless undo_hotkey is set
+	shortRetry  *ui.ShortRetry   // nil unless retry_on_short is set
+	sound       *sound.Player
 }
 
 func main() {
 	flagHelp := flag.Bool("help", false, "Show help")
 	flagDevice := flag.String("device", "", "Audio device")
 	flagNoReturn := flag.Bool("no-return", false, "Don't press Enter after typing")
+	flagDoctor := flag.Bool("doctor", false, "Check the environment and print a diagnostic report")
+	flagTeeFIFO := flag.String("tee-fifo", "", "Also write the live capture as raw PCM to this named pipe (created if missing) for other tools")
+	var flagSets config.Sets
+	flag.Var(&flagSets, "set", "Override a config key for this run only, e.g. --set language=es (repeatable)")
+	flagConfigJSON := flag.String("config-json", "", "Override config keys for this run only from a JSON object, e.g. '{\"auto_return\": true}'")
 	flag.Parse()
 
 	if *flagHelp {
@@ -57,21 +86,41 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Before Load, which would move a corrupt config aside unreported
+	if *flagDoctor {
+		os.Exit(runDoctor(*flagConfigJSON, flagSets))
+	}
+
 	log.Println("VoiceType v" + version + " starting...")
 
-	cfg, _ := config.Load()
-	cfg.AudioDevice = *flagDevice
+	cfg, configErr := config.Load()
+	if configErr != nil {
+		log.Printf("⚠️ %v", configErr)
+	}
+	if err := cfg.ApplyOverrides(*flagConfigJSON, flagSets); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config override: %v\n", err)
+		os.Exit(2)
+	}
 	if *flagNoReturn {
 		cfg.AutoReturn = false
+		cfg.PostTypeKey = typing.PostKeyNone
 	}
 
-	// Load or ask for API key
-	apiKey := loadAPIKey()
-	if apiKey == "" {
-		apiKey = askAPIKey()
-		saveAPIKey(apiKey)
+	provider, err := api.ParseProvider(cfg.Provider)
+	if err != nil {
+		log.Printf("%v, using %s", err, api.ProviderGroq)
+		provider = api.ProviderGroq
+	}
+	cfg.Provider = provider
+	// Load or ask for API key; a local server needs none
+	if provider == api.ProviderGroq {
+		apiKey := loadAPIKey()
+		if apiKey == "" {
+			apiKey = askAPIKey()
+			saveAPIKey(apiKey)
+		}
+		cfg.GROQ_API_KEY = apiKey
 	}
-	cfg.GROQ_API_KEY = apiKey
 
 	app := &VoiceTypeApp{
 		a:       app.NewWithID("com.voicetype.app"),
@@ -79,14 +128,123 @@ func main() {
 		running: true,
 	}
 
-	app.audioSys = audio.NewSystem(nil)
-	if err := app.audioSys.Initialize(cfg.AudioDevice); err != nil {
+	if errors.Is(configErr, errors.ErrConfigReset) && !cfg.DisableNotifications {
+		notifier := notify.NewNotifier(nil)
+		if err := notifier.Initialize(); err != nil {
+			log.Printf("⚠️ Notifications unavailable: %v", err)
+		} else if err := notifier.Notify("VoiceType", configResetMessage); err != nil {
+			log.Printf("⚠️ Notification failed: %v", err)
+		}
+	}
+
+	app.audioSys = audio.NewSystem(nil, cfg.CaptureBackend)
+	device := audio.ResolveDevice(*flagDevice, app.audioSys.GetDevices(), cfg.DeviceHistory, cfg.AudioDevice)
+	if err := app.audioSys.Initialize(device); err != nil {
 		log.Fatalf("Audio init failed: %v", err)
 	}
+	if *flagDevice != "" {
+		rememberDevice(cfg, app.audioSys.Device())
+	}
+	if source := audio.ResolvePulseSource(cfg.PulseSource, app.audioSys.Device(), audio.DefaultSource); source != "" {
+		app.audioSys.SetPulseSource(source)
+		log.Printf("Capturing from PulseAudio source %s", source)
+	}
+	if err := app.audioSys.SetCaptureFormat(cfg.CaptureFormat); err != nil {
+		log.Printf("%v, using %s", err, app.audioSys.CaptureFormat())
+	}
+	if err := app.audioSys.Configure(cfg.SampleRate, 1, 16); err != nil {
+		log.Printf("%v, recording %d Hz", err, app.audioSys.SampleRate())
+	}
+	if err := app.audioSys.SetBufferSizes(cfg.PeriodSize, cfg.BufferSize); err != nil {
+		log.Printf("%v, using ALSA defaults", err)
+	}
+	if err := app.audioSys.ValidateDevice(); err != nil {
+		log.Printf("Capture device check failed: %v", err)
+	}
+	if *flagTeeFIFO != "" {
+		if fifo, err := audio.OpenFIFO(*flagTeeFIFO); err != nil {
+			log.Printf("Audio tee disabled: %v", err)
+		} else {
+			app.audioSys.SetTee(fifo)
+			log.Printf("Teeing live audio (S16_LE, %d Hz, %d ch) to %s", app.audioSys.SampleRate(), app.audioSys.Channels(), *flagTeeFIFO)
+		}
+	}
+	app.audioSys.SetPreroll(cfg.PrerollMs)
+	app.audioSys.SetMinDuration(time.Duration(cfg.MinRecordingMs) * time.Millisecond)
+	app.audioSys.SetLookback(time.Duration(cfg.LookbackSeconds) * time.Second)
+	if err := app.audioSys.StartPreroll(); err != nil {
+		log.Printf("Pre-roll capture failed: %v", err)
+	}
 
-	app.apiClient = api.NewClient(cfg.GROQ_API_KEY, nil)
+	app.apiClient, err = api.NewForProvider(cfg.Provider, cfg.GROQ_API_KEY, cfg.LocalURL, nil)
+	if err != nil {
+		log.Fatalf("API client setup failed: %v", err)
+	}
+	app.apiClient.SetLanguage(cfg.Language)
+	app.apiClient.SetTranslate(cfg.Translate)
+	app.apiClient.SetPrompt(cfg.TranscriptionPrompt)
+	app.apiClient.SetPromptOverrides(cfg.PromptOverrides)
+	app.apiClient.SetGlossary(cfg.Glossary)
+	app.apiClient.SetRetryPolicy(cfg.APIRetries, time.Duration(cfg.APIRetryDelayMs)*time.Millisecond)
+	app.apiClient.SetRetryTimeouts(cfg.APIRetryTimeouts)
+	app.apiClient.SetTimeout(time.Duration(cfg.RequestTimeoutSecs) * time.Second)
+	app.apiClient.SetMaxConcurrentRequests(cfg.MaxConcurrentReqs)
+	app.apiClient.SetVerbose(cfg.Verbose)
+	app.apiClient.SetAudioFormat(app.audioSys.SampleRate(), app.audioSys.Channels(), app.audioSys.BitsPerSample())
+	app.apiClient.SetStreaming(cfg.StreamTranscription)
+	if cfg.StripLabels {
+		labels, err := postprocess.NewLabelStripper(cfg.LabelPatterns)
+		if err != nil {
+			log.Printf("Invalid label pattern: %v, using the built-in patterns", err)
+			labels = postprocess.DefaultLabelStripper()
+		}
+		app.labels = labels
+	}
+	if cfg.EmojiShortcodes {
+		app.emoji = postprocess.NewEmojiReplacer(cfg.EmojiMap)
+	}
+	app.sound = sound.NewPlayer()
+	app.sound.SetErrorSound(cfg.ErrorSound)
 	app.typer = typing.NewSystem()
+	app.typer.SetTypeDelay("xdotool", cfg.XdotoolTypeDelayMs)
+	app.typer.SetTypeDelay("ydotool", cfg.YdotoolTypeDelayMs)
+	app.typer.SetTypeDelay("wtype", cfg.WtypeTypeDelayMs)
+	if err := app.typer.SetReplacements(cfg.Replacements, cfg.AppReplacements); err != nil {
+		log.Printf("⚠️ Invalid replacements, skipping them: %v", err)
+	}
+	if cfg.SaveRecordings {
+		app.history = history.New(cfg.HistoryDir)
+		app.history.SetMaxAge(time.Duration(cfg.HistoryMaxAgeDays) * 24 * time.Hour)
+		app.history.SetMinFreeBytes(retention.MinFreeBytes(cfg.MinFreeSpaceMB))
+		if err := app.history.Prune(); err != nil {
+			log.Printf("❌ History prune error: %v", err)
+		}
+		app.index = history.NewStore("")
+		if err := app.index.Prune(time.Duration(cfg.HistoryMaxAgeDays) * 24 * time.Hour); err != nil {
+			log.Printf("❌ History prune error: %v", err)
+		}
+	}
+	if cfg.JournalDir != "" {
+		app.journal = journal.New(cfg.JournalDir)
+		app.journal.SetRetention(retention.FromConfig(cfg))
+		app.journal.SetMinFreeBytes(retention.MinFreeBytes(cfg.MinFreeSpaceMB))
+		if err := app.journal.Prune(); err != nil {
+			log.Printf("❌ Journal prune error: %v", err)
+		}
+	}
 	app.ctx, app.cancel = context.WithCancel(context.Background())
+	if cfg.KeepWarmInterval > 0 {
+		app.warmer = api.NewWarmer(time.Duration(cfg.KeepWarmInterval)*time.Second, time.Duration(cfg.KeepWarmIdleMinutes)*time.Minute, app.apiClient.Warm)
+		go app.warmer.Run(app.ctx)
+	}
+
+	if cfg.UndoHotkey != "" {
+		app.typer.EnableUndo()
+		app.listenForUndo(cfg.UndoHotkey)
+	}
+	if cfg.RetryOnShort {
+		app.shortRetry = ui.NewShortRetry(cfg.RetryOnShortMax)
+	}
 
 	// Create window
 	app.createWindow()
@@ -96,7 +254,17 @@ func main() {
 
 	fmt.Println()
 	fmt.Println("VoiceType is running!")
-	fmt.Println("Press ENTER to start/stop recording")
+	if terminal.IsTerminal(os.Stdin) {
+		fmt.Println("Press ENTER to start/stop recording")
+		fmt.Println("Type \"retry <model>\" + ENTER to re-transcribe the last recording")
+		if cfg.LookbackSeconds > 0 {
+			fmt.Printf("Type \"last\" + ENTER to transcribe the last %d seconds\n", cfg.LookbackSeconds)
+		}
+		fmt.Println("Type \"clip\" + ENTER to transcribe the audio file whose path is on the clipboard")
+		if cfg.UndoHotkey != "" {
+			fmt.Println("Type \"undo\" + ENTER to delete the text typed last")
+		}
+	}
 	fmt.Println("Or use the GUI window")
 	fmt.Println("Press Ctrl+C to quit")
 	fmt.Println()
@@ -108,6 +276,12 @@ func main() {
 }
 
 func (app *VoiceTypeApp) readStdin() {
+	// Launched from a desktop icon stdin is closed or /dev/null; reading it would spin on EOF
+	if !terminal.IsTerminal(os.Stdin) {
+		log.Println("stdin is not a terminal, Enter-to-toggle is disabled")
+		return
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		select {
@@ -122,9 +296,21 @@ func (app *VoiceTypeApp) readStdin() {
 			return
 		}
 
-		// Only react to pure Enter key
-		if strings.TrimSpace(line) == "" {
+		// Only react to pure Enter key, "last" to transcribe the look-back
+		// window, "clip" for a copied audio file path, "undo" to delete the
+		// last typed text when undo_hotkey is set, or "retry <model>" to
+		// re-run the last recording
+		line = strings.TrimSpace(line)
+		if line == "" {
 			app.toggleRecording()
+		} else if line == "last" {
+			app.transcribeLookback()
+		} else if line == "clip" {
+			app.transcribeClipboardFile()
+		} else if line == "undo" && app.cfg.UndoHotkey != "" {
+			app.undoLast()
+		} else if model, ok := strings.CutPrefix(line, "retry "); ok {
+			app.retryWithModel(strings.TrimSpace(model))
 		}
 	}
 }
@@ -152,10 +338,22 @@ func (app *VoiceTypeApp) startRecording() {
 	app.isRecording = true
 	app.mu.Unlock()
 
+	if app.warmer != nil {
+		app.warmer.Touch()
+	}
+
 	app.updateUI("🔴", "Recording...")
 	log.Println("🎤 Recording... (press Enter to stop)")
 }
 
+// rememberDevice records an explicitly chosen capture device so it is preferred next launch
+func rememberDevice(cfg *config.Config, device string) {
+	cfg.RememberDevice(audio.DeviceID(device))
+	if err := config.SaveKeys("", map[string]interface{}{"device_history": cfg.DeviceHistory}); err != nil {
+		log.Printf("Failed to save device history: %v", err)
+	}
+}
+
 func (app *VoiceTypeApp) stopRecording() {
 	audioData, err := app.audioSys.StopRecording()
 	if err != nil {
@@ -163,9 +361,16 @@ func (app *VoiceTypeApp) stopRecording() {
 		app.mu.Lock()
 		app.isRecording = false
 		app.mu.Unlock()
+		if app.shortRetry.Retry(err) {
+			log.Println("🔁 Too short, listening again...")
+			app.startRecording()
+			app.updateUI("🔴", "Listening again...")
+			return
+		}
 		app.updateUI("🎤", "Ready")
 		return
 	}
+	app.shortRetry.Reset()
 
 	app.mu.Lock()
 	app.isRecording = false
@@ -176,38 +381,280 @@ func (app *VoiceTypeApp) stopRecording() {
 		app.updateUI("🎤", "Ready")
 		return
 	}
+	if app.cfg.NoiseFloor > 0 && !audio.HasSpeech(audioData, app.audioSys.SampleRate(), app.audioSys.Channels(), audio.ThresholdFromFloor(app.cfg.NoiseFloor)) {
+		log.Println("⚠️ Nothing above the calibrated noise floor, not transcribing")
+		app.updateUI("🎤", "Ready")
+		return
+	}
 
 	log.Printf("⏹️ Stopped (%d bytes, transcribing...)", len(audioData))
 	app.updateUI("⏳", "Transcribing...")
+	recording := app.saveRecording(audioData)
 
 	// Transcribe in background
-	go func() {
+	app.inflight.Go(func() {
+		text, err := app.apiClient.TranscribeStream(app.ctx, audioData, func(partial string) {
+			// Live display of the tail of the text received so far
+			if r := []rune(partial); len(r) > 24 {
+				partial = "..." + string(r[len(r)-24:])
+			}
+			app.updateUI("⏳", partial)
+		})
+		app.deliver(text, app.source(app.apiClient.GetModel(), audioData), recording, err)
+	})
+
+	app.resetLater()
+}
+
+// saveRecording keeps audioData in the history when save_recordings is on
+// and returns its file, or "" when it isn't kept
+func (app *VoiceTypeApp) saveRecording(audioData []byte) string {
+	if app.history == nil {
+		return ""
+	}
+	path, err := app.history.SaveRecording(func(path string) error {
+		return app.audioSys.SaveDataToFile(path, audioData)
+	})
+	if err != nil {
+		log.Printf("❌ History error: %v", err)
+	}
+	app.mu.Lock()
+	app.recording = path
+	app.mu.Unlock()
+	return path
+}
+
+// retryWithModel re-transcribes the last recording with model and types the
+// result again, for when a transcription came out wrong
+func (app *VoiceTypeApp) retryWithModel(model string) {
+	app.mu.Lock()
+	recording, file := app.isRecording, app.recording
+	app.mu.Unlock()
+	if recording || model == "" {
+		return
+	}
+
+	log.Printf("🔁 Re-transcribing last recording with %s...", model)
+	app.updateUI("⏳", "Retrying...")
+	app.inflight.Go(func() {
+		text, err := app.apiClient.Retranscribe(app.ctx, model)
+		// The new transcription replaces the old one in the history
+		app.deliver(text, app.source(model, app.apiClient.LastAudio()), file, err)
+	})
+
+	app.resetLater()
+}
+
+// transcribeLookback transcribes the rolling look-back window, i.e. the last
+// few seconds heard, and types it without a recording having been started
+func (app *VoiceTypeApp) transcribeLookback() {
+	audioData := app.audioSys.Lookback()
+	if audioData == nil {
+		log.Println("⚠️ Look-back is off; set lookback_seconds in the config")
+		return
+	}
+	if len(audioData) == 0 {
+		log.Println("⚠️ No audio captured yet")
+		return
+	}
+
+	log.Printf("⏪ Transcribing the last %.1fs...", app.audioSys.DurationOf(audioData).Seconds())
+	app.updateUI("⏳", "Transcribing...")
+	app.inflight.Go(func() {
 		text, err := app.apiClient.Transcribe(app.ctx, audioData)
-		if err != nil {
-			log.Printf("❌ Transcription failed: %v", err)
-			app.updateUI("❌", "Error")
-			return
+		app.deliver(text, app.source(app.apiClient.GetModel(), audioData), "", err)
+	})
+
+	app.resetLater()
+}
+
+// transcribeClipboardFile transcribes the audio file whose path was copied
+// to the clipboard, e.g. from a file manager, and types the text
+func (app *VoiceTypeApp) transcribeClipboardFile() {
+	app.mu.Lock()
+	recording := app.isRecording
+	app.mu.Unlock()
+	if recording {
+		return
+	}
+
+	text, err := app.typer.GetClipboard(app.ctx)
+	if err != nil {
+		log.Printf("⚠️ Could not read the clipboard: %v", err)
+		return
+	}
+	path, err := api.AudioPathFromText(text)
+	if err != nil {
+		log.Printf("⚠️ Clipboard doesn't hold an audio file path: %v", err)
+		return
+	}
+
+	log.Printf("📄 Transcribing %s...", filepath.Base(path))
+	app.updateUI("⏳", "Transcribing...")
+	app.inflight.Go(func() {
+		text, err := app.apiClient.TranscribeFile(app.ctx, path, api.Overrides{})
+		// A compressed file's length isn't known without decoding it
+		app.deliver(text, postprocess.Metadata{Model: app.apiClient.GetModel()}, "", err)
+	})
+
+	app.resetLater()
+}
+
+// source describes a transcription of audioData by model, for
+// output_metadata_template
+func (app *VoiceTypeApp) source(model string, audioData []byte) postprocess.Metadata {
+	return postprocess.Metadata{Model: model, Duration: app.audioSys.DurationOf(audioData)}
+}
+
+// listenForUndo binds combo to undoLast
+func (app *VoiceTypeApp) listenForUndo(combo string) {
+	listener := hotkey.NewListener(nil)
+	if err := listener.Initialize(combo); err != nil {
+		log.Printf("⚠️ Undo hotkey unavailable: %v", err)
+		return
+	}
+	listener.OnPress(app.undoLast)
+	if err := listener.Start(); err != nil {
+		log.Printf("⚠️ Undo hotkey start failed: %v", err)
+		return
+	}
+	app.undoHotkey = listener
+}
+
+// undoLast deletes the text typed last, e.g. a garbled transcription
+func (app *VoiceTypeApp) undoLast() {
+	if err := app.typer.UndoLast(app.ctx); err != nil {
+		log.Printf("⚠️ Undo failed: %v", err)
+		return
+	}
+	log.Println("↩️ Undid the last insertion")
+	app.updateUI("↩️", "Undone")
+	app.resetLater()
+}
+
+// deliver post-processes a finished transcription and types it, with the
+// metadata of where it came from when output_metadata_template is set. The
+// text is also saved next to recording, its file in the history, if any.
+func (app *VoiceTypeApp) deliver(text string, meta postprocess.Metadata, recording string, err error) {
+	if err != nil {
+		log.Printf("❌ Transcription failed: %v", err)
+		app.updateUI("❌", "Error")
+		app.playErrorSound()
+		return
+	}
+
+	if app.cfg.StripModelArtifacts {
+		text = postprocess.StripArtifacts(text)
+	}
+	if app.labels != nil {
+		text = app.labels.Strip(text)
+	}
+	if app.emoji != nil {
+		text = app.emoji.Replace(text)
+	}
+	// Unknown is taken for English, as the default prompt is
+	if language := app.apiClient.LanguageFor(""); app.cfg.FixEnglish && (language == "" || api.IsEnglish(language)) {
+		text = postprocess.FixEnglish(text)
+	}
+	if app.cfg.ForceSentenceCase {
+		text = postprocess.SentenceCase(text, app.cfg.Acronyms)
+	}
+	text = postprocess.NormalizeNewlines(text, app.cfg.NewlineStyle)
+	if !postprocess.HasContent(text) {
+		log.Println("⚠️ No speech detected")
+		app.saveTranscript(recording, text, meta)
+		app.updateUI("🎤", "Ready")
+		return
+	}
+
+	// Never auto-type into what looks like a password prompt, nor log, journal
+	// or keep what was said
+	if typing.ShouldAvoidTyping(app.typer.DetectFieldKind(), app.cfg.AvoidPasswordFields) {
+		log.Println("⚠️ Focused window looks like a password prompt, copied transcription to clipboard instead of typing")
+		if err := app.typer.SetPrimarySelection(app.ctx, text); err != nil {
+			log.Printf("❌ Clipboard error: %v", err)
+		}
+		if recording != "" {
+			if err := app.history.Discard(recording); err != nil {
+				log.Printf("❌ History error: %v", err)
+			}
 		}
+		app.updateUI("📋", "Password field: copied")
+		return
+	}
 
-		if text == "" {
-			log.Println("⚠️ No speech detected")
-			app.updateUI("🎤", "Ready")
+	log.Printf("✅ \"%s\"", text)
+
+	if app.journal != nil {
+		if err := app.journal.Append(text); err != nil {
+			log.Printf("❌ Journal error: %v", err)
+		} else if app.cfg.JournalOnly {
+			app.saveTranscript(recording, text, meta)
+			app.updateUI("✅", "Journaled: "+postprocess.Truncate(text, 24))
 			return
 		}
+	}
+
+	app.saveTranscript(recording, text, meta)
+
+	text = app.typer.ApplyReplacements(text)
+
+	if app.cfg.ReadBack {
+		app.updateUI("🔊", "Reading back...")
+		if err := tts.Speak(app.ctx, text); err != nil {
+			log.Printf("⚠️ Read-back failed, typing anyway: %v", err)
+		}
+	}
 
-		log.Printf("✅ \"%s\"", text)
+	meta.Time = time.Now()
+	text = postprocess.AddMetadata(text, app.cfg.OutputMetadataTemplate, app.cfg.OutputMetadataPosition, meta)
 
-		if err := app.typer.TypeText(app.ctx, text, app.cfg.AutoReturn); err != nil {
-			log.Printf("❌ Type error: %v", err)
+	method := app.typer.DeliveryFor(app.cfg.DeliveryMethod, app.cfg.AppDelivery)
+	if app.emoji != nil && postprocess.HasEmoji(text) {
+		// Typing tools mangle emoji; the clipboard carries them intact
+		method = typing.DeliveryPaste
+	}
+	if err := app.typer.DeliverText(app.ctx, text, typing.PostKeyForText(text, app.cfg.PostTypeKey, app.cfg.AutoReturn, app.cfg.SmartEnter), method); err != nil {
+		log.Printf("❌ Type error: %v", err)
+		if errors.Is(err, typing.ErrLeftOnClipboard) {
+			app.updateUI("📋", "Copied: paste manually")
+		} else {
 			app.updateUI("❌", "Type error")
-			return
 		}
+		app.playErrorSound()
+		return
+	}
 
-		log.Println("📋 Text pasted!")
-		app.updateUI("✅", "Done: "+text[:min(20, len(text))]+"...")
-	}()
+	log.Println("📋 Text pasted!")
+	app.updateUI("✅", "Done: "+postprocess.Truncate(text, 24))
+}
 
-	// Reset to ready after delay
+// saveTranscript writes text next to recording, its file in the history, if
+// any, and adds it to the history window's index
+func (app *VoiceTypeApp) saveTranscript(recording, text string, meta postprocess.Metadata) {
+	if recording != "" {
+		if err := app.history.SaveTranscript(recording, text); err != nil {
+			log.Printf("❌ History error: %v", err)
+		}
+	}
+	if app.index == nil || !postprocess.HasContent(text) {
+		return
+	}
+	entry := history.Entry{Time: time.Now(), Duration: meta.Duration.Seconds(), Text: text, Audio: recording}
+	if err := app.index.Append(entry); err != nil {
+		log.Printf("❌ History error: %v", err)
+	}
+}
+
+// playErrorSound plays the error_sound cue
+func (app *VoiceTypeApp) playErrorSound() {
+	if err := app.sound.Error(app.ctx); err != nil {
+		log.Printf("❌ Error sound failed: %v", err)
+	}
+}
+
+// resetLater returns the window to ready a few seconds after a transcription starts
+func (app *VoiceTypeApp) resetLater() {
 	time.AfterFunc(3*time.Second, func() {
 		app.mu.Lock()
 		if !app.isRecording {
@@ -217,13 +664,6 @@ func (app *VoiceTypeApp) stopRecording() {
 	})
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 func (app *VoiceTypeApp) updateUI(icon, status string) {
 	fyne.DoAndWait(func() {
 		if app.icon != nil {
@@ -270,7 +710,14 @@ func (app *VoiceTypeApp) createWindow() {
 
 func (app *VoiceTypeApp) shutdown() {
 	log.Println("Shutting down...")
+	// Let a transcription in flight finish typing before its context is cancelled
+	if !app.inflight.Wait(ui.DefaultShutdownTimeout) {
+		log.Printf("Delivery still running after %v, exiting anyway", ui.DefaultShutdownTimeout)
+	}
 	app.cancel()
+	if app.undoHotkey != nil {
+		app.undoHotkey.Close()
+	}
 	app.audioSys.Close()
 	if app.window != nil {
 		app.window.Close()
@@ -278,6 +725,57 @@ func (app *VoiceTypeApp) shutdown() {
 	log.Println("Done")
 }
 
+// configResetMessage tells the user their unreadable config was replaced;
+// the log names the backup
+const configResetMessage = "Config file was unreadable and has been reset to defaults. The old file was backed up next to it."
+
+// runDoctor prints the environment checklist and returns the process exit code
+func runDoctor(configJSON string, sets config.Sets) int {
+	// A config file that doesn't parse is one of the doctor's own failures
+	cfg, err := config.Inspect()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if err := cfg.ApplyOverrides(configJSON, sets); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config override: %v\n", err)
+		return 2
+	}
+
+	apiKey := loadAPIKey()
+	if apiKey == "" {
+		apiKey = cfg.GROQ_API_KEY
+	}
+	var healthCheck func(ctx context.Context) error
+	if provider, err := api.ParseProvider(cfg.Provider); err == nil {
+		switch {
+		case provider == api.ProviderLocal:
+			healthCheck = api.NewLocalWhisperClient(cfg.LocalURL, nil).HealthCheck
+		case apiKey != "":
+			healthCheck = api.NewClient(apiKey, nil).HealthCheck
+		}
+	}
+
+	doctor := diagnostics.NewDoctor(healthCheck)
+	// The key saved at first run lives in its own file, not in config.json
+	doctor.LoadConfig = func() (*config.Config, error) {
+		cfg, err := config.Inspect()
+		if err == nil && apiKey != "" {
+			cfg.GROQ_API_KEY = apiKey
+		}
+		return cfg, err
+	}
+
+	report := doctor.Run(context.Background())
+	fmt.Println("VoiceType v" + version + " diagnostics")
+	fmt.Println()
+	report.Print(os.Stdout)
+
+	if report.Worst() == diagnostics.StatusFail {
+		return 1
+	}
+	return 0
+}
+
 func getConfigPath() string {
 	home, _ := os.UserHomeDir()
 	return home + "/" + configFile
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Groq rejects uploads over 25 MB, about 13 minutes of 16 kHz mono audio, so
+// longer recordings are transcribed in overlapping chunks and stitched
+const (
+	DefaultChunkLength  = 10 * time.Minute
+	DefaultChunkOverlap = 5 * time.Second
+)
+
+// maxChunkBytes is the most audio one request carries, leaving room under
+// MaxUploadBytes for the WAV header and the other form fields
+const maxChunkBytes = MaxUploadBytes - 64<<10
+
+// maxSeamWords bounds how many words at a seam are compared for duplicates
+const maxSeamWords = 30
+
+// chunk is a piece of a recording and where it starts in the recording
+type chunk struct {
+	offset time.Duration
+	data   []byte
+}
+
+// SetChunking sets the longest recording uploaded in one request, and how
+// much consecutive chunks of a longer one overlap so a word cut at a chunk
+// boundary is heard whole in one of them. Whatever the length, a chunk never
+// exceeds the upload limit, which at high sample rates is the tighter bound;
+// a length of 0 leaves only that limit.
+func (c *Client) SetChunking(length, overlap time.Duration) {
+	if overlap < 0 {
+		overlap = 0
+	}
+	if length > 0 && overlap > length/2 {
+		overlap = length / 2
+	}
+	c.chunkLength = length
+	c.chunkOverlap = overlap
+}
+
+// needsChunking reports whether n bytes of audio are too long for one request
+func (c *Client) needsChunking(n int) bool {
+	return n > c.chunkBytes()
+}
+
+// chunkBytes returns the most audio uploaded in one request: chunkLength of
+// it, capped by the upload limit, in whole frames
+func (c *Client) chunkBytes() int {
+	frame := c.channels * c.bitsPerSample / 8
+	n := maxChunkBytes - maxChunkBytes%frame
+	if c.chunkLength > 0 {
+		n = min(n, c.audioBytes(c.chunkLength))
+	}
+	return n
+}
+
+// audioBytes returns how many bytes of audio last d, rounded down to whole frames
+func (c *Client) audioBytes(d time.Duration) int {
+	frame := c.channels * c.bitsPerSample / 8
+	n := int(int64(d) * int64(c.sampleRate*frame) / int64(time.Second))
+	return n - n%frame
+}
+
+// splitChunks cuts audio into pieces of length bytes, each starting overlap
+// bytes before the previous one ends. The last piece may be shorter.
+func splitChunks(audio []byte, length, overlap, bytesPerSecond int) []chunk {
+	step := length - overlap
+	var chunks []chunk
+	for start := 0; ; start += step {
+		end := min(start+length, len(audio))
+		chunks = append(chunks, chunk{
+			offset: time.Duration(int64(start) * int64(time.Second) / int64(bytesPerSecond)),
+			data:   audio[start:end],
+		})
+		if end == len(audio) {
+			return chunks
+		}
+	}
+}
+
+// transcribeChunked transcribes a long recording in overlapping chunks, as
+// many at a time as SetMaxConcurrentRequests allows, and stitches the
+// results. It fails if any chunk fails, since a transcript with a hole in
+// the middle would be typed as if complete.
+func (c *Client) transcribeChunked(ctx context.Context, audioData []byte, model, language string) (Response, error) {
+	bytesPerSecond := c.sampleRate * c.channels * c.bitsPerSample / 8
+	length := c.chunkBytes()
+	overlap := min(c.audioBytes(c.chunkOverlap), length/2)
+	chunks := splitChunks(audioData, length, overlap, bytesPerSecond)
+	log.Printf("Recording exceeds %d bytes per request, transcribing it in %d chunks", length, len(chunks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Response, len(chunks))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, ch := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			result, err := c.request(ctx, ch.data, model, language, false, nil)
+			if err != nil {
+				// The first failure is the cause; the rest are cancellations
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return Response{}, firstErr
+	}
+	result := stitch(chunks, results, time.Duration(int64(overlap)*int64(time.Second)/int64(bytesPerSecond)))
+	result.Duration = float64(len(audioData)) / float64(bytesPerSecond)
+	return result, nil
+}
+
+// stitch joins chunk transcripts. Where the response has segment timestamps,
+// each overlap is cut halfway: segments centred before the cut come from the
+// earlier chunk, the rest from the later one. Words still repeated across a
+// seam, from segments straddling the cut or from responses without
+// timestamps, are dropped from the later chunk. The kept segments are
+// renumbered with times relative to the whole recording.
+func stitch(chunks []chunk, results []Response, overlap time.Duration) Response {
+	var merged Response
+	var words []string
+	for i, r := range results {
+		if merged.Language == "" {
+			merged.Language = r.Language
+		}
+		from, to := time.Duration(-1), time.Duration(-1)
+		if i > 0 {
+			from = chunks[i].offset + overlap/2
+		}
+		if i < len(results)-1 {
+			to = chunks[i+1].offset + overlap/2
+		}
+
+		text := r.Text
+		segments := segmentsBetween(r, chunks[i].offset, from, to)
+		if len(r.Segments) > 0 {
+			parts := make([]string, len(segments))
+			for j, seg := range segments {
+				parts[j] = seg.Text
+			}
+			text = strings.Join(parts, " ")
+		}
+
+		next := strings.Fields(text)
+		drop := seamOverlap(words, next)
+		words = append(words, next[drop:]...)
+		merged.Segments = append(merged.Segments, dropLeadingWords(segments, drop)...)
+		// Words are short enough that the midpoint cut alone dedupes them
+		merged.Words = append(merged.Words, wordsBetween(r, chunks[i].offset, from, to)...)
+	}
+
+	for i := range merged.Segments {
+		merged.Segments[i].ID = i
+	}
+	merged.Text = strings.Join(words, " ")
+	return merged
+}
+
+// segmentsBetween returns r's segments whose midpoint, offset into the
+// recording, falls in [from, to), shifted by offset and trimmed; a negative
+// bound is open
+func segmentsBetween(r Response, offset, from, to time.Duration) []Segment {
+	var kept []Segment
+	for _, seg := range r.Segments {
+		mid := offset + time.Duration((seg.Start+seg.End)/2*float64(time.Second))
+		if (from >= 0 && mid < from) || (to >= 0 && mid >= to) {
+			continue
+		}
+		seg.Start += offset.Seconds()
+		seg.End += offset.Seconds()
+		seg.Text = strings.TrimSpace(seg.Text)
+		kept = append(kept, seg)
+	}
+	return kept
+}
+
+// wordsBetween is segmentsBetween for r's word timings
+func wordsBetween(r Response, offset, from, to time.Duration) []Word {
+	var kept []Word
+	for _, w := range r.Words {
+		mid := offset + time.Duration((w.Start+w.End)/2*float64(time.Second))
+		if (from >= 0 && mid < from) || (to >= 0 && mid >= to) {
+			continue
+		}
+		w.Start += offset.Seconds()
+		w.End += offset.Seconds()
+		kept = append(kept, w)
+	}
+	return kept
+}
+
+// dropLeadingWords removes the first n words from segments, dropping any
+// segment left empty
+func dropLeadingWords(segments []Segment, n int) []Segment {
+	for n > 0 && len(segments) > 0 {
+		words := strings.Fields(segments[0].Text)
+		if len(words) > n {
+			segments[0].Text = strings.Join(words[n:], " ")
+			break
+		}
+		n -= len(words)
+		segments = segments[1:]
+	}
+	return segments
+}
+
+// seamOverlap returns how many leading words of next repeat the last words
+// of prev, ignoring case and punctuation
+func seamOverlap(prev, next []string) int {
+	limit := min(maxSeamWords, len(prev), len(next))
+	for k := limit; k > 0; k-- {
+		match := true
+		for j := 0; j < k; j++ {
+			if normalizeWord(prev[len(prev)-k+j]) != normalizeWord(next[j]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k
+		}
+	}
+	return 0
+}
+
+// normalizeWord lowercases w and trims surrounding punctuation
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"speek_to_text_linux/pkg/wav"
+)
+
+func TestSplitChunksBoundaries(t *testing.T) {
+	// 1000 bytes per second: 2.5s of audio in 1s chunks overlapping by 0.2s
+	audio := make([]byte, 2500)
+	chunks := splitChunks(audio, 1000, 200, 1000)
+
+	expected := []struct {
+		offset time.Duration
+		start  int
+		length int
+	}{
+		{0, 0, 1000},
+		{800 * time.Millisecond, 800, 1000},
+		{1600 * time.Millisecond, 1600, 900},
+	}
+	if len(chunks) != len(expected) {
+		t.Fatalf("Expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, want := range expected {
+		got := chunks[i]
+		if got.offset != want.offset || len(got.data) != want.length || &got.data[0] != &audio[want.start] {
+			t.Errorf("Chunk %d: offset %v, %d bytes; want offset %v, %d bytes from %d", i, got.offset, len(got.data), want.offset, want.length, want.start)
+		}
+	}
+}
+
+func TestSplitChunksExactFit(t *testing.T) {
+	chunks := splitChunks(make([]byte, 1000), 1000, 200, 1000)
+	if len(chunks) != 1 || len(chunks[0].data) != 1000 {
+		t.Errorf("Expected audio of exactly one chunk's length to stay whole, got %d chunks", len(chunks))
+	}
+}
+
+func TestAudioBytesWholeFrames(t *testing.T) {
+	client := NewClient("test", nil)
+	client.SetAudioFormat(44100, 2, 16)
+
+	// 44100 * 4 bytes/s * 0.0001s = 17.64 bytes, rounded down to 4 frames
+	if got := client.audioBytes(100 * time.Microsecond); got != 16 {
+		t.Errorf("Expected 16 bytes, got %d", got)
+	}
+	if got := client.audioBytes(time.Second); got != 176400 {
+		t.Errorf("Expected 176400 bytes, got %d", got)
+	}
+}
+
+func TestSeamOverlap(t *testing.T) {
+	testCases := []struct {
+		prev, next string
+		expected   int
+	}{
+		{"we should meet on", "on Tuesday then", 1},
+		{"we should meet on Tuesday.", "Tuesday, then we", 1},
+		{"the quick brown fox", "Quick brown fox jumps", 3},
+		{"no shared words", "at the seam", 0},
+		{"", "anything", 0},
+		{"anything", "", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := seamOverlap(strings.Fields(tc.prev), strings.Fields(tc.next)); got != tc.expected {
+			t.Errorf("seamOverlap(%q, %q) = %d, want %d", tc.prev, tc.next, got, tc.expected)
+		}
+	}
+}
+
+func TestStitchCutsOverlapBySegmentTime(t *testing.T) {
+	chunks := []chunk{{offset: 0}, {offset: 8 * time.Second}}
+	results := []Response{
+		{Segments: []Segment{
+			{Start: 0, End: 4, Text: "first part"},
+			{Start: 4, End: 8.5, Text: "second part"},
+			// Centred at 9.5s, past the cut at 9s: left to the next chunk
+			{Start: 9, End: 10, Text: "heard twice"},
+		}},
+		{Segments: []Segment{
+			// 8.2s, before the cut: already kept from the first chunk
+			{Start: 0, End: 0.4, Text: "part"},
+			{Start: 1, End: 2, Text: "heard twice"},
+			{Start: 2, End: 5, Text: "the end"},
+		}},
+	}
+
+	if got := stitch(chunks, results, 2*time.Second).Text; got != "first part second part heard twice the end" {
+		t.Errorf("Unexpected stitch: %q", got)
+	}
+}
+
+func TestStitchDedupesStraddlingSegment(t *testing.T) {
+	chunks := []chunk{{offset: 0}, {offset: 8 * time.Second}}
+	results := []Response{
+		// Centred at 7.5s, kept here, but runs on to 10s
+		{Segments: []Segment{{Start: 5, End: 10, Text: "see you on Monday"}}},
+		// Centred at 10s, kept here, but starts back at 9s
+		{Segments: []Segment{{Start: 1, End: 3, Text: "Monday, at noon"}}},
+	}
+
+	merged := stitch(chunks, results, 2*time.Second)
+	if merged.Text != "see you on Monday at noon" {
+		t.Errorf("Expected the word repeated across the seam once, got %q", merged.Text)
+	}
+
+	// The later segment loses the repeated word and moves to recording time
+	expected := []Segment{
+		{ID: 0, Start: 5, End: 10, Text: "see you on Monday"},
+		{ID: 1, Start: 9, End: 11, Text: "at noon"},
+	}
+	if !reflect.DeepEqual(merged.Segments, expected) {
+		t.Errorf("Expected segments %+v, got %+v", expected, merged.Segments)
+	}
+}
+
+func TestStitchMergesWords(t *testing.T) {
+	chunks := []chunk{{offset: 0}, {offset: 8 * time.Second}}
+	results := []Response{
+		{
+			Segments: []Segment{{Start: 5, End: 10, Text: "see you Monday"}},
+			Words: []Word{
+				{Word: "see", Start: 5, End: 6},
+				{Word: "you", Start: 6, End: 7},
+				{Word: "Monday", Start: 8.6, End: 9.2},
+			},
+		},
+		{
+			Segments: []Segment{{Start: 0.5, End: 3, Text: "Monday, at noon"}},
+			Words: []Word{
+				// Centred at 8.9s, before the cut: already kept from the first chunk
+				{Word: "Monday", Start: 0.6, End: 1.2},
+				{Word: "at", Start: 1.5, End: 2},
+				{Word: "noon", Start: 2, End: 3},
+			},
+		},
+	}
+
+	merged := stitch(chunks, results, 2*time.Second)
+	expected := []Word{
+		{Word: "see", Start: 5, End: 6},
+		{Word: "you", Start: 6, End: 7},
+		{Word: "Monday", Start: 8.6, End: 9.2},
+		{Word: "at", Start: 9.5, End: 10},
+		{Word: "noon", Start: 10, End: 11},
+	}
+	if !reflect.DeepEqual(merged.Words, expected) {
+		t.Errorf("Expected words %+v, got %+v", expected, merged.Words)
+	}
+}
+
+func TestStitchWithoutSegmentsDedupesText(t *testing.T) {
+	chunks := []chunk{{offset: 0}, {offset: 8 * time.Second}}
+	results := []Response{
+		{Text: "this is the start and the overlap here"},
+		{Text: "The overlap here, and the rest"},
+	}
+
+	if got := stitch(chunks, results, 2*time.Second).Text; got != "this is the start and the overlap here and the rest" {
+		t.Errorf("Unexpected stitch: %q", got)
+	}
+}
+
+func TestLongRecordingTranscribedInChunks(t *testing.T) {
+	// Every 100ms block of audio holds its own index as the sample value, and
+	// the server answers with one word per block, so the stitched text shows
+	// exactly which audio was kept
+	const blockMs = 100
+	client := NewClient("test", nil)
+	blockBytes := client.audioBytes(blockMs * time.Millisecond)
+	blocks := 57
+	audio := make([]byte, blocks*blockBytes)
+	for b := 0; b < blocks; b++ {
+		for i := 0; i < blockBytes; i += 2 {
+			binary.LittleEndian.PutUint16(audio[b*blockBytes+i:], uint16(b))
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		requests int
+		active   int
+		peak     int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("No audio in request: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		_, pcm, err := wav.Decode(data)
+		if err != nil {
+			t.Errorf("Invalid WAV: %v", err)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var resp Response
+		first := int(binary.LittleEndian.Uint16(pcm))
+		for i := 0; i*blockBytes < len(pcm); i++ {
+			start := float64(i*blockMs) / 1000
+			resp.Segments = append(resp.Segments, Segment{Start: start, End: start + blockMs/1000.0, Text: fmt.Sprintf("w%d", first+i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client.baseURL = server.URL
+	client.SetChunking(time.Second, 300*time.Millisecond)
+
+	text, err := client.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+
+	var want []string
+	for b := 0; b < blocks; b++ {
+		want = append(want, fmt.Sprintf("w%d", b))
+	}
+	if text != strings.Join(want, " ") {
+		t.Errorf("Expected every block once in order, got %q", text)
+	}
+	if requests != 8 {
+		t.Errorf("Expected 8 chunk requests, got %d", requests)
+	}
+	if peak > DefaultMaxConcurrentRequests {
+		t.Errorf("Expected at most %d requests at once, saw %d", DefaultMaxConcurrentRequests, peak)
+	}
+	result, err := client.TranscribeDetailed(context.Background(), audio)
+	if err != nil {
+		t.Fatalf("TranscribeDetailed failed: %v", err)
+	}
+	if len(result.Segments) != blocks {
+		t.Fatalf("Expected %d merged segments, got %d", blocks, len(result.Segments))
+	}
+	for i, seg := range result.Segments {
+		if seg.ID != i || seg.Text != want[i] || math.Abs(seg.Start-float64(i*blockMs)/1000) > 1e-6 {
+			t.Errorf("Segment %d: %+v, want %q starting at %vs", i, seg, want[i], float64(i*blockMs)/1000)
+			break
+		}
+	}
+	if math.Abs(result.Duration-5.7) > 1e-6 {
+		t.Errorf("Expected the duration of the whole recording, got %v", result.Duration)
+	}
+
+	if len(client.LastAudio()) != len(audio) {
+		t.Error("Expected the whole recording kept for Retranscribe, not a chunk")
+	}
+}
+
+func TestChunkFailureFailsTranscription(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		if n == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"text":"fine"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetChunking(time.Second, 200*time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, client.audioBytes(3*time.Second))); err == nil {
+		t.Error("Expected a failed chunk to fail the transcription rather than leave a gap")
+	}
+}
+
+func TestShortRecordingNotChunked(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetChunking(time.Second, 200*time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, client.audioBytes(time.Second))); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request for a recording within the chunk length, got %d", requests)
+	}
+}
+
+func TestChunkBytesCappedByUploadLimit(t *testing.T) {
+	client := NewClient("test", nil)
+	client.SetAudioFormat(48000, 2, 16)
+
+	// Ten minutes of 48 kHz stereo is about 110 MB
+	if n := client.chunkBytes(); n > maxChunkBytes || n%4 != 0 {
+		t.Errorf("Expected whole frames within the upload limit, got %d bytes", n)
+	}
+	if !client.needsChunking(client.audioBytes(5 * time.Minute)) {
+		t.Error("Expected five minutes of 48 kHz stereo to be chunked")
+	}
+
+	client.SetChunking(0, 0)
+	if client.needsChunking(maxChunkBytes) || !client.needsChunking(maxChunkBytes+4) {
+		t.Error("Expected chunking at the upload limit without a chunk length")
+	}
+}
+
+func TestStreamedLongRecordingChunked(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Write([]byte(`{"text":"fine"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetStreaming(true)
+	client.SetChunking(time.Second, 200*time.Millisecond)
+
+	var partials []string
+	text, err := client.TranscribeStream(context.Background(), make([]byte, client.audioBytes(3*time.Second)), func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil {
+		t.Fatalf("TranscribeStream failed: %v", err)
+	}
+	if requests < 2 {
+		t.Errorf("Expected a streamed recording over the chunk length to be chunked, got %d requests", requests)
+	}
+	if len(partials) != 1 || partials[0] != text {
+		t.Errorf("Expected the stitched text once, got %q", partials)
+	}
+}
@@ -4,37 +4,111 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"speek_to_text_linux/pkg/config"
 	"speek_to_text_linux/pkg/errors"
 	"speek_to_text_linux/pkg/wav"
 )
 
 // Client represents the Groq API client
 type Client struct {
-	apiKey     string
-	baseURL    string
-	model      string
-	httpClient *http.Client
-	errHandler *errors.Handler
+	apiKey            string // replaced by SetAPIKey while requests run; guarded by lastMu
+	baseURL           string
+	model             string
+	language          string
+	detectedLanguage  string // language Whisper reported for the last transcription; guarded by lastMu
+	raw               atomic.Bool
+	translate         bool   // use /audio/translations, which answers in English
+	granularity       string // timestamp_granularities requested; empty leaves it to the API
+	retries           int
+	retryDelay        time.Duration // first backoff, doubled on each further retry
+	retryTimeouts     bool          // retry an attempt that ran out of time
+	stream            bool
+	verbose           bool // self-check each encoded WAV before upload
+	sampleRate        int  // format of the PCM passed in, written to the WAV header
+	channels          int
+	bitsPerSample     int
+	idempotencyHeader string // header carrying the per-transcription key; empty disables it
+	prompt            string // instruction prompt for English or undetected speech
+	promptOverrides   map[string]string
+	glossary          []string
+	lastMu            sync.Mutex
+	lastAudio         []byte        // most recent recording, kept for Retranscribe
+	chunkLength       time.Duration // recordings longer than this are split; 0 splits only at the upload limit
+	chunkOverlap      time.Duration
+	timeout           time.Duration // per-request timeout; 0 scales it with the audio from baseTimeout
+	baseTimeout       time.Duration
+	slots             chan struct{} // one per request in flight; nil is unlimited
+	warmWithAudio     bool          // Warm transcribes silence rather than listing models
+	httpClient        *http.Client
+	errHandler        *errors.Handler
 }
 
+// neutralPrompt is used for non-English speech, where English filler-word instructions degrade output
+const neutralPrompt = ""
+
+// maxPromptChars caps the prompt sent to Whisper, which only reads its last
+// 224 tokens (roughly four characters each)
+const maxPromptChars = 896
+
+// DefaultIdempotencyHeader is sent to Groq on a best-effort basis; it may ignore it
+const DefaultIdempotencyHeader = "Idempotency-Key"
+
+// retryBackoff is the default delay before the first retry; it doubles on
+// each retry after that
+var retryBackoff = time.Second
+
+// DefaultTimeout is the per-request timeout for no audio at all; each second
+// of audio adds timeoutPerSecond for the upload and transcription
+const DefaultTimeout = 30 * time.Second
+
+const timeoutPerSecond = 2
+
+// DefaultMaxConcurrentRequests caps the requests a client has in flight at
+// once, across chunks and concurrent transcriptions, to stay under rate limits
+const DefaultMaxConcurrentRequests = 3
+
+// maxRetryAfter is the longest Retry-After a rate-limited request waits out;
+// a longer one fails straight away rather than stalling the recording
+const maxRetryAfter = 30 * time.Second
+
+// encodeWAV is swapped in tests to simulate an encoding regression
+var encodeWAV = wav.Encode
+
 // NewClient creates a new API client
 func NewClient(apiKey string, errHandler *errors.Handler) *Client {
 	return &Client{
 		apiKey:  apiKey,
 		baseURL: "https://api.groq.com/openai/v1",
 		model:   "whisper-large-v3",
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		errHandler: errHandler,
+		// Requests get a deadline through their context instead, sized to the audio
+		httpClient:        &http.Client{},
+		baseTimeout:       DefaultTimeout,
+		errHandler:        errHandler,
+		idempotencyHeader: DefaultIdempotencyHeader,
+		retries:           config.DefaultAPIRetries,
+		retryDelay:        retryBackoff,
+		sampleRate:        16000,
+		channels:          1,
+		bitsPerSample:     16,
+		chunkLength:       DefaultChunkLength,
+		chunkOverlap:      DefaultChunkOverlap,
+		prompt:            config.DefaultTranscriptionPrompt,
+		slots:             make(chan struct{}, DefaultMaxConcurrentRequests),
 	}
 }
 
@@ -50,86 +124,458 @@ type Response struct {
 	Language string    `json:"language"`
 	Duration float64   `json:"duration"`
 	Segments []Segment `json:"segments"`
+	Words    []Word    `json:"words,omitempty"`
 }
 
 // AudioData contains audio-related response data
 type AudioData struct {
 }
 
-// Segment represents a segment of transcribed audio
+// Segment represents a segment of transcribed audio. Whisper reports its
+// certainty as AvgLogprob (closer to 0 is more confident) and NoSpeechProb.
 type Segment struct {
-	ID         int     `json:"id"`
-	Start      float64 `json:"start"`
-	End        float64 `json:"end"`
-	Text       string  `json:"text"`
-	Confidence float64 `json:"confidence"`
+	ID           int     `json:"id"`
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	Confidence   float64 `json:"confidence"`
+	AvgLogprob   float64 `json:"avg_logprob"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
 }
 
+// Word is one word's timing, returned when word timestamps are requested
+type Word struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Timestamp granularities accepted by SetTimestampGranularity
+const (
+	GranularitySegment = "segment"
+	GranularityWord    = "word"
+)
+
 // Transcribe sends audio data to the API for transcription
 func (c *Client) Transcribe(ctx context.Context, audioData []byte) (string, error) {
+	return c.TranscribeStream(ctx, audioData, nil)
+}
+
+// TranscribeStream transcribes audio, reporting the text assembled so far to
+// onPartial as it arrives when streaming is enabled. If the provider rejects
+// the stream parameter, or answers with a plain JSON body, it falls back to a
+// regular request and onPartial receives the full text once.
+func (c *Client) TranscribeStream(ctx context.Context, audioData []byte, onPartial func(text string)) (string, error) {
+	stream := c.stream && onPartial != nil && !c.translate
+	result, err := c.transcribe(ctx, audioData, c.model, c.language, stream, onPartial)
+	if stream && err == errStreamingUnsupported {
+		log.Printf("Streaming transcription not supported, falling back to a single response")
+		result, err = c.transcribe(ctx, audioData, c.model, c.language, false, onPartial)
+	}
+	return result.Text, err
+}
+
+// TranscribeDetailed transcribes audio like Transcribe but returns the whole
+// response: the detected language, the duration, and the segments with their
+// timestamps and confidence, plus word timings when SetTimestampGranularity
+// asked for them. A chunked recording's segments and words are merged, with
+// times relative to the start of the recording.
+func (c *Client) TranscribeDetailed(ctx context.Context, audioData []byte) (*Response, error) {
+	result, err := c.transcribe(ctx, audioData, c.model, c.language, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Overrides are one-off settings for a single request; empty fields use the
+// client's configured values
+type Overrides struct {
+	Model    string
+	Language string
+}
+
+// TranscribeWithLanguage transcribes audio with a one-off language override,
+// e.g. from a hotkey bound to a specific language. Empty uses the configured
+// language.
+func (c *Client) TranscribeWithLanguage(ctx context.Context, audioData []byte, language string) (string, error) {
+	return c.TranscribeWith(ctx, audioData, Overrides{Language: language})
+}
+
+// TranscribeWith transcribes audio with one-off overrides
+func (c *Client) TranscribeWith(ctx context.Context, audioData []byte, o Overrides) (string, error) {
+	language := strings.ToLower(strings.TrimSpace(o.Language))
+	if language == "" {
+		language = c.language
+	}
+	model := strings.TrimSpace(o.Model)
+	if model == "" {
+		model = c.model
+	}
+	result, err := c.transcribe(ctx, audioData, model, language, false, nil)
+	return result.Text, err
+}
+
+// LanguageFor returns the language of the text the last transcription made
+// with override produced: English when translating, else override, the
+// configured language or, failing those, the one Whisper detected. Empty
+// means unknown.
+func (c *Client) LanguageFor(override string) string {
+	if c.translate {
+		return "en"
+	}
+	if language := strings.ToLower(strings.TrimSpace(override)); language != "" {
+		return language
+	}
+	if c.language != "" {
+		return c.language
+	}
+	c.lastMu.Lock()
+	defer c.lastMu.Unlock()
+	return c.detectedLanguage
+}
+
+// LastAudio returns the most recent recording sent for transcription, or nil
+func (c *Client) LastAudio() []byte {
+	c.lastMu.Lock()
+	defer c.lastMu.Unlock()
+	return c.lastAudio
+}
+
+// Retranscribe sends the most recent recording again through model, for
+// when a transcription looks wrong and another model may do better. The
+// configured model is left unchanged.
+func (c *Client) Retranscribe(ctx context.Context, model string) (string, error) {
+	audioData := c.LastAudio()
+	if len(audioData) == 0 {
+		return "", errors.ErrNothingToRetry
+	}
+	return c.TranscribeWith(ctx, audioData, Overrides{Model: model})
+}
+
+func (c *Client) transcribe(ctx context.Context, audioData []byte, model, language string, stream bool, onPartial func(text string)) (Response, error) {
 	if len(audioData) == 0 {
-		return "", errors.ErrAudioTooShort
+		return Response{}, errors.ErrAudioTooShort
+	}
+
+	c.lastMu.Lock()
+	c.lastAudio = audioData
+	c.lastMu.Unlock()
+
+	var result Response
+	var err error
+	if c.needsChunking(len(audioData)) {
+		// Too big for one request, streamed or not; the stitched text
+		// arrives all at once
+		result, err = c.transcribeChunked(ctx, audioData, model, language)
+		if err == nil && onPartial != nil {
+			onPartial(result.Text)
+		}
+	} else {
+		result, err = c.request(ctx, audioData, model, language, stream, onPartial)
 	}
+	if err == nil {
+		c.noteLanguage(result.Language)
+	}
+	return result, err
+}
 
-	// Encode audio as WAV
-	wavData, err := wav.Encode(audioData, 16000, 1, 16)
+// noteLanguage remembers the language Whisper reported for a transcription
+// of the user's speech. Keep-warm pings of silence don't count.
+func (c *Client) noteLanguage(language string) {
+	if language == "" {
+		return
+	}
+	c.lastMu.Lock()
+	c.detectedLanguage = language
+	c.lastMu.Unlock()
+}
+
+// request uploads one recording, retrying transient failures
+func (c *Client) request(ctx context.Context, audioData []byte, model, language string, stream bool, onPartial func(text string)) (Response, error) {
+	wavData, err := c.encode(audioData)
 	if err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to encode WAV")
+		return Response{}, err
 	}
+	return c.upload(ctx, "audio.wav", wavData, c.requestTimeout(len(audioData)), model, language, stream, onPartial)
+}
 
+// upload sends an encoded audio file, named so the API can tell its format,
+// retrying transient failures
+func (c *Client) upload(ctx context.Context, filename string, data []byte, timeout time.Duration, model, language string, stream bool, onPartial func(text string)) (Response, error) {
 	// Create multipart form
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
 	// Add audio file
-	part, err := writer.CreateFormFile("file", "audio.wav")
+	part, err := writer.CreateFormFile("file", filename)
 	if err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to create form file")
+		return Response{}, errors.Wrap(err, errors.ErrorTypeAPI, "failed to create form file")
 	}
 
-	if _, err := io.Copy(part, bytes.NewReader(wavData)); err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to write audio data")
+	if _, err := io.Copy(part, bytes.NewReader(data)); err != nil {
+		return Response{}, errors.Wrap(err, errors.ErrorTypeAPI, "failed to write audio data")
 	}
 
 	// Add other fields
-	_ = writer.WriteField("model", c.model)
+	c.writeFields(writer, model, language, stream)
+
+	if err := writer.Close(); err != nil {
+		return Response{}, errors.Wrap(err, errors.ErrorTypeAPI, "failed to close form writer")
+	}
+
+	// One key per logical transcription, reused across retries so a request
+	// that succeeded server-side but timed out client-side isn't billed twice
+	key := newIdempotencyKey()
+
+	for attempt := 0; ; attempt++ {
+		// A slot is held per attempt, not across the backoff, so a request
+		// waiting to retry doesn't hold up others
+		release, err := c.acquire(ctx)
+		if err != nil {
+			return Response{}, err
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, retry, wait, err := c.send(attemptCtx, bytes.NewReader(body.Bytes()), writer.FormDataContentType(), key, stream, onPartial)
+		// Only this attempt's deadline, not the caller cancelling, is worth a retry
+		timedOut := attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		cancel()
+		release()
+		if err == nil {
+			return result, nil
+		}
+		if timedOut {
+			err = errors.Wrap(errors.ErrTimeout, errors.ErrorTypeNetwork, fmt.Sprintf("request timed out after %v", timeout))
+			retry = c.retryTimeouts
+		}
+		if !retry || attempt >= c.retries {
+			return Response{}, err
+		}
+		if wait == 0 {
+			wait = c.retryDelay << attempt
+		} else if wait > maxRetryAfter {
+			log.Printf("Server asked to retry after %v, giving up", wait)
+			return Response{}, err
+		}
+		log.Printf("Transcription attempt %d failed (%v), retrying in %v", attempt+1, err, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Response{}, errors.Wrap(ctx.Err(), errors.ErrorTypeNetwork, "request cancelled")
+		case <-timer.C:
+		}
+	}
+}
+
+// writeFields adds the request parameters to a transcription form
+func (c *Client) writeFields(writer *multipart.Writer, model, language string, stream bool) {
+	_ = writer.WriteField("model", model)
 	_ = writer.WriteField("temperature", "0")
 	_ = writer.WriteField("response_format", "verbose_json")
-	// Add instruction prompt for better flow, punctuation, and cleanup (Wispr Flow style)
-	_ = writer.WriteField("prompt", "Transcribe the audio accurately. Add appropriate punctuation and capitalization. Remove filler words like 'um', 'uh', 'ah'. Ensure the output is natural and professional.")
+	if c.translate {
+		// The translations endpoint detects the spoken language itself and
+		// always answers in English, so the prompt is the English one
+		language = "en"
+	} else if language != "" {
+		_ = writer.WriteField("language", language)
+	}
+	if prompt := c.promptFor(model, language); prompt != "" {
+		_ = writer.WriteField("prompt", prompt)
+	}
+	if stream {
+		_ = writer.WriteField("stream", "true")
+	}
+	switch c.granularity {
+	case GranularityWord:
+		// Asking for words alone drops the segments, which chunk stitching
+		// and the confidence fields rely on
+		_ = writer.WriteField("timestamp_granularities[]", GranularityWord)
+		_ = writer.WriteField("timestamp_granularities[]", GranularitySegment)
+	case GranularitySegment:
+		_ = writer.WriteField("timestamp_granularities[]", GranularitySegment)
+	}
+}
 
-	if err := writer.Close(); err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to close form writer")
+// TranscribeReader transcribes PCM read live from r, e.g. a recording still
+// in progress, uploading it as it arrives so the result is ready soon after r
+// ends rather than a whole upload later. See TranscribeReaderWith.
+func (c *Client) TranscribeReader(ctx context.Context, r io.Reader) (string, error) {
+	return c.TranscribeReaderWith(ctx, r, Overrides{})
+}
+
+// TranscribeReaderWith is TranscribeReader with one-off overrides. The WAV
+// header goes out before the length is known, so it carries unknown sizes.
+// Unlike Transcribe a failed request is not retried, since the audio can't
+// be read again; callers should keep their own copy to fall back on. Nor
+// does it time out, as the upload lasts as long as the recording; only ctx
+// ends it. For the same reason it takes no SetMaxConcurrentRequests slot,
+// which it would hold for the whole recording while chunks and retries wait.
+// The audio read is kept for Retranscribe.
+func (c *Client) TranscribeReaderWith(ctx context.Context, r io.Reader, o Overrides) (string, error) {
+	language := strings.ToLower(strings.TrimSpace(o.Language))
+	if language == "" {
+		language = c.language
 	}
+	model := strings.TrimSpace(o.Model)
+	if model == "" {
+		model = c.model
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	var recorded bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(c.writeStreamForm(writer, io.TeeReader(r, &recorded), model, language))
+	}()
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/audio/transcriptions", body)
+	result, _, _, err := c.send(ctx, pr, writer.FormDataContentType(), newIdempotencyKey(), false, nil)
+	// Unblock the form writer if the request ended before the audio did
+	pr.CloseWithError(io.ErrClosedPipe)
 	if err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to create request")
+		return "", err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	<-done
+	if recorded.Len() > 0 {
+		c.lastMu.Lock()
+		c.lastAudio = recorded.Bytes()
+		c.lastMu.Unlock()
+	}
+	c.noteLanguage(result.Language)
+	return result.Text, nil
+}
+
+// writeStreamForm writes the form for TranscribeReaderWith, parameters first
+// so the audio streams out last
+func (c *Client) writeStreamForm(writer *multipart.Writer, r io.Reader, model, language string) error {
+	c.writeFields(writer, model, language, false)
+	part, err := writer.CreateFormFile("file", "audio.wav")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(wav.StreamHeader(c.sampleRate, c.channels, c.bitsPerSample)); err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// encode wraps the recording in a WAV header. In verbose mode the result is
+// parsed back first, so an encoding regression shows up in the log rather
+// than as an opaque 400 from the API.
+func (c *Client) encode(audioData []byte) ([]byte, error) {
+	wavData, err := encodeWAV(audioData, c.sampleRate, c.channels, c.bitsPerSample)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrorTypeAPI, "failed to encode WAV")
+	}
+	if c.verbose {
+		if _, pcm, err := wav.Decode(wavData); err != nil {
+			log.Printf("Warning: encoded WAV is malformed, the upload may be rejected: %v", err)
+		} else if len(pcm) != len(audioData) {
+			log.Printf("Warning: encoded WAV holds %d bytes of audio, expected %d", len(pcm), len(audioData))
+		}
+	}
+	return wavData, nil
+}
+
+// send performs a single transcription request. retry reports whether the
+// failure is transient (network error, rate limit or server error) and worth
+// another attempt, and wait how long the server asked to wait before it; zero
+// leaves the delay to the caller's backoff.
+func (c *Client) send(ctx context.Context, body io.Reader, contentType, key string, stream bool, onPartial func(text string)) (result Response, retry bool, wait time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+c.endpoint(), body)
+	if err != nil {
+		return Response{}, false, 0, errors.Wrap(err, errors.ErrorTypeAPI, "failed to create request")
+	}
+
+	if apiKey := c.authKey(); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.idempotencyHeader != "" {
+		req.Header.Set(c.idempotencyHeader, key)
+	}
 
 	// Send request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeNetwork, "request failed")
+		return Response{}, ctx.Err() == nil, 0, errors.Wrap(err, errors.ErrorTypeNetwork, "request failed")
 	}
 	defer resp.Body.Close()
 
 	// Check response status
+	if stream && resp.StatusCode == http.StatusBadRequest {
+		return Response{}, false, 0, errStreamingUnsupported
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", c.handleErrorResponse(resp)
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			return Response{}, true, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), c.handleErrorResponse(resp)
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return Response{}, true, 0, c.handleErrorResponse(resp)
+		}
+		return Response{}, false, 0, c.handleErrorResponse(resp)
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		text, err := readTranscriptStream(resp.Body, onPartial)
+		if err != nil {
+			return Response{}, false, 0, errors.Wrap(err, errors.ErrorTypeAPI, "failed to read stream")
+		}
+		return Response{Text: text}, false, 0, nil
 	}
 
 	// Parse response
-	var result Response
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", errors.Wrap(err, errors.ErrorTypeAPI, "failed to decode response")
+		return Response{}, false, 0, errors.Wrap(err, errors.ErrorTypeAPI, "failed to decode response")
+	}
+	if onPartial != nil {
+		onPartial(result.Text)
 	}
 
-	return result.Text, nil
+	return result, false, 0, nil
+}
+
+// parseRetryAfter reads a Retry-After header given either as seconds or as
+// an HTTP date, returning zero when it is missing or unparseable
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// endpoint returns the API path audio is uploaded to
+func (c *Client) endpoint() string {
+	if c.translate {
+		return "/audio/translations"
+	}
+	return "/audio/transcriptions"
+}
+
+// newIdempotencyKey returns a random 128-bit hex key
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
 }
 
 // handleErrorResponse handles API error responses
@@ -150,6 +596,26 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 	}
 }
 
+// NeedsRekey reports whether err means the API key was rejected, so the user
+// should be asked for a new key rather than the request simply failing
+func NeedsRekey(err error) bool {
+	return errors.Is(err, errors.ErrAPIKeyInvalid)
+}
+
+// SetAPIKey replaces the API key used for subsequent requests
+func (c *Client) SetAPIKey(apiKey string) {
+	c.lastMu.Lock()
+	defer c.lastMu.Unlock()
+	c.apiKey = apiKey
+}
+
+// authKey returns the API key to send, "" for none
+func (c *Client) authKey() string {
+	c.lastMu.Lock()
+	defer c.lastMu.Unlock()
+	return c.apiKey
+}
+
 // SetModel sets the transcription model
 func (c *Client) SetModel(model string) {
 	c.model = model
@@ -160,14 +626,293 @@ func (c *Client) GetModel() string {
 	return c.model
 }
 
+// SetRetries sets how many times a transient failure is retried
+func (c *Client) SetRetries(retries int) {
+	if retries < 0 {
+		retries = 0
+	}
+	c.retries = retries
+}
+
+// SetTimeout sets a fixed timeout for each transcription request. Zero or
+// negative restores the default, which grows with the recording: a base of
+// 30 seconds plus two seconds per second of audio. Cancelling the request's
+// context still ends it at once.
+func (c *Client) SetTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	c.timeout = d
+}
+
+// requestTimeout returns the timeout for uploading n bytes of audio
+func (c *Client) requestTimeout(n int) time.Duration {
+	if c.timeout > 0 {
+		return c.timeout
+	}
+	bytesPerSecond := int64(c.sampleRate * c.channels * c.bitsPerSample / 8)
+	if bytesPerSecond <= 0 {
+		return c.baseTimeout
+	}
+	audio := time.Duration(int64(n) * int64(time.Second) / bytesPerSecond)
+	return c.baseTimeout + timeoutPerSecond*audio
+}
+
+// SetRetryTimeouts chooses whether an attempt that timed out is retried like
+// other transient failures, off by default: the server may have finished the
+// transcription anyway, and unless it honours the idempotency key a retry is
+// billed again.
+func (c *Client) SetRetryTimeouts(retry bool) {
+	c.retryTimeouts = retry
+}
+
+// SetRetryPolicy sets how many times a transient failure (network error, 429
+// or 500/502/503/504) is retried and the delay before the first retry, which
+// doubles on each retry after that. A 429 carrying Retry-After waits as long
+// as the server asks instead.
+func (c *Client) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	c.SetRetries(maxRetries)
+	if baseDelay <= 0 {
+		baseDelay = retryBackoff
+	}
+	c.retryDelay = baseDelay
+}
+
+// SetMaxConcurrentRequests caps how many requests the client has in flight
+// at once; further ones, such as the chunks of a long recording, wait their
+// turn. Zero or negative removes the cap. Call it before transcribing.
+func (c *Client) SetMaxConcurrentRequests(n int) {
+	if n <= 0 {
+		c.slots = nil
+		return
+	}
+	c.slots = make(chan struct{}, n)
+}
+
+// acquire waits for a request slot, returning the function that frees it
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), errors.ErrorTypeNetwork, "request cancelled")
+	}
+}
+
+// SetAudioFormat sets the format of the PCM handed to the Transcribe
+// methods, so the uploaded WAV header matches what was captured. It
+// defaults to 16000 Hz mono 16-bit.
+func (c *Client) SetAudioFormat(sampleRate, channels, bitsPerSample int) {
+	c.sampleRate = sampleRate
+	c.channels = channels
+	c.bitsPerSample = bitsPerSample
+}
+
+// SetVerbose turns on extra self-checks, such as validating each encoded
+// WAV before it is uploaded
+func (c *Client) SetVerbose(verbose bool) {
+	c.verbose = verbose
+}
+
+// SetStreaming enables SSE streaming for TranscribeStream callers
+func (c *Client) SetStreaming(stream bool) {
+	c.stream = stream
+}
+
+// SetIdempotencyHeader sets the header used to send the idempotency key,
+// so other providers can use their own name. Empty disables it.
+func (c *Client) SetIdempotencyHeader(name string) {
+	c.idempotencyHeader = name
+}
+
+// SetLanguage sets the ISO-639-1 language hint (empty for auto-detect)
+func (c *Client) SetLanguage(language string) {
+	c.language = strings.ToLower(strings.TrimSpace(language))
+}
+
+// SetTranslate switches between transcribing speech as spoken and
+// translating it to English text. Translation ignores the language hint,
+// since the endpoint detects the spoken language itself, and doesn't stream.
+func (c *Client) SetTranslate(translate bool) {
+	c.translate = translate
+}
+
+// SetPrompt replaces the instruction prompt sent with English or
+// auto-detected speech, e.g. to keep filler words; empty sends none. Other
+// languages still get no instructions, which English ones would degrade.
+func (c *Client) SetPrompt(prompt string) {
+	c.prompt = strings.TrimSpace(prompt)
+}
+
+// SetPromptOverrides sets instruction prompts that replace the usual one
+// for particular models or languages, e.g. {"ja": "", "whisper-large-v3":
+// "..."}, where an empty prompt sends no instructions. A language key is a
+// code such as "es" for a configured language or a name such as "spanish"
+// for one Whisper detected. Keys match in any case, and a model's prompt
+// wins over a language's. The glossary is still appended.
+func (c *Client) SetPromptOverrides(overrides map[string]string) {
+	c.promptOverrides = make(map[string]string, len(overrides))
+	for key, prompt := range overrides {
+		c.promptOverrides[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(prompt)
+	}
+}
+
+// SetTimestampGranularity sets the timing detail TranscribeDetailed returns:
+// "segment", or "word" to also fill Response.Words. Empty restores the
+// default, which sends no granularity and gets segments only.
+func (c *Client) SetTimestampGranularity(level string) error {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch level {
+	case "", GranularitySegment, GranularityWord:
+		c.granularity = level
+		return nil
+	}
+	return fmt.Errorf("unknown timestamp granularity %q (use segment or word)", level)
+}
+
+// SetGlossary sets the user's vocabulary of names and jargon. The terms are
+// appended to the prompt, which biases Whisper toward spelling them that way.
+func (c *Client) SetGlossary(terms []string) {
+	c.glossary = nil
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		c.glossary = append(c.glossary, term)
+	}
+}
+
+// GetLanguage returns the configured language hint
+func (c *Client) GetLanguage() string {
+	return c.language
+}
+
+// SetRaw switches verbatim transcription on or off; raw requests carry no prompt.
+// Safe to call while a transcription is in flight; it applies to the next request.
+func (c *Client) SetRaw(raw bool) {
+	c.raw.Store(raw)
+}
+
+// ToggleRaw flips verbatim transcription and returns the new state
+func (c *Client) ToggleRaw() bool {
+	for {
+		old := c.raw.Load()
+		if c.raw.CompareAndSwap(old, !old) {
+			return !old
+		}
+	}
+}
+
+// IsRaw returns whether verbatim transcription is enabled
+func (c *Client) IsRaw() bool {
+	return c.raw.Load()
+}
+
+// Prompt returns the instruction prompt for the next request.
+// Raw mode sends no prompt. Otherwise a prompt override for the model or
+// language is used, and failing that the English cleanup prompt is only used
+// when the configured language, or the language detected on the previous
+// request if none is configured, is English.
+func (c *Client) Prompt() string {
+	return c.promptFor(c.model, c.language)
+}
+
+// promptFor returns the prompt for a request to model in language (empty
+// for auto-detect)
+func (c *Client) promptFor(model, language string) string {
+	if c.raw.Load() {
+		return ""
+	}
+	if language == "" {
+		c.lastMu.Lock()
+		language = c.detectedLanguage
+		c.lastMu.Unlock()
+	}
+	if prompt, ok := c.promptOverride(model, language); ok {
+		return withGlossary(prompt, c.glossary)
+	}
+	if language == "" || IsEnglish(language) {
+		return withGlossary(c.prompt, c.glossary)
+	}
+	return withGlossary(neutralPrompt, c.glossary)
+}
+
+// promptOverride returns the prompt set by SetPromptOverrides for model,
+// or else for language
+func (c *Client) promptOverride(model, language string) (string, bool) {
+	for _, key := range []string{model, language} {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if prompt, ok := c.promptOverrides[key]; ok {
+			return prompt, true
+		}
+	}
+	return "", false
+}
+
+// withGlossary appends as many whole glossary terms to prompt as fit within
+// maxPromptChars, in the order given, so the user's first terms win
+func withGlossary(prompt string, terms []string) string {
+	if len(terms) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	if prompt != "" {
+		b.WriteString(" ")
+	}
+	b.WriteString("Vocabulary:")
+	added := 0
+	for _, term := range terms {
+		sep := " "
+		if added > 0 {
+			sep = ", "
+		}
+		// +1 for the closing period
+		if b.Len()+len(sep)+len(term)+1 > maxPromptChars {
+			continue
+		}
+		b.WriteString(sep)
+		b.WriteString(term)
+		added++
+	}
+	if added == 0 {
+		return prompt
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+// IsEnglish reports whether a language code or Whisper language name is
+// English. Empty, an unknown language, is not; callers decide what to assume.
+func IsEnglish(language string) bool {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "en", "english":
+		return true
+	}
+	return false
+}
+
 // HealthCheck checks if the API is accessible
 func (c *Client) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeNetwork, "failed to create health check request")
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if apiKey := c.authKey(); apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -0,0 +1,1094 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"speek_to_text_linux/pkg/config"
+	"speek_to_text_linux/pkg/errors"
+	"speek_to_text_linux/pkg/wav"
+)
+
+func TestPromptForLanguage(t *testing.T) {
+	testCases := []struct {
+		language string
+		detected string
+		expected string
+	}{
+		{"", "", config.DefaultTranscriptionPrompt},
+		{"en", "", config.DefaultTranscriptionPrompt},
+		{"EN", "", config.DefaultTranscriptionPrompt},
+		{"de", "", neutralPrompt},
+		{"ja", "english", neutralPrompt},
+		{"", "english", config.DefaultTranscriptionPrompt},
+		{"", "french", neutralPrompt},
+	}
+
+	for _, tc := range testCases {
+		client := NewClient("test", nil)
+		client.SetLanguage(tc.language)
+		client.detectedLanguage = tc.detected
+
+		if got := client.Prompt(); got != tc.expected {
+			t.Errorf("language=%q detected=%q: expected prompt %q, got %q", tc.language, tc.detected, tc.expected, got)
+		}
+	}
+}
+
+func TestToggleRawAffectsNextPrompt(t *testing.T) {
+	client := NewClient("test", nil)
+
+	if client.Prompt() != config.DefaultTranscriptionPrompt {
+		t.Fatal("Expected cleanup prompt by default")
+	}
+
+	if !client.ToggleRaw() {
+		t.Fatal("Expected ToggleRaw to enable raw mode")
+	}
+	if got := client.Prompt(); got != "" {
+		t.Errorf("Expected no prompt in raw mode, got %q", got)
+	}
+
+	if client.ToggleRaw() {
+		t.Fatal("Expected second ToggleRaw to disable raw mode")
+	}
+	if client.Prompt() != config.DefaultTranscriptionPrompt {
+		t.Error("Expected cleanup prompt after leaving raw mode")
+	}
+}
+
+func TestIsEnglish(t *testing.T) {
+	testCases := []struct {
+		language string
+		expected bool
+	}{
+		{"", false},
+		{"en", true},
+		{" English ", true},
+		{"de", false},
+		{"french", false},
+	}
+
+	for _, tc := range testCases {
+		if got := IsEnglish(tc.language); got != tc.expected {
+			t.Errorf("IsEnglish(%q) = %v, expected %v", tc.language, got, tc.expected)
+		}
+	}
+}
+
+func TestLanguageFor(t *testing.T) {
+	client := NewClient("test", nil)
+	client.detectedLanguage = "german"
+
+	testCases := []struct {
+		configured string
+		translate  bool
+		override   string
+		expected   string
+	}{
+		{"", false, "", "german"},
+		{"fr", false, "", "fr"},
+		{"fr", false, " ES ", "es"},
+		{"fr", true, "es", "en"},
+	}
+
+	for _, tc := range testCases {
+		client.SetLanguage(tc.configured)
+		client.SetTranslate(tc.translate)
+		if got := client.LanguageFor(tc.override); got != tc.expected {
+			t.Errorf("configured=%q translate=%v override=%q: expected %q, got %q", tc.configured, tc.translate, tc.override, tc.expected, got)
+		}
+	}
+}
+
+func TestSetPromptOverridesCleanupPrompt(t *testing.T) {
+	client := NewClient("test", nil)
+	client.SetPrompt("  Keep every um and uh.  ")
+	if got := client.Prompt(); got != "Keep every um and uh." {
+		t.Errorf("Expected the custom prompt, got %q", got)
+	}
+
+	client.SetLanguage("de")
+	if got := client.Prompt(); got != neutralPrompt {
+		t.Errorf("Expected no English instructions for German, got %q", got)
+	}
+
+	client.SetLanguage("en")
+	client.SetPrompt("")
+	if got := client.Prompt(); got != "" {
+		t.Errorf("Expected no prompt, got %q", got)
+	}
+	client.SetGlossary([]string{"Groq"})
+	if got := client.Prompt(); got != "Vocabulary: Groq." {
+		t.Errorf("Expected the glossary alone, got %q", got)
+	}
+}
+
+func TestPromptOverrides(t *testing.T) {
+	overrides := map[string]string{
+		"JA":                         "",
+		"es":                         "Transcribe con puntuación.",
+		"french":                     "Transcrivez avec ponctuation.",
+		"distil-whisper-large-v3-en": "Plain dictation.",
+		"whisper-large-v3-turbo":     "",
+	}
+
+	testCases := []struct {
+		model    string
+		language string
+		detected string
+		expected string
+	}{
+		{"whisper-large-v3", "ja", "", ""},
+		{"whisper-large-v3", "es", "", "Transcribe con puntuación."},
+		{"whisper-large-v3", "", "french", "Transcrivez avec ponctuation."},
+		{"whisper-large-v3", "", "", config.DefaultTranscriptionPrompt},
+		{"whisper-large-v3", "de", "", neutralPrompt},
+		{"distil-whisper-large-v3-en", "en", "", "Plain dictation."},
+		{"Whisper-Large-V3-Turbo", "es", "", ""},
+		{"whisper-large-v3-turbo", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		client := NewClient("test", nil)
+		client.SetPromptOverrides(overrides)
+		client.SetLanguage(tc.language)
+		client.detectedLanguage = tc.detected
+
+		if got := client.promptFor(tc.model, tc.language); got != tc.expected {
+			t.Errorf("model=%q language=%q detected=%q: expected prompt %q, got %q", tc.model, tc.language, tc.detected, tc.expected, got)
+		}
+	}
+}
+
+func TestPromptOverrideKeepsGlossaryAndRaw(t *testing.T) {
+	client := NewClient("test", nil)
+	client.SetPromptOverrides(map[string]string{"whisper-large-v3": ""})
+	client.SetGlossary([]string{"Groq"})
+	if got := client.Prompt(); got != "Vocabulary: Groq." {
+		t.Errorf("Expected the glossary after an empty override, got %q", got)
+	}
+
+	client.SetRaw(true)
+	client.SetPromptOverrides(map[string]string{"whisper-large-v3": "Use it anyway."})
+	if got := client.Prompt(); got != "" {
+		t.Errorf("Expected raw mode to send no prompt, got %q", got)
+	}
+}
+
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = time.Second }()
+
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(DefaultIdempotencyHeader))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetries(2)
+
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if text != "hello" {
+		t.Errorf("Expected %q, got %q", "hello", text)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("Expected the same non-empty key on every attempt, got %v", keys)
+	}
+
+	// A new logical transcription gets a new key
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if keys[3] == keys[0] {
+		t.Error("Expected a fresh key for a new transcription")
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetries(2)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != errors.ErrAPIKeyInvalid {
+		t.Fatalf("Expected ErrAPIKeyInvalid, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryBacksOffOnRateLimitAndServerErrors(t *testing.T) {
+	var times []time.Time
+	statuses := []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) <= len(statuses) {
+			w.WriteHeader(statuses[len(times)-1])
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(3, 20*time.Millisecond)
+
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if text != "hello" {
+		t.Errorf("Expected %q, got %q", "hello", text)
+	}
+	if len(times) != 4 {
+		t.Fatalf("Expected 4 attempts, got %d", len(times))
+	}
+	// 20ms, 40ms, 80ms between attempts
+	for i, min := range []time.Duration{20, 40, 80} {
+		if gap := times[i+1].Sub(times[i]); gap < min*time.Millisecond {
+			t.Errorf("Expected at least %dms before retry %d, waited %v", min, i+1, gap)
+		}
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, peak, calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		// Every other request fails once so retries contend for slots too
+		if calls.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(3, 5*time.Millisecond)
+	client.SetMaxConcurrentRequests(2)
+
+	const transcriptions = 6
+	errs := make(chan error, transcriptions)
+	for i := 0; i < transcriptions; i++ {
+		go func() {
+			_, err := client.Transcribe(context.Background(), make([]byte, 3200))
+			errs <- err
+		}()
+	}
+	for i := 0; i < transcriptions; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Transcribe failed: %v", err)
+		}
+	}
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 requests in flight, saw %d", peak.Load())
+	}
+	if calls.Load() <= transcriptions {
+		t.Errorf("Expected some requests to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestWaitingForSlotHonorsCancel(t *testing.T) {
+	client := NewClient("test", nil)
+	client.baseURL = "http://127.0.0.1:0"
+	client.SetMaxConcurrentRequests(1)
+	client.slots <- struct{}{} // another request holds the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Transcribe(ctx, make([]byte, 3200)); err == nil {
+		t.Fatal("Expected waiting for a slot to end with the context")
+	}
+}
+
+func TestLiveUploadTakesNoSlot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"text":"live"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetMaxConcurrentRequests(1)
+	client.slots <- struct{}{} // another request holds the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if text, err := client.TranscribeReader(ctx, bytes.NewReader(make([]byte, 3200))); err != nil || text != "live" {
+		t.Errorf("Expected the live upload not to wait for a slot, got %q, %v", text, err)
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(2, time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); !errors.Is(err, errors.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(3, time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if len(times) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < time.Second {
+		t.Errorf("Expected the retry to wait out Retry-After, waited %v", gap)
+	}
+}
+
+func TestRetryAfterBeyondCapFailsFast(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); !errors.Is(err, errors.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retry for an hour-long Retry-After, got %d attempts", attempts)
+	}
+}
+
+func TestRetryAbortsWhenContextCancelled(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(3, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := client.Transcribe(ctx, make([]byte, 3200)); err == nil {
+		t.Fatal("Expected an error after cancelling")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the backoff to end on cancel, took %v", elapsed)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt before cancelling, got %d", attempts)
+	}
+}
+
+func TestNoRetryOnNotImplemented(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotImplemented)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetryPolicy(3, time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 501 not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 2 ", 2 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	}
+
+	for _, tc := range testCases {
+		if got := parseRetryAfter(tc.value, now); got != tc.expected {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestUnauthorizedTriggersRekey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("expired-key", nil)
+	client.baseURL = server.URL
+
+	_, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if !NeedsRekey(err) {
+		t.Fatalf("Expected 401 to trigger the re-key flow, got %v", err)
+	}
+
+	client.SetAPIKey("new-key")
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil || text != "hello" {
+		t.Fatalf("Expected retry with the new key to succeed, got %q, %v", text, err)
+	}
+}
+
+func TestSetAPIKeyDuringRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("old-key", nil)
+	client.baseURL = server.URL
+
+	// Run with -race: the re-key prompt sets the key while uploads are in flight
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			client.SetAPIKey("new-key")
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+			t.Fatalf("Transcribe failed: %v", err)
+		}
+	}
+	<-done
+}
+
+func TestOtherErrorsDoNotTriggerRekey(t *testing.T) {
+	for _, err := range []error{nil, errors.ErrRateLimited, errors.Wrap(errors.ErrConnectionFailed, errors.ErrorTypeNetwork, "request failed")} {
+		if NeedsRekey(err) {
+			t.Errorf("Expected %v not to trigger the re-key flow", err)
+		}
+	}
+}
+
+func TestLanguageOverrideReachesRequest(t *testing.T) {
+	var language, prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		language = r.FormValue("language")
+		prompt = r.FormValue("prompt")
+		w.Write([]byte(`{"text":"hola"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetLanguage("en")
+
+	if _, err := client.TranscribeWithLanguage(context.Background(), make([]byte, 3200), " ES "); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if language != "es" {
+		t.Errorf("Expected action language %q in request, got %q", "es", language)
+	}
+	if prompt != "" {
+		t.Errorf("Expected no English cleanup prompt for Spanish, got %q", prompt)
+	}
+
+	// Without an override the configured language is used
+	if _, err := client.TranscribeWithLanguage(context.Background(), make([]byte, 3200), ""); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if language != "en" || prompt != config.DefaultTranscriptionPrompt {
+		t.Errorf("Expected configured language and cleanup prompt, got %q and %q", language, prompt)
+	}
+}
+
+func TestTranslateUsesTranslationsEndpoint(t *testing.T) {
+	var path, language, prompt string
+	var hasLanguage bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		language = r.FormValue("language")
+		_, hasLanguage = r.MultipartForm.Value["language"]
+		prompt = r.FormValue("prompt")
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetLanguage("fr")
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if path != "/audio/transcriptions" || language != "fr" {
+		t.Errorf("Expected a French transcription, got %s with language %q", path, language)
+	}
+
+	client.SetTranslate(true)
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if path != "/audio/translations" {
+		t.Errorf("Expected the translations endpoint, got %s", path)
+	}
+	if hasLanguage {
+		t.Errorf("Expected no language field when translating, got %q", language)
+	}
+	if prompt != config.DefaultTranscriptionPrompt {
+		t.Errorf("Expected the English prompt for English output, got %q", prompt)
+	}
+
+	// Auto-detection is kept when no language is set
+	client.SetTranslate(false)
+	client.SetLanguage("")
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if path != "/audio/transcriptions" || hasLanguage {
+		t.Errorf("Expected an auto-detected transcription, got %s with language %q", path, language)
+	}
+}
+
+func TestTranscribeDetailedReturnsFullResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text":" Bonjour. Ça va?","language":"french","duration":2.5,"segments":[` +
+			`{"id":0,"start":0,"end":1,"text":" Bonjour.","avg_logprob":-0.2,"no_speech_prob":0.01},` +
+			`{"id":1,"start":1,"end":2.5,"text":" Ça va?","avg_logprob":-1.4,"no_speech_prob":0.3}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+
+	result, err := client.TranscribeDetailed(context.Background(), make([]byte, 3200))
+	if err != nil {
+		t.Fatalf("TranscribeDetailed failed: %v", err)
+	}
+	if result.Language != "french" || result.Duration != 2.5 {
+		t.Errorf("Expected language and duration, got %q and %v", result.Language, result.Duration)
+	}
+	if len(result.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(result.Segments))
+	}
+	if seg := result.Segments[1]; seg.Text != " Ça va?" || seg.Start != 1 || seg.AvgLogprob != -1.4 || seg.NoSpeechProb != 0.3 {
+		t.Errorf("Unexpected segment %+v", seg)
+	}
+
+	// Transcribe still returns only the text
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil || text != " Bonjour. Ça va?" {
+		t.Errorf("Expected the text alone, got %q, %v", text, err)
+	}
+}
+
+func TestTimestampGranularity(t *testing.T) {
+	var granularities []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseMultipartForm(1 << 20)
+		granularities = r.MultipartForm.Value["timestamp_granularities[]"]
+		w.Write([]byte(`{"text":"hi there","segments":[{"id":0,"start":0,"end":1,"text":"hi there"}],` +
+			`"words":[{"word":"hi","start":0,"end":0.4},{"word":"there","start":0.5,"end":1}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+
+	testCases := []struct {
+		level    string
+		expected []string
+	}{
+		{"", nil},
+		{"segment", []string{"segment"}},
+		{" Word ", []string{"word", "segment"}},
+	}
+
+	for _, tc := range testCases {
+		if err := client.SetTimestampGranularity(tc.level); err != nil {
+			t.Fatalf("SetTimestampGranularity(%q) failed: %v", tc.level, err)
+		}
+		result, err := client.TranscribeDetailed(context.Background(), make([]byte, 3200))
+		if err != nil {
+			t.Fatalf("TranscribeDetailed failed: %v", err)
+		}
+		if !reflect.DeepEqual(granularities, tc.expected) {
+			t.Errorf("level %q: expected fields %v, got %v", tc.level, tc.expected, granularities)
+		}
+		if len(result.Segments) != 1 || len(result.Words) != 2 || result.Words[1] != (Word{Word: "there", Start: 0.5, End: 1}) {
+			t.Errorf("level %q: unexpected result %+v", tc.level, result)
+		}
+	}
+
+	if err := client.SetTimestampGranularity("char"); err == nil {
+		t.Error("Expected an error for an unknown granularity")
+	}
+	if client.granularity != GranularityWord {
+		t.Errorf("Expected a rejected level to keep %q, got %q", GranularityWord, client.granularity)
+	}
+}
+
+func TestTranscribeDetailedEmptyAudio(t *testing.T) {
+	client := NewClient("test", nil)
+	if result, err := client.TranscribeDetailed(context.Background(), nil); err != errors.ErrAudioTooShort || result != nil {
+		t.Errorf("Expected ErrAudioTooShort and no result, got %v, %v", result, err)
+	}
+}
+
+func TestGlossaryAppendedToPromptWithinCap(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prompt = r.FormValue("prompt")
+		w.Write([]byte(`{"text":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetLanguage("en")
+
+	long := strings.Repeat("x", maxPromptChars)
+	var filler []string
+	for i := 0; i < 200; i++ {
+		filler = append(filler, fmt.Sprintf("Term%03d", i))
+	}
+	client.SetGlossary(append([]string{"Kubernetes", " kubernetes ", "", "Groq", long, "Anthropic"}, filler...))
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+
+	if !strings.HasPrefix(prompt, config.DefaultTranscriptionPrompt) {
+		t.Errorf("Expected glossary after the cleanup prompt, got %q", prompt)
+	}
+	if !strings.Contains(prompt, "Vocabulary: Kubernetes, Groq, Anthropic, Term000") {
+		t.Errorf("Expected glossary terms in order without duplicates, got %q", prompt)
+	}
+	if len(prompt) > maxPromptChars {
+		t.Errorf("Expected prompt within %d chars, got %d", maxPromptChars, len(prompt))
+	}
+	if strings.Contains(prompt, "xxx") {
+		t.Error("Expected a term longer than the cap to be skipped")
+	}
+	if strings.Contains(prompt, "Term199") {
+		t.Error("Expected terms beyond the cap to be dropped")
+	}
+
+	// Non-English requests carry the glossary alone; raw requests nothing
+	if got := client.promptFor(client.model, "es"); !strings.HasPrefix(got, "Vocabulary: Kubernetes") {
+		t.Errorf("Expected glossary-only prompt for Spanish, got %q", got)
+	}
+	client.SetRaw(true)
+	if got := client.Prompt(); got != "" {
+		t.Errorf("Expected no prompt in raw mode, got %q", got)
+	}
+}
+
+func TestRetranscribeResendsLastAudioWithModel(t *testing.T) {
+	type request struct {
+		model string
+		audio []byte
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Missing audio file: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		requests = append(requests, request{r.FormValue("model"), data})
+		w.Write([]byte(`{"text":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetModel("distil-whisper-large-v3-en")
+
+	if _, err := client.Retranscribe(context.Background(), "whisper-large-v3"); !errors.Is(err, errors.ErrNothingToRetry) {
+		t.Fatalf("Expected ErrNothingToRetry before any recording, got %v", err)
+	}
+
+	audio := make([]byte, 3200)
+	for i := range audio {
+		audio[i] = byte(i)
+	}
+	if _, err := client.Transcribe(context.Background(), audio); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if _, err := client.Retranscribe(context.Background(), "whisper-large-v3"); err != nil {
+		t.Fatalf("Retranscribe failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].model != "distil-whisper-large-v3-en" || requests[1].model != "whisper-large-v3" {
+		t.Errorf("Expected configured then retry model, got %q and %q", requests[0].model, requests[1].model)
+	}
+	if !bytes.Equal(requests[0].audio, requests[1].audio) {
+		t.Error("Expected the retained recording to be re-sent unchanged")
+	}
+	if client.GetModel() != "distil-whisper-large-v3-en" {
+		t.Errorf("Expected configured model to be kept, got %q", client.GetModel())
+	}
+}
+
+func TestVerboseFlagsMalformedWAV(t *testing.T) {
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+
+	audio := make([]byte, 320)
+	c := NewClient("key", nil)
+
+	// A correct encode passes the check silently
+	c.SetVerbose(true)
+	if _, err := c.encode(audio); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no warning for a valid WAV, got %q", logs.String())
+	}
+
+	// Simulate a regression that drops the data chunk size
+	encodeWAV = func(audioData []byte, sampleRate, channels, bitsPerSample int) ([]byte, error) {
+		b, err := wav.Encode(audioData, sampleRate, channels, bitsPerSample)
+		b[40], b[41], b[42], b[43] = 0xFF, 0xFF, 0, 0
+		return b, err
+	}
+	defer func() { encodeWAV = wav.Encode }()
+
+	if _, err := c.encode(audio); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "encoded WAV is malformed") {
+		t.Errorf("Expected a malformed WAV warning, got %q", logs.String())
+	}
+
+	// Outside verbose mode the check is skipped
+	logs.Reset()
+	c.SetVerbose(false)
+	if _, err := c.encode(audio); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no check outside verbose mode, got %q", logs.String())
+	}
+}
+
+func TestAudioFormatReachesWAVHeader(t *testing.T) {
+	c := NewClient("key", nil)
+	c.SetAudioFormat(44100, 2, 16)
+
+	wavData, err := c.encode(make([]byte, 400))
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	f, _, err := wav.Decode(wavData)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if f != (wav.Format{SampleRate: 44100, Channels: 2, BitsPerSample: 16}) {
+		t.Errorf("Expected a 44100 Hz stereo header, got %+v", f)
+	}
+}
+
+func TestTranscribeReaderStreamsWhileRecording(t *testing.T) {
+	firstChunk := make(chan struct{})
+	var model string
+	var audio []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 {
+			t.Errorf("Expected a chunked upload of unknown length, got %d", r.ContentLength)
+		}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Errorf("Expected a multipart body: %v", err)
+			return
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FormName() == "model" {
+				b, _ := io.ReadAll(part)
+				model = string(b)
+				continue
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+			header := make([]byte, wav.GetWAVHeaderSize())
+			if _, err := io.ReadFull(part, header); err != nil || !bytes.Equal(header, wav.StreamHeader(16000, 1, 16)) {
+				t.Errorf("Expected a streaming WAV header, got %x (%v)", header, err)
+			}
+			first := make([]byte, 320)
+			if _, err := io.ReadFull(part, first); err != nil {
+				t.Errorf("Failed to read the first chunk: %v", err)
+			}
+			close(firstChunk)
+			rest, _ := io.ReadAll(part)
+			audio = append(first, rest...)
+		}
+		w.Write([]byte(`{"text":"streamed"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write(bytes.Repeat([]byte{1}, 320))
+		// The server must see audio while the recording is still going
+		select {
+		case <-firstChunk:
+		case <-time.After(2 * time.Second):
+			t.Error("Expected the upload to start before the recording ended")
+		}
+		pw.Write(bytes.Repeat([]byte{2}, 320))
+		pw.Close()
+	}()
+
+	text, err := client.TranscribeReaderWith(context.Background(), pr, Overrides{Model: "whisper-large-v3-turbo"})
+	if err != nil {
+		t.Fatalf("TranscribeReader failed: %v", err)
+	}
+	if text != "streamed" {
+		t.Errorf("Expected %q, got %q", "streamed", text)
+	}
+	if model != "whisper-large-v3-turbo" {
+		t.Errorf("Expected the model override in the form, got %q", model)
+	}
+	expected := append(bytes.Repeat([]byte{1}, 320), bytes.Repeat([]byte{2}, 320)...)
+	if !bytes.Equal(audio, expected) {
+		t.Errorf("Expected %d bytes of audio to arrive intact, got %d", len(expected), len(audio))
+	}
+	if !bytes.Equal(client.LastAudio(), expected) {
+		t.Error("Expected the streamed audio to be kept for Retranscribe")
+	}
+}
+
+func TestTranscribeReaderFailureNotRetried(t *testing.T) {
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = time.Second }()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetRetries(2)
+
+	if _, err := client.TranscribeReader(context.Background(), bytes.NewReader(make([]byte, 640))); err == nil {
+		t.Fatal("Expected the server error to be returned")
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request for unreplayable audio, got %d", requests)
+	}
+}
+
+func TestRequestTimeoutScalesWithAudio(t *testing.T) {
+	client := NewClient("test", nil)
+
+	testCases := []struct {
+		audio    time.Duration
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{10 * time.Second, 50 * time.Second},
+		{2 * time.Minute, 270 * time.Second},
+	}
+	for _, tc := range testCases {
+		if got := client.requestTimeout(client.audioBytes(tc.audio)); got != tc.expected {
+			t.Errorf("requestTimeout(%v of audio) = %v, want %v", tc.audio, got, tc.expected)
+		}
+	}
+
+	client.SetTimeout(90 * time.Second)
+	if got := client.requestTimeout(client.audioBytes(2 * time.Minute)); got != 90*time.Second {
+		t.Errorf("Expected the fixed timeout, got %v", got)
+	}
+	client.SetTimeout(0)
+	if got := client.requestTimeout(0); got != DefaultTimeout {
+		t.Errorf("Expected SetTimeout(0) to restore scaling, got %v", got)
+	}
+}
+
+func TestRequestTimeoutRetried(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetTimeout(50 * time.Millisecond)
+	client.SetRetryPolicy(1, time.Millisecond)
+
+	if _, err := client.Transcribe(context.Background(), make([]byte, 3200)); !errors.Is(err, errors.ErrTimeout) {
+		t.Fatalf("Expected a timed-out attempt not to be retried by default, got %v", err)
+	}
+
+	attempts.Store(0)
+	client.SetRetryTimeouts(true)
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil {
+		t.Fatalf("Expected the timed-out attempt to be retried, got %v", err)
+	}
+	if n := attempts.Load(); text != "hello" || n != 2 {
+		t.Errorf("Expected hello on the second attempt, got %q after %d", text, n)
+	}
+}
+
+func TestRequestTimeoutReported(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetTimeout(30 * time.Millisecond)
+	client.SetRetries(0)
+
+	_, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if !errors.Is(err, errors.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestCancelWinsOverTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetTimeout(time.Hour)
+	client.SetRetryPolicy(3, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(30*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.Transcribe(ctx, make([]byte, 3200))
+	if err == nil || errors.Is(err, errors.ErrTimeout) {
+		t.Errorf("Expected a cancellation error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancelling to end the request at once, took %v", elapsed)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("Expected no retry after cancelling, got %d attempts", n)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxUploadBytes is the largest file the API accepts in one request
+const MaxUploadBytes = 25 << 20
+
+// audioExtensions are the file formats the transcription endpoint accepts
+var audioExtensions = map[string]bool{
+	".flac": true, ".m4a": true, ".mp3": true, ".mp4": true, ".mpeg": true,
+	".mpga": true, ".ogg": true, ".opus": true, ".wav": true, ".webm": true,
+}
+
+// fileBytesPerSecond is the bitrate assumed when sizing a compressed file's
+// timeout: 16 kbit/s, low even for speech, so the timeout never falls short
+const fileBytesPerSecond = 2000
+
+// AudioPathFromText returns the audio file named by text, such as a path
+// copied from a terminal or a file manager: a plain or quoted absolute path,
+// ~/ for the home directory, or a file:// URI. GNOME's leading "copy" or
+// "cut" line is skipped. It fails unless text names exactly one file that
+// ValidateAudioFile accepts.
+func AudioPathFromText(text string) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > 1 && (lines[0] == "copy" || lines[0] == "cut") {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return "", fmt.Errorf("not a single file path")
+	}
+
+	path := strings.Trim(lines[0], `"'`)
+	if strings.HasPrefix(path, "file://") {
+		u, err := url.Parse(path)
+		if err != nil || (u.Host != "" && u.Host != "localhost") {
+			return "", fmt.Errorf("not a local file URI")
+		}
+		path = u.Path
+	} else if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("not an absolute path")
+	}
+
+	path = filepath.Clean(path)
+	if err := ValidateAudioFile(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ValidateAudioFile checks that path is a non-empty regular file in a
+// format the API accepts and small enough to upload in one request
+func ValidateAudioFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !audioExtensions[ext] {
+		return fmt.Errorf("%s is not a supported audio file", filepath.Base(path))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("%s is not a regular file", path)
+	case info.Size() == 0:
+		return fmt.Errorf("%s is empty", path)
+	case info.Size() > MaxUploadBytes:
+		return fmt.Errorf("%s is %d MB, over the %d MB upload limit", path, info.Size()>>20, MaxUploadBytes>>20)
+	}
+	return nil
+}
+
+// TranscribeFile transcribes an audio file as it is, in any format the API
+// accepts, with one-off overrides. The file is not kept for Retranscribe.
+func (c *Client) TranscribeFile(ctx context.Context, path string, o Overrides) (string, error) {
+	if err := ValidateAudioFile(path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	language := strings.ToLower(strings.TrimSpace(o.Language))
+	if language == "" {
+		language = c.language
+	}
+	model := strings.TrimSpace(o.Model)
+	if model == "" {
+		model = c.model
+	}
+
+	timeout := c.timeout
+	if timeout == 0 {
+		timeout = c.baseTimeout + timeoutPerSecond*time.Duration(int64(len(data))*int64(time.Second)/fileBytesPerSecond)
+	}
+	result, err := c.upload(ctx, filepath.Base(path), data, timeout, model, language, false, nil)
+	if err == nil {
+		c.noteLanguage(result.Language)
+	}
+	return result.Text, err
+}
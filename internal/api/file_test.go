@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAudioPathFromText(t *testing.T) {
+	dir := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	clip := filepath.Join(dir, "my clip.wav")
+	notes := filepath.Join(dir, "notes.txt")
+	empty := filepath.Join(dir, "empty.mp3")
+	homeClip := filepath.Join(home, "memo.m4a")
+	for path, data := range map[string]string{clip: "RIFF", notes: "hello", empty: "", homeClip: "m4a"} {
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain path", clip, clip},
+		{"surrounding space", "  " + clip + "\n", clip},
+		{"quoted", `"` + clip + `"`, clip},
+		{"file URI", "file://" + strings.ReplaceAll(clip, " ", "%20"), clip},
+		{"GNOME copied file", "copy\nfile://" + strings.ReplaceAll(clip, " ", "%20"), clip},
+		{"home", "~/memo.m4a", homeClip},
+		{"sentence", "remember to buy milk", ""},
+		{"relative", "my clip.wav", ""},
+		{"not audio", notes, ""},
+		{"empty file", empty, ""},
+		{"missing", filepath.Join(dir, "gone.wav"), ""},
+		{"directory", dir, ""},
+		{"two files", clip + "\n" + homeClip, ""},
+		{"remote URI", "file://server" + clip, ""},
+		{"blank", "", ""},
+	}
+
+	for _, tc := range testCases {
+		got, err := AudioPathFromText(tc.text)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tc.name, tc.expected, got, err)
+		}
+	}
+}
+
+func TestTranscribeFileUploadsAsIs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memo.ogg")
+	if err := os.WriteFile(path, []byte("OggS fake"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var filename, body, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("No file in request: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		filename, body, model = header.Filename, string(data), r.FormValue("model")
+		w.Write([]byte(`{"text":"from a file"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+
+	text, err := client.TranscribeFile(context.Background(), path, Overrides{Model: "whisper-large-v3-turbo"})
+	if err != nil {
+		t.Fatalf("TranscribeFile failed: %v", err)
+	}
+	if text != "from a file" {
+		t.Errorf("Expected the transcription, got %q", text)
+	}
+	if filename != "memo.ogg" || body != "OggS fake" {
+		t.Errorf("Expected memo.ogg uploaded unchanged, got %q: %q", filename, body)
+	}
+	if model != "whisper-large-v3-turbo" {
+		t.Errorf("Expected the model override, got %q", model)
+	}
+	if client.LastAudio() != nil {
+		t.Error("Expected a file not to replace the last recording")
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// Transcription providers accepted by NewForProvider
+const (
+	// ProviderGroq sends audio to the Groq API and needs an API key
+	ProviderGroq = "groq"
+	// ProviderLocal sends audio to a local OpenAI-compatible server
+	ProviderLocal = "local"
+)
+
+// DefaultLocalURL is where whisper.cpp's server listens by default, started
+// with --inference-path /v1/audio/transcriptions
+const DefaultLocalURL = "http://127.0.0.1:8080/v1"
+
+// localBaseTimeout allows for loading the model and CPU inference, which
+// can take longer than the recording itself
+const localBaseTimeout = 5 * time.Minute
+
+// ParseProvider normalizes a provider name; empty means ProviderGroq
+func ParseProvider(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return ProviderGroq, nil
+	case ProviderGroq, ProviderLocal:
+		return name, nil
+	}
+	return "", errors.NewError(errors.ErrorTypeConfig, fmt.Sprintf("unknown provider %q (use groq or local)", name), nil)
+}
+
+// LocalWhisperClient transcribes with a local server exposing an
+// OpenAI-compatible /audio/transcriptions endpoint, such as whisper.cpp's,
+// so audio never leaves the machine and no API key is needed. It supports
+// everything Client does.
+type LocalWhisperClient struct {
+	*Client
+}
+
+// NewLocalWhisperClient creates a client for the server at baseURL, the part
+// of the endpoint before /audio/transcriptions (e.g. DefaultLocalURL)
+func NewLocalWhisperClient(baseURL string, errHandler *errors.Handler) *LocalWhisperClient {
+	c := NewClient("", errHandler)
+	c.baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if c.baseURL == "" {
+		c.baseURL = DefaultLocalURL
+	}
+	c.baseTimeout = localBaseTimeout
+	// The upload size limit being worked around is Groq's
+	c.chunkLength = 0
+	c.idempotencyHeader = ""
+	// The server loads the model on first use and may not list models
+	c.warmWithAudio = true
+	return &LocalWhisperClient{Client: c}
+}
+
+// HealthCheck checks that the local server answers. It may not list models
+// as Groq does, so any response short of a server error counts.
+func (c *LocalWhisperClient) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeNetwork, "failed to create health check request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeNetwork, "local server at "+c.baseURL+" is not reachable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("health check failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NewForProvider returns the client for provider. apiKey is used by Groq and
+// localURL by the local server. Both are a Client, configured for their
+// endpoint, so the callers keep every Client feature whichever is chosen.
+func NewForProvider(provider, apiKey, localURL string, errHandler *errors.Handler) (*Client, error) {
+	provider, err := ParseProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	if provider == ProviderLocal {
+		return NewLocalWhisperClient(localURL, errHandler).Client, nil
+	}
+	return NewClient(apiKey, errHandler), nil
+}
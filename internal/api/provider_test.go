@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseProvider(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"", ProviderGroq, false},
+		{"groq", ProviderGroq, false},
+		{" Local ", ProviderLocal, false},
+		{"openai", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseProvider(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("ParseProvider(%q) = %q, %v; want %q, error %v", tc.name, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestLocalWhisperClientSendsNoCredentials(t *testing.T) {
+	var path, auth, key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		key = r.Header.Get(DefaultIdempotencyHeader)
+		w.Write([]byte(`{"text":"offline"}`))
+	}))
+	defer server.Close()
+
+	client := NewLocalWhisperClient(server.URL+"/v1/", nil)
+	text, err := client.Transcribe(context.Background(), make([]byte, 3200))
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if text != "offline" {
+		t.Errorf("Expected %q, got %q", "offline", text)
+	}
+	if path != "/v1/audio/transcriptions" {
+		t.Errorf("Expected the OpenAI-compatible path, got %s", path)
+	}
+	if auth != "" || key != "" {
+		t.Errorf("Expected no Authorization or idempotency header, got %q and %q", auth, key)
+	}
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if auth != "" {
+		t.Errorf("Expected the health check to send no Authorization header, got %q", auth)
+	}
+}
+
+func TestLocalWhisperClientHealthCheck(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	client := NewLocalWhisperClient(server.URL+"/v1", nil)
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected a server without /models to count as reachable, got %v", err)
+	}
+
+	status = http.StatusInternalServerError
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected a server error to fail the health check")
+	}
+
+	server.Close()
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected an unreachable server to fail the health check")
+	}
+}
+
+func TestNewForProvider(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"text":"hi"}`))
+	}))
+	defer server.Close()
+
+	local, err := NewForProvider("local", "gsk_secret", server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewForProvider failed: %v", err)
+	}
+	if _, err := local.Transcribe(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if auth != "" {
+		t.Error("Expected the Groq key never to reach the local server")
+	}
+
+	groq, err := NewForProvider("", "gsk_secret", server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewForProvider failed: %v", err)
+	}
+	if groq.baseURL != "https://api.groq.com/openai/v1" {
+		t.Errorf("Expected the Groq endpoint, got %s", groq.baseURL)
+	}
+
+	if _, err := NewForProvider("nope", "", "", nil); err == nil {
+		t.Error("Expected an unknown provider to be rejected")
+	}
+}
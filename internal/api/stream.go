@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errStreamingUnsupported means the provider rejected a streaming request
+var errStreamingUnsupported = fmt.Errorf("streaming transcription not supported")
+
+// streamDone is the sentinel payload that ends an SSE stream
+const streamDone = "[DONE]"
+
+// streamEvent is one transcription event. Deltas carry the next piece of
+// text; the final event may carry the complete text.
+type streamEvent struct {
+	Type  string `json:"type"`
+	Delta string `json:"delta"`
+	Text  string `json:"text"`
+}
+
+// readSSE reads Server-Sent Events from r, calling onData with the data of
+// each event. Multi-line data fields are joined with newlines, comments and
+// other fields are ignored, and a [DONE] payload ends the stream.
+func readSSE(r io.Reader, onData func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var data []string
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			return false, nil
+		}
+		payload := strings.Join(data, "\n")
+		data = data[:0]
+		if payload == streamDone {
+			return true, nil
+		}
+		return false, onData(payload)
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if done, err := dispatch(); done || err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		if field == "data" {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	// A stream may end without a trailing blank line
+	_, err := dispatch()
+	return err
+}
+
+// readTranscriptStream assembles the transcript from an SSE body, reporting
+// the text so far to onPartial after every delta
+func readTranscriptStream(r io.Reader, onPartial func(text string)) (string, error) {
+	var text strings.Builder
+	final := ""
+	err := readSSE(r, func(data string) error {
+		var ev streamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("invalid stream event %q: %w", data, err)
+		}
+		if ev.Delta != "" {
+			text.WriteString(ev.Delta)
+			if onPartial != nil {
+				onPartial(text.String())
+			}
+		}
+		if ev.Text != "" {
+			final = ev.Text
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if final != "" {
+		return final, nil
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cannedStream = `: keep-alive
+
+data: {"type":"transcript.text.delta","delta":"Hello"}
+
+data: {"type":"transcript.text.delta","delta":", wor"}
+
+data: {"type":"transcript.text.delta","delta":"ld."}
+
+data: [DONE]
+
+data: {"type":"transcript.text.delta","delta":" ignored"}
+
+`
+
+func TestReadSSE(t *testing.T) {
+	var got []string
+	err := readSSE(strings.NewReader("event: x\r\ndata: a\r\ndata: b\r\n\r\n: comment\ndata: c"), func(data string) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+	if want := []string{"a\nb", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestReadTranscriptStreamIgnoresAfterDone(t *testing.T) {
+	var partials []string
+	text, err := readTranscriptStream(strings.NewReader(cannedStream), func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil {
+		t.Fatalf("readTranscriptStream failed: %v", err)
+	}
+	if text != "Hello, world." {
+		t.Errorf("Expected assembled text %q, got %q", "Hello, world.", text)
+	}
+	if want := []string{"Hello", "Hello, wor", "Hello, world."}; !reflect.DeepEqual(partials, want) {
+		t.Errorf("Expected partials %q, got %q", want, partials)
+	}
+}
+
+func TestReadTranscriptStreamPrefersFinalText(t *testing.T) {
+	stream := "data: {\"delta\":\"helo\"}\n\ndata: {\"type\":\"transcript.text.done\",\"text\":\"Hello.\"}\n\n"
+	text, err := readTranscriptStream(strings.NewReader(stream), nil)
+	if err != nil || text != "Hello." {
+		t.Errorf("Expected final text %q, got %q, %v", "Hello.", text, err)
+	}
+}
+
+func TestTranscribeStreamFallsBack(t *testing.T) {
+	var streamed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := r.FormValue("stream")
+		streamed = append(streamed, stream)
+		if stream == "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unknown parameter stream"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"Hello."}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetStreaming(true)
+
+	var partials []string
+	text, err := client.TranscribeStream(context.Background(), make([]byte, 3200), func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil || text != "Hello." {
+		t.Fatalf("Expected fallback to return %q, got %q, %v", "Hello.", text, err)
+	}
+	if !reflect.DeepEqual(streamed, []string{"true", ""}) {
+		t.Errorf("Expected a streaming attempt then a plain one, got %q", streamed)
+	}
+	if !reflect.DeepEqual(partials, []string{"Hello."}) {
+		t.Errorf("Expected the full text reported once, got %q", partials)
+	}
+}
+
+func TestTranscribeStreamSSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(cannedStream))
+	}))
+	defer server.Close()
+
+	client := NewClient("test", nil)
+	client.baseURL = server.URL
+	client.SetStreaming(true)
+
+	var last string
+	text, err := client.TranscribeStream(context.Background(), make([]byte, 3200), func(text string) {
+		last = text
+	})
+	if err != nil || text != "Hello, world." || last != text {
+		t.Errorf("Expected streamed text %q, got %q (last partial %q), %v", "Hello, world.", text, last, err)
+	}
+}
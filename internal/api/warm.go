@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// warmTimeout bounds one keep-warm ping
+const warmTimeout = 30 * time.Second
+
+// warmAudio is how much silence a keep-warm transcription sends
+const warmAudio = 500 * time.Millisecond
+
+// Warm makes a cheap request so the next transcription doesn't pay for a
+// cold start. Groq is only asked for its model list, which keeps the
+// connection up without using transcription quota; a local server
+// transcribes half a second of silence with the configured model, so the
+// model stays loaded.
+func (c *Client) Warm(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+	defer cancel()
+	if !c.warmWithAudio {
+		return c.HealthCheck(ctx)
+	}
+	_, err := c.request(ctx, make([]byte, c.audioBytes(warmAudio)), c.model, c.language, false, nil)
+	return err
+}
+
+// Warmer calls a keep-warm ping at a fixed interval while the app is in
+// use. Once nothing has called Touch for the idle time it stops pinging, so
+// an app left running overnight doesn't spend quota, and starts again on
+// the next Touch.
+type Warmer struct {
+	interval time.Duration
+	idle     time.Duration
+	ping     func(ctx context.Context) error
+	mu       sync.Mutex
+	lastUse  time.Time
+	now      func() time.Time
+}
+
+// NewWarmer returns a warmer that calls ping every interval until idle has
+// passed without a Touch; an idle of 0 pings for as long as it runs. It
+// counts as used from now.
+func NewWarmer(interval, idle time.Duration, ping func(ctx context.Context) error) *Warmer {
+	w := &Warmer{
+		interval: interval,
+		idle:     idle,
+		ping:     ping,
+		now:      time.Now,
+	}
+	w.lastUse = w.now()
+	return w
+}
+
+// Touch records that the app was used, e.g. a recording started
+func (w *Warmer) Touch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastUse = w.now()
+}
+
+// idleNow reports whether the app has gone unused for the idle time
+func (w *Warmer) idleNow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.idle > 0 && w.now().Sub(w.lastUse) > w.idle
+}
+
+// Run pings at each interval until ctx is done
+func (w *Warmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if w.idleNow() {
+			continue
+		}
+		if err := w.ping(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Keep-warm ping failed: %v", err)
+		}
+	}
+}
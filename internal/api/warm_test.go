@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWarmerPingsAtInterval(t *testing.T) {
+	pings := make(chan time.Time, 100)
+	w := NewWarmer(20*time.Millisecond, 0, func(ctx context.Context) error {
+		pings <- time.Now()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	for i := 1; i <= 3; i++ {
+		select {
+		case at := <-pings:
+			if elapsed := at.Sub(start); elapsed < time.Duration(i)*20*time.Millisecond {
+				t.Errorf("Ping %d came after %v, before its interval", i, elapsed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected ping %d", i)
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestWarmerStopsWhenIdle(t *testing.T) {
+	var mu sync.Mutex
+	clock := time.Now()
+	now := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(d)
+	}
+
+	pings := make(chan struct{}, 100)
+	w := NewWarmer(5*time.Millisecond, time.Minute, func(ctx context.Context) error {
+		pings <- struct{}{}
+		return nil
+	})
+	w.now = now
+	w.Touch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	waitPing := func() bool {
+		select {
+		case <-pings:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+	if !waitPing() {
+		t.Fatal("Expected pings while in use")
+	}
+
+	advance(2 * time.Minute)
+	// Drain a ping that raced the clock change
+	waitPing()
+	if waitPing() {
+		t.Error("Expected no pings once idle")
+	}
+
+	w.Touch()
+	if !waitPing() {
+		t.Error("Expected pings to resume after Touch")
+	}
+}
+
+func TestWarmByProvider(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"text":"","language":"welsh"}`))
+	}))
+	defer server.Close()
+
+	groq := NewClient("test", nil)
+	groq.baseURL = server.URL
+	if err := groq.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	local := NewLocalWhisperClient(server.URL, nil)
+	if err := local.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+
+	expected := []string{"/models", "/audio/transcriptions"}
+	if len(paths) != 2 || paths[0] != expected[0] || paths[1] != expected[1] {
+		t.Errorf("Expected Groq to list models and the local server to transcribe, got %v", paths)
+	}
+	if local.LastAudio() != nil {
+		t.Error("Expected the keep-warm audio not to replace the last recording")
+	}
+	if language := local.LanguageFor(""); language != "" {
+		t.Errorf("Expected the keep-warm ping not to set the detected language, got %q", language)
+	}
+}
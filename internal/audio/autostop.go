@@ -0,0 +1,87 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// silenceDetector tracks how long captured audio has stayed below a level
+type silenceDetector struct {
+	threshold  float64       // RMS as a fraction of full scale, 0 to 1
+	maxSilence time.Duration // continuous silence that triggers a stop
+	silent     time.Duration
+	fired      bool
+}
+
+// Feed folds in a chunk of S16_LE audio lasting d and reports true, once per
+// recording, when the silence has lasted maxSilence. Any chunk at or above
+// the threshold resets the count, so pauses between sentences don't add up.
+func (sd *silenceDetector) Feed(chunk []byte, d time.Duration) bool {
+	if sd.fired {
+		return false
+	}
+	if chunkRMS(chunk) >= sd.threshold {
+		sd.silent = 0
+		return false
+	}
+	sd.silent += d
+	if sd.silent >= sd.maxSilence {
+		sd.fired = true
+		return true
+	}
+	return false
+}
+
+// Reset starts a new recording with no silence counted
+func (sd *silenceDetector) Reset() {
+	sd.silent = 0
+	sd.fired = false
+}
+
+// chunkRMS returns the RMS level of S16_LE samples as a fraction of full scale
+func chunkRMS(chunk []byte) float64 {
+	count := len(chunk) / 2
+	if count == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i+1 < len(chunk); i += 2 {
+		f := float64(int16(chunk[i])|int16(chunk[i+1])<<8) / 32768.0
+		sum += f * f
+	}
+	return math.Sqrt(sum / float64(count))
+}
+
+// EnableAutoStop makes the system call the OnAutoStop callback once the
+// recording's level stays below silenceThreshold (RMS, 0 to 1) for
+// maxSilence. A zero or negative maxSilence disables it, which is the default.
+// Must be called before recording starts.
+func (s *System) EnableAutoStop(silenceThreshold float64, maxSilence time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxSilence <= 0 {
+		s.silence = nil
+		return
+	}
+	s.silence = &silenceDetector{threshold: silenceThreshold, maxSilence: maxSilence}
+}
+
+// OnAutoStop sets the callback run when auto-stop detects enough silence.
+// It runs on its own goroutine, so it may call StopRecording.
+func (s *System) OnAutoStop(callback func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onAutoStop = callback
+}
+
+// checkSilence feeds a recorded chunk to the silence detector; s.mu is held
+func (s *System) checkSilence(chunk []byte) {
+	if s.silence == nil {
+		return
+	}
+	frames := len(chunk) / (s.bitsPerSample / 8 * s.channels)
+	d := time.Duration(frames) * time.Second / time.Duration(s.sampleRate)
+	if s.silence.Feed(chunk, d) && s.onAutoStop != nil {
+		go s.onAutoStop()
+	}
+}
@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// tone returns d of a 440Hz S16_LE sine at amplitude (0 to 1) and 16kHz
+func tone(d time.Duration, amplitude float64) []byte {
+	samples := int(d * 16000 / time.Second)
+	buf := make([]byte, 0, samples*2)
+	for i := 0; i < samples; i++ {
+		v := int16(amplitude * 32767 * math.Sin(2*math.Pi*440*float64(i)/16000))
+		buf = append(buf, byte(v), byte(v>>8))
+	}
+	return buf
+}
+
+func TestChunkRMS(t *testing.T) {
+	if got := chunkRMS(tone(100*time.Millisecond, 0)); got != 0 {
+		t.Errorf("Expected silence to have RMS 0, got %f", got)
+	}
+	// A sine's RMS is its amplitude over sqrt(2)
+	if got := chunkRMS(tone(100*time.Millisecond, 0.5)); math.Abs(got-0.5/math.Sqrt2) > 0.01 {
+		t.Errorf("Expected RMS near %f, got %f", 0.5/math.Sqrt2, got)
+	}
+	if got := chunkRMS(nil); got != 0 {
+		t.Errorf("Expected empty chunk to have RMS 0, got %f", got)
+	}
+}
+
+func TestSilenceDetector(t *testing.T) {
+	const chunk = 128 * time.Millisecond // 4096 bytes at 16kHz mono
+	speech := tone(chunk, 0.3)
+	quiet := tone(chunk, 0.001)
+
+	testCases := []struct {
+		name     string
+		pattern  []bool // true for speech
+		expected int    // times the detector fires
+	}{
+		{"short pauses between sentences", repeat([]bool{true, true, false, false, false, false, false, false}, 10), 0},
+		{"long silence after speech", append([]bool{true, true}, repeat([]bool{false}, 20)...), 1},
+		{"silence resumes after speech", append(repeat([]bool{false}, 10), append([]bool{true}, repeat([]bool{false}, 30)...)...), 1},
+		{"speech only", repeat([]bool{true}, 30), 0},
+	}
+
+	for _, tc := range testCases {
+		sd := &silenceDetector{threshold: 0.01, maxSilence: 2 * time.Second}
+		fired := 0
+		for _, loud := range tc.pattern {
+			c := quiet
+			if loud {
+				c = speech
+			}
+			if sd.Feed(c, chunk) {
+				fired++
+			}
+		}
+		if fired != tc.expected {
+			t.Errorf("%s: fired %d times, expected %d", tc.name, fired, tc.expected)
+		}
+	}
+}
+
+func repeat(pattern []bool, n int) []bool {
+	var out []bool
+	for i := 0; i < n; i++ {
+		out = append(out, pattern...)
+	}
+	return out
+}
+
+func TestAutoStop(t *testing.T) {
+	var stream []byte
+	stream = append(stream, tone(time.Second, 0.3)...)
+	stream = append(stream, tone(800*time.Millisecond, 0)...)
+	stream = append(stream, tone(time.Second, 0.3)...)
+	stream = append(stream, tone(2500*time.Millisecond, 0)...)
+
+	record := func(s *System) {
+		s.backend = BackendALSA
+		s.stdout = io.NopCloser(bytes.NewReader(stream))
+		s.isRecording = true
+		s.readAudio()
+	}
+
+	// Off by default
+	s := NewSystem(nil, BackendALSA)
+	stopped := make(chan struct{}, 4)
+	s.OnAutoStop(func() { stopped <- struct{}{} })
+	record(s)
+	select {
+	case <-stopped:
+		t.Fatal("Expected no auto-stop unless enabled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s = NewSystem(nil, BackendALSA)
+	s.EnableAutoStop(0.01, 2*time.Second)
+	s.OnAutoStop(func() { stopped <- struct{}{} })
+	record(s)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected auto-stop after 2s of silence")
+	}
+	select {
+	case <-stopped:
+		t.Error("Expected auto-stop to fire only once per recording")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,141 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Capture backends accepted by NewSystem
+const (
+	// BackendAuto uses PipeWire or PulseAudio when detected, else ALSA
+	BackendAuto = "auto"
+	// BackendALSA captures with arecord
+	BackendALSA = "alsa"
+	// BackendPulse captures with parec
+	BackendPulse = "pulse"
+	// BackendPipeWire captures with pw-record
+	BackendPipeWire = "pipewire"
+)
+
+// backendTools is the capture tool each backend runs
+var backendTools = map[string]string{
+	BackendALSA:     "arecord",
+	BackendPulse:    "parec",
+	BackendPipeWire: "pw-record",
+}
+
+// BackendTool returns the capture tool a backend runs, or "" for BackendAuto
+func BackendTool(backend string) string {
+	return backendTools[backend]
+}
+
+// lookPath and pipewireRunning are swapped in tests
+var (
+	lookPath        = exec.LookPath
+	pipewireRunning = func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return exec.CommandContext(ctx, "pw-cli", "info", "0").Run() == nil
+	}
+)
+
+// ParseBackend normalizes a backend name; empty means BackendAuto
+func ParseBackend(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return BackendAuto, nil
+	case "pulseaudio":
+		return BackendPulse, nil
+	case BackendAuto, BackendALSA, BackendPulse, BackendPipeWire:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown capture backend %q (use auto, alsa, pulse or pipewire)", name)
+}
+
+// detectBackend picks PipeWire when pw-cli can reach a running daemon, then
+// PulseAudio when PULSE_SERVER points at one, and ALSA otherwise
+func detectBackend(getenv func(string) string) string {
+	if _, err := lookPath("pw-cli"); err == nil && pipewireRunning() {
+		return BackendPipeWire
+	}
+	if getenv("PULSE_SERVER") != "" {
+		return BackendPulse
+	}
+	return BackendALSA
+}
+
+// resolveBackend returns the backend to capture with for a preference,
+// falling back to ALSA when the chosen backend's tool isn't installed. Auto
+// also picks ALSA for an ALSA device other than "default", e.g. from
+// --device or audio_device, which parec and pw-record know nothing about.
+func resolveBackend(preference, device string, getenv func(string) string) string {
+	backend := preference
+	if backend == BackendAuto {
+		if device != "" && device != "default" {
+			return BackendALSA
+		}
+		backend = detectBackend(getenv)
+	}
+	if backend != BackendALSA {
+		if _, err := lookPath(backendTools[backend]); err != nil {
+			log.Printf("Capture backend %s needs %s, which is missing; falling back to arecord", backend, backendTools[backend])
+			return BackendALSA
+		}
+	}
+	return backend
+}
+
+// Backend returns the capture backend in use, resolving it on first call
+func (s *System) Backend() string {
+	if s.backend == "" {
+		s.backend = resolveBackend(s.backendPreference, s.device, os.Getenv)
+		log.Printf("Audio capture backend: %s (%s)", s.backend, backendTools[s.backend])
+	}
+	return s.backend
+}
+
+// captureCommand returns the tool and arguments capturing S16_LE or
+// s.captureFormat at the configured rate and channel count to stdout
+func (s *System) captureCommand() (string, []string) {
+	switch s.Backend() {
+	case BackendPulse:
+		args := []string{
+			"--raw",
+			"--format=s16le",
+			fmt.Sprintf("--rate=%d", s.sampleRate),
+			fmt.Sprintf("--channels=%d", s.channels),
+		}
+		if s.pulseSource != "" {
+			args = append(args, "--device="+s.pulseSource)
+		}
+		return "parec", args
+	case BackendPipeWire:
+		args := []string{
+			"--raw",
+			"--format=s16",
+			fmt.Sprintf("--rate=%d", s.sampleRate),
+			fmt.Sprintf("--channels=%d", s.channels),
+		}
+		if s.pulseSource != "" {
+			args = append(args, "--target="+s.pulseSource)
+		}
+		return "pw-record", append(args, "-")
+	}
+	return "arecord", s.arecordArgs()
+}
+
+// streamFormat is the sample format arriving on the capture tool's stdout.
+// The sound server converts for parec and pw-record, so only arecord
+// delivers the configured capture format.
+func (s *System) streamFormat() string {
+	if s.Backend() == BackendALSA {
+		return s.captureFormat
+	}
+	return FormatS16LE
+}
@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// fakeEnvironment swaps tool lookup and PipeWire detection for the test
+func fakeEnvironment(t *testing.T, tools []string, pipewire bool) {
+	origLookPath, origRunning := lookPath, pipewireRunning
+	t.Cleanup(func() { lookPath, pipewireRunning = origLookPath, origRunning })
+
+	installed := make(map[string]bool)
+	for _, tool := range tools {
+		installed[tool] = true
+	}
+	lookPath = func(tool string) (string, error) {
+		if installed[tool] {
+			return "/usr/bin/" + tool, nil
+		}
+		return "", errors.New("not found")
+	}
+	pipewireRunning = func() bool { return pipewire }
+}
+
+func TestParseBackend(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", BackendAuto, false},
+		{"auto", BackendAuto, false},
+		{" ALSA ", BackendALSA, false},
+		{"pulse", BackendPulse, false},
+		{"pulseaudio", BackendPulse, false},
+		{"pipewire", BackendPipeWire, false},
+		{"jack", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseBackend(tc.input)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("ParseBackend(%q) = %q, %v; expected %q (error %v)", tc.input, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestResolveBackend(t *testing.T) {
+	all := []string{"arecord", "parec", "pw-record", "pw-cli"}
+	noEnv := func(string) string { return "" }
+	pulseEnv := func(key string) string {
+		if key == "PULSE_SERVER" {
+			return "unix:/run/user/1000/pulse/native"
+		}
+		return ""
+	}
+
+	testCases := []struct {
+		name       string
+		preference string
+		device     string
+		tools      []string
+		pipewire   bool
+		getenv     func(string) string
+		expected   string
+	}{
+		{"auto detects pipewire", BackendAuto, "default", all, true, noEnv, BackendPipeWire},
+		{"auto detects pulse server", BackendAuto, "default", all, false, pulseEnv, BackendPulse},
+		{"auto falls back to alsa", BackendAuto, "default", all, false, noEnv, BackendALSA},
+		{"auto with an explicit device", BackendAuto, "hw:1,0", all, true, pulseEnv, BackendALSA},
+		{"pipewire daemon without pw-cli", BackendAuto, "default", []string{"arecord", "pw-record"}, true, noEnv, BackendALSA},
+		{"explicit alsa", BackendALSA, "default", all, true, pulseEnv, BackendALSA},
+		{"explicit pulse", BackendPulse, "default", all, true, noEnv, BackendPulse},
+		{"explicit pulse with a device", BackendPulse, "hw:1,0", all, true, noEnv, BackendPulse},
+		{"parec missing", BackendPulse, "default", []string{"arecord"}, false, noEnv, BackendALSA},
+		{"pw-record missing", BackendPipeWire, "default", []string{"arecord", "pw-cli"}, true, noEnv, BackendALSA},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeEnvironment(t, tc.tools, tc.pipewire)
+			if got := resolveBackend(tc.preference, tc.device, tc.getenv); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCaptureCommand(t *testing.T) {
+	testCases := []struct {
+		backend  string
+		source   string
+		tool     string
+		expected []string
+	}{
+		{BackendPulse, "", "parec", []string{"--raw", "--format=s16le", "--rate=16000", "--channels=1"}},
+		{BackendPulse, "alsa_input.usb-mic", "parec", []string{"--raw", "--format=s16le", "--rate=16000", "--channels=1", "--device=alsa_input.usb-mic"}},
+		{BackendPipeWire, "", "pw-record", []string{"--raw", "--format=s16", "--rate=16000", "--channels=1", "-"}},
+		{BackendPipeWire, "alsa_input.usb-mic", "pw-record", []string{"--raw", "--format=s16", "--rate=16000", "--channels=1", "--target=alsa_input.usb-mic", "-"}},
+	}
+
+	for _, tc := range testCases {
+		s := NewSystem(nil, tc.backend)
+		s.backend = tc.backend
+		s.SetPulseSource(tc.source)
+		tool, args := s.captureCommand()
+		if tool != tc.tool || !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("%s: got %s %v, expected %s %v", tc.backend, tool, args, tc.tool, tc.expected)
+		}
+	}
+
+	s := NewSystem(nil, BackendALSA)
+	s.backend = BackendALSA
+	if tool, args := s.captureCommand(); tool != "arecord" || !reflect.DeepEqual(args, s.arecordArgs()) {
+		t.Errorf("Expected arecord with its usual arguments, got %s %v", tool, args)
+	}
+}
+
+func TestSoundServerStreamLandsUnchanged(t *testing.T) {
+	stream := make([]byte, 3200)
+	for i := range stream {
+		stream[i] = byte(i * 7)
+	}
+
+	for _, backend := range []string{BackendPulse, BackendPipeWire} {
+		s := NewSystem(nil, backend)
+		s.backend = backend
+		// A configured arecord format must not be applied to parec/pw-record output
+		s.captureFormat = FormatS32LE
+		s.stdout = io.NopCloser(bytes.NewReader(stream))
+		s.isRecording = true
+		s.readAudio()
+
+		if !bytes.Equal(s.audioBuffer, stream) {
+			t.Errorf("%s: expected the S16_LE stream in the buffer unchanged (%d bytes, got %d)", backend, len(stream), len(s.audioBuffer))
+		}
+	}
+}
+
+func TestUnsupportedFormatFailsCapture(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.backend = BackendALSA
+	s.captureFormat = "U8"
+
+	if err := s.startCapture(); err == nil {
+		t.Error("Expected an unsupported format to fail before starting the capture tool")
+	}
+
+	// A reader started anyway must stop instead of dividing by a zero frame size
+	s.stdout = io.NopCloser(bytes.NewReader(make([]byte, 3200)))
+	s.isRecording = true
+	s.readAudio()
+	if len(s.audioBuffer) != 0 {
+		t.Errorf("Expected nothing captured, got %d bytes", len(s.audioBuffer))
+	}
+	if s.stdout != nil {
+		t.Error("Expected the capture to be stopped")
+	}
+}
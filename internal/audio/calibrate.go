@@ -0,0 +1,99 @@
+package audio
+
+import (
+	"sort"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// DefaultCalibrationTime is how much ambient noise --calibrate records
+const DefaultCalibrationTime = 3 * time.Second
+
+// Silence thresholds derived from a noise floor sit this far above it, about
+// +8 dB, so steady room noise counts as silence and quiet speech doesn't
+const floorHeadroom = 2.5
+
+// Derived thresholds are kept within these bounds: a dead-silent input would
+// otherwise make any hiss count as speech, and a very noisy room would make
+// normal speech count as silence
+const (
+	minDerivedThreshold = 0.002
+	maxDerivedThreshold = 0.1
+)
+
+// NoiseFloor returns the ambient level of a recording of S16_LE room noise,
+// as an RMS fraction of full scale: the median level of its levelWindow
+// slices, so a cough or a door closing during calibration doesn't raise it
+func NoiseFloor(pcm []byte, sampleRate, channels int) float64 {
+	levels := windowLevels(pcm, sampleRate, channels)
+	if len(levels) == 0 {
+		return 0
+	}
+	sort.Float64s(levels)
+	mid := len(levels) / 2
+	if len(levels)%2 == 0 {
+		return (levels[mid-1] + levels[mid]) / 2
+	}
+	return levels[mid]
+}
+
+// HasSpeech reports whether any levelWindow slice of a recording of S16_LE
+// audio reaches threshold, e.g. ThresholdFromFloor of the calibrated floor.
+// One of nothing but room noise has none, and Whisper tends to make up words
+// for it.
+func HasSpeech(pcm []byte, sampleRate, channels int, threshold float64) bool {
+	for _, level := range windowLevels(pcm, sampleRate, channels) {
+		if level >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// windowLevels returns the RMS level of each levelWindow slice of pcm
+func windowLevels(pcm []byte, sampleRate, channels int) []float64 {
+	window := int(int64(sampleRate)*int64(levelWindow)/int64(time.Second)) * channels * 2
+	if window <= 0 || len(pcm) < 2 {
+		return nil
+	}
+
+	var levels []float64
+	for start := 0; start < len(pcm); start += window {
+		levels = append(levels, chunkRMS(pcm[start:min(start+window, len(pcm))]))
+	}
+	return levels
+}
+
+// ThresholdFromFloor derives the silence threshold for auto-stop and
+// HasSpeech from a calibrated noise floor
+func ThresholdFromFloor(floor float64) float64 {
+	return min(max(floor*floorHeadroom, minDerivedThreshold), maxDerivedThreshold)
+}
+
+// SilenceThreshold returns the threshold auto-stop should use: the configured
+// one, raised to clear the calibrated noise floor when there is one. A
+// threshold under the floor would never see silence.
+func SilenceThreshold(configured, floor float64) float64 {
+	if floor <= 0 {
+		return configured
+	}
+	return max(configured, ThresholdFromFloor(floor))
+}
+
+// Calibrate records d of ambient noise and returns its noise floor. The room
+// should be quiet apart from its usual background noise while it runs.
+func (s *System) Calibrate(d time.Duration) (float64, error) {
+	if err := s.StartRecording(); err != nil {
+		return 0, err
+	}
+	time.Sleep(d)
+	pcm, err := s.StopRecording()
+	if err != nil {
+		return 0, err
+	}
+	if len(pcm) == 0 {
+		return 0, errors.Wrap(errors.ErrAudioTooShort, errors.ErrorTypeAudio, "no audio captured during calibration")
+	}
+	return NoiseFloor(pcm, s.SampleRate(), s.Channels()), nil
+}
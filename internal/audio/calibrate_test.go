@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// constantPCM returns S16_LE mono samples alternating between +amp and -amp,
+// whose RMS is amp as a fraction of full scale
+func constantPCM(amp float64, samples int) []byte {
+	pcm := make([]byte, samples*2)
+	v := int16(amp * 32768)
+	for i := 0; i < samples; i++ {
+		s := v
+		if i%2 == 1 {
+			s = -v
+		}
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(s))
+	}
+	return pcm
+}
+
+func TestNoiseFloorSteadyNoise(t *testing.T) {
+	pcm := constantPCM(0.005, 16000)
+	if got := NoiseFloor(pcm, 16000, 1); math.Abs(got-0.005) > 0.0002 {
+		t.Errorf("Expected a floor of 0.005, got %v", got)
+	}
+}
+
+func TestNoiseFloorIgnoresBriefSpike(t *testing.T) {
+	// One second of quiet room noise with a loud 100ms bump in the middle
+	pcm := constantPCM(0.004, 16000)
+	copy(pcm[16000:], constantPCM(0.5, 1600))
+
+	if got := NoiseFloor(pcm, 16000, 1); math.Abs(got-0.004) > 0.0002 {
+		t.Errorf("Expected the spike not to raise the floor of 0.004, got %v", got)
+	}
+}
+
+func TestNoiseFloorEmpty(t *testing.T) {
+	if got := NoiseFloor(nil, 16000, 1); got != 0 {
+		t.Errorf("Expected 0 for no audio, got %v", got)
+	}
+}
+
+func TestNoiseFloorOddWindowRate(t *testing.T) {
+	// 22050 Hz makes a 50ms window of 1102.5 frames; slices must stay sample-aligned
+	pcm := constantPCM(0.01, 22050)
+	if got := NoiseFloor(pcm, 22050, 1); math.Abs(got-0.01) > 0.0002 {
+		t.Errorf("Expected a floor of 0.01, got %v", got)
+	}
+}
+
+func TestThresholdFromFloor(t *testing.T) {
+	testCases := []struct {
+		floor    float64
+		expected float64
+	}{
+		{0.004, 0.01},
+		{0.02, 0.05},
+		{0, minDerivedThreshold},
+		{0.0001, minDerivedThreshold},
+		{0.2, maxDerivedThreshold},
+	}
+
+	for _, tc := range testCases {
+		if got := ThresholdFromFloor(tc.floor); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("ThresholdFromFloor(%v) = %v, want %v", tc.floor, got, tc.expected)
+		}
+	}
+}
+
+func TestSilenceThreshold(t *testing.T) {
+	testCases := []struct {
+		configured, floor, expected float64
+	}{
+		// Uncalibrated: the configured threshold as is
+		{0.01, 0, 0.01},
+		// A noisy room raises it above the floor
+		{0.01, 0.02, 0.05},
+		// A quiet room keeps a higher configured threshold
+		{0.03, 0.002, 0.03},
+	}
+
+	for _, tc := range testCases {
+		if got := SilenceThreshold(tc.configured, tc.floor); math.Abs(got-tc.expected) > 1e-9 {
+			t.Errorf("SilenceThreshold(%v, %v) = %v, want %v", tc.configured, tc.floor, got, tc.expected)
+		}
+	}
+}
+
+func TestHasSpeech(t *testing.T) {
+	threshold := ThresholdFromFloor(0.004)
+	noise := constantPCM(0.004, 16000)
+	speech := append(constantPCM(0.004, 8000), constantPCM(0.05, 1600)...)
+
+	testCases := []struct {
+		name     string
+		pcm      []byte
+		expected bool
+	}{
+		{"room noise", noise, false},
+		{"speech over noise", speech, true},
+		{"empty", nil, false},
+	}
+
+	for _, tc := range testCases {
+		if got := HasSpeech(tc.pcm, 16000, 1, threshold); got != tc.expected {
+			t.Errorf("%s: HasSpeech = %v, want %v", tc.name, got, tc.expected)
+		}
+	}
+}
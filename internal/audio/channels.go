@@ -0,0 +1,24 @@
+package audio
+
+// SplitChannels splits interleaved S16_LE audio into one mono buffer per
+// channel, e.g. the two speakers of an interview recorded on a stereo
+// interface. A trailing partial frame is dropped. With one channel or fewer
+// the data is returned as the only buffer.
+func SplitChannels(data []byte, channels int) [][]byte {
+	if channels <= 1 {
+		return [][]byte{data}
+	}
+	frameSize := 2 * channels
+	frames := len(data) / frameSize
+	out := make([][]byte, channels)
+	for ch := range out {
+		out[ch] = make([]byte, 0, frames*2)
+	}
+	for f := 0; f < frames; f++ {
+		frame := data[f*frameSize : (f+1)*frameSize]
+		for ch := range out {
+			out[ch] = append(out[ch], frame[2*ch], frame[2*ch+1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitChannels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		channels int
+		expected [][]byte
+	}{
+		{
+			"stereo",
+			[]byte{0x01, 0x02, 0xA1, 0xA2, 0x03, 0x04, 0xA3, 0xA4},
+			2,
+			[][]byte{{0x01, 0x02, 0x03, 0x04}, {0xA1, 0xA2, 0xA3, 0xA4}},
+		},
+		{
+			"partial trailing frame dropped",
+			[]byte{0x01, 0x02, 0xA1, 0xA2, 0x03, 0x04},
+			2,
+			[][]byte{{0x01, 0x02}, {0xA1, 0xA2}},
+		},
+		{
+			"mono passes through",
+			[]byte{0x01, 0x02, 0x03, 0x04},
+			1,
+			[][]byte{{0x01, 0x02, 0x03, 0x04}},
+		},
+		{
+			"empty",
+			nil,
+			2,
+			[][]byte{{}, {}},
+		},
+	}
+
+	for _, tc := range testCases {
+		got := SplitChannels(tc.data, tc.channels)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestSetChannels(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	if err := s.SetChannels(2); err != nil {
+		t.Fatalf("Expected stereo to be accepted: %v", err)
+	}
+	if s.Channels() != 2 {
+		t.Errorf("Expected 2 channels, got %d", s.Channels())
+	}
+	for _, n := range []int{0, 3, -1} {
+		if err := s.SetChannels(n); err == nil {
+			t.Errorf("Expected %d channels to be rejected", n)
+		}
+	}
+	if s.Channels() != 2 {
+		t.Errorf("Expected a rejected value to leave 2 channels, got %d", s.Channels())
+	}
+}
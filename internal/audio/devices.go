@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// asoundDir is where the kernel lists sound cards; a variable so tests can fake it
+var asoundDir = "/proc/asound"
+
+// cardPattern finds the card in ALSA names like "hw:CARD=Headset,DEV=0" or "plughw:1,0"
+var cardPattern = regexp.MustCompile(`(?:CARD=([^,]+)|^[a-z]*hw:(\d+))`)
+
+// DeviceID returns a stable key for a capture device to remember it by.
+// USB cards are keyed by their vendor:product id, which survives replugging
+// into another port where the card may get a different ALSA name or number.
+// Anything else is keyed by its device name.
+func DeviceID(device string) string {
+	m := cardPattern.FindStringSubmatch(device)
+	if m == nil {
+		return device
+	}
+	card := m[1]
+	if card == "" {
+		card = "card" + m[2]
+	}
+	usbid, err := os.ReadFile(filepath.Join(asoundDir, card, "usbid"))
+	if err != nil {
+		return device
+	}
+	if id := strings.TrimSpace(string(usbid)); id != "" {
+		return "usb:" + id
+	}
+	return device
+}
+
+// ResolveDevice picks the capture device to use at startup.
+// An explicitly requested device always wins. Otherwise the most recently used
+// device from history that is currently available is chosen, so a USB headset
+// is picked again when it is plugged back in. History is keyed by DeviceID;
+// when several available names belong to that device the first listed wins.
+// If none of the remembered devices are present, fallback is used when
+// available, then "default".
+func ResolveDevice(requested string, available []string, history map[string]int64, fallback string) string {
+	if requested != "" {
+		return requested
+	}
+
+	best := ""
+	var bestUsed int64
+	present := make(map[string]bool, len(available))
+	for _, d := range available {
+		present[d] = true
+		lastUsed, ok := history[DeviceID(d)]
+		if !ok {
+			continue
+		}
+		// Strictly newer, so ties go to the first listed device
+		if best == "" || lastUsed > bestUsed {
+			best, bestUsed = d, lastUsed
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	if fallback != "" && present[fallback] {
+		return fallback
+	}
+	return "default"
+}
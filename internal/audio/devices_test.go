@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeAsound lists a USB card named Headset with the given usb id
+func fakeAsound(t *testing.T, usbid string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Headset"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Headset", "usbid"), []byte(usbid+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("Headset", filepath.Join(dir, "card1")); err != nil {
+		t.Fatal(err)
+	}
+	old := asoundDir
+	asoundDir = dir
+	t.Cleanup(func() { asoundDir = old })
+}
+
+func TestDeviceID(t *testing.T) {
+	fakeAsound(t, "046d:0a44")
+
+	testCases := []struct {
+		device   string
+		expected string
+	}{
+		{"hw:CARD=Headset,DEV=0", "usb:046d:0a44"},
+		{"plughw:CARD=Headset,DEV=0", "usb:046d:0a44"},
+		{"sysdefault:CARD=Headset", "usb:046d:0a44"},
+		{"plughw:1,0", "usb:046d:0a44"},
+		{"hw:CARD=PCH,DEV=0", "hw:CARD=PCH,DEV=0"},
+		{"default", "default"},
+		{"pulse", "pulse"},
+	}
+
+	for _, tc := range testCases {
+		if got := DeviceID(tc.device); got != tc.expected {
+			t.Errorf("DeviceID(%q): expected %q, got %q", tc.device, tc.expected, got)
+		}
+	}
+}
+
+func TestResolveDevice(t *testing.T) {
+	fakeAsound(t, "046d:0a44")
+	available := []string{"default", "hw:CARD=PCH,DEV=0", "sysdefault:CARD=Headset", "hw:CARD=Headset,DEV=0"}
+
+	testCases := []struct {
+		name      string
+		requested string
+		available []string
+		history   map[string]int64
+		fallback  string
+		expected  string
+	}{
+		{"explicit wins", "hw:1", available, map[string]int64{"usb:046d:0a44": 100}, "", "hw:1"},
+		{"most recent available", "", available, map[string]int64{"hw:CARD=PCH,DEV=0": 50, "usb:046d:0a44": 100}, "", "sysdefault:CARD=Headset"},
+		{"skip unplugged", "", []string{"default", "hw:CARD=PCH,DEV=0"}, map[string]int64{"hw:CARD=PCH,DEV=0": 50, "usb:046d:0a44": 100}, "", "hw:CARD=PCH,DEV=0"},
+		{"other usb device", "", available, map[string]int64{"hw:CARD=PCH,DEV=0": 50, "usb:1b3f:2008": 100}, "", "hw:CARD=PCH,DEV=0"},
+		{"fallback when no history", "", available, nil, "hw:CARD=PCH,DEV=0", "hw:CARD=PCH,DEV=0"},
+		{"fallback unavailable", "", available, nil, "hw:9", "default"},
+		{"nothing known", "", nil, map[string]int64{"usb:046d:0a44": 100}, "", "default"},
+		{"tie goes to first listed", "", available, map[string]int64{"hw:CARD=PCH,DEV=0": 10, "usb:046d:0a44": 10}, "", "hw:CARD=PCH,DEV=0"},
+	}
+
+	for _, tc := range testCases {
+		got := ResolveDevice(tc.requested, tc.available, tc.history, tc.fallback)
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenFIFO creates the named pipe at path if needed and opens it for
+// writing. It is opened read-write so neither the open nor writes fail
+// while no reader is attached; the kernel buffer fills and chunks are then
+// dropped.
+func OpenFIFO(path string) (*os.File, error) {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			return nil, fmt.Errorf("failed to create FIFO %s: %w", path, err)
+		}
+	case err != nil:
+		return nil, err
+	case info.Mode()&os.ModeNamedPipe == 0:
+		return nil, fmt.Errorf("%s exists and is not a FIFO", path)
+	}
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
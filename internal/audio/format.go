@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Capture formats that can be requested from arecord. Everything is converted
+// to S16_LE internally, since that is what the WAV encoder and API expect.
+const (
+	FormatS16LE   = "S16_LE"
+	FormatS24_3LE = "S24_3LE"
+	FormatS32LE   = "S32_LE"
+	FormatFloatLE = "FLOAT_LE"
+)
+
+// bytesPerSampleFor returns the sample width for a capture format
+func bytesPerSampleFor(format string) (int, error) {
+	switch format {
+	case FormatS16LE:
+		return 2, nil
+	case FormatS24_3LE:
+		return 3, nil
+	case FormatS32LE, FormatFloatLE:
+		return 4, nil
+	default:
+		return 0, fmt.Errorf("unsupported capture format: %s", format)
+	}
+}
+
+// NormalizeFormat canonicalizes a user-supplied format name (e.g. "s24_3le", "float")
+func NormalizeFormat(format string) (string, error) {
+	f := strings.ToUpper(strings.TrimSpace(format))
+	switch f {
+	case "":
+		return FormatS16LE, nil
+	case "FLOAT", "FLOAT32", "FLOAT_LE":
+		return FormatFloatLE, nil
+	}
+	if _, err := bytesPerSampleFor(f); err != nil {
+		return "", err
+	}
+	return f, nil
+}
+
+// ConvertS24_3LEToS16 reduces packed 24-bit little-endian samples to 16-bit
+// by keeping the two most significant bytes. Trailing partial samples are ignored.
+func ConvertS24_3LEToS16(in []byte) []byte {
+	n := len(in) / 3
+	out := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		out[i*2] = in[i*3+1]
+		out[i*2+1] = in[i*3+2]
+	}
+	return out
+}
+
+// ConvertS32LEToS16 reduces 32-bit little-endian samples to 16-bit
+func ConvertS32LEToS16(in []byte) []byte {
+	n := len(in) / 4
+	out := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		out[i*2] = in[i*4+2]
+		out[i*2+1] = in[i*4+3]
+	}
+	return out
+}
+
+// ConvertFloat32LEToS16 converts IEEE-754 float samples in [-1, 1] to 16-bit,
+// clamping out-of-range values
+func ConvertFloat32LEToS16(in []byte) []byte {
+	n := len(in) / 4
+	out := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		f := math.Float32frombits(binary.LittleEndian.Uint32(in[i*4:]))
+		v := float64(f) * 32767.0
+		if v > 32767 {
+			v = 32767
+		} else if v < -32768 {
+			v = -32768
+		} else if math.IsNaN(v) {
+			v = 0
+		}
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(v)))
+	}
+	return out
+}
+
+// toS16 converts a buffer of whole samples in format to S16_LE
+func toS16(format string, in []byte) []byte {
+	switch format {
+	case FormatS24_3LE:
+		return ConvertS24_3LEToS16(in)
+	case FormatS32LE:
+		return ConvertS32LEToS16(in)
+	case FormatFloatLE:
+		return ConvertFloat32LEToS16(in)
+	default:
+		return in
+	}
+}
@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestConvertS24_3LEToS16(t *testing.T) {
+	// 0x123456, -1 (0xFFFFFF), max positive 0x7FFFFF, min negative 0x800000
+	in := []byte{
+		0x56, 0x34, 0x12,
+		0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0x7F,
+		0x00, 0x00, 0x80,
+	}
+	expected := []byte{
+		0x34, 0x12,
+		0xFF, 0xFF,
+		0xFF, 0x7F,
+		0x00, 0x80,
+	}
+
+	if got := ConvertS24_3LEToS16(in); !bytes.Equal(got, expected) {
+		t.Errorf("Expected %x, got %x", expected, got)
+	}
+}
+
+func TestConvertS24_3LEIgnoresPartialSample(t *testing.T) {
+	got := ConvertS24_3LEToS16([]byte{0x00, 0x00, 0x40, 0x01})
+	if len(got) != 2 {
+		t.Errorf("Expected 1 sample (2 bytes), got %d bytes", len(got))
+	}
+}
+
+func TestConvertFloat32LEToS16(t *testing.T) {
+	testCases := []struct {
+		in       float32
+		expected int16
+	}{
+		{0, 0},
+		{1.0, 32767},
+		{-1.0, -32767},
+		{0.5, 16383},
+		{2.0, 32767},
+		{-2.0, -32768},
+		{float32(math.NaN()), 0},
+	}
+
+	in := make([]byte, 4*len(testCases))
+	for i, tc := range testCases {
+		binary.LittleEndian.PutUint32(in[i*4:], math.Float32bits(tc.in))
+	}
+
+	out := ConvertFloat32LEToS16(in)
+	for i, tc := range testCases {
+		got := int16(binary.LittleEndian.Uint16(out[i*2:]))
+		if got != tc.expected {
+			t.Errorf("float %v: expected %d, got %d", tc.in, tc.expected, got)
+		}
+	}
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	testCases := map[string]string{
+		"":         FormatS16LE,
+		"s16_le":   FormatS16LE,
+		"S24_3LE":  FormatS24_3LE,
+		"float":    FormatFloatLE,
+		"FLOAT_LE": FormatFloatLE,
+	}
+	for in, expected := range testCases {
+		got, err := NormalizeFormat(in)
+		if err != nil || got != expected {
+			t.Errorf("NormalizeFormat(%q) = %q, %v; expected %q", in, got, err, expected)
+		}
+	}
+
+	if _, err := NormalizeFormat("U8"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
@@ -0,0 +1,171 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// HWParams is the subset of a device's hardware capabilities VoiceType cares
+// about, as reported by arecord --dump-hw-params
+type HWParams struct {
+	Formats     []string
+	MinRate     int
+	MaxRate     int
+	MinChannels int
+	MaxChannels int
+}
+
+// ParseHWParams parses the output of arecord --dump-hw-params. Values are
+// either a single number ("48000") or an inclusive range ("[8000 192000]").
+func ParseHWParams(output string) (*HWParams, error) {
+	p := &HWParams{}
+	var haveFormat, haveRate, haveChannels bool
+
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch strings.TrimSpace(key) {
+		case "FORMAT":
+			p.Formats = strings.Fields(value)
+			haveFormat = len(p.Formats) > 0
+		case "RATE":
+			p.MinRate, p.MaxRate, err = parseHWRange(value)
+			haveRate = err == nil
+		case "CHANNELS":
+			p.MinChannels, p.MaxChannels, err = parseHWRange(value)
+			haveChannels = err == nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", strings.TrimSpace(key), value, err)
+		}
+	}
+
+	if !haveFormat || !haveRate || !haveChannels {
+		return nil, fmt.Errorf("hw params output is missing FORMAT, RATE or CHANNELS")
+	}
+	return p, nil
+}
+
+// parseHWRange parses "N", "[N M]" or "(N M)"; open bounds are treated as inclusive
+func parseHWRange(value string) (int, int, error) {
+	value = strings.Trim(value, "[]()")
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, fmt.Errorf("expected a value or a range")
+	}
+	lo, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	hi := lo
+	if len(fields) == 2 {
+		if hi, err = strconv.Atoi(fields[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	return lo, hi, nil
+}
+
+// SupportsFormat reports whether the device accepts an arecord sample format
+func (p *HWParams) SupportsFormat(format string) bool {
+	for _, f := range p.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsRate reports whether rate is within the device's rate range
+func (p *HWParams) SupportsRate(rate int) bool {
+	return rate >= p.MinRate && rate <= p.MaxRate
+}
+
+// SupportsChannels reports whether the device can capture that many channels
+func (p *HWParams) SupportsChannels(channels int) bool {
+	return channels >= p.MinChannels && channels <= p.MaxChannels
+}
+
+// ChooseFormat returns preferred if the device supports it, otherwise the
+// first capture format VoiceType can convert that the device does support
+func (p *HWParams) ChooseFormat(preferred string) (string, error) {
+	if p.SupportsFormat(preferred) {
+		return preferred, nil
+	}
+	for _, f := range []string{FormatS16LE, FormatS32LE, FormatS24_3LE, FormatFloatLE} {
+		if p.SupportsFormat(f) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("device supports none of the usable formats (has %s)", strings.Join(p.Formats, " "))
+}
+
+// QueryHWParams asks arecord for a device's hardware capabilities
+func QueryHWParams(device string) (*HWParams, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// arecord prints the params to stderr, then captures a single sample
+	out, err := exec.CommandContext(ctx, "arecord", "-D", device, "--dump-hw-params", "--samples=1", "-t", "raw", "/dev/null").CombinedOutput()
+	params, parseErr := ParseHWParams(string(out))
+	if parseErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("arecord --dump-hw-params failed: %w", err)
+		}
+		return nil, parseErr
+	}
+	return params, nil
+}
+
+// ValidateDevice checks the configured format, rate and channel count against
+// the device's capabilities. An unsupported format is switched to one the
+// device supports; an unsupported rate or channel count is an error, since
+// arecord would otherwise fail or silently resample on some hardware.
+// If the capabilities can't be queried, validation is skipped.
+func (s *System) ValidateDevice() error {
+	// The default device goes through the ALSA plug layer, which converts
+	// anything, so skip the extra arecord start on the common path
+	if s.device == "default" {
+		return nil
+	}
+	// PulseAudio and PipeWire convert to whatever is requested
+	if s.Backend() != BackendALSA {
+		return nil
+	}
+	params, err := QueryHWParams(s.device)
+	if err != nil {
+		log.Printf("Skipping capture validation for %s: %v", s.device, err)
+		return nil
+	}
+	return s.validate(params)
+}
+
+func (s *System) validate(params *HWParams) error {
+	format, err := params.ChooseFormat(s.captureFormat)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeAudio, "device "+s.device+" cannot be used")
+	}
+	if format != s.captureFormat {
+		log.Printf("Device %s does not support %s, capturing as %s", s.device, s.captureFormat, format)
+		s.captureFormat = format
+	}
+	if !params.SupportsRate(s.sampleRate) {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("device %s does not support %d Hz (supports %d-%d Hz); use a plughw: or default device", s.device, s.sampleRate, params.MinRate, params.MaxRate), nil)
+	}
+	if !params.SupportsChannels(s.channels) {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("device %s does not support %d channel(s) (supports %d-%d); use a plughw: or default device", s.device, s.channels, params.MinChannels, params.MaxChannels), nil)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleHWParams = `Recording WAVE '/dev/null' : Signed 16 bit Little Endian, Rate 8000 Hz, Mono
+HW Params of device "hw:1,0":
+--------------------
+ACCESS:  MMAP_INTERLEAVED RW_INTERLEAVED
+FORMAT:  S24_3LE S32_LE
+SUBFORMAT:  STD
+SAMPLE_BITS: [24 32]
+FRAME_BITS: [48 64]
+CHANNELS: 2
+RATE: [44100 48000]
+PERIOD_TIME: (166 371520)
+PERIOD_SIZE: [8 16384]
+BUFFER_SIZE: [16 32768]
+--------------------
+arecord: set_params:1352: Channels count non available
+`
+
+func TestParseHWParams(t *testing.T) {
+	p, err := ParseHWParams(sampleHWParams)
+	if err != nil {
+		t.Fatalf("ParseHWParams failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(p.Formats, []string{"S24_3LE", "S32_LE"}) {
+		t.Errorf("Unexpected formats %v", p.Formats)
+	}
+	if p.MinRate != 44100 || p.MaxRate != 48000 {
+		t.Errorf("Expected rate 44100-48000, got %d-%d", p.MinRate, p.MaxRate)
+	}
+	if p.MinChannels != 2 || p.MaxChannels != 2 {
+		t.Errorf("Expected exactly 2 channels, got %d-%d", p.MinChannels, p.MaxChannels)
+	}
+}
+
+func TestParseHWParamsIncomplete(t *testing.T) {
+	if _, err := ParseHWParams("arecord: main:850: audio open error: No such file or directory\n"); err == nil {
+		t.Error("Expected error for output without hw params")
+	}
+}
+
+func TestChooseFormat(t *testing.T) {
+	p := &HWParams{Formats: []string{"S24_3LE", "S32_LE"}}
+
+	if f, _ := p.ChooseFormat(FormatS24_3LE); f != FormatS24_3LE {
+		t.Errorf("Expected supported preferred format to be kept, got %s", f)
+	}
+	if f, _ := p.ChooseFormat(FormatS16LE); f != FormatS32LE {
+		t.Errorf("Expected fallback to S32_LE, got %s", f)
+	}
+	if _, err := (&HWParams{Formats: []string{"U8"}}).ChooseFormat(FormatS16LE); err == nil {
+		t.Error("Expected error when no usable format is supported")
+	}
+}
+
+func TestValidateAgainstHWParams(t *testing.T) {
+	p, err := ParseHWParams(sampleHWParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSystem(nil, BackendALSA)
+	if err := s.validate(p); err == nil {
+		t.Error("Expected 16000 Hz mono to be rejected by a 44.1-48 kHz stereo device")
+	}
+	if s.CaptureFormat() != FormatS32LE {
+		t.Errorf("Expected capture format adjusted to S32_LE, got %s", s.CaptureFormat())
+	}
+
+	ok := &HWParams{Formats: []string{"S16_LE"}, MinRate: 8000, MaxRate: 192000, MinChannels: 1, MaxChannels: 2}
+	if err := NewSystem(nil, BackendALSA).validate(ok); err != nil {
+		t.Errorf("Expected default settings to validate, got %v", err)
+	}
+}
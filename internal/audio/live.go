@@ -0,0 +1,81 @@
+package audio
+
+import (
+	"io"
+	"sync"
+)
+
+// liveStream hands a recording to a reader as it is captured. Writes never
+// block, so a slow reader (e.g. a stalled upload) can't hold up capture.
+type liveStream struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    []byte
+	closed bool
+}
+
+func newLiveStream(seed []byte) *liveStream {
+	l := &liveStream{buf: append([]byte(nil), seed...)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Write queues p for the reader
+func (l *liveStream) Write(p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.buf = append(l.buf, p...)
+	l.cond.Broadcast()
+}
+
+// Close ends the stream once the queued audio has been read
+func (l *liveStream) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	l.cond.Broadcast()
+}
+
+// Read blocks until audio is queued or the stream is closed
+func (l *liveStream) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.buf) == 0 && !l.closed {
+		l.cond.Wait()
+	}
+	if len(l.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, l.buf)
+	l.buf = l.buf[n:]
+	return n, nil
+}
+
+// Live returns a reader of the current recording's S16_LE audio as it is
+// captured, starting with any pre-roll, that reaches EOF when StopRecording
+// is called. The audio is still buffered and returned by StopRecording as
+// usual. Call it right after StartRecording; it returns nil when not
+// recording.
+func (s *System) Live() io.Reader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isRecording {
+		return nil
+	}
+	if s.live != nil {
+		s.live.Close()
+	}
+	s.live = newLiveStream(s.audioBuffer)
+	return s.live
+}
+
+// closeLive ends the Live reader, if any; s.mu is held
+func (s *System) closeLive() {
+	if s.live != nil {
+		s.live.Close()
+		s.live = nil
+	}
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLiveFollowsRecording(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	if s.Live() != nil {
+		t.Fatal("Expected no live reader when not recording")
+	}
+
+	s.mu.Lock()
+	s.isRecording = true
+	s.audioBuffer = []byte{0xAA, 0xAA} // e.g. pre-roll
+	s.mu.Unlock()
+
+	r := s.Live()
+	got := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		got <- b
+	}()
+
+	s.deliver([]byte{0x01, 0x02})
+	s.deliver([]byte{0x03, 0x04})
+	recorded, err := s.StopRecording()
+	if err != nil {
+		t.Fatalf("StopRecording failed: %v", err)
+	}
+
+	select {
+	case b := <-got:
+		if !bytes.Equal(b, recorded) {
+			t.Errorf("Expected the live reader to see the whole recording %x, got %x", recorded, b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the live reader to reach EOF after StopRecording")
+	}
+}
+
+func TestLiveStreamReadBlocksUntilWrite(t *testing.T) {
+	l := newLiveStream(nil)
+	read := make(chan int)
+	go func() {
+		n, _ := l.Read(make([]byte, 8))
+		read <- n
+	}()
+
+	select {
+	case <-read:
+		t.Fatal("Expected Read to wait for audio")
+	case <-time.After(20 * time.Millisecond):
+	}
+	l.Write([]byte{1, 2, 3})
+	if n := <-read; n != 3 {
+		t.Errorf("Expected 3 bytes, got %d", n)
+	}
+
+	l.Close()
+	l.Write([]byte{4})
+	if _, err := l.Read(make([]byte, 8)); err != io.EOF {
+		t.Errorf("Expected EOF after Close, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package audio
+
+import "sync"
+
+// levelMeter exponentially smooths raw levels for the visualizer
+type levelMeter struct {
+	// mu lets the UI's animation goroutine update the meter while a new
+	// recording resets it
+	mu    sync.Mutex
+	value float64
+}
+
+// smoothingFactor is the weight given to each new raw level
+const smoothingFactor = 0.3
+
+// Update folds a raw level into the smoothed value and returns it
+func (m *levelMeter) Update(level float64) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.value = m.value*(1-smoothingFactor) + level*smoothingFactor
+	return m.value
+}
+
+// Reset clears the smoothed value so a new session starts from silence
+func (m *levelMeter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.value = 0
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PulseSourceOff disables default source detection via the pulse_source config
+const PulseSourceOff = "off"
+
+// pulseDevice is the ALSA plugin device that routes capture through
+// PulseAudio or PipeWire, honouring PULSE_SOURCE
+const pulseDevice = "pulse"
+
+// ParseDefaultSource extracts the source name from the output of
+// `pactl get-default-source`, or from `pactl info` on older pactl versions
+// that lack it ("Default Source: name")
+func ParseDefaultSource(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Default Source" {
+			output = value
+			break
+		}
+	}
+	source := strings.TrimSpace(output)
+	if source == "" || strings.ContainsAny(source, " \t\n") {
+		return "", fmt.Errorf("no default source in pactl output")
+	}
+	return source, nil
+}
+
+// DefaultSource asks PulseAudio (or PipeWire's pulse server) for the
+// default capture source
+func DefaultSource() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "pactl", "get-default-source").Output()
+	if err != nil {
+		// pactl before 15.0 has no get-default-source
+		if out, err = exec.CommandContext(ctx, "pactl", "info").Output(); err != nil {
+			return "", fmt.Errorf("pactl failed: %w", err)
+		}
+	}
+	return ParseDefaultSource(string(out))
+}
+
+// ResolvePulseSource picks the PulseAudio source to capture from. ALSA's
+// "default" often maps to the wrong source under PulseAudio/PipeWire, so when
+// device is "default" the configured source is used, or the server's actual
+// default when none is configured. An explicit ALSA device, pulse_source
+// "off", or a failed lookup leave capture on the ALSA device ("").
+func ResolvePulseSource(configured, device string, detect func() (string, error)) string {
+	if device != "default" {
+		return ""
+	}
+	configured = strings.TrimSpace(configured)
+	if strings.EqualFold(configured, PulseSourceOff) {
+		return ""
+	}
+	if configured != "" {
+		return configured
+	}
+	source, err := detect()
+	if err != nil {
+		return ""
+	}
+	return source
+}
+
+// SetPulseSource captures from a specific PulseAudio source through the ALSA
+// pulse plugin instead of the device set at Initialize, when ALSA has the
+// plugin. Empty restores the device.
+func (s *System) SetPulseSource(source string) {
+	s.pulseSource = source
+}
+
+// pulsePluginAvailable reports whether ALSA lists the pulse plugin device,
+// which alsa-plugins-pulseaudio provides; a variable so tests can fake it
+var pulsePluginAvailable = func() bool {
+	for _, device := range listDevices() {
+		if device == pulseDevice {
+			return true
+		}
+	}
+	return false
+}
+
+// captureDevice returns the ALSA device arecord opens. Without the pulse
+// plugin a PulseAudio source can't be reached through ALSA, so the device set
+// at Initialize is used instead.
+func (s *System) captureDevice() string {
+	if s.pulseSource == "" {
+		return s.device
+	}
+	s.pulseProbe.Do(func() {
+		s.pulsePlugin = pulsePluginAvailable()
+		if !s.pulsePlugin {
+			log.Printf("ALSA pulse plugin not found, capturing from %s rather than PulseAudio source %s", s.device, s.pulseSource)
+		}
+	})
+	if !s.pulsePlugin {
+		return s.device
+	}
+	return pulseDevice
+}
+
+// arecordEnv returns the environment for arecord, or nil to inherit ours
+func (s *System) arecordEnv() []string {
+	if s.captureDevice() != pulseDevice {
+		return nil
+	}
+	return append(os.Environ(), "PULSE_SOURCE="+s.pulseSource)
+}
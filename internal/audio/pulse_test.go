@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultSource(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected string
+		wantErr  bool
+	}{
+		{"get-default-source", "alsa_input.usb-Blue_Yeti-00.analog-stereo\n", "alsa_input.usb-Blue_Yeti-00.analog-stereo", false},
+		{"pipewire", "alsa_input.pci-0000_00_1f.3.analog-stereo", "alsa_input.pci-0000_00_1f.3.analog-stereo", false},
+		{"pactl info", "Server String: /run/user/1000/pulse/native\nServer Name: PulseAudio (on PipeWire 1.0.5)\nDefault Sink: alsa_output.pci.analog-stereo\nDefault Source: alsa_input.pci.analog-stereo\nCookie: 1a2b:3c4d\n", "alsa_input.pci.analog-stereo", false},
+		{"empty", "\n", "", true},
+		{"error text", "Connection failure: Connection refused", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDefaultSource(tc.output)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tc.wantErr, err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestResolvePulseSource(t *testing.T) {
+	detected := func() (string, error) { return "alsa_input.usb-mic", nil }
+	noPulse := func() (string, error) { return "", errors.New("pactl not found") }
+
+	testCases := []struct {
+		name       string
+		configured string
+		device     string
+		detect     func() (string, error)
+		expected   string
+	}{
+		{"auto-detect", "", "default", detected, "alsa_input.usb-mic"},
+		{"config override", "alsa_input.headset", "default", detected, "alsa_input.headset"},
+		{"disabled", "off", "default", detected, ""},
+		{"explicit alsa device", "", "hw:1,0", detected, ""},
+		{"no pulse server", "", "default", noPulse, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ResolvePulseSource(tc.configured, tc.device, tc.detect); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// fakePulsePlugin makes the ALSA pulse plugin look installed or not
+func fakePulsePlugin(t *testing.T, available bool) {
+	t.Helper()
+	old := pulsePluginAvailable
+	pulsePluginAvailable = func() bool { return available }
+	t.Cleanup(func() { pulsePluginAvailable = old })
+}
+
+func TestPulseSourceSelectsDevice(t *testing.T) {
+	fakePulsePlugin(t, true)
+	s := NewSystem(nil, BackendALSA)
+	if args := strings.Join(s.arecordArgs(), " "); !strings.Contains(args, "-D default") || s.arecordEnv() != nil {
+		t.Errorf("Expected plain default device without a source, got %q", args)
+	}
+
+	s.SetPulseSource("alsa_input.usb-mic")
+	if args := strings.Join(s.arecordArgs(), " "); !strings.Contains(args, "-D pulse") {
+		t.Errorf("Expected capture through the pulse plugin, got %q", args)
+	}
+	env := s.arecordEnv()
+	if len(env) == 0 || env[len(env)-1] != "PULSE_SOURCE=alsa_input.usb-mic" {
+		t.Errorf("Expected PULSE_SOURCE in arecord env, got %v", env[max(0, len(env)-1):])
+	}
+}
+
+func TestPulseSourceWithoutPlugin(t *testing.T) {
+	fakePulsePlugin(t, false)
+	s := NewSystem(nil, BackendALSA)
+	if err := s.Initialize("hw:CARD=Headset,DEV=0"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetPulseSource("alsa_input.usb-mic")
+	if args := strings.Join(s.arecordArgs(), " "); !strings.Contains(args, "-D hw:CARD=Headset,DEV=0") {
+		t.Errorf("Expected capture to fall back to the configured device, got %q", args)
+	}
+	if env := s.arecordEnv(); env != nil {
+		t.Errorf("Expected no PULSE_SOURCE without the plugin, got %v", env[max(0, len(env)-1):])
+	}
+}
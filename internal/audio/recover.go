@@ -0,0 +1,31 @@
+package audio
+
+import (
+	"log"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// Recorder is the part of System a caller needs to start a session
+type Recorder interface {
+	StartRecording() error
+	StopRecording() ([]byte, error)
+	IsRecording() bool
+}
+
+// StartFresh starts a recording. If r is already recording, a session the
+// caller lost track of, that session is discarded and a new one started, so
+// the caller's state can't diverge from the recorder's. restarted reports
+// whether a stale session was discarded.
+func StartFresh(r Recorder) (restarted bool, err error) {
+	err = r.StartRecording()
+	if !errors.Is(err, errors.ErrAlreadyRecording) {
+		return false, err
+	}
+
+	// The stale audio is of no use; a too short error is expected here
+	if _, err := r.StopRecording(); err != nil && !errors.Is(err, errors.ErrAudioTooShort) {
+		log.Printf("Stopping the stale recording failed: %v", err)
+	}
+	return true, r.StartRecording()
+}
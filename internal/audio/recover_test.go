@@ -0,0 +1,76 @@
+package audio
+
+import (
+	"testing"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// fakeRecorder mimics System's state transitions without spawning arecord
+type fakeRecorder struct {
+	recording bool
+	starts    int
+	stops     int
+	failStart error
+}
+
+func (f *fakeRecorder) StartRecording() error {
+	if f.recording {
+		return errors.Wrap(errors.ErrAlreadyRecording, errors.ErrorTypeAudio, "cannot start recording")
+	}
+	if f.failStart != nil {
+		return f.failStart
+	}
+	f.starts++
+	f.recording = true
+	return nil
+}
+
+func (f *fakeRecorder) StopRecording() ([]byte, error) {
+	f.stops++
+	f.recording = false
+	return []byte{0, 0}, nil
+}
+
+func (f *fakeRecorder) IsRecording() bool {
+	return f.recording
+}
+
+func TestStartFreshRecoversStuckRecording(t *testing.T) {
+	r := &fakeRecorder{recording: true}
+
+	restarted, err := StartFresh(r)
+	if err != nil {
+		t.Fatalf("Expected recovery to succeed, got %v", err)
+	}
+	if !restarted {
+		t.Error("Expected stale session to be reported as restarted")
+	}
+	if r.stops != 1 || r.starts != 1 || !r.IsRecording() {
+		t.Errorf("Expected one stop then one start, got stops=%d starts=%d recording=%v", r.stops, r.starts, r.IsRecording())
+	}
+}
+
+func TestStartFreshNormalStart(t *testing.T) {
+	r := &fakeRecorder{}
+
+	restarted, err := StartFresh(r)
+	if err != nil || restarted {
+		t.Fatalf("Expected plain start, got restarted=%v err=%v", restarted, err)
+	}
+	if r.stops != 0 || !r.IsRecording() {
+		t.Errorf("Expected no stop and recording, got stops=%d recording=%v", r.stops, r.IsRecording())
+	}
+}
+
+func TestStartFreshReportsOtherErrors(t *testing.T) {
+	r := &fakeRecorder{failStart: errors.ErrNoMicrophone}
+
+	restarted, err := StartFresh(r)
+	if err != errors.ErrNoMicrophone || restarted {
+		t.Fatalf("Expected ErrNoMicrophone without restart, got restarted=%v err=%v", restarted, err)
+	}
+	if r.IsRecording() {
+		t.Error("Expected recorder to stay stopped")
+	}
+}
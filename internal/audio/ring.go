@@ -0,0 +1,55 @@
+package audio
+
+// ring is a fixed-size byte ring buffer holding the most recent audio.
+// It is not safe for concurrent use; System guards it with its mutex.
+type ring struct {
+	buf  []byte
+	pos  int
+	full bool
+}
+
+func newRing(size int) *ring {
+	return &ring{buf: make([]byte, size)}
+}
+
+// Write appends p, overwriting the oldest bytes once the ring is full
+func (r *ring) Write(p []byte) {
+	if len(r.buf) == 0 {
+		return
+	}
+	// Only the tail of a write larger than the ring can survive
+	if len(p) >= len(r.buf) {
+		copy(r.buf, p[len(p)-len(r.buf):])
+		r.pos = 0
+		r.full = true
+		return
+	}
+
+	n := copy(r.buf[r.pos:], p)
+	if n < len(p) {
+		copy(r.buf, p[n:])
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % len(r.buf)
+	if r.pos == 0 {
+		r.full = true
+	}
+}
+
+// Snapshot returns the buffered bytes, oldest first
+func (r *ring) Snapshot() []byte {
+	if !r.full {
+		out := make([]byte, r.pos)
+		copy(out, r.buf[:r.pos])
+		return out
+	}
+	out := make([]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.pos:]...)
+	return append(out, r.buf[:r.pos]...)
+}
+
+// Reset empties the ring
+func (r *ring) Reset() {
+	r.pos = 0
+	r.full = false
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRingWrap(t *testing.T) {
+	r := newRing(4)
+
+	r.Write([]byte{1, 2})
+	if got := r.Snapshot(); !bytes.Equal(got, []byte{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", got)
+	}
+
+	r.Write([]byte{3, 4, 5})
+	if got := r.Snapshot(); !bytes.Equal(got, []byte{2, 3, 4, 5}) {
+		t.Errorf("Expected [2 3 4 5], got %v", got)
+	}
+
+	r.Write([]byte{6, 7, 8, 9, 10})
+	if got := r.Snapshot(); !bytes.Equal(got, []byte{7, 8, 9, 10}) {
+		t.Errorf("Expected [7 8 9 10], got %v", got)
+	}
+
+	r.Reset()
+	if got := r.Snapshot(); len(got) != 0 {
+		t.Errorf("Expected empty snapshot after reset, got %v", got)
+	}
+}
+
+func TestPrerollPrependedToRecording(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.SetPreroll(1) // 1ms at 16kHz mono S16 = 32 bytes
+
+	if len(s.preroll.buf) != 32 {
+		t.Fatalf("Expected 32-byte pre-roll, got %d", len(s.preroll.buf))
+	}
+
+	// Audio streamed before the hotkey: only the most recent 32 bytes survive
+	before := bytes.Repeat([]byte{0xAA}, 40)
+	copy(before[8:], bytes.Repeat([]byte{0xBB}, 32))
+	s.deliver(before)
+
+	s.beginSession()
+	s.deliver([]byte{0x01, 0x02})
+
+	expected := append(bytes.Repeat([]byte{0xBB}, 32), 0x01, 0x02)
+	if !bytes.Equal(s.audioBuffer, expected) {
+		t.Errorf("Expected pre-roll followed by recording, got %x", s.audioBuffer)
+	}
+
+	// The pre-roll is consumed by the session and doesn't leak into the next one
+	if got := s.preroll.Snapshot(); len(got) != 0 {
+		t.Errorf("Expected pre-roll to be empty after session start, got %d bytes", len(got))
+	}
+}
+
+func TestNoPrerollByDefault(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.deliver([]byte{0x01, 0x02})
+
+	if s.preroll != nil || len(s.audioBuffer) != 0 {
+		t.Error("Expected audio outside a recording to be dropped without pre-roll")
+	}
+}
+
+func TestLookbackKeepsLastWindow(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.SetLookback(time.Millisecond) // 16kHz mono S16 = 32 bytes
+
+	if got := s.Lookback(); len(got) != 0 {
+		t.Fatalf("Expected an empty look-back before any audio, got %d bytes", len(got))
+	}
+
+	// Audio between recordings and during one both land in the window
+	s.deliver(bytes.Repeat([]byte{0xAA}, 20))
+	s.beginSession()
+	s.deliver(bytes.Repeat([]byte{0xBB}, 20))
+
+	expected := append(bytes.Repeat([]byte{0xAA}, 12), bytes.Repeat([]byte{0xBB}, 20)...)
+	if got := s.Lookback(); !bytes.Equal(got, expected) {
+		t.Errorf("Expected the most recent 32 bytes, got %x", got)
+	}
+	if !bytes.Equal(s.audioBuffer, bytes.Repeat([]byte{0xBB}, 20)) {
+		t.Errorf("Expected the recording to hold only its own audio, got %x", s.audioBuffer)
+	}
+
+	// A snapshot is a copy: later audio doesn't change it
+	snapshot := s.Lookback()
+	s.deliver(bytes.Repeat([]byte{0xCC}, 32))
+	if !bytes.Equal(snapshot, expected) {
+		t.Errorf("Expected the snapshot to be unaffected by later audio, got %x", snapshot)
+	}
+	if got := s.Lookback(); !bytes.Equal(got, bytes.Repeat([]byte{0xCC}, 32)) {
+		t.Errorf("Expected the window to roll over, got %x", got)
+	}
+}
+
+func TestLookbackDisabled(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.SetLookback(0)
+	s.deliver([]byte{0x01, 0x02})
+	if got := s.Lookback(); got != nil {
+		t.Errorf("Expected no look-back when disabled, got %v", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"speek_to_text_linux/pkg/errors"
@@ -21,20 +22,65 @@ type System struct {
 	channels      int
 	bitsPerSample int
 	device        string
-	isRecording   bool
-	audioBuffer   []byte
-	cmd           *exec.Cmd
-	stdout        io.ReadCloser
+	captureFormat string
+	periodSize    int    // arecord --period-size in frames, 0 for the ALSA default
+	bufferSize    int    // arecord --buffer-size in frames, 0 for the ALSA default
+	pulseSource   string // PulseAudio source captured via the pulse plugin, "" to use device
+	pulseProbe    sync.Once
+	pulsePlugin   bool // whether ALSA has the pulse plugin, probed on first use of pulseSource
+
+	// mu guards the capture state shared with the readAudio goroutine
+	mu          sync.Mutex
+	isRecording bool
+	audioBuffer []byte
+	cmd         *exec.Cmd
+	stdout      io.ReadCloser
+	live        *liveStream // reader of the recording in progress, see Live
+
+	// Pre-roll: when enabled, arecord keeps streaming between recordings into
+	// preroll, and its contents are prepended when a recording starts
+	preroll   *ring
+	streaming bool
+
+	// Recordings shorter than minDuration, not counting the prerollBytes of
+	// pre-roll they started with, are rejected as too short
+	minDuration  time.Duration
+	prerollBytes int
+
+	// Look-back: the last few seconds of audio, kept whether or not a
+	// recording is running, for transcribing what was just said on demand
+	lookback *ring
+
+	// Tee: a copy of the live capture for another program, see SetTee
+	tee       *tee
+	teeWriter io.WriteCloser
+
+	meter levelMeter
+
+	// Auto-stop: when enabled, onAutoStop runs after a stretch of silence
+	silence    *silenceDetector
+	onAutoStop func()
+
+	backendPreference string // BackendAuto, BackendALSA, BackendPulse or BackendPipeWire
+	backend           string // resolved backend, set on first use
 }
 
-// NewSystem creates a new audio system
-func NewSystem(errHandler *errors.Handler) *System {
+// NewSystem creates a new audio system capturing with backend (one of the
+// Backend constants). An unknown backend is logged and treated as BackendAuto.
+func NewSystem(errHandler *errors.Handler, backend string) *System {
+	preference, err := ParseBackend(backend)
+	if err != nil {
+		log.Printf("%v, using auto", err)
+		preference = BackendAuto
+	}
 	return &System{
-		errHandler:    errHandler,
-		sampleRate:    16000,
-		channels:      1,
-		bitsPerSample: 16,
-		device:        "default",
+		errHandler:        errHandler,
+		sampleRate:        16000,
+		channels:          1,
+		bitsPerSample:     16,
+		device:            "default",
+		captureFormat:     FormatS16LE,
+		backendPreference: preference,
 	}
 }
 
@@ -42,56 +88,276 @@ func NewSystem(errHandler *errors.Handler) *System {
 func (s *System) Initialize(device string) error {
 	if device != "" {
 		s.device = device
+		// The backend depends on the device
+		s.backend = ""
 	}
 	log.Printf("Audio system initialized with device: %s", s.device)
 	return nil
 }
 
+// SetCaptureFormat sets the sample format requested from the device.
+// Non-S16 formats are converted to S16_LE as they are read.
+func (s *System) SetCaptureFormat(format string) error {
+	f, err := NormalizeFormat(format)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrorTypeAudio, "invalid capture format")
+	}
+	s.captureFormat = f
+	return nil
+}
+
+// CaptureFormat returns the sample format requested from the device
+func (s *System) CaptureFormat() string {
+	return s.captureFormat
+}
+
+// SupportedSampleRates are the capture rates Configure accepts
+var SupportedSampleRates = []int{8000, 11025, 16000, 22050, 24000, 32000, 44100, 48000}
+
+// Configure sets the capture sample rate, channel count and bits per sample,
+// e.g. 44100 Hz for a USB mic that distorts when forced to 16000. Audio is
+// always delivered as 16-bit samples (deeper devices are handled by
+// SetCaptureFormat), so bitsPerSample must be 16. An unsupported combination
+// returns an error and leaves the settings unchanged. Must be called before
+// SetPreroll and before recording starts.
+func (s *System) Configure(sampleRate, channels, bitsPerSample int) error {
+	supported := false
+	for _, rate := range SupportedSampleRates {
+		if rate == sampleRate {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("unsupported sample rate %d Hz (use one of %v)", sampleRate, SupportedSampleRates), nil)
+	}
+	if channels != 1 && channels != 2 {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("unsupported channel count %d (use 1 or 2)", channels), nil)
+	}
+	if bitsPerSample != 16 {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("unsupported %d bits per sample (only 16; use capture_format for deeper devices)", bitsPerSample), nil)
+	}
+	s.sampleRate = sampleRate
+	s.channels = channels
+	s.bitsPerSample = bitsPerSample
+	return nil
+}
+
+// SetChannels sets how many channels are captured: 1 for mono, or 2 to keep
+// a stereo interface's left and right inputs apart (see SplitChannels).
+// Must be called before SetPreroll and before recording starts.
+func (s *System) SetChannels(channels int) error {
+	if channels != 1 && channels != 2 {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("unsupported channel count %d (use 1 or 2)", channels), nil)
+	}
+	s.channels = channels
+	return nil
+}
+
+// SetBufferSizes sets arecord's period and buffer sizes in frames for latency
+// tuning. Smaller values lower capture latency but make overruns (xruns) more
+// likely on a busy system. Zero keeps the ALSA default.
+func (s *System) SetBufferSizes(periodSize, bufferSize int) error {
+	if periodSize < 0 || bufferSize < 0 {
+		return errors.NewError(errors.ErrorTypeAudio, "period and buffer sizes must not be negative", nil)
+	}
+	if periodSize > 0 && bufferSize > 0 && bufferSize < 2*periodSize {
+		return errors.NewError(errors.ErrorTypeAudio, fmt.Sprintf("buffer size %d must be at least twice the period size %d", bufferSize, periodSize), nil)
+	}
+	s.periodSize = periodSize
+	s.bufferSize = bufferSize
+	return nil
+}
+
+// SetPreroll enables a look-back buffer of ms milliseconds that is prepended
+// to each recording, so speech that starts just before the hotkey isn't clipped.
+// Zero disables it. Must be called before recording starts.
+func (s *System) SetPreroll(ms int) {
+	if ms <= 0 {
+		s.preroll = nil
+		return
+	}
+	frameSize := s.channels * s.bitsPerSample / 8
+	size := s.sampleRate * ms / 1000 * frameSize
+	s.preroll = newRing(size)
+}
+
+// SetMinDuration makes StopRecording return errors.ErrAudioTooShort for
+// recordings shorter than d, not counting pre-roll, e.g. a tap on the hotkey.
+// Zero only rejects empty recordings.
+func (s *System) SetMinDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minDuration = d
+}
+
+// SetLookback keeps the last d of audio in a rolling buffer that Lookback
+// returns, streaming continuously once StartPreroll is called. Zero disables
+// it. Must be called before recording starts.
+func (s *System) SetLookback(d time.Duration) {
+	if d <= 0 {
+		s.lookback = nil
+		return
+	}
+	frameSize := s.channels * s.bitsPerSample / 8
+	frames := int(time.Duration(s.sampleRate) * d / time.Second)
+	s.lookback = newRing(frames * frameSize)
+}
+
+// Lookback returns a copy of the rolling look-back buffer, oldest first, or
+// nil when it is disabled
+func (s *System) Lookback() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lookback == nil {
+		return nil
+	}
+	return s.lookback.Snapshot()
+}
+
+// StartPreroll begins streaming into the pre-roll and look-back buffers
+// ahead of the first recording
+func (s *System) StartPreroll() error {
+	if (s.preroll == nil && s.lookback == nil) || s.isStreaming() {
+		return nil
+	}
+	if err := s.startCapture(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.streaming = true
+	s.mu.Unlock()
+	go s.readAudio()
+	log.Printf("Continuous capture started for pre-roll and look-back")
+	return nil
+}
+
 // StartRecording starts audio recording from microphone
 func (s *System) StartRecording() error {
-	if s.isRecording {
-		return errors.NewError(errors.ErrorTypeAudio, "already recording", nil)
+	if s.IsRecording() {
+		return errors.Wrap(errors.ErrAlreadyRecording, errors.ErrorTypeAudio, "cannot start recording")
 	}
 
+	if s.preroll != nil || s.lookback != nil {
+		if err := s.StartPreroll(); err != nil {
+			return err
+		}
+		s.beginSession()
+		log.Printf("Started recording audio at %d Hz (%s) with %d bytes of pre-roll", s.sampleRate, s.captureFormat, len(s.audioBuffer))
+		return nil
+	}
+
+	if err := s.startCapture(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
 	s.audioBuffer = make([]byte, 0)
+	s.prerollBytes = 0
+	if s.silence != nil {
+		s.silence.Reset()
+	}
+	s.isRecording = true
+	s.mu.Unlock()
+	s.meter.Reset()
 
-	// Use arecord to capture real audio from microphone
+	// Read audio data in background
+	go s.readAudio()
+
+	log.Printf("Started recording audio at %d Hz (%s)", s.sampleRate, s.captureFormat)
+	return nil
+}
+
+// beginSession starts a recording on a running stream, seeding it with the pre-roll
+func (s *System) beginSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.preroll != nil {
+		s.audioBuffer = s.preroll.Snapshot()
+		s.preroll.Reset()
+	} else {
+		s.audioBuffer = make([]byte, 0)
+	}
+	s.prerollBytes = len(s.audioBuffer)
+	s.meter.Reset()
+	if s.silence != nil {
+		s.silence.Reset()
+	}
+	s.isRecording = true
+}
+
+// arecordArgs builds the arecord command line for the current settings
+func (s *System) arecordArgs() []string {
 	args := []string{
-		"-D", s.device,
-		"-f", "S16_LE",
+		"-D", s.captureDevice(),
+		"-f", s.captureFormat,
 		"-r", fmt.Sprintf("%d", s.sampleRate),
 		"-c", fmt.Sprintf("%d", s.channels),
 		"-t", "raw",
 	}
+	if s.periodSize > 0 {
+		args = append(args, fmt.Sprintf("--period-size=%d", s.periodSize))
+	}
+	if s.bufferSize > 0 {
+		args = append(args, fmt.Sprintf("--buffer-size=%d", s.bufferSize))
+	}
+	return args
+}
+
+// startCapture spawns the backend's capture tool writing raw audio to s.stdout
+func (s *System) startCapture() error {
+	if _, err := bytesPerSampleFor(s.streamFormat()); err != nil {
+		return err
+	}
 
-	s.cmd = exec.Command("arecord", args...)
+	tool, args := s.captureCommand()
+	cmd := exec.Command(tool, args...)
+	if tool == "arecord" {
+		cmd.Env = s.arecordEnv()
+	}
 
-	var err error
-	s.stdout, err = s.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	if err := s.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start arecord: %w", err)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", tool, err)
 	}
 
-	s.isRecording = true
-
-	// Read audio data in background
-	go s.readAudio()
-
-	log.Printf("Started recording audio at %d Hz", s.sampleRate)
+	s.mu.Lock()
+	s.cmd = cmd
+	s.stdout = stdout
+	s.mu.Unlock()
 	return nil
 }
 
-// readAudio reads audio data from arecord
+// readAudio reads audio data from the capture tool, converting it to S16_LE
 func (s *System) readAudio() {
 	buffer := make([]byte, 4096)
-	for s.isRecording {
-		n, err := s.stdout.Read(buffer)
+	format := s.streamFormat()
+	sampleBytes, err := bytesPerSampleFor(format)
+	if err != nil {
+		// startCapture checks the format first, so only a change since then lands here
+		log.Printf("Stopping capture: %v", err)
+		s.stopCapture()
+		return
+	}
+	frameSize := sampleBytes * s.channels
+	var pending []byte
+	s.mu.Lock()
+	stdout := s.stdout
+	s.mu.Unlock()
+	for s.capturing() {
+		n, err := stdout.Read(buffer)
 		if n > 0 {
-			s.audioBuffer = append(s.audioBuffer, buffer[:n]...)
+			// Reads can split a frame; carry the partial frame over to the next read
+			pending = append(pending, buffer[:n]...)
+			whole := len(pending) - len(pending)%frameSize
+			chunk := toS16(format, pending[:whole])
+			s.teeChunk(chunk)
+			s.deliver(chunk)
+			pending = append(pending[:0], pending[whole:]...)
 		}
 		if err != nil {
 			break
@@ -99,85 +365,150 @@ func (s *System) readAudio() {
 	}
 }
 
+// capturing reports whether readAudio should keep reading
+func (s *System) capturing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isRecording || s.streaming
+}
+
+// isStreaming reports whether the pre-roll stream is running
+func (s *System) isStreaming() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streaming
+}
+
+// deliver routes converted audio to the recording, or to the pre-roll between
+// recordings, and always to the look-back buffer
+func (s *System) deliver(chunk []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lookback != nil {
+		s.lookback.Write(chunk)
+	}
+	if s.isRecording {
+		s.audioBuffer = append(s.audioBuffer, chunk...)
+		if s.live != nil {
+			s.live.Write(chunk)
+		}
+		s.checkSilence(chunk)
+	} else if s.preroll != nil {
+		s.preroll.Write(chunk)
+	}
+}
+
 // StopRecording stops recording and returns audio data
 func (s *System) StopRecording() ([]byte, error) {
+	s.mu.Lock()
 	if !s.isRecording {
+		s.mu.Unlock()
 		return nil, errors.NewError(errors.ErrorTypeAudio, "not recording", nil)
 	}
-
+	// Once isRecording is false deliver no longer appends, so the buffer is ours
 	s.isRecording = false
+	s.closeLive()
+	result := s.audioBuffer
+	s.audioBuffer = nil
+	spoken := len(result) - s.prerollBytes
+	streaming := s.streaming
+	s.mu.Unlock()
 
-	// Stop arecord
-	if s.cmd != nil && s.cmd.Process != nil {
-		s.cmd.Process.Kill()
-		s.cmd.Wait()
-	}
-
-	if s.stdout != nil {
-		s.stdout.Close()
+	// Keep arecord running between recordings while pre-roll is streaming
+	if !streaming {
+		s.stopCapture()
 	}
 
-	if len(s.audioBuffer) == 0 {
+	if len(result) == 0 || s.durationOf(spoken) < s.minDuration {
 		return nil, errors.ErrAudioTooShort
 	}
 
-	log.Printf("Stopped recording, captured %d bytes of audio", len(s.audioBuffer))
+	log.Printf("Stopped recording, captured %d bytes of audio", len(result))
+	return result, nil
+}
 
-	result := make([]byte, len(s.audioBuffer))
-	copy(result, s.audioBuffer)
-	s.audioBuffer = nil
+// stopCapture kills arecord and closes its output
+func (s *System) stopCapture() {
+	s.mu.Lock()
+	cmd, stdout := s.cmd, s.stdout
+	s.cmd, s.stdout = nil, nil
+	s.mu.Unlock()
 
-	return result, nil
+	if cmd != nil && cmd.Process != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	if stdout != nil {
+		stdout.Close()
+	}
 }
 
 // Close closes the audio system
 func (s *System) Close() error {
-	if s.isRecording {
+	if s.IsRecording() {
 		s.StopRecording()
 	}
+	s.mu.Lock()
+	streaming := s.streaming
+	s.streaming = false
+	s.mu.Unlock()
+	if streaming {
+		s.stopCapture()
+	}
+	s.SetTee(nil)
 	log.Println("Audio system closed")
 	return nil
 }
 
-// GetAudioBuffer returns the current audio buffer
+// GetAudioBuffer returns a copy of the current audio buffer
 func (s *System) GetAudioBuffer() []byte {
-	return s.audioBuffer
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.audioBuffer...)
 }
 
 // IsRecording returns whether the system is currently recording
 func (s *System) IsRecording() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.isRecording
 }
 
-// GetLevel returns the current audio level (0.0 to 1.0)
+// levelWindow is how much of the most recent audio GetLevel measures
+const levelWindow = 50 * time.Millisecond
+
+// GetLevel returns the current audio level (0.0 to 1.0) from the RMS of the
+// last levelWindow of audio, or 0 when not recording. It is safe to call from
+// the animation goroutine while readAudio appends.
 func (s *System) GetLevel() float64 {
-	if !s.isRecording || len(s.audioBuffer) < 400 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isRecording {
 		return 0
 	}
 
-	// Read the last 400 bytes (~12.5ms at 16kHz)
-	buf := s.audioBuffer[len(s.audioBuffer)-400:]
-	var sum float64
-	count := 0
-	for i := 0; i < len(buf)-1; i += 2 {
-		// Convert to int16 (Little Endian)
-		sample := int16(buf[i]) | (int16(buf[i+1]) << 8)
-		f := float64(sample) / 32768.0
-		sum += f * f
-		count++
+	frameSize := s.bitsPerSample / 8 * s.channels
+	window := int(time.Duration(s.sampleRate)*levelWindow/time.Second) * frameSize
+	buf := s.audioBuffer
+	if len(buf) > window {
+		buf = buf[len(buf)-window:]
 	}
 
-	if count == 0 {
-		return 0
-	}
-
-	rms := math.Sqrt(sum / float64(count))
 	// Amplify and clamp
-	level := rms * 5.0
-	if level > 1.0 {
-		level = 1.0
-	}
-	return level
+	return math.Min(chunkRMS(buf)*5.0, 1.0)
+}
+
+// Device returns the capture device name
+func (s *System) Device() string {
+	return s.device
+}
+
+// SmoothedLevel returns the current level with exponential smoothing applied.
+// The smoothing state is reset whenever a recording starts, so a new session
+// never shows the previous session's level.
+func (s *System) SmoothedLevel() float64 {
+	return s.meter.Update(s.GetLevel())
 }
 
 // SampleRate returns the sample rate
@@ -197,18 +528,38 @@ func (s *System) BitsPerSample() int {
 
 // Duration returns the duration of recorded audio
 func (s *System) Duration() time.Duration {
-	if len(s.audioBuffer) == 0 {
+	s.mu.Lock()
+	size := len(s.audioBuffer)
+	s.mu.Unlock()
+	return s.durationOf(size)
+}
+
+// DurationOf returns how long data plays for in the recording format, e.g.
+// for audio StopRecording returned
+func (s *System) DurationOf(data []byte) time.Duration {
+	return s.durationOf(len(data))
+}
+
+// durationOf returns how long size bytes of audio play for
+func (s *System) durationOf(size int) time.Duration {
+	if size == 0 {
 		return 0
 	}
 
 	bytesPerSample := s.bitsPerSample / 8
-	samples := len(s.audioBuffer) / (bytesPerSample * s.channels)
+	samples := size / (bytesPerSample * s.channels)
 	return time.Duration(samples) * time.Second / time.Duration(s.sampleRate)
 }
 
 // SaveToFile saves audio buffer to a WAV file (for testing)
 func (s *System) SaveToFile(filename string) error {
-	if len(s.audioBuffer) == 0 {
+	return s.SaveDataToFile(filename, s.GetAudioBuffer())
+}
+
+// SaveDataToFile saves audioData, e.g. a recording StopRecording returned,
+// to a WAV file in the capture format
+func (s *System) SaveDataToFile(filename string, audioData []byte) error {
+	if len(audioData) == 0 {
 		return fmt.Errorf("no audio data to save")
 	}
 
@@ -220,7 +571,7 @@ func (s *System) SaveToFile(filename string) error {
 	defer file.Close()
 
 	// Write WAV header
-	dataSize := len(s.audioBuffer)
+	dataSize := len(audioData)
 	fileSize := 36 + dataSize
 
 	// RIFF header
@@ -241,14 +592,21 @@ func (s *System) SaveToFile(filename string) error {
 	// data chunk
 	file.Write([]byte("data"))
 	writeInt32(file, dataSize)
-	file.Write(s.audioBuffer)
+	if _, err := file.Write(audioData); err != nil {
+		return err
+	}
 
 	log.Printf("Saved audio to %s (%d bytes)", filename, fileSize)
-	return nil
+	return file.Close()
 }
 
 // GetDevices returns a list of available ALSA recording devices
 func (s *System) GetDevices() []string {
+	return listDevices()
+}
+
+// listDevices lists the ALSA capture devices arecord knows about
+func listDevices() []string {
 	var devices []string
 	cmd := exec.Command("arecord", "-L")
 	output, err := cmd.Output()
@@ -0,0 +1,201 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+func TestArecordArgsBufferSizes(t *testing.T) {
+	testCases := []struct {
+		period, buffer int
+		expected       []string
+		unexpected     []string
+	}{
+		{0, 0, nil, []string{"--period-size", "--buffer-size"}},
+		{256, 1024, []string{"--period-size=256", "--buffer-size=1024"}, nil},
+		{512, 0, []string{"--period-size=512"}, []string{"--buffer-size"}},
+	}
+
+	for _, tc := range testCases {
+		s := NewSystem(nil, BackendALSA)
+		if err := s.SetBufferSizes(tc.period, tc.buffer); err != nil {
+			t.Fatalf("SetBufferSizes(%d, %d) failed: %v", tc.period, tc.buffer, err)
+		}
+		args := strings.Join(s.arecordArgs(), " ")
+		for _, want := range tc.expected {
+			if !strings.Contains(args, want) {
+				t.Errorf("period=%d buffer=%d: expected %q in %q", tc.period, tc.buffer, want, args)
+			}
+		}
+		for _, bad := range tc.unexpected {
+			if strings.Contains(args, bad) {
+				t.Errorf("period=%d buffer=%d: unexpected %q in %q", tc.period, tc.buffer, bad, args)
+			}
+		}
+	}
+}
+
+func TestSetBufferSizesValidation(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	if err := s.SetBufferSizes(-1, 0); err == nil {
+		t.Error("Expected error for negative period size")
+	}
+	if err := s.SetBufferSizes(512, 512); err == nil {
+		t.Error("Expected error for buffer smaller than two periods")
+	}
+	if s.periodSize != 0 || s.bufferSize != 0 {
+		t.Error("Expected invalid sizes to leave the defaults in place")
+	}
+}
+
+func TestStartStopWithFakeReader(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.backend = BackendALSA
+
+	pr, pw := io.Pipe()
+	go func() {
+		chunk := make([]byte, 640)
+		for {
+			if _, err := pw.Write(chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	s.mu.Lock()
+	s.stdout = pr
+	s.audioBuffer = make([]byte, 0)
+	s.isRecording = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.readAudio()
+		close(done)
+	}()
+
+	// Poll like the GUI's animation goroutine while audio arrives
+	for s.Duration() < 50*time.Millisecond {
+		s.GetLevel()
+		s.IsRecording()
+	}
+
+	audioData, err := s.StopRecording()
+	if err != nil {
+		t.Fatalf("StopRecording failed: %v", err)
+	}
+	if len(audioData) == 0 {
+		t.Error("Expected captured audio")
+	}
+	if s.IsRecording() {
+		t.Error("Expected recording to have stopped")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected readAudio to return after StopRecording")
+	}
+	if _, err := s.StopRecording(); err == nil {
+		t.Error("Expected a second StopRecording to fail")
+	}
+}
+
+func TestStopRecordingTooShort(t *testing.T) {
+	s := NewSystem(nil, BackendALSA)
+	s.SetMinDuration(250 * time.Millisecond)
+	// 16 kHz mono 16-bit: 32 bytes per millisecond
+	const perMs = 32
+
+	testCases := []struct {
+		name     string
+		recorded int
+		preroll  int
+		tooShort bool
+	}{
+		{"empty", 0, 0, true},
+		{"tap", 100 * perMs, 0, true},
+		{"long enough", 300 * perMs, 0, false},
+		{"tap padded by pre-roll", 400 * perMs, 300 * perMs, true},
+		{"speech after pre-roll", 600 * perMs, 300 * perMs, false},
+	}
+
+	for _, tc := range testCases {
+		s.mu.Lock()
+		s.audioBuffer = make([]byte, tc.recorded)
+		s.prerollBytes = tc.preroll
+		s.isRecording = true
+		s.mu.Unlock()
+
+		_, err := s.StopRecording()
+		if tooShort := errors.Is(err, errors.ErrAudioTooShort); tooShort != tc.tooShort {
+			t.Errorf("%s: expected too short %v, got %v", tc.name, tc.tooShort, err)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	testCases := []struct {
+		rate, channels, bits int
+		valid                bool
+	}{
+		{16000, 1, 16, true},
+		{44100, 1, 16, true},
+		{48000, 2, 16, true},
+		{44000, 1, 16, false},
+		{0, 1, 16, false},
+		{44100, 3, 16, false},
+		{44100, 0, 16, false},
+		{44100, 1, 24, false},
+	}
+
+	for _, tc := range testCases {
+		s := NewSystem(nil, BackendALSA)
+		err := s.Configure(tc.rate, tc.channels, tc.bits)
+		if tc.valid != (err == nil) {
+			t.Errorf("Configure(%d, %d, %d): valid=%v, got error %v", tc.rate, tc.channels, tc.bits, tc.valid, err)
+			continue
+		}
+		if !tc.valid {
+			// A rejected combination keeps the defaults
+			if s.SampleRate() != 16000 || s.Channels() != 1 || s.BitsPerSample() != 16 {
+				t.Errorf("Configure(%d, %d, %d) changed settings despite failing", tc.rate, tc.channels, tc.bits)
+			}
+			continue
+		}
+
+		args := strings.Join(s.arecordArgs(), " ")
+		want := fmt.Sprintf("-r %d -c %d", tc.rate, tc.channels)
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected arecord args to contain %q, got %q", want, args)
+		}
+	}
+}
+
+func TestDurationOf(t *testing.T) {
+	testCases := []struct {
+		rate, channels int
+		bytes          int
+		expected       time.Duration
+	}{
+		{16000, 1, 32000, time.Second},
+		{16000, 2, 32000, 500 * time.Millisecond},
+		{48000, 1, 9600, 100 * time.Millisecond},
+		{16000, 1, 0, 0},
+	}
+
+	for _, tc := range testCases {
+		s := NewSystem(nil, BackendALSA)
+		if err := s.Configure(tc.rate, tc.channels, 16); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.DurationOf(make([]byte, tc.bytes)); got != tc.expected {
+			t.Errorf("DurationOf(%d bytes at %d Hz, %d ch) = %v, expected %v", tc.bytes, tc.rate, tc.channels, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// teeDepth is how many chunks may wait for a slow tee reader before new
+// ones are dropped; at 4 KiB reads that is about eight seconds of 16 kHz mono
+const teeDepth = 64
+
+// tee copies captured audio to a writer, such as a FIFO read by another
+// program, without ever holding up the capture: chunks queue for a
+// background writer and are dropped once the queue is full
+type tee struct {
+	w       io.Writer
+	queue   chan []byte
+	done    chan struct{}
+	mu      sync.Mutex // guards closed against a Write racing Close
+	closed  bool
+	dropped atomic.Int64
+}
+
+func newTee(w io.Writer, depth int) *tee {
+	t := &tee{
+		w:     w,
+		queue: make(chan []byte, depth),
+		done:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// run writes queued chunks until Close
+func (t *tee) run() {
+	defer close(t.done)
+	for chunk := range t.queue {
+		if _, err := t.w.Write(chunk); err != nil {
+			t.dropped.Add(1)
+		}
+	}
+}
+
+// Write queues a copy of p, dropping it if the reader is too far behind.
+// Writes after Close are ignored.
+func (t *tee) Write(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.queue <- append([]byte(nil), p...):
+	default:
+		if t.dropped.Add(1) == 1 {
+			log.Println("Audio tee reader is too slow, dropping chunks")
+		}
+	}
+}
+
+// Dropped returns how many chunks never reached the writer
+func (t *tee) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// Close stops the writer once the queue is drained. A writer blocked on a
+// reader that went away is not waited for.
+func (t *tee) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+}
+
+// teeChunk passes a converted chunk to the tee, if one is set
+func (s *System) teeChunk(chunk []byte) {
+	s.mu.Lock()
+	t := s.tee
+	s.mu.Unlock()
+	if t != nil {
+		t.Write(chunk)
+	}
+}
+
+// SetTee copies every chunk of captured audio, as 16-bit little-endian PCM
+// at the configured rate and channels, to w in real time, e.g. a FIFO from
+// OpenFIFO. Chunks a slow reader can't keep up with are dropped rather than
+// delaying the recording. Nil stops teeing. Close closes w.
+func (s *System) SetTee(w io.WriteCloser) {
+	s.mu.Lock()
+	old, oldW := s.tee, s.teeWriter
+	s.tee, s.teeWriter = nil, w
+	if w != nil {
+		s.tee = newTee(w, teeDepth)
+	}
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+		oldW.Close()
+	}
+}
@@ -0,0 +1,113 @@
+package audio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter holds every Write until release is closed
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     [][]byte
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.got = append(w.got, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestTeeDropsInsteadOfBlocking(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	tee := newTee(w, 2)
+
+	// The writer takes the first chunk and blocks; two more fill the queue
+	// and the rest are dropped, all without Write waiting
+	done := make(chan struct{})
+	go func() {
+		for i := byte(0); i < 10; i++ {
+			tee.Write([]byte{i})
+			// Let the writer pick up the first chunk
+			for i == 0 && len(tee.queue) > 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Write not to block on a stuck reader")
+	}
+	if tee.Dropped() != 7 {
+		t.Errorf("Expected 7 dropped chunks, got %d", tee.Dropped())
+	}
+
+	close(w.release)
+	tee.Close()
+	<-tee.done
+	expected := [][]byte{{0}, {1}, {2}}
+	if len(w.got) != len(expected) {
+		t.Fatalf("Expected %d chunks written, got %v", len(expected), w.got)
+	}
+	for i := range expected {
+		if !bytes.Equal(w.got[i], expected[i]) {
+			t.Errorf("Chunk %d: expected %v, got %v", i, expected[i], w.got[i])
+		}
+	}
+
+	// Writes after Close are ignored rather than panicking
+	tee.Write([]byte{42})
+}
+
+func TestTeeCopiesChunks(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	close(w.release)
+	tee := newTee(w, 4)
+
+	chunk := []byte{1, 2, 3}
+	tee.Write(chunk)
+	chunk[0] = 9 // the caller may reuse its buffer
+	tee.Close()
+	<-tee.done
+
+	if len(w.got) != 1 || !bytes.Equal(w.got[0], []byte{1, 2, 3}) {
+		t.Errorf("Expected the chunk as written, got %v", w.got)
+	}
+}
+
+func TestOpenFIFO(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vt.pcm")
+
+	f, err := OpenFIFO(path)
+	if err != nil {
+		t.Fatalf("OpenFIFO failed: %v", err)
+	}
+	f.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("Expected a FIFO at %s, got %v, %v", path, info, err)
+	}
+
+	// An existing FIFO is reused
+	f, err = OpenFIFO(path)
+	if err != nil {
+		t.Fatalf("Reopening the FIFO failed: %v", err)
+	}
+	f.Close()
+
+	regular := filepath.Join(dir, "file")
+	if err := os.WriteFile(regular, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFIFO(regular); err == nil {
+		t.Error("Expected an error for a regular file")
+	}
+}
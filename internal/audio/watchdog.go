@@ -0,0 +1,53 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+// Watchdog invokes a stop callback once a recording has run for a fixed duration
+type Watchdog struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	expired bool
+}
+
+// StartWatchdog calls onExpire after d unless Stop is called first
+func StartWatchdog(d time.Duration, onExpire func()) *Watchdog {
+	w := &Watchdog{}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = time.AfterFunc(d, func() {
+		w.mu.Lock()
+		if w.timer == nil {
+			w.mu.Unlock()
+			return
+		}
+		w.expired = true
+		w.mu.Unlock()
+		onExpire()
+	})
+	return w
+}
+
+// Stop cancels the watchdog. It returns false if the callback already ran.
+func (w *Watchdog) Stop() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer == nil {
+		return !w.expired
+	}
+	w.timer.Stop()
+	w.timer = nil
+	return !w.expired
+}
+
+// Expired reports whether the callback has fired
+func (w *Watchdog) Expired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.expired
+}
@@ -0,0 +1,48 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogStopsAfterFixedDuration(t *testing.T) {
+	stopped := make(chan time.Time, 1)
+	start := time.Now()
+
+	w := StartWatchdog(50*time.Millisecond, func() {
+		stopped <- time.Now()
+	})
+
+	select {
+	case at := <-stopped:
+		if at.Sub(start) < 50*time.Millisecond {
+			t.Errorf("Expected stop after 50ms, got %v", at.Sub(start))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected watchdog to fire")
+	}
+
+	if !w.Expired() {
+		t.Error("Expected watchdog to report expired")
+	}
+	if w.Stop() {
+		t.Error("Expected Stop after expiry to return false")
+	}
+}
+
+func TestWatchdogCancelledByManualStop(t *testing.T) {
+	fired := make(chan struct{}, 1)
+	w := StartWatchdog(30*time.Millisecond, func() {
+		fired <- struct{}{}
+	})
+
+	if !w.Stop() {
+		t.Fatal("Expected Stop before expiry to return true")
+	}
+
+	select {
+	case <-fired:
+		t.Error("Expected cancelled watchdog not to fire")
+	case <-time.After(80 * time.Millisecond):
+	}
+}
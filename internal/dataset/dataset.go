@@ -0,0 +1,122 @@
+// Package dataset saves recordings paired with their transcriptions, for
+// building fine-tuning or evaluation sets
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"speek_to_text_linux/internal/retention"
+	"speek_to_text_linux/pkg/wav"
+)
+
+// ManifestName is the index file listing every pair in the directory
+const ManifestName = "manifest.jsonl"
+
+// Entry is one line of the manifest
+type Entry struct {
+	ID       string  `json:"id"`
+	Audio    string  `json:"audio"`
+	Text     string  `json:"text"`
+	Duration float64 `json:"duration_sec"`
+	Created  string  `json:"created"`
+}
+
+// Writer saves sessions as NNNN.wav and NNNN.txt in a directory
+type Writer struct {
+	mu      sync.Mutex
+	dir     string
+	now     func() time.Time
+	minFree int64 // free space required before saving; 0 disables the check
+}
+
+// NewWriter creates a dataset writer for dir
+func NewWriter(dir string) *Writer {
+	return &Writer{dir: dir, now: time.Now}
+}
+
+// SetMinFreeBytes makes Save skip a pair when less than n bytes are free on
+// the dataset's disk. Zero disables the check.
+func (w *Writer) SetMinFreeBytes(n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.minFree = n
+}
+
+// Save writes pcm (S16_LE) as a WAV file and text alongside it under the
+// next free number, then appends the pair to the manifest
+func (w *Writer) Save(pcm []byte, sampleRate, channels int, text string) (*Entry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := retention.CheckSpace(w.dir, w.minFree); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+
+	id, err := w.nextID()
+	if err != nil {
+		return nil, err
+	}
+
+	wavData, err := wav.Encode(pcm, sampleRate, channels, 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode WAV: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, id+".wav"), wavData, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, id+".txt"), []byte(text+"\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		ID:       id,
+		Audio:    id + ".wav",
+		Text:     text,
+		Duration: float64(len(pcm)) / float64(sampleRate*channels*2),
+		Created:  w.now().Format(time.RFC3339),
+	}
+	if err := w.appendManifest(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// nextID returns one more than the highest NNNN.wav in the directory
+func (w *Writer) nextID() (string, error) {
+	matches, err := filepath.Glob(filepath.Join(w.dir, "*.wav"))
+	if err != nil {
+		return "", err
+	}
+	highest := 0
+	for _, m := range matches {
+		n, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(m), ".wav"))
+		if err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("%04d", highest+1), nil
+}
+
+func (w *Writer) appendManifest(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(w.dir, ManifestName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,90 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSavePairsAudioAndText(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dataset")
+	w := NewWriter(dir)
+	w.now = func() time.Time { return time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC) }
+
+	pcm := make([]byte, 32000) // one second of 16 kHz mono S16_LE
+	first, err := w.Save(pcm, 16000, 1, "hello world")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	second, err := w.Save(pcm[:16000], 16000, 1, "second take")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if first.ID != "0001" || second.ID != "0002" {
+		t.Fatalf("Expected sequential ids 0001 and 0002, got %s and %s", first.ID, second.ID)
+	}
+
+	wavInfo, err := os.Stat(filepath.Join(dir, "0001.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wavInfo.Size() != 44+32000 {
+		t.Errorf("Expected 44-byte header plus PCM, got %d bytes", wavInfo.Size())
+	}
+	text, err := os.ReadFile(filepath.Join(dir, "0002.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "second take\n" {
+		t.Errorf("Expected transcription next to audio, got %q", text)
+	}
+}
+
+func TestManifestFormat(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(dir)
+	w.now = func() time.Time { return time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC) }
+
+	if _, err := w.Save(make([]byte, 16000), 16000, 1, `say "hi"`); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, ManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("Expected a manifest line")
+	}
+	want := `{"id":"0001","audio":"0001.wav","text":"say \"hi\"","duration_sec":0.5,"created":"2024-01-15T09:00:00Z"}`
+	if scanner.Text() != want {
+		t.Errorf("Unexpected manifest line:\n got %s\nwant %s", scanner.Text(), want)
+	}
+
+	var e Entry
+	if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Text != `say "hi"` {
+		t.Errorf("Expected manifest line to round-trip, got %+v, %v", e, err)
+	}
+}
+
+func TestNumberingContinuesAfterExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0041.wav"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := NewWriter(dir).Save(make([]byte, 320), 16000, 1, "next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.ID != "0042" {
+		t.Errorf("Expected id 0042, got %s", e.ID)
+	}
+}
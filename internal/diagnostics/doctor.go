@@ -0,0 +1,281 @@
+// Package diagnostics probes the environment and reports VoiceType capabilities
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"speek_to_text_linux/internal/api"
+	"speek_to_text_linux/internal/audio"
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+	"speek_to_text_linux/pkg/config"
+)
+
+// Status represents the outcome of a single check
+type Status int
+
+const (
+	// StatusPass means the capability is available
+	StatusPass Status = iota
+	// StatusWarn means the capability is degraded but VoiceType can still work
+	StatusWarn
+	// StatusFail means the capability is missing and VoiceType will not work
+	StatusFail
+)
+
+// String returns the checklist label for a status
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "PASS"
+	case StatusWarn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// Check is the result of probing a single capability
+type Check struct {
+	Name   string
+	Status Status
+	Detail string
+	Hint   string
+}
+
+// Report is the full list of checks produced by a Doctor run
+type Report struct {
+	Checks []Check
+}
+
+// Worst returns the most severe status in the report
+func (r *Report) Worst() Status {
+	worst := StatusPass
+	for _, c := range r.Checks {
+		if c.Status > worst {
+			worst = c.Status
+		}
+	}
+	return worst
+}
+
+// Print writes the report as a human-readable checklist
+func (r *Report) Print(w io.Writer) {
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "[%s] %-14s %s\n", c.Status, c.Name, c.Detail)
+		if c.Status != StatusPass && c.Hint != "" {
+			fmt.Fprintf(w, "       %-14s -> %s\n", "", c.Hint)
+		}
+	}
+}
+
+// Doctor aggregates capability checks for every subsystem
+type Doctor struct {
+	// LookPath reports whether a tool exists (defaults to exec.LookPath)
+	LookPath func(tool string) (string, error)
+	// Getenv reads environment variables (defaults to os.Getenv)
+	Getenv func(key string) string
+	// HealthCheck probes API connectivity; nil skips the check
+	HealthCheck func(ctx context.Context) error
+	// LoadConfig loads the configuration (defaults to config.Inspect, which
+	// leaves a corrupt file in place)
+	LoadConfig func() (*config.Config, error)
+}
+
+// NewDoctor creates a Doctor backed by the real environment
+func NewDoctor(healthCheck func(ctx context.Context) error) *Doctor {
+	return &Doctor{
+		LookPath:    exec.LookPath,
+		Getenv:      os.Getenv,
+		HealthCheck: healthCheck,
+		LoadConfig:  config.Inspect,
+	}
+}
+
+// Run executes all checks and returns the report
+func (d *Doctor) Run(ctx context.Context) *Report {
+	cfg, cfgErr := d.LoadConfig()
+	backend := config.DefaultConfig().CaptureBackend
+	if cfgErr == nil {
+		backend = cfg.CaptureBackend
+	}
+
+	r := &Report{}
+	r.Checks = append(r.Checks,
+		d.checkDisplay(),
+		d.checkCapture(backend),
+		d.checkClipboard(),
+		d.checkTyping(),
+		d.checkTools("Notifications", []string{"notify-send", "dunstify", "gdbus"}, StatusWarn, "Install libnotify: sudo apt install libnotify-bin"),
+		d.checkConfig(cfg, cfgErr),
+		d.checkAPI(ctx, localURL(cfg)),
+	)
+	return r
+}
+
+// localURL returns the server the local provider transcribes with, or ""
+// when audio goes to Groq
+func localURL(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	if provider, err := api.ParseProvider(cfg.Provider); err != nil || provider != api.ProviderLocal {
+		return ""
+	}
+	if cfg.LocalURL == "" {
+		return api.DefaultLocalURL
+	}
+	return cfg.LocalURL
+}
+
+func (d *Doctor) isWayland() bool {
+	return session.Detect(d.Getenv) == session.Wayland
+}
+
+func (d *Doctor) checkDisplay() Check {
+	c := Check{Name: "Display"}
+	switch {
+	case d.isWayland():
+		c.Detail = "Wayland (" + d.Getenv("WAYLAND_DISPLAY") + ")"
+	case session.Detect(d.Getenv) == session.X11:
+		c.Detail = "X11 (" + d.Getenv("DISPLAY") + ")"
+	default:
+		c.Status = StatusFail
+		c.Detail = "no display server detected"
+		c.Hint = "Run VoiceType from a graphical X11 or Wayland session"
+	}
+	return c
+}
+
+// checkTools passes if any of the tools is present, otherwise reports missing
+func (d *Doctor) checkTools(name string, tools []string, missing Status, hint string) Check {
+	for _, tool := range tools {
+		if toolpath.AvailableWith(d.LookPath, tool) {
+			return Check{Name: name, Detail: tool}
+		}
+	}
+	return Check{
+		Name:   name,
+		Status: missing,
+		Detail: "none of " + strings.Join(tools, ", ") + " found",
+		Hint:   hint,
+	}
+}
+
+// captureHints says which package provides each capture tool
+var captureHints = map[string]string{
+	"arecord":   "Install alsa-utils: sudo apt install alsa-utils",
+	"parec":     "Install pulseaudio-utils: sudo apt install pulseaudio-utils",
+	"pw-record": "Install pipewire: sudo apt install pipewire-bin",
+}
+
+// checkCapture looks for the tool the capture_backend setting records with.
+// A chosen backend whose tool is missing falls back to arecord, so that only
+// warns; auto is satisfied by any capture tool.
+func (d *Doctor) checkCapture(backend string) Check {
+	backend, err := audio.ParseBackend(backend)
+	if err != nil {
+		return Check{Name: "Capture", Status: StatusFail, Detail: err.Error(), Hint: "Fix capture_backend in ~/.config/voicetype/config.json"}
+	}
+	if backend == audio.BackendAuto {
+		return d.checkTools("Capture", []string{"pw-record", "parec", "arecord"}, StatusFail, captureHints["arecord"])
+	}
+
+	tool := audio.BackendTool(backend)
+	if toolpath.AvailableWith(d.LookPath, tool) {
+		return Check{Name: "Capture", Detail: tool}
+	}
+	fallback := audio.BackendTool(audio.BackendALSA)
+	if toolpath.AvailableWith(d.LookPath, fallback) {
+		return Check{
+			Name:   "Capture",
+			Status: StatusWarn,
+			Detail: tool + " not found, falling back to " + fallback,
+			Hint:   captureHints[tool],
+		}
+	}
+	return Check{
+		Name:   "Capture",
+		Status: StatusFail,
+		Detail: "neither " + tool + " nor " + fallback + " found",
+		Hint:   captureHints[tool],
+	}
+}
+
+func (d *Doctor) checkClipboard() Check {
+	if d.isWayland() {
+		return d.checkTools("Clipboard", []string{"wl-copy", "xclip", "xsel"}, StatusWarn, "Install wl-clipboard: sudo apt install wl-clipboard")
+	}
+	return d.checkTools("Clipboard", []string{"xclip", "xsel"}, StatusWarn, "Install xclip: sudo apt install xclip")
+}
+
+func (d *Doctor) checkTyping() Check {
+	if d.isWayland() {
+		return d.checkTools("Typing", []string{"wtype", "ydotool", "xdotool"}, StatusFail, "Install wtype: sudo apt install wtype")
+	}
+	return d.checkTools("Typing", []string{"xdotool", "ydotool"}, StatusFail, "Install xdotool: sudo apt install xdotool")
+}
+
+func (d *Doctor) checkConfig(cfg *config.Config, err error) Check {
+	c := Check{Name: "Config"}
+	if err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Hint = "Fix or remove ~/.config/voicetype/config.json"
+		return c
+	}
+	provider, err := api.ParseProvider(cfg.Provider)
+	if err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Hint = "Set provider to groq or local in ~/.config/voicetype/config.json"
+		return c
+	}
+	// A local server needs no API key
+	if provider == api.ProviderLocal {
+		c.Detail = "ok (local provider)"
+		return c
+	}
+	if cfg.GROQ_API_KEY == "" {
+		c.Status = StatusFail
+		c.Detail = "GROQ_API_KEY is not set"
+		c.Hint = "export GROQ_API_KEY=... or run with --settings"
+		return c
+	}
+	c.Detail = "ok"
+	return c
+}
+
+func (d *Doctor) checkAPI(ctx context.Context, localURL string) Check {
+	c := Check{Name: "API"}
+	if d.HealthCheck == nil {
+		c.Status = StatusWarn
+		c.Detail = "skipped (no API key)"
+		c.Hint = "Set GROQ_API_KEY to test connectivity"
+		return c
+	}
+
+	hCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := d.HealthCheck(hCtx); err != nil {
+		c.Status = StatusFail
+		c.Detail = err.Error()
+		c.Hint = "Check network access to api.groq.com and that the API key is valid"
+		if localURL != "" {
+			c.Hint = "Start the local server or fix local_url (" + localURL + ")"
+		}
+		return c
+	}
+	c.Detail = "reachable"
+	if localURL != "" {
+		c.Detail = "reachable (" + localURL + ")"
+	}
+	return c
+}
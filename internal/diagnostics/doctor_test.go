@@ -0,0 +1,169 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"speek_to_text_linux/pkg/config"
+)
+
+func fakeDoctor(tools []string, env map[string]string, apiErr error) *Doctor {
+	present := make(map[string]bool)
+	for _, t := range tools {
+		present[t] = true
+	}
+	return &Doctor{
+		LookPath: func(tool string) (string, error) {
+			if present[tool] {
+				return "/usr/bin/" + tool, nil
+			}
+			return "", exec.ErrNotFound
+		},
+		Getenv: func(key string) string { return env[key] },
+		HealthCheck: func(ctx context.Context) error {
+			return apiErr
+		},
+		LoadConfig: func() (*config.Config, error) {
+			cfg := config.DefaultConfig()
+			cfg.GROQ_API_KEY = "gsk_test"
+			return cfg, nil
+		},
+	}
+}
+
+func findCheck(t *testing.T, r *Report, name string) Check {
+	t.Helper()
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("check %q not found in report", name)
+	return Check{}
+}
+
+func TestDoctorAllPass(t *testing.T) {
+	d := fakeDoctor([]string{"arecord", "xclip", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, nil)
+	r := d.Run(context.Background())
+
+	if r.Worst() != StatusPass {
+		var buf bytes.Buffer
+		r.Print(&buf)
+		t.Fatalf("Expected all checks to pass, got:\n%s", buf.String())
+	}
+}
+
+func TestDoctorMissingTools(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tools  []string
+		env    map[string]string
+		check  string
+		status Status
+	}{
+		{"no capture", []string{"xclip", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, "Capture", StatusFail},
+		{"no clipboard", []string{"arecord", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, "Clipboard", StatusWarn},
+		{"no typing", []string{"arecord", "xclip", "notify-send"}, map[string]string{"DISPLAY": ":0"}, "Typing", StatusFail},
+		{"no notify", []string{"arecord", "xclip", "xdotool"}, map[string]string{"DISPLAY": ":0"}, "Notifications", StatusWarn},
+		{"wayland typing", []string{"arecord", "wl-copy", "wtype", "notify-send"}, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, "Typing", StatusPass},
+		{"no display", []string{"arecord", "xclip", "xdotool", "notify-send"}, nil, "Display", StatusFail},
+	}
+
+	for _, tc := range testCases {
+		r := fakeDoctor(tc.tools, tc.env, nil).Run(context.Background())
+		c := findCheck(t, r, tc.check)
+		if c.Status != tc.status {
+			t.Errorf("%s: expected %s status %v, got %v (%s)", tc.name, tc.check, tc.status, c.Status, c.Detail)
+		}
+		if c.Status != StatusPass && c.Hint == "" {
+			t.Errorf("%s: expected a remediation hint for %s", tc.name, tc.check)
+		}
+	}
+}
+
+func TestDoctorAPIFailure(t *testing.T) {
+	d := fakeDoctor([]string{"arecord", "xclip", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, errors.New("connection refused"))
+	r := d.Run(context.Background())
+
+	c := findCheck(t, r, "API")
+	if c.Status != StatusFail {
+		t.Errorf("Expected API check to fail, got %v", c.Status)
+	}
+
+	var buf bytes.Buffer
+	r.Print(&buf)
+	if !strings.Contains(buf.String(), "[FAIL] API") {
+		t.Errorf("Expected printed report to contain API failure, got:\n%s", buf.String())
+	}
+}
+
+func TestDoctorCaptureBackend(t *testing.T) {
+	testCases := []struct {
+		name    string
+		backend string
+		tools   []string
+		status  Status
+	}{
+		{"auto with parec only", "auto", []string{"parec"}, StatusPass},
+		{"auto with pw-record only", "auto", []string{"pw-record"}, StatusPass},
+		{"auto without tools", "auto", nil, StatusFail},
+		{"pipewire", "pipewire", []string{"pw-record"}, StatusPass},
+		{"pulse falls back to arecord", "pulse", []string{"arecord"}, StatusWarn},
+		{"pulse without tools", "pulse", nil, StatusFail},
+		{"unknown backend", "jack", []string{"arecord"}, StatusFail},
+	}
+
+	for _, tc := range testCases {
+		d := fakeDoctor(tc.tools, map[string]string{"DISPLAY": ":0"}, nil)
+		d.LoadConfig = func() (*config.Config, error) {
+			cfg := config.DefaultConfig()
+			cfg.GROQ_API_KEY = "gsk_test"
+			cfg.CaptureBackend = tc.backend
+			return cfg, nil
+		}
+		c := findCheck(t, d.Run(context.Background()), "Capture")
+		if c.Status != tc.status {
+			t.Errorf("%s: expected Capture status %v, got %v (%s)", tc.name, tc.status, c.Status, c.Detail)
+		}
+	}
+}
+
+func TestDoctorCorruptConfig(t *testing.T) {
+	d := fakeDoctor([]string{"arecord", "xclip", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, nil)
+	d.LoadConfig = func() (*config.Config, error) {
+		return nil, errors.New("config file is not valid JSON")
+	}
+	r := d.Run(context.Background())
+
+	if c := findCheck(t, r, "Config"); c.Status != StatusFail {
+		t.Errorf("Expected Config check to fail, got %v", c.Status)
+	}
+	if c := findCheck(t, r, "Capture"); c.Status != StatusPass {
+		t.Errorf("Expected Capture check to use the default backend, got %v (%s)", c.Status, c.Detail)
+	}
+}
+
+func TestDoctorLocalProvider(t *testing.T) {
+	d := fakeDoctor([]string{"arecord", "xclip", "xdotool", "notify-send"}, map[string]string{"DISPLAY": ":0"}, errors.New("connection refused"))
+	d.LoadConfig = func() (*config.Config, error) {
+		cfg := config.DefaultConfig()
+		cfg.Provider = "local"
+		return cfg, nil
+	}
+	r := d.Run(context.Background())
+
+	if c := findCheck(t, r, "Config"); c.Status != StatusPass {
+		t.Errorf("Expected Config to pass without an API key for the local provider, got %v (%s)", c.Status, c.Detail)
+	}
+	c := findCheck(t, r, "API")
+	if c.Status != StatusFail {
+		t.Errorf("Expected the local server health check to fail, got %v", c.Status)
+	}
+	if !strings.Contains(c.Hint, "local_url") {
+		t.Errorf("Expected a hint about local_url, got %q", c.Hint)
+	}
+}
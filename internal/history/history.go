@@ -0,0 +1,162 @@
+// Package history keeps every recording and its transcription, named by
+// the time it was made, as an audit trail of what was dictated, and an
+// index of past transcriptions to search and replay them
+package history
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"speek_to_text_linux/internal/retention"
+)
+
+// timeLayout names each recording; it sorts in time order
+const timeLayout = "2006-01-02_15-04-05"
+
+// History saves recordings as <dir>/<timestamp>.wav, each followed by a
+// <timestamp>.txt with its transcription once that returns
+type History struct {
+	mu     sync.Mutex
+	dir    string
+	now    func() time.Time
+	maxAge time.Duration
+	// minFree is the free space required before saving; 0 disables the check
+	minFree int64
+}
+
+// DefaultDir is where recordings are kept unless history_dir is set, next
+// to the config file
+const DefaultDir = "~/.config/voicetype/history"
+
+// New creates a history in dir, or DefaultDir when dir is empty. A leading
+// "~/" is expanded to the home directory.
+func New(dir string) *History {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &History{dir: expandHome(dir), now: time.Now}
+}
+
+// SetMaxAge makes Prune remove recordings and transcriptions older than
+// maxAge. Zero keeps them forever.
+func (h *History) SetMaxAge(maxAge time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.maxAge = maxAge
+}
+
+// SetMinFreeBytes makes SaveRecording skip a recording when less than n
+// bytes are free on the history's disk. Zero disables the check.
+func (h *History) SetMinFreeBytes(n int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.minFree = n
+}
+
+// Prune removes files older than the maximum age
+func (h *History) Prune() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	policy := retention.Policy{MaxAge: h.maxAge}
+	removed := 0
+	for _, pattern := range []string{"*.wav", "*.txt"} {
+		paths, err := retention.PruneDated(h.dir, pattern, recordedAt, policy)
+		removed += len(paths)
+		if err != nil {
+			return err
+		}
+	}
+	if removed > 0 {
+		log.Printf("History retention removed %d old file(s)", removed)
+	}
+	return nil
+}
+
+// SaveRecording creates the directory as needed and calls write with the
+// path for a recording made now, e.g. audio.System.SaveDataToFile. It
+// returns that path for SaveTranscript. A second recording within the same
+// second gets a numbered name.
+func (h *History) SaveRecording(write func(path string) error) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := retention.CheckSpace(h.dir, h.minFree); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	base := filepath.Join(h.dir, h.now().Format(timeLayout))
+	path := base + ".wav"
+	for n := 2; exists(path); n++ {
+		path = fmt.Sprintf("%s-%d.wav", base, n)
+	}
+	if err := write(path); err != nil {
+		return "", fmt.Errorf("failed to save recording: %w", err)
+	}
+	return path, nil
+}
+
+// SaveTranscript writes text next to the recording SaveRecording saved at
+// recordingPath, replacing any earlier transcription of it
+func (h *History) SaveTranscript(recordingPath, text string) error {
+	path := strings.TrimSuffix(recordingPath, ".wav") + ".txt"
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(text)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save transcription: %w", err)
+	}
+	return nil
+}
+
+// Discard removes the recording SaveRecording saved at recordingPath along
+// with its transcription, e.g. when it turns out to hold a password
+func (h *History) Discard(recordingPath string) error {
+	txt := strings.TrimSuffix(recordingPath, ".wav") + ".txt"
+	for _, path := range []string{recordingPath, txt} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to discard recording: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordedAt returns when a file SaveRecording or SaveTranscript named was
+// made. Other names don't parse, so history_dir pointed at a folder with
+// files of its own never loses them to Prune.
+func recordedAt(name string) (time.Time, bool) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if len(name) < len(timeLayout) {
+		return time.Time{}, false
+	}
+	// A numbered name, e.g. 2024-01-15_09-05-03-2, made within the same second
+	if n := name[len(timeLayout):]; n != "" {
+		if _, err := strconv.Atoi(strings.TrimPrefix(n, "-")); err != nil || n[0] != '-' {
+			return time.Time{}, false
+		}
+	}
+	t, err := time.ParseInLocation(timeLayout, name[:len(timeLayout)], time.Local)
+	return t, err == nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func expandHome(dir string) string {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~"))
+}
@@ -0,0 +1,166 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveRecordingAndTranscript(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	h := New(dir)
+	now := time.Date(2024, 1, 15, 9, 5, 3, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	write := func(path string) error { return os.WriteFile(path, []byte("RIFF"), 0600) }
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, err := h.SaveRecording(write)
+		if err != nil {
+			t.Fatalf("SaveRecording failed: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	expected := []string{"2024-01-15_09-05-03.wav", "2024-01-15_09-05-03-2.wav", "2024-01-15_09-05-03-3.wav"}
+	for i, path := range paths {
+		if path != filepath.Join(dir, expected[i]) {
+			t.Errorf("Recording %d: expected %s, got %s", i, expected[i], path)
+		}
+	}
+
+	if err := h.SaveTranscript(paths[1], "  hello world\n"); err != nil {
+		t.Fatalf("SaveTranscript failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "2024-01-15_09-05-03-2.txt"))
+	if err != nil {
+		t.Fatalf("Expected a transcription next to the recording: %v", err)
+	}
+	if string(data) != "hello world\n" {
+		t.Errorf("Expected %q, got %q", "hello world\n", data)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	dir := t.TempDir()
+	h := New(dir)
+	path, err := h.SaveRecording(func(path string) error { return os.WriteFile(path, []byte("RIFF"), 0600) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SaveTranscript(path, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Discard(path); err != nil {
+		t.Fatalf("Discard failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the recording and transcription to be removed, got %v", entries)
+	}
+	if err := h.Discard(path); err != nil {
+		t.Errorf("Expected discarding a missing recording to succeed, got %v", err)
+	}
+}
+
+func TestNewDefaultsToConfigDir(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	if got := New("").dir; got != "/home/user/.config/voicetype/history" {
+		t.Errorf("Expected the default directory, got %s", got)
+	}
+	if got := New("~/audit").dir; got != "/home/user/audit" {
+		t.Errorf("Expected ~ to be expanded, got %s", got)
+	}
+}
+
+func TestSaveRecordingReportsWriteFailure(t *testing.T) {
+	h := New(t.TempDir())
+	if _, err := h.SaveRecording(func(string) error { return os.ErrPermission }); err == nil {
+		t.Error("Expected the write error")
+	}
+}
+
+func TestPruneRemovesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	h := New(dir)
+	h.SetMaxAge(30 * 24 * time.Hour)
+
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	oldName := old.Format(timeLayout)
+	newName := time.Now().Format(timeLayout)
+	files := map[string]time.Time{
+		oldName + ".wav":   old,
+		oldName + ".txt":   old,
+		oldName + "-2.wav": old,
+		newName + ".wav":   time.Now(),
+		newName + ".txt":   time.Now(),
+		"notes.md":         old,
+		// Not named by SaveRecording, e.g. history_dir is a folder of its own
+		"interview.wav": old,
+		"todo.txt":      old,
+	}
+	for name, mtime := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := h.Prune(); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		gone := os.IsNotExist(err)
+		if wantGone := strings.HasPrefix(name, oldName); gone != wantGone {
+			t.Errorf("%s: removed=%v, expected %v", name, gone, wantGone)
+		}
+	}
+}
+
+func TestPruneKeepsEverythingWithoutMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2020-01-01_10-00-00.wav")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-5 * 365 * 24 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(dir).Prune(); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the recording to be kept: %v", err)
+	}
+}
+
+func TestRecordedAt(t *testing.T) {
+	want := time.Date(2024, 1, 15, 9, 5, 3, 0, time.Local)
+	testCases := []struct {
+		name string
+		ok   bool
+	}{
+		{"2024-01-15_09-05-03.wav", true},
+		{"2024-01-15_09-05-03-2.txt", true},
+		{"2024-01-15_09-05-03.wav.bak", false},
+		{"2024-01-15_09-05-03x.wav", false},
+		{"2024-01-15_09-05-03-.wav", false},
+		{"interview.wav", false},
+		{"notes.txt", false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := recordedAt(tc.name)
+		if ok != tc.ok || (ok && !got.Equal(want)) {
+			t.Errorf("recordedAt(%q) = %v, %v, expected ok=%v", tc.name, got, ok, tc.ok)
+		}
+	}
+}
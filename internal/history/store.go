@@ -0,0 +1,151 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStorePath is the index of past transcriptions, next to the config file
+const DefaultStorePath = "~/.config/voicetype/history.jsonl"
+
+// Entry is one past transcription
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_sec"`
+	Text     string    `json:"text"`
+	// Audio is the recording's file in the history, empty when it wasn't kept
+	Audio string `json:"audio,omitempty"`
+}
+
+// Store keeps entries as JSON lines, oldest first
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewStore opens the store at path, or DefaultStorePath when path is empty.
+// The file is created by the first Append.
+func NewStore(path string) *Store {
+	if path == "" {
+		path = DefaultStorePath
+	}
+	return &Store{path: expandHome(path)}
+}
+
+// Path returns the store's file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds an entry to the end of the store
+func (s *Store) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Query returns the entries whose text contains term in any case, newest
+// first; an empty term returns them all. A missing store has no entries,
+// and lines that don't parse are skipped.
+func (s *Store) Query(term string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	term = strings.ToLower(strings.TrimSpace(term))
+	var matches []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(entries[i].Text), term) {
+			matches = append(matches, entries[i])
+		}
+	}
+	return matches, nil
+}
+
+// Prune removes entries older than maxAge, like History.Prune does their
+// recordings. Zero keeps them forever.
+func (s *Store) Prune(maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.read()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var b strings.Builder
+	kept := 0
+	for _, e := range entries {
+		if e.Time.Before(cutoff) {
+			continue
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+		kept++
+	}
+	if kept == len(entries) {
+		return nil
+	}
+
+	// Replace the file whole, so a crash can't leave it half written
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// read returns every entry in the store, oldest first
+func (s *Store) read() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	// A long dictation makes a long line
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStoreAppendAndQuery(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "config", "history.jsonl"))
+
+	if entries, err := s.Query(""); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected no entries before the first Append, got %v, %v", entries, err)
+	}
+
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	for i, text := range []string{"Meeting notes for Monday", "Buy milk", "Email the monday team"} {
+		e := Entry{Time: base.Add(time.Duration(i) * time.Minute), Duration: 2.5, Text: text}
+		if err := s.Append(e); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		term     string
+		expected []string
+	}{
+		{"", []string{"Email the monday team", "Buy milk", "Meeting notes for Monday"}},
+		{"MONDAY", []string{"Email the monday team", "Meeting notes for Monday"}},
+		{" milk ", []string{"Buy milk"}},
+		{"tuesday", nil},
+	}
+
+	for _, tc := range testCases {
+		entries, err := s.Query(tc.term)
+		if err != nil {
+			t.Fatalf("Query(%q) failed: %v", tc.term, err)
+		}
+		var texts []string
+		for _, e := range entries {
+			texts = append(texts, e.Text)
+		}
+		if !reflect.DeepEqual(texts, tc.expected) {
+			t.Errorf("Query(%q) = %q, expected %q", tc.term, texts, tc.expected)
+		}
+	}
+
+	entries, _ := s.Query("milk")
+	if len(entries) != 1 || !entries[0].Time.Equal(base.Add(time.Minute)) || entries[0].Duration != 2.5 {
+		t.Errorf("Expected the entry's time and duration to round-trip, got %+v", entries)
+	}
+}
+
+func TestStoreQuerySkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"time":"2024-01-15T09:00:00Z","text":"first"}
+not json
+{"time":"2024-01-15T09:01:00Z","text":"second","audio":"/h/2024-01-15_09-01-00.wav"}
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := NewStore(path).Query("")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Audio != "/h/2024-01-15_09-01-00.wav" || entries[1].Text != "first" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	now := time.Now()
+	for _, e := range []Entry{
+		{Time: now.Add(-40 * 24 * time.Hour), Text: "old"},
+		{Time: now.Add(-time.Hour), Text: "recent"},
+	} {
+		if err := s.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Prune(0); err != nil {
+		t.Fatalf("Prune(0) failed: %v", err)
+	}
+	if entries, _ := s.Query(""); len(entries) != 2 {
+		t.Errorf("Expected no max age to keep everything, got %d entries", len(entries))
+	}
+
+	if err := s.Prune(30 * 24 * time.Hour); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	entries, _ := s.Query("")
+	if len(entries) != 1 || entries[0].Text != "recent" {
+		t.Errorf("Expected only the recent entry, got %+v", entries)
+	}
+}
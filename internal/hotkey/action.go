@@ -0,0 +1,82 @@
+package hotkey
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Action carries per-binding options from a hotkey's command line to the
+// running instance, e.g. `voicetype-gui --toggle --language es` bound to one
+// key and `--language en` to another. It is written next to the pid file,
+// in pidfile.RuntimeDir, just before the toggle signal is sent.
+type Action struct {
+	// Language overrides the configured transcription language for the
+	// recording this action starts; empty keeps the configured one
+	Language string `json:"language,omitempty"`
+	// RecordSeconds stops the recording this action starts after this many
+	// seconds; 0 records until it is stopped
+	RecordSeconds int `json:"record_seconds,omitempty"`
+	// RetryModel, when set, re-transcribes the last recording with this
+	// model instead of toggling
+	RetryModel string `json:"retry_model,omitempty"`
+	// OpenSettings opens the settings window in the running instance, so
+	// changes apply at once and only one process writes the config
+	OpenSettings bool `json:"open_settings,omitempty"`
+}
+
+// ActionHandlers are the running instance's reactions to an Action
+type ActionHandlers struct {
+	Toggle       func(a Action)
+	RetryModel   func(model string)
+	OpenSettings func()
+}
+
+// Dispatch runs the handler for a. Opening settings and retrying are
+// commands of their own; anything else toggles recording with a's options.
+func (h ActionHandlers) Dispatch(a Action) {
+	switch {
+	case a.OpenSettings:
+		if h.OpenSettings != nil {
+			h.OpenSettings()
+		}
+	case a.RetryModel != "":
+		if h.RetryModel != nil {
+			h.RetryModel(a.RetryModel)
+		}
+	default:
+		if h.Toggle != nil {
+			h.Toggle(a)
+		}
+	}
+}
+
+// WriteAction stores the action for the running instance to pick up
+func WriteAction(path string, a Action) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// TakeAction reads and removes a pending action. A missing file yields the
+// zero Action, so a plain toggle behaves as before; so does one that can't
+// be read, along with the error.
+func TakeAction(path string) (Action, error) {
+	var a Action
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return a, err
+	}
+	if err := os.Remove(path); err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return Action{}, fmt.Errorf("invalid action file %s: %w", path, err)
+	}
+	return a, nil
+}
@@ -0,0 +1,127 @@
+package hotkey
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.action")
+
+	if err := WriteAction(path, Action{Language: "es", RecordSeconds: 5}); err != nil {
+		t.Fatalf("WriteAction failed: %v", err)
+	}
+	if a, err := TakeAction(path); err != nil || a.Language != "es" || a.RecordSeconds != 5 {
+		t.Errorf("Expected language es for 5 seconds, got %+v, %v", a, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected action file to be consumed")
+	}
+	if a, err := TakeAction(path); err != nil || a != (Action{}) {
+		t.Errorf("Expected zero action once consumed, got %+v, %v", a, err)
+	}
+}
+
+func TestTakeActionIgnoresGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.action")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := TakeAction(path)
+	if a != (Action{}) {
+		t.Errorf("Expected zero action for unreadable file, got %+v", a)
+	}
+	if err == nil {
+		t.Error("Expected an error for unreadable file")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the unreadable file to be consumed")
+	}
+}
+
+func TestDispatchOpenSettings(t *testing.T) {
+	var opened, toggled int
+	var retried string
+	h := ActionHandlers{
+		Toggle:       func(Action) { toggled++ },
+		RetryModel:   func(model string) { retried = model },
+		OpenSettings: func() { opened++ },
+	}
+
+	h.Dispatch(Action{OpenSettings: true})
+	if opened != 1 || toggled != 0 || retried != "" {
+		t.Errorf("Expected only the settings to open, got opened=%d toggled=%d retried=%q", opened, toggled, retried)
+	}
+}
+
+func TestDispatchRoutesActions(t *testing.T) {
+	testCases := []struct {
+		action   Action
+		expected string
+	}{
+		{Action{}, "toggle"},
+		{Action{Language: "es"}, "toggle:es"},
+		{Action{RetryModel: "whisper-large-v3"}, "retry:whisper-large-v3"},
+		{Action{OpenSettings: true, Language: "es"}, "settings"},
+	}
+
+	for _, tc := range testCases {
+		var got string
+		h := ActionHandlers{
+			Toggle: func(a Action) {
+				got = "toggle"
+				if a.Language != "" {
+					got += ":" + a.Language
+				}
+			},
+			RetryModel:   func(model string) { got = "retry:" + model },
+			OpenSettings: func() { got = "settings" },
+		}
+		h.Dispatch(tc.action)
+		if got != tc.expected {
+			t.Errorf("Dispatch(%+v) ran %q, want %q", tc.action, got, tc.expected)
+		}
+	}
+}
+
+func TestDispatchRetryActionRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.action")
+	if err := WriteAction(path, Action{RetryModel: "whisper-large-v3"}); err != nil {
+		t.Fatalf("WriteAction failed: %v", err)
+	}
+
+	a, err := TakeAction(path)
+	if err != nil {
+		t.Fatalf("TakeAction failed: %v", err)
+	}
+	var retried string
+	ActionHandlers{RetryModel: func(model string) { retried = model }}.Dispatch(a)
+	if retried != "whisper-large-v3" {
+		t.Errorf("Expected the retry written by --retry-model to run, got %q", retried)
+	}
+}
+
+func TestDispatchSettingsActionRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.action")
+	if err := WriteAction(path, Action{OpenSettings: true}); err != nil {
+		t.Fatalf("WriteAction failed: %v", err)
+	}
+
+	a, err := TakeAction(path)
+	if err != nil {
+		t.Fatalf("TakeAction failed: %v", err)
+	}
+	opened := false
+	ActionHandlers{OpenSettings: func() { opened = true }}.Dispatch(a)
+	if !opened {
+		t.Error("Expected the settings action written by --settings to open settings")
+	}
+}
+
+func TestDispatchMissingHandler(t *testing.T) {
+	// A handler the instance doesn't provide is skipped, not a panic
+	ActionHandlers{}.Dispatch(Action{OpenSettings: true})
+	ActionHandlers{}.Dispatch(Action{RetryModel: "x"})
+	ActionHandlers{}.Dispatch(Action{})
+}
@@ -0,0 +1,105 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+)
+
+// comboKey is one key of a hotkey combination: a label for logs and the
+// keysyms any of which satisfies it, e.g. ctrl is Control_L or Control_R
+type comboKey struct {
+	name    string
+	keysyms []string
+}
+
+// modifierKeysyms maps modifier names to their left and right keysyms
+var modifierKeysyms = map[string][]string{
+	"ctrl":    {"Control_L", "Control_R"},
+	"control": {"Control_L", "Control_R"},
+	"alt":     {"Alt_L", "Alt_R"},
+	"shift":   {"Shift_L", "Shift_R"},
+	"super":   {"Super_L", "Super_R"},
+	"win":     {"Super_L", "Super_R"},
+	"meta":    {"Super_L", "Super_R"},
+	"altgr":   {"ISO_Level3_Shift"},
+}
+
+// keyAliases maps friendly key names to X keysyms
+var keyAliases = map[string]string{
+	"space":     "space",
+	"enter":     "Return",
+	"return":    "Return",
+	"esc":       "Escape",
+	"escape":    "Escape",
+	"tab":       "Tab",
+	"backspace": "BackSpace",
+	"delete":    "Delete",
+	"insert":    "Insert",
+	"home":      "Home",
+	"end":       "End",
+	"pageup":    "Prior",
+	"pagedown":  "Next",
+	"up":        "Up",
+	"down":      "Down",
+	"left":      "Left",
+	"right":     "Right",
+	"print":     "Print",
+	"pause":     "Pause",
+	"menu":      "Menu",
+}
+
+// defaultKeycodes are the usual evdev keycodes, used when xmodmap can't
+// resolve a keysym
+var defaultKeycodes = map[string][]string{
+	"Control_L": {"37"},
+	"Control_R": {"105"},
+	"space":     {"65"},
+}
+
+// parseCombo splits a hotkey such as "ctrl+space", "alt+shift+d" or "F9"
+// into its keys. Names are case-insensitive; a name that is neither a
+// modifier nor an alias is taken as a keysym, so "Print" or "XF86AudioMute"
+// work too.
+func parseCombo(hotkey string) ([]comboKey, error) {
+	var keys []comboKey
+	for _, part := range strings.Split(hotkey, "+") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid hotkey %q", hotkey)
+		}
+		name := strings.ToLower(part)
+		switch {
+		case modifierKeysyms[name] != nil:
+			keys = append(keys, comboKey{name: name, keysyms: modifierKeysyms[name]})
+		case keyAliases[name] != "":
+			keys = append(keys, comboKey{name: name, keysyms: []string{keyAliases[name]}})
+		case len(name) == 1:
+			// Letter keysyms are lowercase; digits are the same either way
+			keys = append(keys, comboKey{name: name, keysyms: []string{name}})
+		case isFunctionKey(name):
+			keys = append(keys, comboKey{name: name, keysyms: []string{strings.ToUpper(name)}})
+		default:
+			keys = append(keys, comboKey{name: name, keysyms: []string{part}})
+		}
+	}
+	return keys, nil
+}
+
+// isFunctionKey reports whether name is f1 to f35
+func isFunctionKey(name string) bool {
+	var n int
+	if _, err := fmt.Sscanf(name, "f%d", &n); err != nil {
+		return false
+	}
+	return n >= 1 && n <= 35 && name == fmt.Sprintf("f%d", n)
+}
+
+// anyKeyDown reports whether xinput query-state output shows any of codes down
+func anyKeyDown(state string, codes []string) bool {
+	for _, code := range codes {
+		if strings.Contains(state, "key["+code+"]=down") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package hotkey
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCombo(t *testing.T) {
+	testCases := []struct {
+		hotkey   string
+		expected [][]string
+		wantErr  bool
+	}{
+		{"ctrl+space", [][]string{{"Control_L", "Control_R"}, {"space"}}, false},
+		{"Ctrl+Space", [][]string{{"Control_L", "Control_R"}, {"space"}}, false},
+		{"super+a", [][]string{{"Super_L", "Super_R"}, {"a"}}, false},
+		{"alt+shift+D", [][]string{{"Alt_L", "Alt_R"}, {"Shift_L", "Shift_R"}, {"d"}}, false},
+		{"F9", [][]string{{"F9"}}, false},
+		{"ctrl + f12", [][]string{{"Control_L", "Control_R"}, {"F12"}}, false},
+		{"shift+enter", [][]string{{"Shift_L", "Shift_R"}, {"Return"}}, false},
+		{"ctrl+1", [][]string{{"Control_L", "Control_R"}, {"1"}}, false},
+		{"XF86AudioMute", [][]string{{"XF86AudioMute"}}, false},
+		{"f99", [][]string{{"f99"}}, false},
+		{"ctrl+", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tc := range testCases {
+		keys, err := parseCombo(tc.hotkey)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseCombo(%q) error = %v, expected error %v", tc.hotkey, err, tc.wantErr)
+			continue
+		}
+		var got [][]string
+		for _, key := range keys {
+			got = append(got, key.keysyms)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("parseCombo(%q) = %v, expected %v", tc.hotkey, got, tc.expected)
+		}
+	}
+}
+
+func TestKeycodesFor(t *testing.T) {
+	output := "  9    \t0xff1b (Escape)\t0x0000 (NoSymbol)\n" +
+		" 37    \t0xffe3 (Control_L)\t0x0000 (NoSymbol)\n" +
+		" 38    \t0x0061 (a)\t0x0041 (A)\n" +
+		" 48    \t0x0027 (apostrophe)\t0x0022 (quotedbl)\n" +
+		" 65    \t0x0020 (space)\t0x0000 (NoSymbol)\n" +
+		"105    \t0xffe4 (Control_R)\t0x0000 (NoSymbol)\n" +
+		"keycode  75 = F9 F9 F9\n"
+
+	testCases := []struct {
+		names    []string
+		expected []string
+	}{
+		{[]string{"Control_L", "Control_R"}, []string{"37", "105"}},
+		{[]string{"a"}, []string{"38"}},
+		{[]string{"space"}, []string{"65"}},
+		{[]string{"F9"}, []string{"75"}},
+		{[]string{"9"}, nil},
+		{[]string{"Super_L"}, nil},
+	}
+
+	for _, tc := range testCases {
+		if got := keycodesFor(output, tc.names...); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("keycodesFor(%v) = %v, expected %v", tc.names, got, tc.expected)
+		}
+	}
+}
+
+func TestAnyKeyDown(t *testing.T) {
+	state := "key[37]=down\nkey[38]=up\nkey[65]=down\n"
+
+	testCases := []struct {
+		codes    []string
+		expected bool
+	}{
+		{[]string{"37", "105"}, true},
+		{[]string{"38"}, false},
+		{[]string{"105"}, false},
+		{nil, false},
+	}
+
+	for _, tc := range testCases {
+		if got := anyKeyDown(state, tc.codes); got != tc.expected {
+			t.Errorf("anyKeyDown(%v) = %v, expected %v", tc.codes, got, tc.expected)
+		}
+	}
+}
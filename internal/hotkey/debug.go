@@ -0,0 +1,48 @@
+package hotkey
+
+import (
+	"fmt"
+)
+
+// Kinds of debug events reported to OnDebug
+const (
+	// EventKeycodes reports the keyboard and keycodes being watched
+	EventKeycodes = "keycodes"
+	// EventState reports a key going down or up
+	EventState = "state"
+	// EventPress reports the hotkey firing
+	EventPress = "press"
+	// EventRelease reports the hotkey being released
+	EventRelease = "release"
+	// EventSuppressed reports a press ignored by the repeat guard
+	EventSuppressed = "suppressed"
+)
+
+// Event is a single hotkey detection step, for --hotkey-debug
+type Event struct {
+	Kind   string
+	Detail string
+}
+
+// String formats the event as one line of debug output
+func (e Event) String() string {
+	return fmt.Sprintf("%-10s %s", e.Kind, e.Detail)
+}
+
+// debug reports an event to the OnDebug callback, if any
+func (l *Listener) debug(kind, format string, args ...interface{}) {
+	l.mu.Lock()
+	callback := l.onDebug
+	l.mu.Unlock()
+	if callback != nil {
+		callback(Event{Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+}
+
+// upDown names a key state
+func upDown(down bool) string {
+	if down {
+		return "down"
+	}
+	return "up"
+}
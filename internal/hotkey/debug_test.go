@@ -0,0 +1,45 @@
+package hotkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebugCallbackReceivesFireEvents(t *testing.T) {
+	l := NewListener(nil)
+	l.hotkey = "ctrl+space"
+
+	var events []Event
+	l.OnDebug(func(e Event) {
+		events = append(events, e)
+	})
+	pressed := make(chan struct{}, 1)
+	l.OnPress(func() { pressed <- struct{}{} })
+
+	l.firePress()
+	l.fireRelease()
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 debug events, got %v", events)
+	}
+	if events[0] != (Event{Kind: EventPress, Detail: "ctrl+space"}) {
+		t.Errorf("Expected press event first, got %+v", events[0])
+	}
+	if events[1] != (Event{Kind: EventRelease, Detail: "ctrl+space"}) {
+		t.Errorf("Expected release event second, got %+v", events[1])
+	}
+
+	// The regular callback still fires alongside the debug one
+	select {
+	case <-pressed:
+	case <-time.After(time.Second):
+		t.Error("Expected OnPress callback to run")
+	}
+}
+
+func TestDebugWithoutCallback(t *testing.T) {
+	l := NewListener(nil)
+	// Must not panic when no debug callback is set
+	l.debug(EventState, "ctrl %s", upDown(true))
+	l.firePress()
+}
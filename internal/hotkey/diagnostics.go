@@ -0,0 +1,80 @@
+package hotkey
+
+import (
+	"fmt"
+	"os/exec"
+
+	"speek_to_text_linux/internal/session"
+)
+
+// Detection methods reported by Diagnostics
+const (
+	// MethodEvdev reads key events from /dev/input
+	MethodEvdev = "evdev"
+	// MethodXinput polls key state with xinput, resolving keycodes with xmodmap
+	MethodXinput = "xinput"
+	// MethodYdotool polls with ydotool on Wayland
+	MethodYdotool = "ydotool"
+	// MethodNone waits for a tool to be installed; the hotkey never fires
+	MethodNone = "none"
+)
+
+// detectionTools are the external programs the listener may use
+var detectionTools = []string{"xdotool", "xinput", "xmodmap", "ydotool"}
+
+// lookPath is swapped in tests to fake which tools are installed
+var lookPath = exec.LookPath
+
+// setMethod records the detection method in use
+func (l *Listener) setMethod(method string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.method = method
+}
+
+// getMethod returns the detection method in use
+func (l *Listener) getMethod() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.method
+}
+
+// noteFallback records why a better detection method was skipped
+func (l *Listener) noteFallback(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fallbacks = append(l.fallbacks, fmt.Sprintf(format, args...))
+}
+
+// Diagnostics reports how the hotkey is detected, one line each: the
+// method chosen by Initialize, whether each tool it may use is installed,
+// and why any better method was passed over
+func (l *Listener) Diagnostics() []string {
+	l.mu.Lock()
+	method, fallbacks := l.method, append([]string(nil), l.fallbacks...)
+	l.mu.Unlock()
+
+	if method == "" {
+		method = "not initialized"
+	}
+	lines := []string{"method: " + method}
+	for _, tool := range detectionTools {
+		if path, err := lookPath(tool); err == nil {
+			lines = append(lines, fmt.Sprintf("%s: found at %s", tool, path))
+		} else {
+			lines = append(lines, tool+": not installed")
+		}
+	}
+	for _, reason := range fallbacks {
+		lines = append(lines, "fallback: "+reason)
+	}
+	return lines
+}
+
+// installHint says what makes the hotkey work on this session
+func installHint() string {
+	if session.IsWayland() {
+		return "add yourself to the input group, or install xdotool and xinput"
+	}
+	return "install xdotool and xinput (sudo apt install xdotool xinput)"
+}
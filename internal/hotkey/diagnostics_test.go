@@ -0,0 +1,78 @@
+package hotkey
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+func TestDiagnosticsReportsTools(t *testing.T) {
+	installed := map[string]string{"xdotool": "/usr/bin/xdotool", "xinput": "/usr/bin/xinput"}
+	lookPath = func(tool string) (string, error) {
+		if path, ok := installed[tool]; ok {
+			return path, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	defer func() { lookPath = exec.LookPath }()
+
+	l := NewListener(nil)
+	if got := l.Diagnostics()[0]; got != "method: not initialized" {
+		t.Errorf("Expected an uninitialized method, got %q", got)
+	}
+
+	l.noteFallback("evdev unavailable: %s", "permission denied")
+	l.setMethod(MethodXinput)
+	expected := []string{
+		"method: xinput",
+		"xdotool: found at /usr/bin/xdotool",
+		"xinput: found at /usr/bin/xinput",
+		"xmodmap: not installed",
+		"ydotool: not installed",
+		"fallback: evdev unavailable: permission denied",
+	}
+	if got := l.Diagnostics(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Diagnostics() = %q, expected %q", got, expected)
+	}
+}
+
+func TestInitializeWarnsWithoutDetectionMethod(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+
+	l := NewListener(nil)
+	err := l.Initialize("ctrl+space")
+	defer l.Close()
+	if !errors.Is(err, errors.ErrNoHotkeyTool) {
+		t.Fatalf("Expected ErrNoHotkeyTool, got %v", err)
+	}
+	if !errors.IsType(err, errors.ErrorTypeHotkey) {
+		t.Errorf("Expected a hotkey error, got %v", err)
+	}
+	diag := l.Diagnostics()
+	if diag[0] != "method: "+MethodNone {
+		t.Errorf("Expected method none first, got %v", diag)
+	}
+	if last := diag[len(diag)-1]; last != "fallback: no display detected" {
+		t.Errorf("Expected the fallback reason last, got %q", last)
+	}
+}
+
+func TestUnresolvableHotkeyReportsNoTool(t *testing.T) {
+	// xmodmap names the comma key "comma", so "," never resolves
+	l := NewListener(nil)
+	l.hotkey = "ctrl+,"
+	if _, _, err := l.comboKeycodes(); !errors.Is(err, errors.ErrNoHotkeyTool) {
+		t.Errorf("Expected ErrNoHotkeyTool for a key without a keycode, got %v", err)
+	}
+	if lines := l.Diagnostics(); lines[len(lines)-1] != `fallback: no keycode for ","` {
+		t.Errorf("Expected the unresolved key in diagnostics, got %v", lines)
+	}
+
+	l.hotkey = "ctrl+space"
+	if _, codes, err := l.comboKeycodes(); err != nil || len(codes) != 2 {
+		t.Errorf("Expected ctrl+space to resolve, got %v, %v", codes, err)
+	}
+}
@@ -0,0 +1,262 @@
+package hotkey
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Linux input event constants, from linux/input-event-codes.h
+const (
+	evKey        = 1 // EV_KEY
+	keyReleased  = 0
+	keyPressed   = 1
+	keyRepeated  = 2
+	inputDevices = "/proc/bus/input/devices"
+)
+
+// inputEventSize is sizeof(struct input_event): a timeval of two longs, then
+// type and code (uint16) and value (int32)
+var inputEventSize = 2*strconv.IntSize/8 + 8
+
+// evdevCodes maps keysyms to Linux key codes. Codes name physical keys, so
+// letters and digits assume a US layout.
+var evdevCodes = map[string]uint16{
+	"Escape": 1, "BackSpace": 14, "Tab": 15, "Return": 28, "space": 57,
+	"Control_L": 29, "Control_R": 97, "Shift_L": 42, "Shift_R": 54,
+	"Alt_L": 56, "Alt_R": 100, "ISO_Level3_Shift": 100, "Super_L": 125, "Super_R": 126,
+	"Print": 99, "Pause": 119, "Menu": 127,
+	"Home": 102, "Up": 103, "Prior": 104, "Left": 105, "Right": 106,
+	"End": 107, "Down": 108, "Next": 109, "Insert": 110, "Delete": 111,
+	"q": 16, "w": 17, "e": 18, "r": 19, "t": 20, "y": 21, "u": 22, "i": 23, "o": 24, "p": 25,
+	"a": 30, "s": 31, "d": 32, "f": 33, "g": 34, "h": 35, "j": 36, "k": 37, "l": 38,
+	"z": 44, "x": 45, "c": 46, "v": 47, "b": 48, "n": 49, "m": 50,
+	"1": 2, "2": 3, "3": 4, "4": 5, "5": 6, "6": 7, "7": 8, "8": 9, "9": 10, "0": 11,
+	"F1": 59, "F2": 60, "F3": 61, "F4": 62, "F5": 63, "F6": 64, "F7": 65, "F8": 66,
+	"F9": 67, "F10": 68, "F11": 87, "F12": 88,
+}
+
+func init() {
+	// F13 to F24 are contiguous from 183
+	for n := 13; n <= 24; n++ {
+		evdevCodes[fmt.Sprintf("F%d", n)] = uint16(183 + n - 13)
+	}
+}
+
+// evdevCombo returns the key codes for each key of a combination, or an
+// error naming a key that has no known code
+func evdevCombo(keys []comboKey) ([][]uint16, error) {
+	codes := make([][]uint16, len(keys))
+	for i, key := range keys {
+		for _, keysym := range key.keysyms {
+			if code, ok := evdevCodes[keysym]; ok {
+				codes[i] = append(codes[i], code)
+			}
+		}
+		if len(codes[i]) == 0 {
+			return nil, fmt.Errorf("no evdev key code for %q", key.name)
+		}
+	}
+	return codes, nil
+}
+
+// keyboardEvents returns the event device names, e.g. event3, of the
+// keyboards listed in /proc/bus/input/devices: those whose handlers include
+// kbd and whose key capabilities cover the letter keys
+func keyboardEvents(devices string) []string {
+	var events []string
+	for _, block := range strings.Split(devices, "\n\n") {
+		var handlers []string
+		letters := false
+		for _, line := range strings.Split(block, "\n") {
+			if h, ok := strings.CutPrefix(line, "H: Handlers="); ok {
+				handlers = strings.Fields(h)
+			}
+			if k, ok := strings.CutPrefix(line, "B: KEY="); ok {
+				letters = hasLetterKeys(strings.Fields(k))
+			}
+		}
+		kbd, event := false, ""
+		for _, h := range handlers {
+			if h == "kbd" {
+				kbd = true
+			}
+			if strings.HasPrefix(h, "event") {
+				event = h
+			}
+		}
+		if kbd && letters && event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// hasLetterKeys reports whether a KEY= capability bitmap, printed as hex
+// words with the highest first, includes KEY_Q to KEY_P (16 to 25), which
+// power buttons and media remotes lack
+func hasLetterKeys(words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	// Codes 0 to 63 sit in the last word on 64-bit and the last two on 32-bit
+	low, err := strconv.ParseUint(words[len(words)-1], 16, 64)
+	if err != nil {
+		return false
+	}
+	const letters = uint64(0x3FF) << 16
+	return low&letters == letters
+}
+
+// keyEvent decodes a key event from one struct input_event, reporting false
+// for any other event type
+func keyEvent(buf []byte) (code uint16, value int32, ok bool) {
+	tail := buf[len(buf)-8:]
+	if binary.LittleEndian.Uint16(tail[0:2]) != evKey {
+		return 0, 0, false
+	}
+	return binary.LittleEndian.Uint16(tail[2:4]), int32(binary.LittleEndian.Uint32(tail[4:8])), true
+}
+
+// comboState tracks which keys are held across all keyboards
+type comboState struct {
+	mu    sync.Mutex
+	codes [][]uint16
+	held  map[uint16]bool
+}
+
+func newComboState(codes [][]uint16) *comboState {
+	return &comboState{codes: codes, held: make(map[uint16]bool)}
+}
+
+// watches reports whether code is one of the combination's keys
+func (c *comboState) watches(code uint16) bool {
+	for _, alternatives := range c.codes {
+		for _, want := range alternatives {
+			if want == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// update records a key event and reports whether the whole combination is
+// now held; auto-repeat events change nothing
+func (c *comboState) update(code uint16, value int32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch value {
+	case keyPressed:
+		c.held[code] = true
+	case keyReleased:
+		delete(c.held, code)
+	}
+	for _, alternatives := range c.codes {
+		down := false
+		for _, code := range alternatives {
+			if c.held[code] {
+				down = true
+				break
+			}
+		}
+		if !down {
+			return false
+		}
+	}
+	return true
+}
+
+// setupEvdevHotkey reads key events straight from the keyboards' event
+// devices, which works on any compositor but needs read access to
+// /dev/input, usually by being in the input group. It fails, leaving the
+// caller to fall back, when no keyboard can be opened.
+func (l *Listener) setupEvdevHotkey() error {
+	keys, err := parseCombo(l.hotkey)
+	if err != nil {
+		return err
+	}
+	codes, err := evdevCombo(keys)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(inputDevices)
+	if err != nil {
+		return fmt.Errorf("cannot list input devices: %w", err)
+	}
+	var files []*os.File
+	var openErr error
+	for _, event := range keyboardEvents(string(data)) {
+		f, err := os.Open(filepath.Join("/dev/input", event))
+		if err != nil {
+			openErr = err
+			continue
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		if openErr != nil {
+			return fmt.Errorf("cannot read keyboards (add yourself to the input group): %w", openErr)
+		}
+		return fmt.Errorf("no keyboard found in %s", inputDevices)
+	}
+
+	log.Printf("Using evdev for hotkey detection (%d keyboards, codes %v)", len(files), codes)
+	l.debug(EventKeycodes, "evdev keyboards=%d hotkey=%s codes=%v", len(files), l.hotkey, codes)
+
+	// Keys held before the devices were opened never read as down, so the
+	// press that launched the app can't fire and there is nothing to wait for
+	state := newComboState(codes)
+	tracker := l.newTracker()
+	var trackerMu sync.Mutex
+	for _, f := range files {
+		go l.readEvdev(f, state, tracker, &trackerMu)
+	}
+	go func() {
+		<-l.stopChan
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	return nil
+}
+
+// readEvdev feeds one keyboard's key events to the shared combination
+// state until the device is closed
+func (l *Listener) readEvdev(f *os.File, state *comboState, tracker *pressTracker, trackerMu *sync.Mutex) {
+	buf := make([]byte, inputEventSize)
+	for {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			return
+		}
+		code, value, ok := keyEvent(buf)
+		if !ok || value == keyRepeated {
+			continue
+		}
+		// Only the combination's own keys are logged, never what is typed
+		if state.watches(code) {
+			l.debug(EventState, "key %d %s", code, upDown(value == keyPressed))
+		}
+
+		trackerMu.Lock()
+		edge := tracker.update(state.update(code, value), time.Now())
+		trackerMu.Unlock()
+		switch edge {
+		case edgePress:
+			log.Printf("Hotkey Detected: %s", l.hotkey)
+			l.firePress()
+		case edgeRelease:
+			l.fireRelease()
+		case edgeSuppressed:
+			l.debug(EventSuppressed, "pressed within %v of the last toggle", tracker.debounce)
+		}
+	}
+}
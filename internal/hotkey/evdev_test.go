@@ -0,0 +1,116 @@
+package hotkey
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestEvdevCombo(t *testing.T) {
+	testCases := []struct {
+		hotkey   string
+		expected [][]uint16
+		wantErr  bool
+	}{
+		{"ctrl+space", [][]uint16{{29, 97}, {57}}, false},
+		{"alt+shift+d", [][]uint16{{56, 100}, {42, 54}, {32}}, false},
+		{"super+a", [][]uint16{{125, 126}, {30}}, false},
+		{"F9", [][]uint16{{67}}, false},
+		{"F13", [][]uint16{{183}}, false},
+		{"ctrl+0", [][]uint16{{29, 97}, {11}}, false},
+		{"XF86AudioMute", nil, true},
+	}
+
+	for _, tc := range testCases {
+		keys, err := parseCombo(tc.hotkey)
+		if err != nil {
+			t.Fatalf("parseCombo(%q) failed: %v", tc.hotkey, err)
+		}
+		got, err := evdevCombo(keys)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("evdevCombo(%q) error = %v, expected error %v", tc.hotkey, err, tc.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("evdevCombo(%q) = %v, expected %v", tc.hotkey, got, tc.expected)
+		}
+	}
+}
+
+func TestKeyboardEvents(t *testing.T) {
+	devices := `I: Bus=0019 Vendor=0000 Product=0001 Version=0000
+N: Name="Power Button"
+H: Handlers=kbd event0
+B: EV=3
+B: KEY=10000000000000 0
+
+I: Bus=0011 Vendor=0001 Product=0001 Version=ab41
+N: Name="AT Translated Set 2 keyboard"
+H: Handlers=sysrq kbd leds event3
+B: EV=120013
+B: KEY=402000000 3803078f800d001 feffffdfffefffff fffffffffffffffe
+
+I: Bus=0003 Vendor=046d Product=c52b Version=0111
+N: Name="Logitech USB Receiver Mouse"
+H: Handlers=mouse0 event5
+B: EV=17
+B: KEY=ffff0000 0 0 0 0
+
+I: Bus=0003 Vendor=04d9 Product=0169 Version=0110
+N: Name="USB Keyboard"
+H: Handlers=sysrq kbd leds event7
+B: EV=120013
+B: KEY=1000000000007 ff9f207ac14057ff febeffdfffefffff fffffffffffffffe
+`
+	expected := []string{"event3", "event7"}
+	if got := keyboardEvents(devices); !reflect.DeepEqual(got, expected) {
+		t.Errorf("keyboardEvents() = %v, expected %v", got, expected)
+	}
+}
+
+func TestKeyEvent(t *testing.T) {
+	event := func(typ, code uint16, value int32) []byte {
+		buf := make([]byte, inputEventSize)
+		tail := buf[len(buf)-8:]
+		binary.LittleEndian.PutUint16(tail[0:2], typ)
+		binary.LittleEndian.PutUint16(tail[2:4], code)
+		binary.LittleEndian.PutUint32(tail[4:8], uint32(value))
+		return buf
+	}
+
+	code, value, ok := keyEvent(event(evKey, 57, keyPressed))
+	if !ok || code != 57 || value != keyPressed {
+		t.Errorf("Expected space pressed, got code=%d value=%d ok=%v", code, value, ok)
+	}
+	// EV_SYN reports are not key events
+	if _, _, ok := keyEvent(event(0, 0, 0)); ok {
+		t.Error("Expected a sync event to be skipped")
+	}
+}
+
+func TestComboState(t *testing.T) {
+	state := newComboState([][]uint16{{29, 97}, {57}})
+
+	steps := []struct {
+		code     uint16
+		value    int32
+		expected bool
+	}{
+		{57, keyPressed, false},  // space alone
+		{29, keyPressed, true},   // left ctrl completes it
+		{57, keyRepeated, true},  // auto-repeat changes nothing
+		{97, keyPressed, true},   // right ctrl as well
+		{29, keyReleased, true},  // right ctrl still holds
+		{97, keyReleased, false}, // no ctrl left
+		{30, keyPressed, false},  // unrelated key
+	}
+
+	for i, step := range steps {
+		if got := state.update(step.code, step.value); got != step.expected {
+			t.Errorf("Step %d (key %d value %d): expected %v, got %v", i, step.code, step.value, step.expected, got)
+		}
+	}
+	if !state.watches(97) || state.watches(30) {
+		t.Error("Expected only the combination's keys to be watched")
+	}
+}
@@ -0,0 +1,71 @@
+package hotkey
+
+import (
+	"sync"
+	"time"
+)
+
+// Gate filters toggle requests so synthetic key events don't re-trigger recording.
+// It combines a debounce window between toggles with a cooldown that starts
+// after text delivery, when the injected Ctrl+V or Enter can be misread as the hotkey.
+type Gate struct {
+	mu            sync.Mutex
+	debounce      time.Duration
+	cooldown      time.Duration
+	lastToggle    time.Time
+	cooldownUntil time.Time
+	now           func() time.Time
+}
+
+// NewGate creates a gate with the given debounce and post-typing cooldown
+func NewGate(debounce, cooldown time.Duration) *Gate {
+	return &Gate{
+		debounce: debounce,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether a toggle may proceed and records it if so
+func (g *Gate) Allow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if now.Before(g.cooldownUntil) {
+		return false
+	}
+	if now.Sub(g.lastToggle) < g.debounce {
+		return false
+	}
+	g.lastToggle = now
+	return true
+}
+
+// AllowHold is Allow for a push-to-talk press: only the post-typing cooldown
+// applies, as the debounce would swallow a quick second tap
+func (g *Gate) AllowHold() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if now.Before(g.cooldownUntil) {
+		return false
+	}
+	g.lastToggle = now
+	return true
+}
+
+// Touch records a toggle without checking, e.g. for the hotkey that launched the app
+func (g *Gate) Touch() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastToggle = g.now()
+}
+
+// StartCooldown ignores toggles for the cooldown period, starting now
+func (g *Gate) StartCooldown() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cooldownUntil = g.now().Add(g.cooldown)
+}
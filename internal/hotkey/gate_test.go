@@ -0,0 +1,70 @@
+package hotkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGateCooldown(t *testing.T) {
+	now := time.Unix(1000, 0)
+	g := NewGate(600*time.Millisecond, 1500*time.Millisecond)
+	g.now = func() time.Time { return now }
+
+	if !g.Allow() {
+		t.Fatal("Expected first toggle to be allowed")
+	}
+
+	now = now.Add(200 * time.Millisecond)
+	if g.Allow() {
+		t.Error("Expected toggle within debounce window to be ignored")
+	}
+
+	now = now.Add(time.Second)
+	g.StartCooldown()
+
+	for _, offset := range []time.Duration{0, 700 * time.Millisecond, 1400 * time.Millisecond} {
+		now2 := now.Add(offset)
+		g.now = func() time.Time { return now2 }
+		if g.Allow() {
+			t.Errorf("Expected press %v into cooldown to be ignored", offset)
+		}
+	}
+
+	after := now.Add(1600 * time.Millisecond)
+	g.now = func() time.Time { return after }
+	if !g.Allow() {
+		t.Error("Expected toggle after cooldown to be allowed")
+	}
+}
+
+func TestGateTouchDebouncesLaunchKey(t *testing.T) {
+	now := time.Unix(1000, 0)
+	g := NewGate(600*time.Millisecond, 0)
+	g.now = func() time.Time { return now }
+
+	g.Touch()
+	now = now.Add(100 * time.Millisecond)
+	if g.Allow() {
+		t.Error("Expected toggle right after Touch to be debounced")
+	}
+}
+
+func TestGateAllowHoldSkipsDebounce(t *testing.T) {
+	now := time.Unix(1000, 0)
+	g := NewGate(600*time.Millisecond, 1500*time.Millisecond)
+	g.now = func() time.Time { return now }
+
+	if !g.AllowHold() {
+		t.Fatal("Expected first hold to be allowed")
+	}
+	now = now.Add(100 * time.Millisecond)
+	if !g.AllowHold() {
+		t.Error("Expected a quick second hold to be allowed")
+	}
+
+	g.StartCooldown()
+	now = now.Add(time.Second)
+	if g.AllowHold() {
+		t.Error("Expected a hold during the cooldown to be ignored")
+	}
+}
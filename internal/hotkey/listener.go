@@ -3,20 +3,27 @@ package hotkey
 import (
 	"fmt"
 	"log"
-	"os"
 	"os/exec"
 	"strings"
 	"sync"
 	"time"
 
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
 	"speek_to_text_linux/pkg/errors"
 )
 
 type Listener struct {
 	errHandler *errors.Handler
 	hotkey     string
+	mode       string // ModeToggle or ModePushToTalk
+	debounce   time.Duration
+	skipHeld   bool // pollers ignore a hotkey held at startup until released
 	onPress    func()
 	onRelease  func()
+	onDebug    func(Event)
+	method     string   // detection method in use, for Diagnostics
+	fallbacks  []string // why better methods were skipped
 	isRunning  bool
 	mu         sync.Mutex
 	stopChan   chan struct{}
@@ -25,34 +32,55 @@ type Listener struct {
 func NewListener(errHandler *errors.Handler) *Listener {
 	return &Listener{
 		errHandler: errHandler,
+		mode:       ModeToggle,
+		debounce:   DefaultDebounce,
+		skipHeld:   true,
 	}
 }
 
+// Initialize starts detecting hotkey with the best method available. If
+// there is none, the listener still waits for xdotool to be installed but
+// Initialize returns an error wrapping errors.ErrNoHotkeyTool, so the user
+// can be told why the hotkey does nothing; Diagnostics has the details. So
+// does a hotkey with a key that has no keycode, which could never fire.
 func (l *Listener) Initialize(hotkey string) error {
 	l.hotkey = hotkey
 	l.stopChan = make(chan struct{})
 
-	if err := l.detectAndSetup(); err != nil {
+	if err := l.detectAndSetup(); errors.Is(err, errors.ErrNoHotkeyTool) {
+		return err
+	} else if err != nil {
 		return errors.Wrap(err, errors.ErrorTypeHotkey, "failed to setup hotkey")
 	}
 
 	log.Printf("Hotkey listener initialized: %s", hotkey)
+	if l.getMethod() == MethodNone {
+		return errors.Wrap(errors.ErrNoHotkeyTool, errors.ErrorTypeHotkey, installHint())
+	}
 	return nil
 }
 
 func (l *Listener) detectAndSetup() error {
-	if strings.Contains(os.Getenv("WAYLAND_DISPLAY"), "wayland") {
-		// Try xdotool first (more reliable)
-		if l.isToolAvailable("xdotool") {
-			log.Println("Using xdotool for hotkey detection (Wayland)")
-			go l.pollKeyPressX11()
+	if session.IsWayland() {
+		// Reading /dev/input directly works under every compositor
+		err := l.setupEvdevHotkey()
+		if err == nil {
+			l.setMethod(MethodEvdev)
 			return nil
 		}
+		log.Printf("evdev hotkey unavailable, falling back to polling: %v", err)
+		l.noteFallback("evdev unavailable: %v", err)
+
+		// Then xdotool, which sees XWayland key state
+		if toolpath.AvailableWith(lookPath, "xdotool") {
+			log.Println("Using xdotool for hotkey detection (Wayland)")
+			return l.setupXinputPolling()
+		}
 		// Fall back to ydotool
 		return l.setupWaylandHotkey()
 	}
 
-	if strings.Contains(os.Getenv("DISPLAY"), ":") {
+	if session.IsX11() {
 		return l.setupX11Hotkey()
 	}
 
@@ -60,39 +88,82 @@ func (l *Listener) detectAndSetup() error {
 }
 
 func (l *Listener) setupX11Hotkey() error {
-	if l.isToolAvailable("xdotool") {
+	if toolpath.AvailableWith(lookPath, "xdotool") {
 		log.Println("Using xdotool for hotkey detection")
-		go l.pollKeyPressX11()
-		return nil
+		return l.setupXinputPolling()
 	}
 
 	log.Println("Warning: No hotkey tool found. Install xdotool: sudo apt install xdotool")
+	l.noteFallback("xdotool not installed")
+	l.setMethod(MethodNone)
 	go l.pollKeyPressGeneric()
 	return nil
 }
 
-func (l *Listener) pollKeyPressX11() {
+// setupXinputPolling finds the keyboard and the keycodes of every key of the
+// hotkey before polling their state with xinput, so a key that can't be
+// resolved fails Initialize with errors.ErrNoHotkeyTool
+func (l *Listener) setupXinputPolling() error {
 	keyboardID := l.findKeyboardID()
 	if keyboardID == "" {
 		log.Println("Could not find keyboard ID, falling back to generic polling")
-		l.pollKeyPressGeneric()
-		return
+		l.noteFallback("no keyboard found by xinput")
+		l.setMethod(MethodNone)
+		go l.pollKeyPressGeneric()
+		return nil
+	}
+
+	keys, codes, err := l.comboKeycodes()
+	if err != nil {
+		l.setMethod(MethodNone)
+		return err
 	}
 
-	// Dynamically find keycodes
-	ctrlCodes := l.resolveKeycodes("Control_L", "Control_R")
-	spaceCodes := l.resolveKeycodes("space")
+	log.Printf("Monitoring keyboard ID %s for hotkey %s (keycodes: %v)", keyboardID, l.hotkey, codes)
+	l.debug(EventKeycodes, "keyboard id=%s hotkey=%s codes=%v", keyboardID, l.hotkey, codes)
+	l.setMethod(MethodXinput)
+	go l.pollKeyPressX11(keyboardID, keys, codes)
+	return nil
+}
 
-	if len(ctrlCodes) == 0 || len(spaceCodes) == 0 {
-		log.Printf("Warning: Could not resolve keycodes (ctrl: %v, space: %v), using defaults (37, 105 for Ctrl, 65 for Space)", ctrlCodes, spaceCodes)
-		ctrlCodes = []string{"37", "105"} // Default for Ctrl_L, Ctrl_R
-		spaceCodes = []string{"65"}       // Default for Space
+// comboKeycodes resolves the X keycodes of every key of the hotkey, falling
+// back to the usual ones when xmodmap can't tell
+func (l *Listener) comboKeycodes() ([]comboKey, [][]string, error) {
+	keys, err := parseCombo(l.hotkey)
+	if err != nil {
+		log.Printf("%v, using ctrl+space", err)
+		if keys, err = parseCombo("ctrl+space"); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	log.Printf("Monitoring keyboard ID %s for hotkeys (Ctrl: %v, Space: %v)", keyboardID, ctrlCodes, spaceCodes)
+	// Dynamically find keycodes for every key of the combination
+	codes := make([][]string, len(keys))
+	for i, key := range keys {
+		codes[i] = l.resolveKeycodes(key.keysyms...)
+		if len(codes[i]) > 0 {
+			continue
+		}
+		for _, keysym := range key.keysyms {
+			codes[i] = append(codes[i], defaultKeycodes[keysym]...)
+		}
+		if len(codes[i]) == 0 {
+			log.Printf("Warning: Could not resolve a keycode for %q in hotkey %q; it cannot fire", key.name, l.hotkey)
+			l.debug(EventKeycodes, "resolution failed for %s (%v)", key.name, key.keysyms)
+			l.noteFallback("no keycode for %q", key.name)
+			return nil, nil, errors.Wrap(errors.ErrNoHotkeyTool, errors.ErrorTypeHotkey, fmt.Sprintf("no keycode for %q in hotkey %q", key.name, l.hotkey))
+		}
+		log.Printf("Warning: Could not resolve keycodes for %s, using defaults %v", key.name, codes[i])
+		l.debug(EventKeycodes, "resolution failed for %s, using defaults %v", key.name, codes[i])
+	}
+	return keys, codes, nil
+}
 
-	lastToggle := time.Now()
-	isPressed := false
+// pollKeyPressX11 fires the callbacks as the hotkey's keys, resolved by
+// comboKeycodes, go down and up on keyboardID
+func (l *Listener) pollKeyPressX11(keyboardID string, keys []comboKey, codes [][]string) {
+	tracker := l.newPollTracker()
+	wasDown := make([]bool, len(keys))
 
 	for {
 		select {
@@ -106,35 +177,26 @@ func (l *Listener) pollKeyPressX11() {
 		output, _ := cmd.CombinedOutput()
 		outputStr := string(output)
 
-		ctrlDown := false
-		for _, code := range ctrlCodes {
-			if strings.Contains(outputStr, "key["+code+"]=down") {
-				ctrlDown = true
-				break
+		allDown := true
+		for i, key := range keys {
+			down := anyKeyDown(outputStr, codes[i])
+			if down != wasDown[i] {
+				l.debug(EventState, "%s %s", key.name, upDown(down))
+				wasDown[i] = down
 			}
+			allDown = allDown && down
 		}
 
-		spaceDown := false
-		for _, code := range spaceCodes {
-			if strings.Contains(outputStr, "key["+code+"]=down") {
-				spaceDown = true
-				break
-			}
-		}
-
-		currentlyDown := ctrlDown && spaceDown
-
-		if currentlyDown && !isPressed {
-			// Key just pressed
-			if time.Since(lastToggle) > 400*time.Millisecond {
-				log.Println("Hotkey Detected: Ctrl + Space")
-				l.firePress()
-				isPressed = true
-				lastToggle = time.Now()
-			}
-		} else if !currentlyDown && isPressed {
-			// Key released
-			isPressed = false
+		switch tracker.update(allDown, time.Now()) {
+		case edgePress:
+			log.Printf("Hotkey Detected: %s", l.hotkey)
+			l.firePress()
+		case edgeRelease:
+			l.fireRelease()
+		case edgeSuppressed:
+			l.debug(EventSuppressed, "pressed within %v of the last toggle", tracker.debounce)
+		case edgeHeldAtLaunch:
+			l.debug(EventSuppressed, "held since launch, waiting for its release")
 		}
 
 		time.Sleep(40 * time.Millisecond)
@@ -142,30 +204,30 @@ func (l *Listener) pollKeyPressX11() {
 }
 
 func (l *Listener) resolveKeycodes(names ...string) []string {
-	var codes []string
 	cmd := exec.Command("xmodmap", "-pk")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		log.Printf("Warning: Could not run xmodmap to resolve keycodes: %v", err)
-		return codes
+		return nil
 	}
+	return keycodesFor(string(output), names...)
+}
 
-	lines := strings.Split(string(output), "\n")
+// keycodesFor finds the keycodes bound to any of the keysym names in xmodmap
+// output. A keysym must match whole, so "a" doesn't match "apostrophe".
+func keycodesFor(output string, names ...string) []string {
+	var codes []string
+	lines := strings.Split(output, "\n")
 	for _, name := range names {
-		// Prepare common keysym variations
-		patterns := []string{
-			" " + name + " ",
-			" " + name + ")",
-			"(" + name + ")",
-			"= " + name,
-		}
-
 		for _, line := range lines {
-			matched := false
-			for _, p := range patterns {
-				if strings.Contains(line, p) {
-					matched = true
-					break
+			// "38 0x0061 (a) ..." from -pk, "keycode 38 = a A" from -pke
+			matched := strings.Contains(line, "("+name+")")
+			if _, after, ok := strings.Cut(line, "="); ok && !matched {
+				for _, keysym := range strings.Fields(after) {
+					if keysym == name {
+						matched = true
+						break
+					}
 				}
 			}
 
@@ -224,9 +286,11 @@ func (l *Listener) pollKeyPressGeneric() {
 		default:
 		}
 
-		if l.isToolAvailable("xdotool") {
+		if toolpath.AvailableWith(lookPath, "xdotool") {
 			log.Println("xdotool detected, switching to xdotool polling")
-			go l.pollKeyPressX11()
+			if err := l.setupXinputPolling(); err != nil {
+				log.Printf("Hotkey unavailable: %v", err)
+			}
 			return
 		}
 
@@ -235,11 +299,14 @@ func (l *Listener) pollKeyPressGeneric() {
 }
 
 func (l *Listener) setupWaylandHotkey() error {
-	if l.isToolAvailable("ydotool") {
+	if toolpath.AvailableWith(lookPath, "ydotool") {
 		log.Println("Using ydotool for Wayland hotkey detection")
+		l.setMethod(MethodYdotool)
 		go l.pollKeyPressWayland()
 		return nil
 	}
+	l.noteFallback("neither xdotool nor ydotool installed")
+	l.setMethod(MethodNone)
 
 	log.Println("Warning: Using generic polling for Wayland hotkey detection")
 	log.Println("Please install ydotool for Wayland support")
@@ -251,6 +318,7 @@ func (l *Listener) pollKeyPressWayland() {
 	keyName := l.hotkeyToXdotool(l.hotkey)
 	log.Printf("Wayland polling for key: %s", keyName)
 
+	tracker := l.newPollTracker()
 	prevPressed := false
 
 	for {
@@ -265,15 +333,22 @@ func (l *Listener) pollKeyPressWayland() {
 		err := cmd.Run()
 
 		isPressed := err == nil
+		if isPressed != prevPressed {
+			l.debug(EventState, "%s %s", keyName, upDown(isPressed))
+			prevPressed = isPressed
+		}
 
-		if isPressed && !prevPressed {
+		switch tracker.update(isPressed, time.Now()) {
+		case edgePress:
 			log.Println("Hotkey pressed")
 			l.firePress()
-			prevPressed = true
-		} else if !isPressed && prevPressed {
+		case edgeRelease:
 			log.Println("Hotkey released")
 			l.fireRelease()
-			prevPressed = false
+		case edgeSuppressed:
+			l.debug(EventSuppressed, "pressed within %v of the last toggle", tracker.debounce)
+		case edgeHeldAtLaunch:
+			l.debug(EventSuppressed, "held since launch, waiting for its release")
 		}
 
 		time.Sleep(30 * time.Millisecond)
@@ -282,6 +357,8 @@ func (l *Listener) pollKeyPressWayland() {
 
 func (l *Listener) setupGlobalHotkey() error {
 	log.Println("Warning: No display detected, using fallback hotkey method")
+	l.noteFallback("no display detected")
+	l.setMethod(MethodNone)
 	go l.pollKeyPressGeneric()
 	return nil
 }
@@ -303,11 +380,6 @@ func (l *Listener) hotkeyToXdotool(hotkey string) string {
 	}
 }
 
-func (l *Listener) isToolAvailable(tool string) bool {
-	_, err := exec.LookPath(tool)
-	return err == nil
-}
-
 func (l *Listener) OnPress(callback func()) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -320,6 +392,15 @@ func (l *Listener) OnRelease(callback func()) {
 	l.onRelease = callback
 }
 
+// OnDebug sets a callback that receives every key-state transition and fire
+// event, for diagnosing a hotkey that doesn't trigger. It is called
+// synchronously from the polling loop, so events arrive in order.
+func (l *Listener) OnDebug(callback func(Event)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onDebug = callback
+}
+
 func (l *Listener) Start() error {
 	l.mu.Lock()
 	if l.isRunning {
@@ -361,7 +442,15 @@ func (l *Listener) GetHotkey() string {
 	return l.hotkey
 }
 
+// getMode returns the mode set by SetMode
+func (l *Listener) getMode() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mode
+}
+
 func (l *Listener) firePress() {
+	l.debug(EventPress, "%s", l.hotkey)
 	l.mu.Lock()
 	callback := l.onPress
 	l.mu.Unlock()
@@ -371,6 +460,7 @@ func (l *Listener) firePress() {
 }
 
 func (l *Listener) fireRelease() {
+	l.debug(EventRelease, "%s", l.hotkey)
 	l.mu.Lock()
 	callback := l.onRelease
 	l.mu.Unlock()
@@ -0,0 +1,167 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Hotkey modes accepted by SetMode
+const (
+	// ModeToggle starts recording on one press and stops it on the next
+	ModeToggle = "toggle"
+	// ModePushToTalk records while the hotkey is held down
+	ModePushToTalk = "push_to_talk"
+)
+
+// DefaultDebounce is the repeat guard in toggle mode: a second press this
+// soon after the last one is taken for key bounce or auto-repeat
+const DefaultDebounce = 400 * time.Millisecond
+
+// ParseMode normalizes a hotkey mode name; empty means ModeToggle
+func ParseMode(mode string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		return ModeToggle, nil
+	case "push-to-talk", "ptt", "hold":
+		return ModePushToTalk, nil
+	case ModeToggle, ModePushToTalk:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown hotkey mode %q (use toggle or push_to_talk)", mode)
+}
+
+// SetMode chooses between toggle and push-to-talk. In push-to-talk the
+// release callback fires when the key comes up, and presses aren't debounced
+// so a quick tap still records. Must be called before Initialize.
+func (l *Listener) SetMode(mode string) error {
+	parsed, err := ParseMode(mode)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.mode = parsed
+	return nil
+}
+
+// SetDebounce sets how soon after a press another one is ignored in toggle
+// mode, DefaultDebounce unless set; zero or negative turns it off. An app
+// that filters toggles again, as the GUI's Gate does, should give both the
+// same window: whichever is longer decides, and a press the listener lets
+// through but the app drops still restarts the listener's window. Must be
+// called before Initialize.
+func (l *Listener) SetDebounce(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debounce = d
+}
+
+// SetIgnoreLaunchPress chooses whether a hotkey already held when the
+// listener starts is ignored until it is released, on by default. The key
+// combination that launched the app through a desktop shortcut is often
+// still down when polling begins; without this its first poll would toggle
+// straight back. Must be called before Initialize.
+func (l *Listener) SetIgnoreLaunchPress(ignore bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.skipHeld = ignore
+}
+
+// newTracker returns a press tracker for the listener's mode and debounce
+func (l *Listener) newTracker() *pressTracker {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return newPressTracker(l.mode, l.debounce, time.Now())
+}
+
+// newPollTracker returns a press tracker for polling the key state, which
+// can find the hotkey already down, e.g. still held from launching the app.
+// With SetIgnoreLaunchPress it waits for that hold to end before arming.
+func (l *Listener) newPollTracker() *pressTracker {
+	tracker := l.newTracker()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tracker.unarmed = l.skipHeld
+	return tracker
+}
+
+// edge is what a change in the polled key state means for the callbacks
+type edge int
+
+const (
+	edgeNone edge = iota
+	edgePress
+	edgeRelease
+	edgeSuppressed
+	edgeHeldAtLaunch
+)
+
+// pressTracker turns polled key states into press and release edges
+type pressTracker struct {
+	pushToTalk bool
+	debounce   time.Duration
+	down       bool // key held at the last poll
+	fired      bool // the current hold fired a press
+	lastFire   time.Time
+	unarmed    bool // no release seen yet, so a down key was held at launch
+	polled     bool // update has been called
+}
+
+// newPressTracker returns a tracker for mode; the debounce window starts
+// now, as the app was likely launched by this very key
+func newPressTracker(mode string, debounce time.Duration, now time.Time) *pressTracker {
+	return &pressTracker{pushToTalk: mode == ModePushToTalk, debounce: debounce, lastFire: now}
+}
+
+// update takes the key state at time now. A release is only reported in
+// push-to-talk, and only for a hold whose press fired. Until an unarmed
+// tracker sees the key up, a down key is the hold that launched the app: it
+// fires nothing, and in push-to-talk its release ends the recording the
+// launch started, as does finding the key already up at the first poll, for
+// a tap let go before polling began.
+func (t *pressTracker) update(down bool, now time.Time) edge {
+	if t.unarmed {
+		first := !t.polled
+		t.polled = true
+		if down {
+			if t.down {
+				return edgeNone
+			}
+			t.down = true
+			return edgeHeldAtLaunch
+		}
+		t.unarmed = false
+		held := t.down || first
+		t.down = false
+		if held && t.pushToTalk {
+			return edgeRelease
+		}
+		return edgeNone
+	}
+
+	if down == t.down {
+		return edgeNone
+	}
+	t.down = down
+
+	if !down {
+		fired := t.fired
+		t.fired = false
+		if fired && t.pushToTalk {
+			return edgeRelease
+		}
+		return edgeNone
+	}
+
+	if !t.pushToTalk && now.Sub(t.lastFire) < t.debounce {
+		return edgeSuppressed
+	}
+	t.fired = true
+	t.lastFire = now
+	return edgePress
+}
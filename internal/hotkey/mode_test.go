@@ -0,0 +1,188 @@
+package hotkey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", ModeToggle, false},
+		{"toggle", ModeToggle, false},
+		{" Push_To_Talk ", ModePushToTalk, false},
+		{"push-to-talk", ModePushToTalk, false},
+		{"ptt", ModePushToTalk, false},
+		{"double-tap", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseMode(tc.input)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("ParseMode(%q) = %q, %v; expected %q, error %v", tc.input, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestPressTracker(t *testing.T) {
+	type step struct {
+		at   time.Duration // since the tracker was created
+		down bool
+		want edge
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		debounce time.Duration // DefaultDebounce if zero
+		steps    []step
+	}{
+		{"toggle fires presses only", ModeToggle, 0, []step{
+			{500 * time.Millisecond, true, edgePress},
+			{600 * time.Millisecond, true, edgeNone},
+			{700 * time.Millisecond, false, edgeNone},
+			{1500 * time.Millisecond, true, edgePress},
+		}},
+		{"toggle debounces a quick second press", ModeToggle, 0, []step{
+			{500 * time.Millisecond, true, edgePress},
+			{550 * time.Millisecond, false, edgeNone},
+			{700 * time.Millisecond, true, edgeSuppressed},
+			{750 * time.Millisecond, false, edgeNone},
+		}},
+		{"toggle ignores the launching key", ModeToggle, 0, []step{
+			{100 * time.Millisecond, true, edgeSuppressed},
+		}},
+		{"shorter debounce allows a quick stop-then-start", ModeToggle, 200 * time.Millisecond, []step{
+			{300 * time.Millisecond, true, edgePress},
+			{350 * time.Millisecond, false, edgeNone},
+			{450 * time.Millisecond, true, edgeSuppressed},
+			{480 * time.Millisecond, false, edgeNone},
+			{550 * time.Millisecond, true, edgePress},
+		}},
+		{"push-to-talk pairs press and release", ModePushToTalk, 0, []step{
+			{500 * time.Millisecond, true, edgePress},
+			{2 * time.Second, true, edgeNone},
+			{3 * time.Second, false, edgeRelease},
+		}},
+		{"push-to-talk keeps a short tap", ModePushToTalk, 0, []step{
+			{50 * time.Millisecond, true, edgePress},
+			{90 * time.Millisecond, false, edgeRelease},
+			{130 * time.Millisecond, true, edgePress},
+			{170 * time.Millisecond, false, edgeRelease},
+		}},
+		{"release without a press", ModePushToTalk, 0, []step{
+			{100 * time.Millisecond, false, edgeNone},
+		}},
+	}
+
+	start := time.Now()
+	for _, tc := range testCases {
+		debounce := tc.debounce
+		if debounce == 0 {
+			debounce = DefaultDebounce
+		}
+		tracker := newPressTracker(tc.mode, debounce, start)
+		for i, s := range tc.steps {
+			if got := tracker.update(s.down, start.Add(s.at)); got != s.want {
+				t.Errorf("%s: step %d (down=%v at %v) = %v, expected %v", tc.name, i, s.down, s.at, got, s.want)
+			}
+		}
+	}
+}
+
+func TestPressTrackerArmsAfterRelease(t *testing.T) {
+	type step struct {
+		at   time.Duration // since the tracker was created
+		down bool
+		want edge
+	}
+
+	testCases := []struct {
+		name  string
+		mode  string
+		steps []step
+	}{
+		{"toggle ignores a hold from launch", ModeToggle, []step{
+			{0, true, edgeHeldAtLaunch},
+			{2 * time.Second, true, edgeNone},
+			{3 * time.Second, false, edgeNone},
+			{4 * time.Second, true, edgePress},
+		}},
+		{"push-to-talk ends the launch recording on release", ModePushToTalk, []step{
+			{0, true, edgeHeldAtLaunch},
+			{time.Second, false, edgeRelease},
+			{2 * time.Second, true, edgePress},
+			{3 * time.Second, false, edgeRelease},
+		}},
+		{"key up at launch arms at once", ModeToggle, []step{
+			{0, false, edgeNone},
+			{time.Second, true, edgePress},
+		}},
+		{"push-to-talk tap let go before the first poll", ModePushToTalk, []step{
+			{0, false, edgeRelease},
+			{time.Second, true, edgePress},
+			{2 * time.Second, false, edgeRelease},
+		}},
+		{"still debounced after arming", ModeToggle, []step{
+			{0, true, edgeHeldAtLaunch},
+			{100 * time.Millisecond, false, edgeNone},
+			{200 * time.Millisecond, true, edgeSuppressed},
+		}},
+	}
+
+	start := time.Now()
+	for _, tc := range testCases {
+		tracker := newPressTracker(tc.mode, DefaultDebounce, start)
+		tracker.unarmed = true
+		for i, s := range tc.steps {
+			if got := tracker.update(s.down, start.Add(s.at)); got != s.want {
+				t.Errorf("%s: step %d (down=%v at %v) = %v, expected %v", tc.name, i, s.down, s.at, got, s.want)
+			}
+		}
+	}
+}
+
+func TestSetIgnoreLaunchPress(t *testing.T) {
+	l := NewListener(nil)
+	if !l.newPollTracker().unarmed {
+		t.Error("Expected polling to wait for a launch hold by default")
+	}
+	if l.newTracker().unarmed {
+		t.Error("Expected evdev's tracker to start armed")
+	}
+	l.SetIgnoreLaunchPress(false)
+	if l.newPollTracker().unarmed {
+		t.Error("Expected polling to start armed when turned off")
+	}
+}
+
+func TestSetDebounce(t *testing.T) {
+	l := NewListener(nil)
+	if tracker := l.newTracker(); tracker.debounce != DefaultDebounce {
+		t.Errorf("Expected the default debounce, got %v", tracker.debounce)
+	}
+	l.SetDebounce(200 * time.Millisecond)
+	if tracker := l.newTracker(); tracker.debounce != 200*time.Millisecond {
+		t.Errorf("Expected 200ms, got %v", tracker.debounce)
+	}
+	l.SetDebounce(-time.Second)
+	if tracker := l.newTracker(); tracker.debounce != 0 {
+		t.Errorf("Expected a negative debounce to turn it off, got %v", tracker.debounce)
+	}
+}
+
+func TestSetModeRejectsUnknown(t *testing.T) {
+	l := NewListener(nil)
+	if err := l.SetMode("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	if l.getMode() != ModeToggle {
+		t.Errorf("Expected the default mode to be kept, got %q", l.getMode())
+	}
+	if err := l.SetMode("push_to_talk"); err != nil || l.getMode() != ModePushToTalk {
+		t.Errorf("Expected push-to-talk, got %q, %v", l.getMode(), err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package journal appends transcriptions to a daily notes file
+package journal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"speek_to_text_linux/internal/retention"
+)
+
+// Journal writes each transcription to <dir>/<YYYY-MM-DD>.md
+type Journal struct {
+	mu     sync.Mutex
+	dir    string
+	now    func() time.Time
+	policy retention.Policy
+	// minFree is the free space required before writing; 0 disables the check
+	minFree int64
+}
+
+// New creates a journal in dir. A leading "~/" is expanded to the home directory.
+func New(dir string) *Journal {
+	return &Journal{dir: expandHome(dir), now: time.Now}
+}
+
+// SetRetention limits the journal. MaxEntries and MaxBytes count entries
+// across all daily files, so a single busy day is bounded too, and MaxAge
+// goes by the date in each file's name. The oldest entries are pruned by
+// Prune and after every Append.
+func (j *Journal) SetRetention(p retention.Policy) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.policy = p
+}
+
+// SetMinFreeBytes makes Append skip writing, after pruning old files, when
+// less than n bytes are free on the journal's disk. Zero disables the check.
+func (j *Journal) SetMinFreeBytes(n int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.minFree = n
+}
+
+// Prune removes the entries that violate the retention policy
+func (j *Journal) Prune() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.prune()
+}
+
+func (j *Journal) prune() error {
+	removed, err := retention.PruneDated(j.dir, dayPattern, dayOf, retention.Policy{MaxAge: j.policy.MaxAge})
+	if len(removed) > 0 {
+		log.Printf("Journal retention removed %d old file(s)", len(removed))
+	}
+	if err != nil {
+		return err
+	}
+	dropped, err := j.trim()
+	if dropped > 0 {
+		log.Printf("Journal retention removed %d old entries", dropped)
+	}
+	return err
+}
+
+// trim keeps the newest MaxEntries entries within MaxBytes, walking the daily
+// files newest first. The file where a limit is reached loses its oldest
+// entries and every earlier file is removed. The newest entry is always kept.
+func (j *Journal) trim() (int, error) {
+	if j.policy.MaxEntries <= 0 && j.policy.MaxBytes <= 0 {
+		return 0, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(j.dir, dayPattern))
+	if err != nil {
+		return 0, err
+	}
+	days := paths[:0]
+	for _, path := range paths {
+		if _, ok := dayOf(filepath.Base(path)); ok {
+			days = append(days, path)
+		}
+	}
+	// YYYY-MM-DD names sort by date
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+
+	entries, size := 0, int64(0)
+	for i, path := range days {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read journal file: %w", err)
+		}
+		sections := splitEntries(string(data))
+		keep := len(sections)
+		for k := len(sections) - 1; k >= 0; k-- {
+			over := (j.policy.MaxEntries > 0 && entries+1 > j.policy.MaxEntries) ||
+				(j.policy.MaxBytes > 0 && size+int64(len(sections[k])) > j.policy.MaxBytes)
+			if over && entries > 0 {
+				keep = len(sections) - 1 - k
+				break
+			}
+			entries++
+			size += int64(len(sections[k]))
+		}
+		if keep == len(sections) {
+			continue
+		}
+
+		dropped := len(sections) - keep
+		if keep == 0 {
+			err = os.Remove(path)
+		} else {
+			err = writeFile(path, strings.Join(sections[dropped:], ""))
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, older := range days[i+1:] {
+			data, err := os.ReadFile(older)
+			if err != nil {
+				return dropped, fmt.Errorf("failed to read journal file: %w", err)
+			}
+			if err := os.Remove(older); err != nil && !os.IsNotExist(err) {
+				return dropped, err
+			}
+			dropped += len(splitEntries(string(data)))
+		}
+		return dropped, nil
+	}
+	return 0, nil
+}
+
+// writeFile replaces path with content without leaving it half written
+func writeFile(path, content string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+	return nil
+}
+
+// dayPattern matches the daily files
+const dayPattern = "????-??-??.md"
+
+// dayOf returns the end of the day a daily file is named for, the last time
+// it could have been written to, so MaxAge counts from its newest entry
+func dayOf(name string) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(name, ".md"), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.AddDate(0, 0, 1), true
+}
+
+// entryHeader matches the header FormatEntry starts each entry with
+var entryHeader = regexp.MustCompile(`(?m)^## \d{2}:\d{2}:\d{2}\n`)
+
+// splitEntries splits a daily file into its entries. Text before the first
+// header, e.g. a note added by hand, stays with the first entry.
+func splitEntries(content string) []string {
+	starts := entryHeader.FindAllStringIndex(content, -1)
+	if len(starts) == 0 {
+		if content == "" {
+			return nil
+		}
+		return []string{content}
+	}
+	sections := make([]string, 0, len(starts))
+	for i := range starts {
+		from, to := starts[i][0], len(content)
+		if i == 0 {
+			from = 0
+		}
+		if i+1 < len(starts) {
+			to = starts[i+1][0]
+		}
+		sections = append(sections, content[from:to])
+	}
+	return sections
+}
+
+// PathFor returns the journal file for the day containing t
+func (j *Journal) PathFor(t time.Time) string {
+	return filepath.Join(j.dir, t.Format("2006-01-02")+".md")
+}
+
+// Append writes text to today's file under a timestamp header, creating the
+// directory and file as needed. The date is taken per entry, so a session
+// running past midnight rolls over to the next day's file.
+func (j *Journal) Append(text string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := retention.CheckSpace(j.dir, j.minFree); err != nil {
+		// Pruning old days may free enough to write today's entry
+		if err := j.prune(); err != nil {
+			log.Printf("Journal retention failed: %v", err)
+		}
+		if err := retention.CheckSpace(j.dir, j.minFree); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	now := j.now()
+	f, err := os.OpenFile(j.PathFor(now), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(FormatEntry(now, text)); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return j.prune()
+}
+
+// FormatEntry renders a single entry as a markdown section
+func FormatEntry(t time.Time, text string) string {
+	return "## " + t.Format("15:04:05") + "\n\n" + strings.TrimSpace(text) + "\n\n"
+}
+
+func expandHome(dir string) string {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~"))
+}
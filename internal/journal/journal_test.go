@@ -0,0 +1,152 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"speek_to_text_linux/internal/retention"
+)
+
+func TestPathFor(t *testing.T) {
+	j := New("/notes")
+	got := j.PathFor(time.Date(2024, 1, 15, 23, 59, 0, 0, time.UTC))
+	if got != filepath.Join("/notes", "2024-01-15.md") {
+		t.Errorf("Expected /notes/2024-01-15.md, got %s", got)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"~/notes", "/home/user/notes"},
+		{"~", "/home/user"},
+		{"/abs/notes", "/abs/notes"},
+		{"~other/notes", "~other/notes"},
+	}
+	for _, tt := range tests {
+		if got := expandHome(tt.in); got != tt.want {
+			t.Errorf("expandHome(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatEntry(t *testing.T) {
+	got := FormatEntry(time.Date(2024, 1, 15, 9, 5, 3, 0, time.UTC), "  hello world\n")
+	want := "## 09:05:03\n\nhello world\n\n"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestAppendRollsOverAtMidnight(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "notes")
+	j := New(dir)
+
+	times := []time.Time{
+		time.Date(2024, 1, 15, 23, 59, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 23, 59, 30, 0, time.UTC),
+		time.Date(2024, 1, 16, 0, 0, 10, 0, time.UTC),
+	}
+	for i, text := range []string{"one", "two", "three"} {
+		now := times[i]
+		j.now = func() time.Time { return now }
+		if err := j.Append(text); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	day1, err := os.ReadFile(filepath.Join(dir, "2024-01-15.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## 23:59:00\n\none\n\n## 23:59:30\n\ntwo\n\n"; string(day1) != want {
+		t.Errorf("Expected %q, got %q", want, day1)
+	}
+
+	day2, err := os.ReadFile(filepath.Join(dir, "2024-01-16.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## 00:00:10\n\nthree\n\n"; string(day2) != want {
+		t.Errorf("Expected %q, got %q", want, day2)
+	}
+}
+
+func TestAppendPrunesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	j := New(dir)
+	j.SetRetention(retention.Policy{MaxEntries: 1})
+
+	old := filepath.Join(dir, "2024-01-14.md")
+	if err := os.WriteFile(old, []byte("## 10:00:00\n\nold\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Append("new"); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected previous day's file to be pruned")
+	}
+	if _, err := os.Stat(j.PathFor(time.Now())); err != nil {
+		t.Errorf("Expected today's file to be kept: %v", err)
+	}
+}
+
+func TestAppendCapsTodaysEntries(t *testing.T) {
+	dir := t.TempDir()
+	j := New(dir)
+	j.SetRetention(retention.Policy{MaxEntries: 2})
+	clock := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	j.now = func() time.Time { return clock }
+
+	for i, text := range []string{"one", "two", "three"} {
+		clock = clock.Add(time.Duration(i) * time.Second)
+		if err := j.Append(text); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(j.PathFor(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## 09:00:01\n\ntwo\n\n## 09:00:03\n\nthree\n\n"; string(data) != want {
+		t.Errorf("Expected %q, got %q", want, data)
+	}
+}
+
+func TestPruneAgeUsesFileName(t *testing.T) {
+	dir := t.TempDir()
+	j := New(dir)
+	j.SetRetention(retention.Policy{MaxAge: 7 * 24 * time.Hour})
+
+	// A copied file has a fresh mtime but its name still dates it
+	old := filepath.Join(dir, "2000-01-01.md")
+	if err := os.WriteFile(old, []byte("## 10:00:00\n\nold\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	today := j.PathFor(time.Now())
+	if err := os.WriteFile(today, []byte("## 10:00:00\n\nnew\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Prune(); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected the file named for 2000-01-01 to be pruned")
+	}
+	if _, err := os.Stat(today); err != nil {
+		t.Errorf("Expected today's file to be kept: %v", err)
+	}
+}
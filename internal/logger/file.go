@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"speek_to_text_linux/pkg/config"
+)
+
+// LogFileName is the debug log written next to config.json
+const LogFileName = "debug.log"
+
+// LogPath returns the debug log path, in the same directory as the config file
+func LogPath() (string, error) {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), LogFileName), nil
+}
+
+// Tail returns at most the last maxBytes of the file at path, starting at a
+// line boundary. A missing file reads as empty.
+func Tail(path string, maxBytes int64) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// Start one byte early so a window that begins exactly on a line keeps it
+	offset := info.Size() - maxBytes - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop the partial line the window starts in
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return string(data), nil
+}
+
+// Clear truncates the log file
+func Clear(path string) error {
+	err := os.Truncate(path, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogPathNextToConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := LogPath()
+	if err != nil {
+		t.Fatalf("LogPath failed: %v", err)
+	}
+	want := filepath.Join(home, ".config", "voicetype", "debug.log")
+	if path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+	if err := os.WriteFile(path, []byte("first line\nsecond line\nthird\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		maxBytes int64
+		want     string
+	}{
+		{1000, "first line\nsecond line\nthird\n"},
+		{17, "third\n"},
+		{18, "second line\nthird\n"},
+	}
+	for _, tt := range tests {
+		got, err := Tail(path, tt.maxBytes)
+		if err != nil {
+			t.Fatalf("Tail failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Tail(%d) = %q, want %q", tt.maxBytes, got, tt.want)
+		}
+	}
+}
+
+func TestTailMissingFile(t *testing.T) {
+	got, err := Tail(filepath.Join(t.TempDir(), "missing.log"), 100)
+	if err != nil || got != "" {
+		t.Errorf("Expected empty tail for missing file, got %q, %v", got, err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+	if err := os.WriteFile(path, []byte("line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Clear(path); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if got, _ := Tail(path, 100); got != "" {
+		t.Errorf("Expected empty log after Clear, got %q", got)
+	}
+}
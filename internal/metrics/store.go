@@ -0,0 +1,114 @@
+// Package metrics keeps local, telemetry-free success/error counters
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"speek_to_text_linux/pkg/config"
+	"speek_to_text_linux/pkg/errors"
+)
+
+// Counter names
+const (
+	TranscriptionsOK = "transcriptions_ok"
+	AudioErrors      = "audio_errors"
+	TypingErrors     = "typing_errors"
+	apiErrorsPrefix  = "api_errors."
+)
+
+// Store is a counters file on local disk. Nothing is ever sent over the network.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns the metrics file path next to the config file
+func DefaultPath() (string, error) {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "metrics.json"), nil
+}
+
+// Increment adds one to the named counter and persists the result atomically
+func (s *Store) Increment(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters, err := s.read()
+	if err != nil {
+		return err
+	}
+	counters[name]++
+	return s.write(counters)
+}
+
+// Read returns a snapshot of all counters
+func (s *Store) Read() (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Names returns the counter names in a snapshot, sorted
+func Names(counters map[string]int64) []string {
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// APIError returns the counter name for a transcription error
+func APIError(err error) string {
+	switch {
+	case err == errors.ErrAPIKeyInvalid:
+		return apiErrorsPrefix + "auth"
+	case err == errors.ErrRateLimited:
+		return apiErrorsPrefix + "rate_limited"
+	case errors.IsType(err, errors.ErrorTypeNetwork):
+		return apiErrorsPrefix + "network"
+	default:
+		return apiErrorsPrefix + "other"
+	}
+}
+
+func (s *Store) read() (map[string]int64, error) {
+	counters := make(map[string]int64)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return nil, err
+	}
+	return counters, nil
+}
+
+// write replaces the file via rename so a crash never leaves a partial file
+func (s *Store) write(counters map[string]int64) error {
+	data, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+func TestIncrementAndRead(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "metrics.json"))
+
+	counters, err := store.Read()
+	if err != nil {
+		t.Fatalf("Read() on missing file failed: %v", err)
+	}
+	if len(counters) != 0 {
+		t.Errorf("Expected no counters, got %v", counters)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := store.Increment(TranscriptionsOK); err != nil {
+			t.Fatalf("Increment() failed: %v", err)
+		}
+	}
+	if err := store.Increment(TypingErrors); err != nil {
+		t.Fatalf("Increment() failed: %v", err)
+	}
+
+	// Read back through a fresh store to verify persistence
+	counters, err = NewStore(store.path).Read()
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if counters[TranscriptionsOK] != 3 {
+		t.Errorf("Expected 3 transcriptions, got %d", counters[TranscriptionsOK])
+	}
+	if counters[TypingErrors] != 1 {
+		t.Errorf("Expected 1 typing error, got %d", counters[TypingErrors])
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{errors.ErrAPIKeyInvalid, "api_errors.auth"},
+		{errors.ErrRateLimited, "api_errors.rate_limited"},
+		{errors.Wrap(errors.ErrTimeout, errors.ErrorTypeNetwork, "request failed"), "api_errors.network"},
+		{errors.ErrNotSupported, "api_errors.other"},
+	}
+
+	for _, tc := range testCases {
+		if got := APIError(tc.err); got != tc.expected {
+			t.Errorf("APIError(%v) = %q, expected %q", tc.err, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Urgency levels defined by the freedesktop notification spec
+const (
+	urgencyNormal   = 1
+	urgencyCritical = 2
+)
+
+// sendWithGDBus calls org.freedesktop.Notifications.Notify directly over the
+// session bus. This works on minimal systems that have a notification daemon
+// but none of the CLI helpers.
+func (n *Notifier) sendWithGDBus(title, message, icon string, urgency int) error {
+	cmd := exec.Command("gdbus", gdbusNotifyArgs(title, message, icon, urgency)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gdbus notify failed: %v, output: %s", err, output)
+	}
+
+	return nil
+}
+
+// gdbusNotifyArgs builds the gdbus arguments for Notify(app_name, replaces_id,
+// app_icon, summary, body, actions, hints, expire_timeout)
+func gdbusNotifyArgs(title, message, icon string, urgency int) []string {
+	return []string{
+		"call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString("VoiceType"),
+		"0",
+		gvariantString(icon),
+		gvariantString(title),
+		gvariantString(message),
+		"[]",
+		fmt.Sprintf("{'urgency': <byte %d>}", urgency),
+		"-1",
+	}
+}
+
+// gvariantString quotes s as a GVariant text-format string literal
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
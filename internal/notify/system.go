@@ -7,21 +7,61 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"speek_to_text_linux/internal/postprocess"
+	"speek_to_text_linux/internal/toolpath"
 	"speek_to_text_linux/pkg/errors"
 )
 
+// DefaultTranscriptChars is how much of a transcription a notification shows
+const DefaultTranscriptChars = 100
+
 // Notifier represents the notification system
 type Notifier struct {
 	errHandler *errors.Handler
 	isReady    bool
+	respectDND bool
+	dndQuery   func() bool
+	maxChars   int  // transcription preview length; 0 shows it whole
+	showCount  bool // append the transcription's length
 }
 
 // NewNotifier creates a new notifier
 func NewNotifier(errHandler *errors.Handler) *Notifier {
-	return &Notifier{
+	n := &Notifier{
 		errHandler: errHandler,
+		respectDND: true,
+		maxChars:   DefaultTranscriptChars,
+	}
+	n.dndQuery = n.isDoNotDisturb
+	return n
+}
+
+// SetRespectDND controls whether non-critical notifications are suppressed
+// while the desktop is in Do-Not-Disturb mode
+func (n *Notifier) SetRespectDND(respect bool) {
+	n.respectDND = respect
+}
+
+// isDoNotDisturb queries the desktop's Do-Not-Disturb state (dunst, GNOME)
+func (n *Notifier) isDoNotDisturb() bool {
+	if toolpath.Available("dunstctl") {
+		if out, err := exec.Command("dunstctl", "is-paused").Output(); err == nil {
+			return strings.TrimSpace(string(out)) == "true"
+		}
+	}
+	if toolpath.Available("gsettings") {
+		if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output(); err == nil {
+			return strings.TrimSpace(string(out)) == "false"
+		}
 	}
+	return false
+}
+
+// suppressed reports whether a non-critical notification should be dropped
+func (n *Notifier) suppressed() bool {
+	return n.respectDND && n.dndQuery()
 }
 
 // Initialize initializes the notification system
@@ -39,29 +79,28 @@ func (n *Notifier) Initialize() error {
 // detectNotificationSystem detects available notification systems
 func (n *Notifier) detectNotificationSystem() error {
 	// Check for notify-send (libnotify)
-	if n.isToolAvailable("notify-send") {
+	if toolpath.Available("notify-send") {
 		return nil
 	}
 
 	// Check for other notification tools
 	tools := []string{"dunstify", "knotify", "xfce4-notifyd"}
 	for _, tool := range tools {
-		if n.isToolAvailable(tool) {
+		if toolpath.Available(tool) {
 			return nil
 		}
 	}
 
+	// Fall back to calling the D-Bus notification service directly
+	if toolpath.Available("gdbus") {
+		return nil
+	}
+
 	// Notifications might still work with notify-send even if not installed
 	log.Println("Warning: No notification daemon found, notifications may not appear")
 	return nil
 }
 
-// isToolAvailable checks if a tool is available
-func (n *Notifier) isToolAvailable(tool string) bool {
-	_, err := exec.LookPath(tool)
-	return err == nil
-}
-
 // Notify sends a notification
 func (n *Notifier) Notify(title, message string) error {
 	if !n.isReady {
@@ -69,16 +108,25 @@ func (n *Notifier) Notify(title, message string) error {
 		return nil
 	}
 
+	if n.suppressed() {
+		log.Printf("Notification (do not disturb): %s: %s", title, message)
+		return nil
+	}
+
 	// Try notify-send first
-	if n.isToolAvailable("notify-send") {
+	if toolpath.Available("notify-send") {
 		return n.sendWithNotifySend(title, message)
 	}
 
 	// Try other tools
-	if n.isToolAvailable("dunstify") {
+	if toolpath.Available("dunstify") {
 		return n.sendWithDunstify(title, message)
 	}
 
+	if toolpath.Available("gdbus") {
+		return n.sendWithGDBus(title, message, "microphone", urgencyNormal)
+	}
+
 	log.Printf("Notification: %s - %s", title, message)
 	return nil
 }
@@ -129,7 +177,7 @@ func (n *Notifier) NotifyError(title, message string) error {
 	}
 
 	// Try notify-send with critical urgency
-	if n.isToolAvailable("notify-send") {
+	if toolpath.Available("notify-send") {
 		cmd := exec.Command(
 			"notify-send",
 			"--app-name=VoiceType",
@@ -147,10 +195,43 @@ func (n *Notifier) NotifyError(title, message string) error {
 		return nil
 	}
 
+	if toolpath.Available("gdbus") {
+		return n.sendWithGDBus(title, message, "dialog-error", urgencyCritical)
+	}
+
 	log.Printf("Error notification: %s - %s", title, message)
 	return nil
 }
 
+// SetTranscriptFormat sets how NotifyTranscript shows a transcription: at
+// most maxChars characters, 0 for all of it, and with its full length
+// appended if showCount
+func (n *Notifier) SetTranscriptFormat(maxChars int, showCount bool) {
+	if maxChars < 0 {
+		maxChars = 0
+	}
+	n.maxChars = maxChars
+	n.showCount = showCount
+}
+
+// NotifyTranscript sends a notification quoting a transcription after
+// message, shortened as set by SetTranscriptFormat
+func (n *Notifier) NotifyTranscript(title, message, text string) error {
+	return n.Notify(title, message+"\n"+FormatTranscript(text, n.maxChars, n.showCount))
+}
+
+// FormatTranscript quotes text on one line for a notification, cut on a
+// character boundary to maxRunes, as backends truncating by bytes would
+// otherwise split a multi-byte character into garbage
+func FormatTranscript(text string, maxRunes int, showCount bool) string {
+	text = strings.Join(strings.Fields(text), " ")
+	quoted := "“" + postprocess.Truncate(text, maxRunes) + "”"
+	if showCount {
+		quoted += fmt.Sprintf(" (%d chars)", utf8.RuneCountInString(text))
+	}
+	return quoted
+}
+
 // NotifySuccess sends a success notification
 func (n *Notifier) NotifySuccess(title, message string) error {
 	return n.Notify(title, message)
@@ -178,6 +259,3 @@ func (n *Notifier) Close() {
 func (n *Notifier) IsReady() bool {
 	return n.isReady
 }
-
-// Import strings for environment variable checking
-var _ = strings.Contains
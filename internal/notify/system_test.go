@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSuppressedDuringDND(t *testing.T) {
+	testCases := []struct {
+		respect  bool
+		dnd      bool
+		expected bool
+	}{
+		{true, true, true},
+		{true, false, false},
+		{false, true, false},
+	}
+
+	for _, tc := range testCases {
+		n := NewNotifier(nil)
+		n.SetRespectDND(tc.respect)
+		queried := false
+		n.dndQuery = func() bool {
+			queried = true
+			return tc.dnd
+		}
+
+		if got := n.suppressed(); got != tc.expected {
+			t.Errorf("respect=%v dnd=%v: expected suppressed=%v, got %v", tc.respect, tc.dnd, tc.expected, got)
+		}
+		if !tc.respect && queried {
+			t.Error("Expected DND not to be queried when disabled")
+		}
+	}
+}
+
+func TestNotifyDuringDNDDoesNotSend(t *testing.T) {
+	n := NewNotifier(nil)
+	n.isReady = true
+	n.dndQuery = func() bool { return true }
+
+	// With DND active Notify must return before looking for any tool
+	if err := n.Notify("VoiceType", "hello"); err != nil {
+		t.Errorf("Expected suppressed notification to succeed, got %v", err)
+	}
+}
+
+func TestGDBusNotifyArgs(t *testing.T) {
+	args := gdbusNotifyArgs("Done", "It's typed", "microphone", urgencyNormal)
+
+	expected := []string{
+		"call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		"'VoiceType'",
+		"0",
+		"'microphone'",
+		"'Done'",
+		`'It\'s typed'`,
+		"[]",
+		"{'urgency': <byte 1>}",
+		"-1",
+	}
+
+	if len(args) != len(expected) {
+		t.Fatalf("Expected %d args, got %d: %q", len(expected), len(args), args)
+	}
+	for i := range expected {
+		if args[i] != expected[i] {
+			t.Errorf("arg %d: expected %q, got %q", i, expected[i], args[i])
+		}
+	}
+}
+
+func TestFormatTranscript(t *testing.T) {
+	testCases := []struct {
+		text      string
+		max       int
+		showCount bool
+		expected  string
+	}{
+		{"short note", 100, false, "“short note”"},
+		{"línea uno\nlínea dos", 100, false, "“línea uno línea dos”"},
+		{"ça coûte très cher", 10, false, "“ça coûte…”"},
+		{"東京で会議があります", 5, true, "“東京で会…” (10 chars)"},
+		{"emoji 🎉🎉🎉 party", 8, false, "“emoji 🎉…”"},
+		{"keep everything", 0, true, "“keep everything” (15 chars)"},
+	}
+
+	for _, tc := range testCases {
+		got := FormatTranscript(tc.text, tc.max, tc.showCount)
+		if got != tc.expected {
+			t.Errorf("FormatTranscript(%q, %d, %v) = %q, expected %q", tc.text, tc.max, tc.showCount, got, tc.expected)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("FormatTranscript(%q) produced invalid UTF-8", tc.text)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package pidfile manages the single-instance lock file of the GUI
+package pidfile
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// File is a PID file owned by this process
+type File struct {
+	path string
+}
+
+// exePath resolves the executable of a running process; swapped in tests
+var exePath = func(pid int) (string, error) {
+	return os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "exe"))
+}
+
+// Read returns the PID stored in path
+func Read(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file %s", path)
+	}
+	return pid, nil
+}
+
+// Running returns the process recorded in path if it is alive and is
+// another instance of this program. A dead PID, or one the kernel has
+// since reused for an unrelated program, is reported as not running.
+func Running(path string) (*os.Process, bool) {
+	pid, err := Read(path)
+	if err != nil || pid == os.Getpid() {
+		return nil, false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, false
+	}
+	// EPERM still means the process exists, just under another user
+	if err := p.Signal(syscall.Signal(0)); err != nil && err != syscall.EPERM {
+		return nil, false
+	}
+	if !sameProgram(pid) {
+		return nil, false
+	}
+	return p, true
+}
+
+// sameProgram reports whether pid runs the same executable as this process.
+// When either side can't be resolved (no /proc) the PID is trusted.
+func sameProgram(pid int) bool {
+	self, err := os.Executable()
+	if err != nil {
+		return true
+	}
+	other, err := exePath(pid)
+	if err != nil {
+		return true
+	}
+	// A binary replaced by a rebuild is still the same program
+	other = strings.TrimSuffix(other, " (deleted)")
+	return filepath.Base(other) == filepath.Base(self)
+}
+
+// RuntimeDir returns the directory for the pid file and the files a launch
+// hands a running instance: $XDG_RUNTIME_DIR, which only the user can reach,
+// or else a directory of the user's own in the temp dir. A fixed name in the
+// shared temp dir would let any local user plant actions.
+func RuntimeDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("voicetype-%d", os.Getuid()))
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	// Someone else may have created it first
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || info.Mode().Perm() != 0700 || (ok && int(st.Uid) != os.Getuid()) {
+		return "", fmt.Errorf("%s is not a private directory", dir)
+	}
+	return dir, nil
+}
+
+// Acquire writes this process's PID to path, first removing a stale file
+// left behind by an instance that crashed or was killed
+func Acquire(path string) (*File, error) {
+	if _, err := os.Stat(path); err == nil {
+		if _, ok := Running(path); ok {
+			return nil, fmt.Errorf("another instance is running (%s)", path)
+		}
+		log.Printf("Removing stale pid file %s", path)
+		os.Remove(path)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return nil, err
+	}
+	return &File{path: path}, nil
+}
+
+// Path returns the location of the PID file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Remove deletes the PID file if it still belongs to this process
+func (f *File) Remove() {
+	if pid, err := Read(f.path); err == nil && pid != os.Getpid() {
+		return
+	}
+	os.Remove(f.path)
+}
+
+// Recover is deferred at the top of main and of every long-lived goroutine.
+// On panic it removes the PID file before letting the panic continue, so a
+// crash doesn't leave a lock that turns the next launch into a toggle
+// signal to a dead process.
+func (f *File) Recover() {
+	if r := recover(); r != nil {
+		f.Remove()
+		panic(r)
+	}
+}
+
+// Go runs fn in a goroutine guarded by Recover
+func (f *File) Go(fn func()) {
+	go func() {
+		defer f.Recover()
+		fn()
+	}()
+}
@@ -0,0 +1,162 @@
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// deadPID returns the PID of a process that has already exited
+func deadPID(t *testing.T) int {
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run helper process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+// otherProcess starts a long-running process and returns its PID
+func otherProcess(t *testing.T) int {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+func writePID(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireRemovesStaleFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func(t *testing.T) string
+	}{
+		{"dead process", func(t *testing.T) string { return strconv.Itoa(deadPID(t)) }},
+		{"reused pid", func(t *testing.T) string { return strconv.Itoa(otherProcess(t)) }},
+		{"garbage", func(t *testing.T) string { return "not a pid" }},
+		{"empty", func(t *testing.T) string { return "" }},
+		{"own pid", func(t *testing.T) string { return strconv.Itoa(os.Getpid()) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "voicetype-gui.pid")
+			writePID(t, path, tt.content(t))
+
+			f, err := Acquire(path)
+			if err != nil {
+				t.Fatalf("Acquire failed on stale file: %v", err)
+			}
+			if pid, err := Read(path); err != nil || pid != os.Getpid() {
+				t.Errorf("Expected pid file to hold %d, got %d (%v)", os.Getpid(), pid, err)
+			}
+			f.Remove()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("Expected Remove to delete the pid file")
+			}
+		})
+	}
+}
+
+func TestRunningDetectsLiveInstance(t *testing.T) {
+	pid := otherProcess(t)
+	self, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	orig := exePath
+	defer func() { exePath = orig }()
+
+	path := filepath.Join(t.TempDir(), "voicetype-gui.pid")
+	writePID(t, path, strconv.Itoa(pid))
+
+	// The helper looks like another copy of this binary
+	exePath = func(int) (string, error) { return self + " (deleted)", nil }
+	if p, ok := Running(path); !ok || p.Pid != pid {
+		t.Fatalf("Expected live instance %d to be detected", pid)
+	}
+	if _, err := Acquire(path); err == nil {
+		t.Error("Expected Acquire to refuse while another instance runs")
+	}
+
+	// Without /proc the PID is trusted
+	exePath = func(int) (string, error) { return "", errors.New("no /proc") }
+	if _, ok := Running(path); !ok {
+		t.Error("Expected live PID to be trusted when its executable is unknown")
+	}
+}
+
+func TestRemoveKeepsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.pid")
+	f, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A newer instance took over the lock after ours was considered stale
+	writePID(t, path, "999999")
+	f.Remove()
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Expected Remove to leave another instance's pid file alone")
+	}
+}
+
+func TestRecoverRemovesFileOnPanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "voicetype-gui.pid")
+	f, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected panic to propagate, got %v", r)
+			}
+		}()
+		defer f.Recover()
+		panic("boom")
+	}()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected pid file to be removed on panic")
+	}
+}
+
+func TestRuntimeDir(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if dir, err := RuntimeDir(); err != nil || dir != "/run/user/1000" {
+		t.Errorf("Expected $XDG_RUNTIME_DIR, got %s, %v", dir, err)
+	}
+
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("TMPDIR", tmp)
+	dir, err := RuntimeDir()
+	if err != nil {
+		t.Fatalf("RuntimeDir failed: %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || filepath.Dir(dir) != tmp || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected a private directory in the temp dir, got %s (%v)", dir, err)
+	}
+
+	// A directory someone else could write to is refused
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RuntimeDir(); err == nil {
+		t.Error("Expected a shared directory to be refused")
+	}
+}
@@ -0,0 +1,85 @@
+// Package postprocess provides text cleanup applied to transcriptions before typing
+package postprocess
+
+import (
+	"strings"
+)
+
+// quotePairs maps opening quote characters to their closing counterpart
+var quotePairs = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'“':  '”',
+	'‘':  '’',
+	'«':  '»',
+}
+
+// bulletPrefixes are list markers some models prepend to a single utterance
+var bulletPrefixes = []string{"- ", "* ", "• ", "– "}
+
+// StripArtifacts removes formatting the model sometimes wraps around a transcription:
+// surrounding quotes, a leading bullet, and stray backticks or code fences.
+// Quotes are only stripped when they enclose the whole text, so intentional
+// quotes mid-text (or a quoted word at the start) are preserved.
+func StripArtifacts(text string) string {
+	text = strings.TrimSpace(text)
+
+	// Repeat until stable since artifacts can nest, e.g. "- `hello`"
+	for {
+		prev := text
+		text = stripFence(text)
+		text = stripBullet(text)
+		text = stripEnclosingQuotes(text)
+		text = strings.TrimSpace(text)
+		if text == prev {
+			return text
+		}
+	}
+}
+
+// stripFence removes ``` fences or single backticks that wrap the entire text
+func stripFence(text string) string {
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") && len(text) >= 6 {
+		inner := text[3 : len(text)-3]
+		// Drop an optional language tag on the opening fence line
+		if i := strings.Index(inner, "\n"); i >= 0 && !strings.Contains(strings.TrimSpace(inner[:i]), " ") {
+			inner = inner[i+1:]
+		}
+		return inner
+	}
+	if len(text) >= 2 && text[0] == '`' && text[len(text)-1] == '`' && !strings.Contains(text[1:len(text)-1], "`") {
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// stripBullet removes a single leading list marker
+func stripBullet(text string) string {
+	for _, prefix := range bulletPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return text[len(prefix):]
+		}
+	}
+	return text
+}
+
+// stripEnclosingQuotes removes a quote pair that wraps the whole text and
+// does not appear anywhere else inside it
+func stripEnclosingQuotes(text string) string {
+	runes := []rune(text)
+	if len(runes) < 2 {
+		return text
+	}
+
+	open, last := runes[0], runes[len(runes)-1]
+	closing, ok := quotePairs[open]
+	if !ok || last != closing {
+		return text
+	}
+
+	inner := string(runes[1 : len(runes)-1])
+	if strings.ContainsRune(inner, open) || strings.ContainsRune(inner, closing) {
+		return text
+	}
+	return inner
+}
@@ -0,0 +1,36 @@
+package postprocess
+
+import "testing"
+
+func TestStripArtifacts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "Hello world.", "Hello world."},
+		{"double quotes", `"Hello world."`, "Hello world."},
+		{"smart quotes", "“Hello world.”", "Hello world."},
+		{"single quotes", "'Hello world.'", "Hello world."},
+		{"leading bullet", "- Hello world.", "Hello world."},
+		{"star bullet", "* Hello world.", "Hello world."},
+		{"backticks", "`Hello world.`", "Hello world."},
+		{"code fence", "```\nHello world.\n```", "Hello world."},
+		{"fence with language", "```text\nHello world.\n```", "Hello world."},
+		{"nested", "- \"Hello world.\"", "Hello world."},
+		{"whitespace", "  \"Hello world.\"  ", "Hello world."},
+		{"mid-text quotes kept", `He said "hi" to me.`, `He said "hi" to me.`},
+		{"quoted phrases kept", `"Yes" and "no" are answers.`, `"Yes" and "no" are answers.`},
+		{"apostrophes kept", "'Tis the season, isn't it'", "'Tis the season, isn't it'"},
+		{"inline code kept", "Run `make` then `go test`.", "Run `make` then `go test`."},
+		{"dash mid-text kept", "Well - maybe.", "Well - maybe."},
+		{"lone quote", `"`, `"`},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := StripArtifacts(tc.input); got != tc.expected {
+			t.Errorf("%s: StripArtifacts(%q) = %q, expected %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package postprocess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultChannelLabels name the left and right speakers of a stereo recording
+var DefaultChannelLabels = []string{"Left", "Right"}
+
+// MergeChannels joins per-channel transcripts into one labeled transcript,
+// one paragraph per channel in channel order. Channels with no speech are
+// left out. A channel without a label in labels falls back to
+// DefaultChannelLabels, then to "Channel N".
+func MergeChannels(texts, labels []string) string {
+	var parts []string
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if !HasContent(text) {
+			continue
+		}
+		parts = append(parts, channelLabel(i, labels)+": "+text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// channelLabel returns the label of channel i
+func channelLabel(i int, labels []string) string {
+	if i < len(labels) && strings.TrimSpace(labels[i]) != "" {
+		return strings.TrimSpace(labels[i])
+	}
+	if i < len(DefaultChannelLabels) {
+		return DefaultChannelLabels[i]
+	}
+	return fmt.Sprintf("Channel %d", i+1)
+}
@@ -0,0 +1,55 @@
+package postprocess
+
+import "testing"
+
+func TestMergeChannels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		texts    []string
+		labels   []string
+		expected string
+	}{
+		{
+			"default labels",
+			[]string{"How did you start?", " I was a teacher. "},
+			nil,
+			"Left: How did you start?\n\nRight: I was a teacher.",
+		},
+		{
+			"configured labels",
+			[]string{"Welcome.", "Thanks."},
+			[]string{"Host", "Guest"},
+			"Host: Welcome.\n\nGuest: Thanks.",
+		},
+		{
+			"blank label falls back",
+			[]string{"Welcome.", "Thanks."},
+			[]string{"Host", " "},
+			"Host: Welcome.\n\nRight: Thanks.",
+		},
+		{
+			"silent channel left out",
+			[]string{"Just me talking.", "..."},
+			nil,
+			"Left: Just me talking.",
+		},
+		{
+			"extra channels numbered",
+			[]string{"a", "b", "c"},
+			nil,
+			"Left: a\n\nRight: b\n\nChannel 3: c",
+		},
+		{
+			"nothing said",
+			[]string{"", " "},
+			nil,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := MergeChannels(tc.texts, tc.labels); got != tc.expected {
+			t.Errorf("%s: got %q, expected %q", tc.name, got, tc.expected)
+		}
+	}
+}
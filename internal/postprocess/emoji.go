@@ -0,0 +1,168 @@
+package postprocess
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultEmojiPhrases map spoken phrases to the emoji they insert. Phrases
+// that are also ordinary speech, like "thumbs up", need the word "emoji" so
+// they aren't replaced by accident.
+var DefaultEmojiPhrases = map[string]string{
+	"smiley face":          "🙂",
+	"smiling face":         "😊",
+	"winking face":         "😉",
+	"laughing face":        "😂",
+	"sad face":             "🙁",
+	"crying face":          "😢",
+	"thinking face":        "🤔",
+	"thumbs up emoji":      "👍",
+	"thumbs down emoji":    "👎",
+	"heart emoji":          "❤️",
+	"fire emoji":           "🔥",
+	"party emoji":          "🎉",
+	"rocket emoji":         "🚀",
+	"check mark emoji":     "✅",
+	"cross mark emoji":     "❌",
+	"eyes emoji":           "👀",
+	"clapping emoji":       "👏",
+	"folded hands emoji":   "🙏",
+	"waving hand emoji":    "👋",
+	"ok hand emoji":        "👌",
+	"shrug emoji":          "🤷",
+	"face palm emoji":      "🤦",
+	"sparkles emoji":       "✨",
+	"warning emoji":        "⚠️",
+	"hundred points emoji": "💯",
+}
+
+// DefaultShortcodes map GitHub-style shortcodes, without their colons, to
+// emoji
+var DefaultShortcodes = map[string]string{
+	"smile":                 "😄",
+	"slightly_smiling_face": "🙂",
+	"blush":                 "😊",
+	"wink":                  "😉",
+	"joy":                   "😂",
+	"laughing":              "😆",
+	"frowning_face":         "☹️",
+	"cry":                   "😢",
+	"sob":                   "😭",
+	"thinking":              "🤔",
+	"+1":                    "👍",
+	"thumbsup":              "👍",
+	"-1":                    "👎",
+	"thumbsdown":            "👎",
+	"heart":                 "❤️",
+	"fire":                  "🔥",
+	"tada":                  "🎉",
+	"rocket":                "🚀",
+	"white_check_mark":      "✅",
+	"heavy_check_mark":      "✔️",
+	"x":                     "❌",
+	"eyes":                  "👀",
+	"clap":                  "👏",
+	"pray":                  "🙏",
+	"wave":                  "👋",
+	"ok_hand":               "👌",
+	"shrug":                 "🤷",
+	"facepalm":              "🤦",
+	"sparkles":              "✨",
+	"warning":               "⚠️",
+	"100":                   "💯",
+	"shipit":                "🐿️",
+}
+
+// shortcodePattern matches a :shortcode: token
+var shortcodePattern = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+
+// EmojiReplacer turns spoken emoji phrases and :shortcodes: into emoji
+type EmojiReplacer struct {
+	phrases    map[string]string
+	shortcodes map[string]string
+	pattern    *regexp.Regexp
+}
+
+// NewEmojiReplacer builds a replacer from the defaults plus overrides. An
+// override key wrapped in colons, e.g. ":shipit:", sets a shortcode; any
+// other key sets a phrase. An empty value removes the default entry.
+func NewEmojiReplacer(overrides map[string]string) *EmojiReplacer {
+	r := &EmojiReplacer{
+		phrases:    make(map[string]string, len(DefaultEmojiPhrases)),
+		shortcodes: make(map[string]string, len(DefaultShortcodes)),
+	}
+	for phrase, emoji := range DefaultEmojiPhrases {
+		r.phrases[phrase] = emoji
+	}
+	for code, emoji := range DefaultShortcodes {
+		r.shortcodes[code] = emoji
+	}
+	for key, emoji := range overrides {
+		key = strings.ToLower(strings.TrimSpace(key))
+		target := r.phrases
+		if len(key) > 2 && strings.HasPrefix(key, ":") && strings.HasSuffix(key, ":") {
+			key, target = key[1:len(key)-1], r.shortcodes
+		} else {
+			key = strings.Join(strings.Fields(key), " ")
+		}
+		if key == "" {
+			continue
+		}
+		if emoji == "" {
+			delete(target, key)
+		} else {
+			target[key] = emoji
+		}
+	}
+
+	if len(r.phrases) > 0 {
+		// Longest first so "thumbs up emoji" wins over a shorter phrase it contains
+		phrases := make([]string, 0, len(r.phrases))
+		for phrase := range r.phrases {
+			phrases = append(phrases, phrase)
+		}
+		sort.Slice(phrases, func(i, j int) bool {
+			if len(phrases[i]) != len(phrases[j]) {
+				return len(phrases[i]) > len(phrases[j])
+			}
+			return phrases[i] < phrases[j]
+		})
+		alts := make([]string, len(phrases))
+		for i, phrase := range phrases {
+			alts[i] = strings.ReplaceAll(regexp.QuoteMeta(phrase), " ", `\s+`)
+		}
+		r.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(alts, "|") + `)\b`)
+	}
+	return r
+}
+
+// Replace substitutes every known phrase and shortcode. Unknown shortcodes
+// are left as typed.
+func (r *EmojiReplacer) Replace(text string) string {
+	if r.pattern != nil {
+		text = r.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return r.phrases[strings.ToLower(strings.Join(strings.Fields(match), " "))]
+		})
+	}
+	return shortcodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if emoji, ok := r.shortcodes[match[1:len(match)-1]]; ok {
+			return emoji
+		}
+		return match
+	})
+}
+
+// HasEmoji reports whether text contains an emoji, which typing tools often
+// can't inject and so should be pasted
+func HasEmoji(text string) bool {
+	for _, r := range text {
+		switch {
+		case r >= 0x1F000 && r <= 0x1FAFF,
+			r >= 0x2600 && r <= 0x27BF,
+			r == 0xFE0F:
+			return true
+		}
+	}
+	return false
+}
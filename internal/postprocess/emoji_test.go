@@ -0,0 +1,63 @@
+package postprocess
+
+import "testing"
+
+func TestEmojiReplacer(t *testing.T) {
+	r := NewEmojiReplacer(map[string]string{
+		"party parrot":    "🦜",
+		":shipit:":        "🚢",
+		"  Coffee   Cup ": "☕",
+		"sad face":        "",
+	})
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"phrase", "great job smiley face", "great job 🙂"},
+		{"phrase with emoji suffix", "sounds good thumbs up emoji", "sounds good 👍"},
+		{"case insensitive", "Thumbs Up Emoji.", "👍."},
+		{"extra whitespace", "nice smiley  \n face", "nice 🙂"},
+		{"plain phrase needs suffix", "she gave a thumbs up", "she gave a thumbs up"},
+		{"not inside a word", "smiley faceless", "smiley faceless"},
+		{"several", "heart emoji and fire emoji", "❤️ and 🔥"},
+		{"shortcode", "ship it :thumbsup:", "ship it 👍"},
+		{"plus shortcode", ":+1: works", "👍 works"},
+		{"adjacent shortcodes", ":tada::rocket:", "🎉🚀"},
+		{"unknown shortcode", "see :not_an_emoji: here", "see :not_an_emoji: here"},
+		{"time is not a shortcode", "meet at 10:30:00", "meet at 10:30:00"},
+		{"user phrase", "party parrot time", "🦜 time"},
+		{"user phrase normalized", "coffee cup please", "☕ please"},
+		{"user shortcode overrides default", ":shipit:", "🚢"},
+		{"removed default", "sad face", "sad face"},
+		{"no emoji", "hello world", "hello world"},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := r.Replace(tc.input); got != tc.expected {
+			t.Errorf("%s: Replace(%q) = %q, expected %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestHasEmoji(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"hello 👍", true},
+		{"❤️", true},
+		{"✅ done", true},
+		{"café naïve", false},
+		{"plain text", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if got := HasEmoji(tc.input); got != tc.expected {
+			t.Errorf("HasEmoji(%q) = %v, expected %v", tc.input, got, tc.expected)
+		}
+	}
+}
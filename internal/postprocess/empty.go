@@ -0,0 +1,23 @@
+package postprocess
+
+import (
+	"unicode"
+)
+
+// Behaviours for transcriptions with nothing worth typing
+const (
+	OnEmptyIgnore = "ignore"
+	OnEmptyNotify = "notify"
+)
+
+// HasContent reports whether text contains at least one letter or digit.
+// Whisper sometimes returns only whitespace or punctuation (e.g. ".") for
+// silence, which should be treated the same as an empty transcription.
+func HasContent(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package postprocess
+
+import "testing"
+
+func TestHasContent(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"", false},
+		{".", false},
+		{"   ", false},
+		{"\n", false},
+		{" ... ?! ", false},
+		{"“”", false},
+		{"Hello.", true},
+		{"42", true},
+		{"ok", true},
+		{"日本語", true},
+		{"Привет", true},
+	}
+
+	for _, tc := range testCases {
+		if got := HasContent(tc.input); got != tc.expected {
+			t.Errorf("HasContent(%q) = %v, expected %v", tc.input, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// contractions maps apostrophe-less spellings to the contraction meant.
+// Words that are also real words without the apostrophe, like "wont",
+// "ill", "well", "were", "its" or "lets", are left out.
+var contractions = map[string]string{
+	"dont":     "don't",
+	"doesnt":   "doesn't",
+	"didnt":    "didn't",
+	"isnt":     "isn't",
+	"arent":    "aren't",
+	"wasnt":    "wasn't",
+	"werent":   "weren't",
+	"hasnt":    "hasn't",
+	"havent":   "haven't",
+	"hadnt":    "hadn't",
+	"couldnt":  "couldn't",
+	"wouldnt":  "wouldn't",
+	"shouldnt": "shouldn't",
+	"mustnt":   "mustn't",
+	"neednt":   "needn't",
+	"aint":     "ain't",
+	"im":       "I'm",
+	"ive":      "I've",
+	"youre":    "you're",
+	"youve":    "you've",
+	"youll":    "you'll",
+	"theyre":   "they're",
+	"theyve":   "they've",
+	"theyll":   "they'll",
+	"weve":     "we've",
+	"thats":    "that's",
+	"whats":    "what's",
+	"theres":   "there's",
+	"wheres":   "where's",
+	"whos":     "who's",
+	"shes":     "she's",
+}
+
+// FixEnglish capitalizes a standalone "i" and restores the apostrophe in
+// common contractions like "dont". Only whole words are touched: letters,
+// digits, apostrophes, hyphens and underscores all count as part of a word,
+// so "wi-fi", "i18n" and "don't" are safe, and "i" directly followed by a
+// period and a letter is taken for "i.e." and left alone.
+func FixEnglish(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isWordRune(r) {
+			b.WriteRune(r)
+			i += size
+			continue
+		}
+		j := i
+		for j < len(text) {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !isWordRune(r) {
+				break
+			}
+			j += size
+		}
+		// Quotes around a word aren't part of it
+		word := strings.TrimRight(strings.TrimLeft(text[i:j], "'’"), "'’")
+		start := i + strings.Index(text[i:j], word)
+		end := start + len(word)
+		b.WriteString(text[i:start])
+		b.WriteString(fixWord(word, text[end:]))
+		b.WriteString(text[end:j])
+		i = j
+	}
+	return b.String()
+}
+
+// fixWord returns the corrected spelling of word, given the text after it
+func fixWord(word, rest string) string {
+	if word == "i" {
+		if len(rest) >= 2 && rest[0] == '.' {
+			if r, _ := utf8.DecodeRuneInString(rest[1:]); unicode.IsLetter(r) {
+				return word
+			}
+		}
+		return "I"
+	}
+
+	fixed, ok := contractions[strings.ToLower(word)]
+	if !ok {
+		return word
+	}
+	switch {
+	case len(word) > 1 && word == strings.ToUpper(word):
+		return strings.ToUpper(fixed)
+	case unicode.IsUpper([]rune(word)[0]):
+		r, size := utf8.DecodeRuneInString(fixed)
+		return string(unicode.ToUpper(r)) + fixed[size:]
+	}
+	return fixed
+}
+
+// isWordRune reports whether r continues a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’' || r == '-' || r == '_'
+}
@@ -0,0 +1,35 @@
+package postprocess
+
+import "testing"
+
+func TestFixEnglish(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"standalone i", "i think so", "I think so"},
+		{"i mid-sentence", "then i left", "then I left"},
+		{"i before punctuation", "it was i.", "it was I."},
+		{"quoted i", "she said 'i know'", "she said 'I know'"},
+		{"i.e. untouched", "fruit, i.e. apples", "fruit, i.e. apples"},
+		{"i inside words untouched", "iPhone, wi-fi, i18n, pi", "iPhone, wi-fi, i18n, pi"},
+		{"contraction", "i dont know", "I don't know"},
+		{"several contractions", "didnt and wouldnt", "didn't and wouldn't"},
+		{"capitalized", "Dont go", "Don't go"},
+		{"all caps", "DONT", "DON'T"},
+		{"first person", "im sure ive seen it", "I'm sure I've seen it"},
+		{"already correct", "I don't think it's fine", "I don't think it's fine"},
+		{"smart apostrophe kept", "don’t", "don’t"},
+		{"ambiguous words kept", "we were well, its fine, ill lets wont, cant", "we were well, its fine, ill lets wont, cant"},
+		{"not a prefix match", "donteller cants", "donteller cants"},
+		{"non-ascii neighbours", "naïve i", "naïve I"},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := FixEnglish(tc.input); got != tc.expected {
+			t.Errorf("%s: FixEnglish(%q) = %q, expected %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package postprocess
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultLabelPatterns match timestamps and speaker labels some models and
+// prompts put into the transcript, e.g. "[00:00]", "[00:01.500 --> 00:03.000]"
+// and "Speaker 1:"
+var DefaultLabelPatterns = []string{
+	`\[\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?(?:\s*-->\s*\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?)?\]`,
+	`\(\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?\)`,
+	`(?m)^[ \t]*(?:\[\s*)?(?:Speaker|SPEAKER)[ _]?\d+(?:\s*\])?[ \t]*:[ \t]*`,
+}
+
+// spaceRun collapses the gaps left where a label sat mid-line
+var spaceRun = regexp.MustCompile(`[ \t]{2,}`)
+
+// LabelStripper removes timestamp and speaker-label artifacts by regex
+type LabelStripper struct {
+	patterns []*regexp.Regexp
+}
+
+// NewLabelStripper compiles patterns, or DefaultLabelPatterns when none are
+// given. It fails on the first invalid pattern.
+func NewLabelStripper(patterns []string) (*LabelStripper, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultLabelPatterns
+	}
+	s := &LabelStripper{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+// DefaultLabelStripper strips DefaultLabelPatterns, e.g. in place of custom
+// patterns that failed to compile
+func DefaultLabelStripper() *LabelStripper {
+	s := &LabelStripper{}
+	for _, p := range DefaultLabelPatterns {
+		s.patterns = append(s.patterns, regexp.MustCompile(p))
+	}
+	return s
+}
+
+// Strip removes every match and tidies the whitespace left behind. Text
+// without labels is returned unchanged.
+func (s *LabelStripper) Strip(text string) string {
+	stripped := text
+	for _, re := range s.patterns {
+		stripped = re.ReplaceAllString(stripped, " ")
+	}
+	if stripped == text {
+		return text
+	}
+
+	lines := strings.Split(stripped, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(spaceRun.ReplaceAllString(line, " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,64 @@
+package postprocess
+
+import "testing"
+
+func TestLabelStripperDefaults(t *testing.T) {
+	s, err := NewLabelStripper(nil)
+	if err != nil {
+		t.Fatalf("Default patterns failed to compile: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "Hello world.", "Hello world."},
+		{"leading timestamp", "[00:00] Hello world.", "Hello world."},
+		{"hour timestamp", "[01:02:03] Hello world.", "Hello world."},
+		{"fractional timestamp", "[00:01.500] Hello world.", "Hello world."},
+		{"timestamp range", "[00:00.000 --> 00:02.500] Hello world.", "Hello world."},
+		{"parenthesised timestamp", "Hello (00:05) world.", "Hello world."},
+		{"speaker label", "Speaker 1: Hello world.", "Hello world."},
+		{"diarization label", "SPEAKER_00: Hello world.", "Hello world."},
+		{"bracketed speaker", "[Speaker 2]: Hello world.", "Hello world."},
+		{"timestamp and speaker", "[00:00] Speaker 1: Hello.\n[00:03] Speaker 2: Hi there.", "Hello.\nHi there."},
+		{"line of only a timestamp", "[00:00]\nHello world.", "Hello world."},
+		{"speaker mid-sentence kept", "Ask the Speaker 1: question first.", "Ask the Speaker 1: question first."},
+		{"clock time kept", "Meet at 10:30 tomorrow.", "Meet at 10:30 tomorrow."},
+		{"bracketed words kept", "[laughs] Hello.", "[laughs] Hello."},
+		{"whitespace untouched without labels", "Hello  world.", "Hello  world."},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := s.Strip(tc.input); got != tc.expected {
+			t.Errorf("%s: Strip(%q) = %q, expected %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestLabelStripperCustomPatterns(t *testing.T) {
+	s, err := NewLabelStripper([]string{`(?m)^(?:Interviewer|Guest):\s*`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Strip("Interviewer: How are you?\nGuest: Fine, [00:01] thanks."); got != "How are you?\nFine, [00:01] thanks." {
+		t.Errorf("Expected only the custom labels removed, got %q", got)
+	}
+
+	if _, err := NewLabelStripper([]string{`[unclosed`}); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
+
+func TestDefaultLabelStripperMatchesDefaults(t *testing.T) {
+	want, err := NewLabelStripper(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "[00:01] Speaker 1: hello (00:02) there"
+	if got := DefaultLabelStripper().Strip(text); got != want.Strip(text) {
+		t.Errorf("Expected %q, got %q", want.Strip(text), got)
+	}
+}
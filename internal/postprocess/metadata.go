@@ -0,0 +1,59 @@
+package postprocess
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Where AddMetadata puts the resolved template
+const (
+	MetadataPrefix = "prefix"
+	MetadataSuffix = "suffix"
+)
+
+// metadataTimeLayout formats {timestamp}
+const metadataTimeLayout = "2006-01-02 15:04:05"
+
+// Metadata describes how a transcription was made, for the placeholders of
+// an output metadata template
+type Metadata struct {
+	Model    string
+	Duration time.Duration // of the audio; zero when unknown
+	Time     time.Time     // when the text was delivered
+}
+
+// FormatMetadata resolves {model}, {duration} and {timestamp} in template,
+// e.g. "[{model}, {duration}]" to "[whisper-large-v3, 12.3s]". An unknown
+// duration reads "?".
+func FormatMetadata(template string, m Metadata) string {
+	duration := "?"
+	if m.Duration > 0 {
+		duration = fmt.Sprintf("%.1fs", m.Duration.Seconds())
+	}
+	return strings.NewReplacer(
+		"{model}", m.Model,
+		"{duration}", duration,
+		"{timestamp}", m.Time.Format(metadataTimeLayout),
+	).Replace(template)
+}
+
+// AddMetadata puts the resolved template before text, or after it when
+// position is MetadataSuffix, separated by a space unless the template
+// brings its own whitespace. An empty template leaves text unchanged.
+func AddMetadata(text, template, position string, m Metadata) string {
+	if template == "" {
+		return text
+	}
+	meta := FormatMetadata(template, m)
+	if position == MetadataSuffix {
+		if !strings.HasPrefix(meta, " ") && !strings.HasPrefix(meta, "\n") {
+			meta = " " + meta
+		}
+		return text + meta
+	}
+	if !strings.HasSuffix(meta, " ") && !strings.HasSuffix(meta, "\n") {
+		meta += " "
+	}
+	return meta + text
+}
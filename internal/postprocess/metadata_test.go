@@ -0,0 +1,53 @@
+package postprocess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatMetadata(t *testing.T) {
+	at := time.Date(2026, 3, 4, 9, 5, 7, 0, time.Local)
+	testCases := []struct {
+		template string
+		meta     Metadata
+		expected string
+	}{
+		{"[{model}, {duration}]", Metadata{Model: "whisper-large-v3", Duration: 12340 * time.Millisecond}, "[whisper-large-v3, 12.3s]"},
+		{"({timestamp})", Metadata{Time: at}, "(2026-03-04 09:05:07)"},
+		{"{duration}", Metadata{}, "?"},
+		{"{model} {model}", Metadata{Model: "m"}, "m m"},
+		{"no placeholders", Metadata{Model: "m"}, "no placeholders"},
+		{"{unknown}", Metadata{Model: "m"}, "{unknown}"},
+	}
+
+	for _, tc := range testCases {
+		got := FormatMetadata(tc.template, tc.meta)
+		if got != tc.expected {
+			t.Errorf("FormatMetadata(%q) = %q, expected %q", tc.template, got, tc.expected)
+		}
+	}
+}
+
+func TestAddMetadata(t *testing.T) {
+	meta := Metadata{Model: "m", Duration: 2 * time.Second}
+	testCases := []struct {
+		template string
+		position string
+		expected string
+	}{
+		{"", MetadataPrefix, "hello"},
+		{"", MetadataSuffix, "hello"},
+		{"[{model}, {duration}]", MetadataPrefix, "[m, 2.0s] hello"},
+		{"[{model}, {duration}]", "", "[m, 2.0s] hello"},
+		{"[{model}, {duration}]", MetadataSuffix, "hello [m, 2.0s]"},
+		{"{model}:\n", MetadataPrefix, "m:\nhello"},
+		{"\n-- {model}", MetadataSuffix, "hello\n-- m"},
+	}
+
+	for _, tc := range testCases {
+		got := AddMetadata("hello", tc.template, tc.position, meta)
+		if got != tc.expected {
+			t.Errorf("AddMetadata(%q, %q) = %q, expected %q", tc.template, tc.position, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package postprocess
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Newline styles accepted by NormalizeNewlines
+const (
+	NewlineLF       = "lf"
+	NewlineCRLF     = "crlf"
+	NewlinePlatform = "platform"
+)
+
+// NormalizeNewlines rewrites every CRLF, lone CR or LF in text to the given style.
+// Unknown styles are treated as LF.
+func NormalizeNewlines(text, style string) string {
+	if !strings.ContainsAny(text, "\r\n") {
+		return text
+	}
+
+	lf := strings.ReplaceAll(text, "\r\n", "\n")
+	lf = strings.ReplaceAll(lf, "\r", "\n")
+
+	switch strings.ToLower(style) {
+	case NewlineCRLF:
+		return strings.ReplaceAll(lf, "\n", "\r\n")
+	case NewlinePlatform:
+		if runtime.GOOS == "windows" {
+			return strings.ReplaceAll(lf, "\n", "\r\n")
+		}
+		return lf
+	default:
+		return lf
+	}
+}
@@ -0,0 +1,26 @@
+package postprocess
+
+import "testing"
+
+func TestNormalizeNewlines(t *testing.T) {
+	mixed := "one\r\ntwo\nthree\rfour"
+
+	testCases := []struct {
+		style    string
+		input    string
+		expected string
+	}{
+		{NewlineLF, mixed, "one\ntwo\nthree\nfour"},
+		{NewlineCRLF, mixed, "one\r\ntwo\r\nthree\r\nfour"},
+		{NewlinePlatform, mixed, "one\ntwo\nthree\nfour"},
+		{"", mixed, "one\ntwo\nthree\nfour"},
+		{"CRLF", "a\n\nb", "a\r\n\r\nb"},
+		{NewlineCRLF, "no newlines", "no newlines"},
+	}
+
+	for _, tc := range testCases {
+		if got := NormalizeNewlines(tc.input, tc.style); got != tc.expected {
+			t.Errorf("NormalizeNewlines(%q, %q) = %q, expected %q", tc.input, tc.style, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceOpeners may sit between a sentence boundary and its first letter
+const sentenceOpeners = "\"'“‘«([{¿¡"
+
+// abbreviations end in a period without ending the sentence
+var abbreviations = []string{"e.g.", "i.e.", "etc.", "vs.", "cf."}
+
+// SentenceCase capitalizes the first letter of the text and of every
+// sentence after '.', '!' or '?' followed by whitespace, looking past opening
+// quotes and brackets. Words listed in acronyms (e.g. "iOS", "npm") are left
+// exactly as written, as are sentences after an ellipsis or a common
+// abbreviation such as "e.g.", and words starting with a digit.
+func SentenceCase(text string, acronyms []string) string {
+	keep := make(map[string]bool, len(acronyms))
+	for _, a := range acronyms {
+		keep[strings.TrimSpace(a)] = true
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	start := true // at the start of a sentence, before its first letter
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case start && unicode.IsLetter(r):
+			if !keep[wordAt(text, i)] {
+				r = unicode.ToUpper(r)
+			}
+			start = false
+		case start && (unicode.IsSpace(r) || strings.ContainsRune(sentenceOpeners, r)):
+			// Still looking for the first letter
+		case start:
+			// A digit or symbol starts this sentence; leave it alone
+			start = false
+		case strings.ContainsRune(".!?", r):
+			start = endsSentence(text, i, size)
+		}
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
+
+// endsSentence reports whether the punctuation at text[i:i+size] closes a
+// sentence: it is followed by whitespace (or a closing quote/bracket, then
+// whitespace) and is not part of an ellipsis or a known abbreviation
+func endsSentence(text string, i, size int) bool {
+	rest := strings.TrimLeft(text[i+size:], "\"'”’»)]}")
+	next, _ := utf8.DecodeRuneInString(rest)
+	if rest == "" || !unicode.IsSpace(next) {
+		return false
+	}
+	if text[i] == '.' {
+		if i > 0 && text[i-1] == '.' {
+			return false
+		}
+		word := strings.ToLower(lastWord(text[:i+size]))
+		for _, abbr := range abbreviations {
+			if word == abbr {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// wordAt returns the word starting at byte offset i
+func wordAt(text string, i int) string {
+	end := strings.IndexFunc(text[i:], func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '-' && r != '\'')
+	})
+	if end < 0 {
+		return text[i:]
+	}
+	return text[i : i+end]
+}
+
+// lastWord returns the final whitespace-separated word of text
+func lastWord(text string) string {
+	if i := strings.LastIndexFunc(text, unicode.IsSpace); i >= 0 {
+		return text[i+1:]
+	}
+	return text
+}
@@ -0,0 +1,40 @@
+package postprocess
+
+import "testing"
+
+func TestSentenceCase(t *testing.T) {
+	acronyms := []string{"iOS", "npm", "eBay"}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"already cased", "Hello world. How are you?", "Hello world. How are you?"},
+		{"first letter", "hello world.", "Hello world."},
+		{"after period", "First. second.", "First. Second."},
+		{"after question and exclamation", "really? yes! okay.", "Really? Yes! Okay."},
+		{"after newline", "one.\ntwo.", "One.\nTwo."},
+		{"leading whitespace", "  hello.", "  Hello."},
+		{"opening quote", `He left. "see you," he said.`, `He left. "See you," he said.`},
+		{"smart quote", "He left. “see you.”", "He left. “See you.”"},
+		{"parenthesis", "Done. (see above.)", "Done. (See above.)"},
+		{"closing quote before space", `"it works." then we left.`, `"It works." Then we left.`},
+		{"acronym at start", "iOS is out. npm install works. eBay too.", "iOS is out. npm install works. eBay too."},
+		{"acronym mid-sentence untouched", "update iOS. then reboot.", "Update iOS. Then reboot."},
+		{"ellipsis", "well... maybe later.", "Well... maybe later."},
+		{"abbreviation", "bring fruit, e.g. apples. then go.", "Bring fruit, e.g. apples. Then go."},
+		{"decimal", "it costs 3.5 dollars.", "It costs 3.5 dollars."},
+		{"url", "visit example.com today.", "Visit example.com today."},
+		{"digit starts sentence", "done. 3 items left.", "Done. 3 items left."},
+		{"non-ascii", "fin. élan.", "Fin. Élan."},
+		{"lowercase word after acronym-like prefix", "iosevka is a font.", "Iosevka is a font."},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := SentenceCase(tc.input, acronyms); got != tc.expected {
+			t.Errorf("%s: SentenceCase(%q) = %q, expected %q", tc.name, tc.input, got, tc.expected)
+		}
+	}
+}
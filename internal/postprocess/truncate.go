@@ -0,0 +1,29 @@
+package postprocess
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Ellipsis marks text cut short by Truncate
+const Ellipsis = "…"
+
+// Truncate shortens text to at most maxRunes characters, cutting on a rune
+// boundary so a multi-byte character is never split, and ending with an
+// ellipsis when anything was cut. Zero or negative leaves text whole.
+func Truncate(text string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	// Keep room for the ellipsis
+	keep := maxRunes - 1
+	cut := 0
+	for i := range text {
+		if keep == 0 {
+			cut = i
+			break
+		}
+		keep--
+	}
+	return strings.TrimRight(text[:cut], " \t\n") + Ellipsis
+}
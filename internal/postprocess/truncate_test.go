@@ -0,0 +1,35 @@
+package postprocess
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	testCases := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"hello world", 20, "hello world"},
+		{"hello world", 11, "hello world"},
+		{"hello world", 8, "hello w…"},
+		{"hello world", 7, "hello…"},
+		{"héllo wörld", 5, "héll…"},
+		{"日本語のテキストです", 4, "日本語…"},
+		{"👍👍👍👍", 3, "👍👍…"},
+		{"ab", 1, "…"},
+		{"anything", 0, "anything"},
+		{"", 5, ""},
+	}
+
+	for _, tc := range testCases {
+		got := Truncate(tc.text, tc.max)
+		if got != tc.expected {
+			t.Errorf("Truncate(%q, %d) = %q, expected %q", tc.text, tc.max, got, tc.expected)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Truncate(%q, %d) split a character: %q", tc.text, tc.max, got)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package retention prunes stores that grow one file per entry or per day,
+// such as the journal, so they don't grow without bound
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"speek_to_text_linux/pkg/config"
+)
+
+// Policy limits a store. Zero values disable the corresponding limit.
+type Policy struct {
+	// MaxEntries is the maximum number of files kept
+	MaxEntries int
+	// MaxAge removes files last modified longer ago than this
+	MaxAge time.Duration
+	// MaxBytes is the maximum combined size of all files
+	MaxBytes int64
+}
+
+// FromConfig builds the policy configured by the retention_* settings
+func FromConfig(cfg *config.Config) Policy {
+	return Policy{
+		MaxEntries: cfg.RetentionMaxEntries,
+		MaxAge:     time.Duration(cfg.RetentionMaxAgeDays) * 24 * time.Hour,
+		MaxBytes:   cfg.RetentionMaxBytes,
+	}
+}
+
+// Enabled reports whether any limit is set
+func (p Policy) Enabled() bool {
+	return p.MaxEntries > 0 || p.MaxAge > 0 || p.MaxBytes > 0
+}
+
+// File is a candidate for pruning
+type File struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// Select returns the files that violate the policy, oldest first.
+// The newest file is always kept, since it is the one being written to.
+func Select(files []File, p Policy, now time.Time) []File {
+	if len(files) == 0 || !p.Enabled() {
+		return nil
+	}
+
+	sorted := append([]File(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ModTime.After(sorted[j].ModTime)
+	})
+
+	// Walk newest to oldest; once a limit is crossed everything older goes
+	var total int64
+	for i, f := range sorted {
+		total += f.Size
+		if i == 0 {
+			continue
+		}
+		if (p.MaxEntries > 0 && i >= p.MaxEntries) ||
+			(p.MaxAge > 0 && now.Sub(f.ModTime) > p.MaxAge) ||
+			(p.MaxBytes > 0 && total > p.MaxBytes) {
+			expired := sorted[i:]
+			for l, r := 0, len(expired)-1; l < r; l, r = l+1, r-1 {
+				expired[l], expired[r] = expired[r], expired[l]
+			}
+			return expired
+		}
+	}
+	return nil
+}
+
+// Prune removes the files in dir matching pattern that violate the policy
+// and returns the paths removed
+func Prune(dir, pattern string, p Policy) ([]string, error) {
+	if !p.Enabled() {
+		return nil, nil
+	}
+
+	return prune(dir, pattern, nil, p)
+}
+
+// PruneDated is Prune for a store that names its files by when they were
+// written. Only files whose name date parses are considered, so others
+// sharing dir are never removed, and their age comes from that date rather
+// than the modification time, which copying a file changes.
+func PruneDated(dir, pattern string, date func(name string) (time.Time, bool), p Policy) ([]string, error) {
+	if !p.Enabled() {
+		return nil, nil
+	}
+	return prune(dir, pattern, date, p)
+}
+
+// prune removes the files Select picks from those matching pattern, dated by
+// date when it is set
+func prune(dir, pattern string, date func(name string) (time.Time, bool), p Policy) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		f := File{Path: path, ModTime: info.ModTime(), Size: info.Size()}
+		if date != nil {
+			written, ok := date(filepath.Base(path))
+			if !ok {
+				continue
+			}
+			f.ModTime = written
+		}
+		files = append(files, f)
+	}
+
+	var removed []string
+	for _, f := range Select(files, p, time.Now()) {
+		if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed = append(removed, f.Path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,122 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// files returns one 100-byte file per day, newest (day 0) first
+func files(days int) []File {
+	var fs []File
+	for d := 0; d < days; d++ {
+		fs = append(fs, File{
+			Path:    filepath.Join("journal", now.AddDate(0, 0, -d).Format("2006-01-02")+".md"),
+			ModTime: now.AddDate(0, 0, -d),
+			Size:    100,
+		})
+	}
+	return fs
+}
+
+func paths(fs []File) []string {
+	var ps []string
+	for _, f := range fs {
+		ps = append(ps, filepath.Base(f.Path))
+	}
+	return ps
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{"disabled", Policy{}, nil},
+		{"count", Policy{MaxEntries: 3}, []string{"2024-01-11.md", "2024-01-12.md"}},
+		{"count within limit", Policy{MaxEntries: 10}, nil},
+		{"age", Policy{MaxAge: 36 * time.Hour}, []string{"2024-01-11.md", "2024-01-12.md", "2024-01-13.md"}},
+		{"size", Policy{MaxBytes: 250}, []string{"2024-01-11.md", "2024-01-12.md", "2024-01-13.md"}},
+		{"strictest wins", Policy{MaxEntries: 4, MaxBytes: 150}, []string{"2024-01-11.md", "2024-01-12.md", "2024-01-13.md", "2024-01-14.md"}},
+		{"newest always kept", Policy{MaxBytes: 1}, []string{"2024-01-11.md", "2024-01-12.md", "2024-01-13.md", "2024-01-14.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paths(Select(files(5), tt.policy, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range files(3) {
+		path := filepath.Join(dir, filepath.Base(f.Path))
+		if err := os.WriteFile(path, []byte("entry"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, f.ModTime, f.ModTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files not matching the pattern are never touched
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(dir, "*.md", Policy{MaxEntries: 1})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("Expected 2 files removed, got %v", removed)
+	}
+
+	left, _ := filepath.Glob(filepath.Join(dir, "*"))
+	want := []string{filepath.Join(dir, "2024-01-15.md"), other}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("Expected %v left, got %v", want, left)
+	}
+}
+
+func TestPruneDated(t *testing.T) {
+	dir := t.TempDir()
+	today := time.Now()
+	names := []string{
+		today.AddDate(0, 0, -40).Format("2006-01-02") + ".md",
+		today.Format("2006-01-02") + ".md",
+		"ideas.md",
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("entry"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Every file looks old by its modification time
+		old := today.AddDate(-1, 0, 0)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	date := func(name string) (time.Time, bool) {
+		d, err := time.ParseInLocation("2006-01-02.md", name, time.Local)
+		return d, err == nil
+	}
+
+	removed, err := PruneDated(dir, "*.md", date, Policy{MaxAge: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("PruneDated failed: %v", err)
+	}
+	if want := []string{filepath.Join(dir, names[0])}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("Expected %v removed, got %v", want, removed)
+	}
+}
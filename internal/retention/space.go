@@ -0,0 +1,53 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrLowDiskSpace is returned when a write is skipped to keep the disk from filling
+var ErrLowDiskSpace = errors.New("not enough free disk space")
+
+// statfs is swapped in tests
+var statfs = syscall.Statfs
+
+// hasFreeSpace reports whether the filesystem holding path has at least
+// minBytes available to unprivileged users, and how much it has. A path that
+// doesn't exist yet is checked at its nearest existing parent. When the
+// space can't be determined the answer is yes, so an unusual filesystem
+// never blocks saving.
+func hasFreeSpace(path string, minBytes int64) (bool, uint64) {
+	for {
+		var st syscall.Statfs_t
+		err := statfs(path, &st)
+		if err == nil {
+			free := st.Bavail * uint64(st.Bsize)
+			return free >= uint64(minBytes), free
+		}
+		parent := filepath.Dir(path)
+		if !os.IsNotExist(err) || parent == path {
+			return true, 0
+		}
+		path = parent
+	}
+}
+
+// CheckSpace returns an error wrapping ErrLowDiskSpace when dir's filesystem
+// has less than minBytes free. Zero or negative minBytes disables the check.
+func CheckSpace(dir string, minBytes int64) error {
+	if minBytes <= 0 {
+		return nil
+	}
+	if ok, free := hasFreeSpace(dir, minBytes); !ok {
+		return fmt.Errorf("%w in %s (%d MB free, %d MB required)", ErrLowDiskSpace, dir, free>>20, minBytes>>20)
+	}
+	return nil
+}
+
+// MinFreeBytes converts the min_free_space_mb setting to bytes
+func MinFreeBytes(mb int) int64 {
+	return int64(mb) << 20
+}
@@ -0,0 +1,81 @@
+package retention
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// fakeStatfs reports blocks 4096-byte blocks available on every path in
+// existing and ENOENT elsewhere
+func fakeStatfs(blocks uint64, existing ...string) func(string, *syscall.Statfs_t) error {
+	return func(path string, st *syscall.Statfs_t) error {
+		for _, p := range existing {
+			if p == path {
+				st.Bsize = 4096
+				st.Bavail = blocks
+				return nil
+			}
+		}
+		return &os.PathError{Op: "statfs", Path: path, Err: syscall.ENOENT}
+	}
+}
+
+func TestHasFreeSpace(t *testing.T) {
+	defer func() { statfs = syscall.Statfs }()
+
+	const mb = 1 << 20
+	tests := []struct {
+		name     string
+		blocks   uint64
+		minBytes int64
+		want     bool
+	}{
+		{"plenty", 25600, 50 * mb, true}, // 100 MB free
+		{"exactly enough", 12800, 50 * mb, true},
+		{"one block short", 12799, 50 * mb, false},
+		{"full disk", 0, 1, false},
+	}
+
+	for _, tt := range tests {
+		statfs = fakeStatfs(tt.blocks, "/data")
+		if got, _ := hasFreeSpace("/data", tt.minBytes); got != tt.want {
+			t.Errorf("%s: hasFreeSpace = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A directory that will be created later is checked at its parent
+	statfs = fakeStatfs(0, "/data")
+	if got, _ := hasFreeSpace("/data/journal/2024", mb); got {
+		t.Error("Expected the missing directory to be checked on its parent's full disk")
+	}
+
+	// Unknown space doesn't block writes
+	statfs = func(string, *syscall.Statfs_t) error { return syscall.ENOSYS }
+	if got, _ := hasFreeSpace("/data", mb); !got {
+		t.Error("Expected a statfs failure to allow the write")
+	}
+}
+
+func TestCheckSpace(t *testing.T) {
+	defer func() { statfs = syscall.Statfs }()
+	statfs = fakeStatfs(100, "/data")
+
+	if err := CheckSpace("/data", 0); err != nil {
+		t.Errorf("Expected a zero threshold to disable the check, got %v", err)
+	}
+	if err := CheckSpace("/data", MinFreeBytes(1)); !errors.Is(err, ErrLowDiskSpace) {
+		t.Errorf("Expected ErrLowDiskSpace, got %v", err)
+	}
+	if err := CheckSpace("/data", 4096); err != nil {
+		t.Errorf("Expected 400 KB free to satisfy 4 KB, got %v", err)
+	}
+}
+
+func TestCheckSpaceRealFilesystem(t *testing.T) {
+	if err := CheckSpace(filepath.Join(t.TempDir(), "missing"), 1); err != nil {
+		t.Errorf("Expected the temp filesystem to have a byte free, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package session detects the graphical session type (X11 or Wayland)
+package session
+
+import (
+	"os"
+	"strings"
+)
+
+// Type is a display server protocol
+type Type int
+
+const (
+	// Unknown means no graphical session was detected
+	Unknown Type = iota
+	// X11 is an X11 session (including XWayland-only environments)
+	X11
+	// Wayland is a native Wayland session; DISPLAY may also be set for XWayland
+	Wayland
+)
+
+// String returns the session type name
+func (t Type) String() string {
+	switch t {
+	case X11:
+		return "x11"
+	case Wayland:
+		return "wayland"
+	default:
+		return "unknown"
+	}
+}
+
+// Detect determines the session type from environment variables.
+// XDG_SESSION_TYPE is trusted first when its display variable is present,
+// since XWayland sessions set both DISPLAY and WAYLAND_DISPLAY. Otherwise
+// the presence of WAYLAND_DISPLAY, then DISPLAY, decides.
+func Detect(getenv func(string) string) Type {
+	wayland := getenv("WAYLAND_DISPLAY") != ""
+	x11 := getenv("DISPLAY") != ""
+
+	switch strings.ToLower(strings.TrimSpace(getenv("XDG_SESSION_TYPE"))) {
+	case "wayland":
+		if wayland {
+			return Wayland
+		}
+	case "x11":
+		if x11 {
+			return X11
+		}
+	}
+
+	switch {
+	case wayland:
+		return Wayland
+	case x11:
+		return X11
+	default:
+		return Unknown
+	}
+}
+
+// Current returns the session type of this process
+func Current() Type {
+	return Detect(os.Getenv)
+}
+
+// IsWayland reports whether this process runs in a Wayland session
+func IsWayland() bool {
+	return Current() == Wayland
+}
+
+// IsX11 reports whether this process runs in an X11 session
+func IsX11() bool {
+	return Current() == X11
+}
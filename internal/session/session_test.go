@@ -0,0 +1,30 @@
+package session
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected Type
+	}{
+		{"nothing", map[string]string{}, Unknown},
+		{"plain x11", map[string]string{"DISPLAY": ":0"}, X11},
+		{"plain wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, Wayland},
+		{"xwayland", map[string]string{"DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-0"}, Wayland},
+		{"custom socket name", map[string]string{"WAYLAND_DISPLAY": "wl-1"}, Wayland},
+		{"xdg wayland", map[string]string{"XDG_SESSION_TYPE": "wayland", "DISPLAY": ":0", "WAYLAND_DISPLAY": "wl-1"}, Wayland},
+		{"xdg x11 with stray wayland var", map[string]string{"XDG_SESSION_TYPE": "x11", "DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-0"}, X11},
+		{"xdg wayland without socket", map[string]string{"XDG_SESSION_TYPE": "wayland", "DISPLAY": ":1"}, X11},
+		{"xdg x11 without display", map[string]string{"XDG_SESSION_TYPE": "x11", "WAYLAND_DISPLAY": "wayland-0"}, Wayland},
+		{"tty", map[string]string{"XDG_SESSION_TYPE": "tty"}, Unknown},
+		{"xdg case", map[string]string{"XDG_SESSION_TYPE": "Wayland", "WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, Wayland},
+	}
+
+	for _, tc := range testCases {
+		got := Detect(func(key string) string { return tc.env[key] })
+		if got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package sound plays short audio cues (earcons) for accessibility
+package sound
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	"speek_to_text_linux/pkg/wav"
+)
+
+// toneRate is the sample rate earcons are synthesized at
+const toneRate = 16000
+
+// playTimeout bounds one earcon, so a stuck player can't hold up an exit
+const playTimeout = 2 * time.Second
+
+// playTools are tried in order; each plays a WAV file given as its last argument
+var playTools = [][]string{
+	{"pw-play"},
+	{"paplay"},
+	{"aplay", "-q"},
+}
+
+// lookPath and play are swapped in tests
+var (
+	lookPath = exec.LookPath
+	play     = playWAV
+)
+
+// Player plays earcons through the first available playback tool
+type Player struct {
+	errorSound bool
+}
+
+// NewPlayer creates a player with every cue disabled
+func NewPlayer() *Player {
+	return &Player{}
+}
+
+// SetErrorSound enables the cue played by Error
+func (p *Player) SetErrorSound(enabled bool) {
+	p.errorSound = enabled
+}
+
+// Error plays the error earcon, a falling two-note tone, when enabled. It
+// returns once playback has finished, so the cue is heard in full even when
+// the app quits right after.
+func (p *Player) Error(ctx context.Context) error {
+	if !p.errorSound {
+		return nil
+	}
+	data, err := wav.Encode(ErrorTone(), toneRate, 1, 16)
+	if err != nil {
+		return err
+	}
+	pCtx, cancel := context.WithTimeout(ctx, playTimeout)
+	defer cancel()
+	return play(pCtx, data)
+}
+
+// ErrorTone returns the error earcon as 16-bit mono PCM at 16 kHz: 660 Hz
+// then 440 Hz, 120ms each. A falling interval reads as "failed" and is unlike
+// a single start or stop beep.
+func ErrorTone() []byte {
+	return append(tone(660, 120*time.Millisecond), tone(440, 120*time.Millisecond)...)
+}
+
+// tone synthesizes a sine at freq Hz for d, faded in and out over 5ms so it
+// doesn't click
+func tone(freq float64, d time.Duration) []byte {
+	n := int(d.Seconds() * toneRate)
+	fade := toneRate * 5 / 1000
+	pcm := make([]byte, n*2)
+	for i := 0; i < n; i++ {
+		gain := 0.3
+		if i < fade {
+			gain *= float64(i) / float64(fade)
+		} else if n-i < fade {
+			gain *= float64(n-i) / float64(fade)
+		}
+		s := int16(gain * math.MaxInt16 * math.Sin(2*math.Pi*freq*float64(i)/toneRate))
+		pcm[2*i] = byte(s)
+		pcm[2*i+1] = byte(uint16(s) >> 8)
+	}
+	return pcm
+}
+
+// playWAV writes data to a temporary file and plays it with the first
+// available tool, waiting for playback to finish
+func playWAV(ctx context.Context, data []byte) error {
+	args := playerArgs()
+	if args == nil {
+		return fmt.Errorf("no audio player found (install pipewire, pulseaudio-utils or alsa-utils)")
+	}
+
+	f, err := os.CreateTemp("", "voicetype-cue-*.wav")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return exec.CommandContext(ctx, args[0], append(args[1:], f.Name())...).Run()
+}
+
+// playerArgs returns the command of the first installed playback tool, or
+// nil when none is
+func playerArgs() []string {
+	for _, args := range playTools {
+		if _, err := lookPath(args[0]); err == nil {
+			return args
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package sound
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"speek_to_text_linux/pkg/wav"
+)
+
+func TestErrorPlaysOnlyWhenEnabled(t *testing.T) {
+	defer func() { play = playWAV }()
+
+	var played [][]byte
+	play = func(ctx context.Context, data []byte) error {
+		played = append(played, data)
+		return nil
+	}
+
+	p := NewPlayer()
+	if err := p.Error(context.Background()); err != nil {
+		t.Fatalf("Error failed: %v", err)
+	}
+	if len(played) != 0 {
+		t.Fatal("Expected no sound with error_sound off")
+	}
+
+	p.SetErrorSound(true)
+	if err := p.Error(context.Background()); err != nil {
+		t.Fatalf("Error failed: %v", err)
+	}
+	if len(played) != 1 {
+		t.Fatalf("Expected the error earcon to play once, played %d times", len(played))
+	}
+
+	format, pcm, err := wav.Decode(played[0])
+	if err != nil {
+		t.Fatalf("Expected a valid WAV, got %v", err)
+	}
+	if format != (wav.Format{SampleRate: toneRate, Channels: 1, BitsPerSample: 16}) {
+		t.Errorf("Unexpected format %+v", format)
+	}
+	if len(pcm) != len(ErrorTone()) {
+		t.Errorf("Expected %d bytes of tone, got %d", len(ErrorTone()), len(pcm))
+	}
+}
+
+func TestErrorWaitsForPlayback(t *testing.T) {
+	defer func() { play = playWAV }()
+
+	finished := false
+	play = func(ctx context.Context, data []byte) error {
+		time.Sleep(30 * time.Millisecond)
+		finished = true
+		return nil
+	}
+
+	p := NewPlayer()
+	p.SetErrorSound(true)
+	_ = p.Error(context.Background())
+	if !finished {
+		t.Error("Expected Error to return only after playback, so a quit right after doesn't cut it off")
+	}
+}
+
+func TestErrorReportsPlaybackFailure(t *testing.T) {
+	defer func() { play = playWAV }()
+
+	failure := errors.New("no device")
+	play = func(ctx context.Context, data []byte) error { return failure }
+
+	p := NewPlayer()
+	p.SetErrorSound(true)
+	if err := p.Error(context.Background()); err != failure {
+		t.Errorf("Expected the playback error, got %v", err)
+	}
+}
+
+func TestErrorToneFallsInPitch(t *testing.T) {
+	pcm := ErrorTone()
+	half := len(pcm) / 2
+	first, second := zeroCrossings(pcm[:half]), zeroCrossings(pcm[half:])
+	if first <= second {
+		t.Errorf("Expected the first note higher than the second, got %d vs %d zero crossings", first, second)
+	}
+}
+
+func TestPlayerArgsPicksFirstInstalled(t *testing.T) {
+	defer func() { lookPath = exec.LookPath }()
+
+	testCases := []struct {
+		installed []string
+		expected  []string
+	}{
+		{[]string{"pw-play", "paplay", "aplay"}, []string{"pw-play"}},
+		{[]string{"paplay", "aplay"}, []string{"paplay"}},
+		{[]string{"aplay"}, []string{"aplay", "-q"}},
+		{nil, nil},
+	}
+
+	for _, tc := range testCases {
+		lookPath = func(name string) (string, error) {
+			for _, tool := range tc.installed {
+				if tool == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+		if got := playerArgs(); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("playerArgs() with %v = %v, want %v", tc.installed, got, tc.expected)
+		}
+	}
+}
+
+// zeroCrossings counts sign changes in 16-bit PCM, proportional to pitch
+func zeroCrossings(pcm []byte) int {
+	count := 0
+	prev := int16(0)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s := int16(uint16(pcm[i]) | uint16(pcm[i+1])<<8)
+		if (s < 0) != (prev < 0) {
+			count++
+		}
+		prev = s
+	}
+	return count
+}
@@ -0,0 +1,18 @@
+// Package terminal detects whether standard streams are attached to a TTY
+package terminal
+
+import (
+	"golang.org/x/term"
+)
+
+// Fder is implemented by *os.File
+type Fder interface {
+	Fd() uintptr
+}
+
+// IsTerminal reports whether f is a TTY, i.e. has terminal attributes.
+// Pipes, regular files, /dev/null redirections and closed descriptors
+// (common when launched from a desktop icon) all report false.
+func IsTerminal(f Fder) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,51 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() failed: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	regular, err := os.Create(filepath.Join(t.TempDir(), "input.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer regular.Close()
+
+	closed, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	testCases := []struct {
+		name string
+		file *os.File
+	}{
+		{"pipe", r},
+		// A character device like a TTY, but never interactive
+		{"dev null", devNull},
+		{"regular file", regular},
+		{"closed", closed},
+	}
+
+	for _, tc := range testCases {
+		if IsTerminal(tc.file) {
+			t.Errorf("%s: expected not to be a terminal", tc.name)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Package toolpath checks for the command-line tools VoiceType shells out to
+package toolpath
+
+import "os/exec"
+
+// Available reports whether tool is found on PATH
+func Available(tool string) bool {
+	return AvailableWith(exec.LookPath, tool)
+}
+
+// AvailableWith reports whether lookPath finds tool, for callers that swap
+// their lookup in tests
+func AvailableWith(lookPath func(string) (string, error), tool string) bool {
+	_, err := lookPath(tool)
+	return err == nil
+}
@@ -0,0 +1,22 @@
+package toolpath
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestAvailableWith(t *testing.T) {
+	lookPath := func(tool string) (string, error) {
+		if tool == "xdotool" {
+			return "/usr/bin/xdotool", nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	if !AvailableWith(lookPath, "xdotool") {
+		t.Error("xdotool should be available")
+	}
+	if AvailableWith(lookPath, "wtype") {
+		t.Error("wtype should not be available")
+	}
+}
@@ -0,0 +1,80 @@
+// Package tts reads text aloud, so a transcription can be heard and checked
+// before it is typed
+package tts
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// speakTool is a speech command. A stdin tool reads the text from standard
+// input; the others take it as the last argument.
+type speakTool struct {
+	args  []string
+	stdin bool
+}
+
+// speakTools are tried in order; each returns once the text is spoken
+var speakTools = []speakTool{
+	{[]string{"espeak-ng"}, true},
+	{[]string{"espeak"}, true},
+	{[]string{"spd-say", "--wait", "--"}, false},
+}
+
+// ErrNoSpeaker means no text-to-speech tool is installed
+var ErrNoSpeaker = fmt.Errorf("no text-to-speech tool found (install espeak-ng or speech-dispatcher)")
+
+// lookPath and run are swapped in tests
+var (
+	lookPath = exec.LookPath
+	run      = runTool
+)
+
+// Available reports whether Speak has a tool to use
+func Available() bool {
+	_, ok := speaker()
+	return ok
+}
+
+// Speak reads text aloud with the first installed tool and returns once it
+// has been spoken, or when ctx ends, which cuts the speech short. It returns
+// ErrNoSpeaker when there is no tool, so callers can carry on without it.
+func Speak(ctx context.Context, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	tool, ok := speaker()
+	if !ok {
+		return ErrNoSpeaker
+	}
+	args := tool.args
+	stdin := ""
+	if tool.stdin {
+		stdin = text
+	} else {
+		args = append(append([]string(nil), args...), text)
+	}
+	return run(ctx, args, stdin)
+}
+
+// speaker returns the first installed tool
+func speaker() (speakTool, bool) {
+	for _, tool := range speakTools {
+		if _, err := lookPath(tool.args[0]); err == nil {
+			return tool, true
+		}
+	}
+	return speakTool{}, false
+}
+
+// runTool runs a speech command, writing stdin to it if not empty
+func runTool(ctx context.Context, args []string, stdin string) error {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	return cmd.Run()
+}
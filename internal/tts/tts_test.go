@@ -0,0 +1,69 @@
+package tts
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestSpeakUsesFirstInstalledTool(t *testing.T) {
+	defer func() { lookPath, run = exec.LookPath, runTool }()
+
+	testCases := []struct {
+		installed []string
+		args      []string
+		stdin     string
+		err       error
+	}{
+		{[]string{"espeak-ng", "spd-say"}, []string{"espeak-ng"}, "hello there", nil},
+		{[]string{"espeak"}, []string{"espeak"}, "hello there", nil},
+		{[]string{"spd-say"}, []string{"spd-say", "--wait", "--", "hello there"}, "", nil},
+		{nil, nil, "", ErrNoSpeaker},
+	}
+
+	for _, tc := range testCases {
+		lookPath = func(tool string) (string, error) {
+			for _, name := range tc.installed {
+				if name == tool {
+					return "/usr/bin/" + tool, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+		var gotArgs []string
+		var gotStdin string
+		run = func(ctx context.Context, args []string, stdin string) error {
+			gotArgs, gotStdin = args, stdin
+			return nil
+		}
+
+		err := Speak(context.Background(), "  hello there\n")
+		if !errors.Is(err, tc.err) {
+			t.Errorf("installed %v: expected error %v, got %v", tc.installed, tc.err, err)
+		}
+		if !reflect.DeepEqual(gotArgs, tc.args) || gotStdin != tc.stdin {
+			t.Errorf("installed %v: ran %q with stdin %q, expected %q with %q", tc.installed, gotArgs, gotStdin, tc.args, tc.stdin)
+		}
+		if Available() != (tc.err == nil) {
+			t.Errorf("installed %v: Available() = %v", tc.installed, Available())
+		}
+	}
+
+	// spd-say's argument list is not shared between calls
+	if len(speakTools[2].args) != 3 {
+		t.Errorf("Expected the spd-say command to be left as is, got %q", speakTools[2].args)
+	}
+}
+
+func TestSpeakSkipsEmptyText(t *testing.T) {
+	defer func() { run = runTool }()
+	run = func(ctx context.Context, args []string, stdin string) error {
+		t.Error("Expected nothing to be spoken")
+		return nil
+	}
+	if err := Speak(context.Background(), " \n"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package typing
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// clipboardTimeout bounds each clipboard write. Without a clipboard manager
+// some tools (notably wl-copy) can block; typing must carry on regardless.
+var clipboardTimeout = 2 * time.Second
+
+// clipboardWaitDelay is how long Wait may take after the context is done.
+// Selection owners fork a child that can inherit our pipes, which would
+// otherwise keep Wait blocked after the tool itself was killed.
+var clipboardWaitDelay = 500 * time.Millisecond
+
+// writeClipboard runs a clipboard tool with text on its stdin. Stdin is closed
+// once the text is written, so tools that read until EOF finish.
+func writeClipboard(ctx context.Context, text, tool string, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, clipboardTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.WaitDelay = clipboardWaitDelay
+	return cmd.Run()
+}
+
+// readClipboard runs a clipboard tool and returns what it prints
+func readClipboard(ctx context.Context, tool string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, clipboardTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, tool, args...)
+	cmd.WaitDelay = clipboardWaitDelay
+	out, err := cmd.Output()
+	return string(out), err
+}
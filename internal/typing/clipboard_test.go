@@ -0,0 +1,86 @@
+package typing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTool writes a shell script standing in for a clipboard tool
+func fakeTool(t *testing.T, script string) string {
+	t.Helper()
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	path := filepath.Join(t.TempDir(), "fake-clip")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteClipboardCancelsHangingTool(t *testing.T) {
+	origTimeout, origDelay := clipboardTimeout, clipboardWaitDelay
+	defer func() { clipboardTimeout, clipboardWaitDelay = origTimeout, origDelay }()
+	clipboardTimeout = 200 * time.Millisecond
+	clipboardWaitDelay = 100 * time.Millisecond
+
+	testCases := []struct {
+		name   string
+		script string
+		text   string
+	}{
+		// Blocks like wl-copy with no clipboard manager
+		{"ignores stdin", "exec sleep 30", "hello"},
+		// A forked child keeps stdin open and unread after the tool is
+		// killed, so writing a large text would block forever
+		{"child holds stdin", "sleep 30 <&0 &\nexec sleep 30", strings.Repeat("x", 1<<20)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := fakeTool(t, tc.script)
+			start := time.Now()
+			err := writeClipboard(context.Background(), tc.text, tool)
+			if err == nil {
+				t.Error("Expected an error from a tool that never finishes")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Expected the hanging tool to be cancelled, took %v", elapsed)
+			}
+		})
+	}
+
+	// The caller's context cancels it too
+	tool := fakeTool(t, "exec sleep 30")
+	clipboardTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := writeClipboard(ctx, "hello", tool); err == nil {
+		t.Error("Expected an error when the context is cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected context cancellation to stop the tool, took %v", elapsed)
+	}
+}
+
+func TestWriteClipboardClosesStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clipboard")
+	// Reads until EOF, like xsel --input and xclip
+	tool := fakeTool(t, "cat > "+out)
+
+	if err := writeClipboard(context.Background(), "hello world", tool); err != nil {
+		t.Fatalf("writeClipboard failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("Expected text on stdin, got %q", data)
+	}
+}
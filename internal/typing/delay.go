@@ -0,0 +1,49 @@
+package typing
+
+import (
+	"fmt"
+)
+
+// defaultXdotoolDelayMs is the per-character delay xdotool has always used here
+const defaultXdotoolDelayMs = 2
+
+// SetTypeDelay sets the per-character delay in milliseconds for a direct
+// typing tool (xdotool, ydotool or wtype). A negative delay leaves the tool's
+// own default in place.
+func (s *System) SetTypeDelay(tool string, ms int) {
+	if s.typeDelays == nil {
+		s.typeDelays = make(map[string]int)
+	}
+	s.typeDelays[tool] = ms
+}
+
+// typeDelay returns the configured delay for tool, or -1 for the tool default
+func (s *System) typeDelay(tool string) int {
+	if ms, ok := s.typeDelays[tool]; ok {
+		return ms
+	}
+	return -1
+}
+
+// typeArgs returns the arguments for tool to type text with a per-character
+// delay of delayMs, omitting the delay flag when delayMs is negative
+func typeArgs(tool, text string, delayMs int) []string {
+	var args []string
+	switch tool {
+	case "xdotool":
+		args = []string{"type", "--clearmodifiers"}
+		if delayMs >= 0 {
+			args = append(args, "--delay", fmt.Sprintf("%d", delayMs))
+		}
+	case "ydotool":
+		args = []string{"type"}
+		if delayMs >= 0 {
+			args = append(args, "--key-delay", fmt.Sprintf("%d", delayMs))
+		}
+	case "wtype":
+		if delayMs >= 0 {
+			args = append(args, "-d", fmt.Sprintf("%d", delayMs))
+		}
+	}
+	return append(args, text)
+}
@@ -0,0 +1,48 @@
+package typing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypeArgs(t *testing.T) {
+	testCases := []struct {
+		tool     string
+		delay    int
+		expected []string
+	}{
+		{"xdotool", 2, []string{"type", "--clearmodifiers", "--delay", "2", "hi"}},
+		{"xdotool", 0, []string{"type", "--clearmodifiers", "--delay", "0", "hi"}},
+		{"xdotool", -1, []string{"type", "--clearmodifiers", "hi"}},
+		{"ydotool", 15, []string{"type", "--key-delay", "15", "hi"}},
+		{"ydotool", -1, []string{"type", "hi"}},
+		{"wtype", 5, []string{"-d", "5", "hi"}},
+		{"wtype", -1, []string{"hi"}},
+	}
+
+	for _, tc := range testCases {
+		got := typeArgs(tc.tool, "hi", tc.delay)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("typeArgs(%q, %d) = %v, want %v", tc.tool, tc.delay, got, tc.expected)
+		}
+	}
+}
+
+func TestTypeDelayFromConfig(t *testing.T) {
+	s := NewSystem()
+	if got := s.typeDelay("xdotool"); got != defaultXdotoolDelayMs {
+		t.Errorf("Expected default xdotool delay %d, got %d", defaultXdotoolDelayMs, got)
+	}
+	if got := s.typeDelay("wtype"); got != -1 {
+		t.Errorf("Expected wtype to use its own default, got %d", got)
+	}
+
+	s.SetTypeDelay("xdotool", 10)
+	s.SetTypeDelay("ydotool", 20)
+	if got := typeArgs("xdotool", "hi", s.typeDelay("xdotool")); !reflect.DeepEqual(got, []string{"type", "--clearmodifiers", "--delay", "10", "hi"}) {
+		t.Errorf("Expected configured xdotool delay, got %v", got)
+	}
+	if got := typeArgs("ydotool", "hi", s.typeDelay("ydotool")); !reflect.DeepEqual(got, []string{"type", "--key-delay", "20", "hi"}) {
+		t.Errorf("Expected configured ydotool delay, got %v", got)
+	}
+}
@@ -0,0 +1,143 @@
+package typing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"speek_to_text_linux/internal/toolpath"
+)
+
+// Delivery methods for getting text into the focused app
+const (
+	// DeliveryPaste sets the clipboard and presses paste, typing only if that fails
+	DeliveryPaste = "paste"
+	// DeliveryType types the text key by key, pasting only if that fails
+	DeliveryType = "type"
+)
+
+// ParseDelivery normalizes a delivery method name; empty means DeliveryPaste
+func ParseDelivery(method string) (string, error) {
+	method = strings.ToLower(strings.TrimSpace(method))
+	switch method {
+	case "":
+		return DeliveryPaste, nil
+	case DeliveryPaste, DeliveryType:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown delivery method %q (use paste or type)", method)
+}
+
+// ResolveDelivery picks the delivery method for the focused window's class
+// from per-app rules keyed by WM_CLASS, e.g. {"slack": "paste",
+// "gnome-terminal-server": "type"}. Keys match case-insensitively, an exact
+// match first, then the longest key contained in the class, so "terminal"
+// covers every terminal emulator. Without a matching valid rule, or when the
+// class is unknown, def is used.
+func ResolveDelivery(class string, rules map[string]string, def string) string {
+	valid := make(map[string]string, len(rules))
+	for key, method := range rules {
+		if m, err := ParseDelivery(method); err == nil {
+			valid[key] = m
+		}
+	}
+	if key, ok := matchClass(class, valid); ok {
+		return valid[key]
+	}
+	return def
+}
+
+// matchClass returns the key of rules, keyed by WM_CLASS, that applies to
+// class: an exact case-insensitive match first, then the longest key
+// contained in the class. Ties between equally long keys go to the
+// alphabetically first, so map order can't change the answer.
+func matchClass[V any](class string, rules map[string]V) (string, bool) {
+	class = normalizeClass(class)
+	if class == "" {
+		return "", false
+	}
+
+	best, bestKey := "", ""
+	for key := range rules {
+		norm := normalizeClass(key)
+		if norm == "" {
+			continue
+		}
+		if norm == class {
+			return key, true
+		}
+		if strings.Contains(class, norm) && (len(norm) > len(bestKey) || (len(norm) == len(bestKey) && norm < bestKey)) {
+			best, bestKey = key, norm
+		}
+	}
+	return best, bestKey != ""
+}
+
+// DeliveryFor resolves the delivery method for the focused window from the
+// configured default and per-app rules. An invalid default is logged and
+// treated as DeliveryPaste; the window is only queried when there are rules.
+func (s *System) DeliveryFor(def string, rules map[string]string) string {
+	method, err := ParseDelivery(def)
+	if err != nil {
+		log.Printf("[Typing] %v, using paste", err)
+		method = DeliveryPaste
+	}
+	if len(rules) == 0 {
+		return method
+	}
+	class, err := s.GetActiveWindowClass()
+	if err != nil {
+		return method
+	}
+	resolved := ResolveDelivery(class, rules, method)
+	log.Printf("[Typing] Delivering to %s by %s", class, resolved)
+	return resolved
+}
+
+// DeliverText gets text into the focused app with method, one of the
+// Delivery constants, then presses postKey. Each method falls back to the
+// other when its tools fail. What it inserted can be taken back with UndoLast.
+func (s *System) DeliverText(ctx context.Context, text, postKey, method string) error {
+	if err := s.deliverText(ctx, text, postKey, method); err != nil {
+		return err
+	}
+	s.remember(ctx, text, postKey)
+	return nil
+}
+
+// deliverText is DeliverText without remembering the insertion
+func (s *System) deliverText(ctx context.Context, text, postKey, method string) error {
+	if method != DeliveryType {
+		return s.TypeText(ctx, text, postKey)
+	}
+
+	log.Printf("[Typing] Delivering transcription (%d chars) by direct typing...", len(text))
+	tCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	if err := s.typeDirect(tCtx, text, postKey); err == nil {
+		return nil
+	}
+	log.Printf("[Typing] Direct typing failed, falling back to paste")
+	if err := s.pasteThrough(tCtx, text, postKey); err != nil {
+		return s.leaveOnClipboard(ctx, text)
+	}
+	return nil
+}
+
+// typeDirect types text with the first typing tool that works, then presses
+// postKey with the same tool
+func (s *System) typeDirect(ctx context.Context, text, postKey string) error {
+	for _, tool := range []string{"ydotool", "wtype", "xdotool"} {
+		if !toolpath.AvailableWith(lookPath, tool) {
+			continue
+		}
+		if err := run(ctx, tool, typeArgs(tool, text, s.typeDelay(tool))...); err == nil {
+			s.pressPostKeyAfter(ctx, tool, postKey)
+			return nil
+		}
+	}
+	return fmt.Errorf("all typing methods failed")
+}
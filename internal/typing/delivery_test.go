@@ -0,0 +1,213 @@
+package typing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveDelivery(t *testing.T) {
+	rules := map[string]string{
+		"Slack":                 "paste",
+		"gnome-terminal-server": "type",
+		"terminal":              "type",
+		"term":                  "paste",
+		"kitty":                 "TYPE",
+		"firefox":               "bogus",
+		"":                      "type",
+	}
+
+	testCases := []struct {
+		class    string
+		def      string
+		expected string
+	}{
+		{"slack", DeliveryType, DeliveryPaste}, // exact, case-insensitive key
+		{"Gnome-terminal-server", DeliveryPaste, DeliveryType},
+		{"xfce4-terminal", DeliveryPaste, DeliveryType}, // longest contained key wins over "term"
+		{"xterm", DeliveryType, DeliveryPaste},          // only "term" is contained
+		{"kitty", DeliveryPaste, DeliveryType},          // method names are normalized
+		{"firefox", DeliveryPaste, DeliveryPaste},       // invalid rule falls back
+		{"code", DeliveryPaste, DeliveryPaste},          // no rule, default
+		{"code", DeliveryType, DeliveryType},
+		{"", DeliveryPaste, DeliveryPaste}, // unknown class, default
+	}
+
+	for _, tc := range testCases {
+		if got := ResolveDelivery(tc.class, rules, tc.def); got != tc.expected {
+			t.Errorf("ResolveDelivery(%q, default %s) = %s, expected %s", tc.class, tc.def, got, tc.expected)
+		}
+	}
+
+	if got := ResolveDelivery("slack", nil, DeliveryType); got != DeliveryType {
+		t.Errorf("Expected the default without rules, got %s", got)
+	}
+}
+
+func TestParseDelivery(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"", DeliveryPaste, false},
+		{"paste", DeliveryPaste, false},
+		{" Type ", DeliveryType, false},
+		{"clipboard", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseDelivery(tc.input)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("ParseDelivery(%q) = %q, %v; expected %q, error %v", tc.input, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestPostKeyUsesDeliveringTool(t *testing.T) {
+	defer func() { lookPath, run = exec.LookPath, runTool }()
+
+	testCases := []struct {
+		name      string
+		wayland   bool
+		installed []string
+		failing   []string // "tool" fails everything, "tool key" only the Enter
+		method    string
+		delivered string
+		enter     []string // tools tried for Enter, in order
+	}{
+		{"paste via wtype", true, []string{"wtype", "xdotool"}, nil, DeliveryPaste, "wtype", []string{"wtype"}},
+		{"wtype can't paste", true, []string{"wtype", "xdotool"}, []string{"wtype"}, DeliveryPaste, "xdotool", []string{"xdotool"}},
+		{"paste on X11", false, []string{"wtype", "xdotool"}, nil, DeliveryPaste, "xdotool", []string{"xdotool"}},
+		{"nothing pastes", true, []string{"wtype", "xdotool"}, []string{"wtype paste", "xdotool paste"}, DeliveryPaste, "wtype", []string{"wtype"}},
+		{"typed via ydotool", true, []string{"ydotool", "wtype"}, nil, DeliveryType, "ydotool", []string{"ydotool"}},
+		{"ydotool can't press Enter", true, []string{"ydotool", "wtype"}, []string{"ydotool key"}, DeliveryType, "ydotool", []string{"ydotool", "wtype"}},
+		{"no wtype on X11", false, []string{"ydotool", "wtype", "xdotool"}, []string{"ydotool key"}, DeliveryType, "ydotool", []string{"ydotool", "xdotool"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.wayland {
+				t.Setenv("XDG_SESSION_TYPE", "wayland")
+				t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+			} else {
+				t.Setenv("XDG_SESSION_TYPE", "x11")
+				t.Setenv("WAYLAND_DISPLAY", "")
+			}
+			t.Setenv("DISPLAY", ":0")
+
+			lookPath = func(tool string) (string, error) {
+				for _, installed := range tc.installed {
+					if tool == installed {
+						return "/usr/bin/" + tool, nil
+					}
+				}
+				return "", exec.ErrNotFound
+			}
+			var delivered string
+			var enter []string
+			run = func(_ context.Context, tool string, args ...string) error {
+				call := strings.Join(args, " ")
+				kind := "type"
+				switch {
+				case strings.Contains(call, "Return") || strings.Contains(call, "28:1"):
+					kind = "key"
+				case strings.Contains(call, "ctrl") || strings.Contains(call, "Insert"):
+					kind = "paste"
+				}
+				if kind == "key" {
+					enter = append(enter, tool)
+				}
+				for _, f := range tc.failing {
+					if f == tool || f == tool+" "+kind {
+						return fmt.Errorf("%s failed", f)
+					}
+				}
+				if kind != "key" && delivered == "" {
+					delivered = tool
+				}
+				return nil
+			}
+
+			s := NewSystem()
+			if err := s.DeliverText(context.Background(), "hello", PostKeyEnter, tc.method); err != nil {
+				t.Fatalf("DeliverText failed: %v", err)
+			}
+			if delivered != tc.delivered {
+				t.Errorf("Delivered via %q, expected %q", delivered, tc.delivered)
+			}
+			if !reflect.DeepEqual(enter, tc.enter) {
+				t.Errorf("Enter tried via %v, expected %v", enter, tc.enter)
+			}
+		})
+	}
+}
+
+func TestFailedDeliveryLeavesTextOnClipboard(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("no cat")
+	}
+
+	testCases := []struct {
+		name      string
+		method    string
+		clipboard string // the fake xclip's script
+		wantErr   error
+		notified  bool
+	}{
+		{"paste then type", DeliveryPaste, "exec " + cat + ` > "$0.$2"`, ErrLeftOnClipboard, true},
+		{"type then paste", DeliveryType, "exec " + cat + ` > "$0.$2"`, ErrLeftOnClipboard, true},
+		{"clipboard fails too", DeliveryPaste, "exit 1", nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Every typing and paste tool is installed and fails
+			dir := t.TempDir()
+			tools := map[string]string{"xdotool": "exit 1", "ydotool": "exit 1", "wtype": "exit 1", "xclip": tc.clipboard}
+			for tool, script := range tools {
+				if err := os.WriteFile(filepath.Join(dir, tool), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PATH", dir)
+			t.Setenv("XDG_SESSION_TYPE", "x11")
+			t.Setenv("WAYLAND_DISPLAY", "")
+			t.Setenv("DISPLAY", ":0")
+
+			s := NewSystem()
+			notified := false
+			s.OnManualPaste(func() { notified = true })
+
+			err := s.DeliverText(context.Background(), "hello world", PostKeyEnter, tc.method)
+			if err == nil {
+				t.Fatal("Expected an error when nothing can type or paste")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && errors.Is(err, ErrLeftOnClipboard) {
+				t.Errorf("Expected no claim the text is on the clipboard, got %v", err)
+			}
+			if notified != tc.notified {
+				t.Errorf("Notified %v, expected %v", notified, tc.notified)
+			}
+			if tc.notified {
+				data, err := os.ReadFile(filepath.Join(dir, "xclip.clipboard"))
+				if err != nil || string(data) != "hello world" {
+					t.Errorf("Expected the clipboard to hold the text, got %q, %v", data, err)
+				}
+			}
+		})
+	}
+}
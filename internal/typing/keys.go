@@ -0,0 +1,158 @@
+package typing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+)
+
+// Trailing keys that can be pressed after the text is delivered
+const (
+	PostKeyNone       = "none"
+	PostKeyEnter      = "enter"
+	PostKeyTab        = "tab"
+	PostKeyShiftEnter = "shift_enter"
+)
+
+// ResolvePostTypeKey returns the trailing key to press. An explicit
+// post_type_key wins; otherwise the legacy AutoReturn flag maps to Enter.
+func ResolvePostTypeKey(postTypeKey string, autoReturn bool) string {
+	switch strings.ToLower(strings.TrimSpace(postTypeKey)) {
+	case PostKeyNone:
+		return PostKeyNone
+	case PostKeyEnter, "return":
+		return PostKeyEnter
+	case PostKeyTab:
+		return PostKeyTab
+	case PostKeyShiftEnter, "shift+enter":
+		return PostKeyShiftEnter
+	}
+	if autoReturn {
+		return PostKeyEnter
+	}
+	return PostKeyNone
+}
+
+// PostKeyForText resolves the trailing key for a specific transcription. With
+// smartEnter, the Enter implied by AutoReturn is skipped for multi-line text,
+// where it would usually submit mid-thought; an explicit post_type_key of
+// "enter" still forces it.
+func PostKeyForText(text, postTypeKey string, autoReturn, smartEnter bool) string {
+	key := ResolvePostTypeKey(postTypeKey, autoReturn)
+	forced := ResolvePostTypeKey(postTypeKey, false) == PostKeyEnter
+	if smartEnter && key == PostKeyEnter && !forced && strings.ContainsAny(strings.TrimSpace(text), "\r\n") {
+		return PostKeyNone
+	}
+	return key
+}
+
+// postKeyArgs returns the arguments for tool to press key, or nil for none
+func postKeyArgs(tool, key string) []string {
+	switch tool {
+	case "xdotool":
+		switch key {
+		case PostKeyEnter:
+			return []string{"key", "Return"}
+		case PostKeyTab:
+			return []string{"key", "Tab"}
+		case PostKeyShiftEnter:
+			return []string{"key", "shift+Return"}
+		}
+	case "wtype":
+		switch key {
+		case PostKeyEnter:
+			return []string{"-k", "Return"}
+		case PostKeyTab:
+			return []string{"-k", "Tab"}
+		case PostKeyShiftEnter:
+			return []string{"-M", "shift", "-k", "Return", "-m", "shift"}
+		}
+	case "ydotool":
+		// Linux input event codes: 28 = KEY_ENTER, 15 = KEY_TAB, 42 = KEY_LEFTSHIFT
+		switch key {
+		case PostKeyEnter:
+			return []string{"key", "28:1", "28:0"}
+		case PostKeyTab:
+			return []string{"key", "15:1", "15:0"}
+		case PostKeyShiftEnter:
+			return []string{"key", "42:1", "28:1", "28:0", "42:0"}
+		}
+	}
+	return nil
+}
+
+// pressPostKeyWith presses key using a specific tool
+func (s *System) pressPostKeyWith(ctx context.Context, tool, key string) error {
+	args := postKeyArgs(tool, key)
+	if args == nil {
+		return nil
+	}
+	time.Sleep(100 * time.Millisecond)
+	return run(ctx, tool, args...)
+}
+
+// postKeyTools lists the tools to press the trailing key with, in order:
+// used, the tool that delivered the text and so reached the focused window,
+// then the others that can work in this session. wtype only works under
+// Wayland, and xdotool only reaches XWayland windows there, so it goes last.
+func postKeyTools(used string, wayland bool) []string {
+	others := []string{"xdotool", "ydotool"}
+	if wayland {
+		others = []string{"wtype", "ydotool", "xdotool"}
+	}
+	var tools []string
+	if used != "" {
+		tools = append(tools, used)
+	}
+	for _, tool := range others {
+		if tool != used {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// postKeyTimeout bounds pressing the trailing key across all the tools tried
+const postKeyTimeout = 2 * time.Second
+
+// pressPostKeyAfter presses key with the tool that delivered the text,
+// falling back to the other tools when it can't. A key that can't be
+// pressed at all is logged rather than failing the delivery, as the text
+// itself is in.
+func (s *System) pressPostKeyAfter(ctx context.Context, used, key string) {
+	tCtx, cancel := context.WithTimeout(ctx, postKeyTimeout)
+	defer cancel()
+	if err := s.pressPostKeyChain(tCtx, used, key); err != nil {
+		log.Printf("[Typing] %v", err)
+	}
+}
+
+// pressPostKeyChain presses key with the first of postKeyTools that works
+func (s *System) pressPostKeyChain(ctx context.Context, used, key string) error {
+	if key == PostKeyNone || key == "" {
+		return nil
+	}
+	for _, tool := range postKeyTools(used, session.IsWayland()) {
+		if !toolpath.AvailableWith(lookPath, tool) {
+			continue
+		}
+		err := s.pressPostKeyWith(ctx, tool, key)
+		if err == nil {
+			return nil
+		}
+		log.Printf("[Typing] Pressing %s via %s failed: %v", key, tool, err)
+	}
+	return fmt.Errorf("no tool could press %s", key)
+}
+
+// PressPostKey presses the trailing key with the best available tool
+func (s *System) PressPostKey(ctx context.Context, key string) error {
+	tCtx, cancel := context.WithTimeout(ctx, postKeyTimeout)
+	defer cancel()
+	return s.pressPostKeyChain(tCtx, "", key)
+}
@@ -0,0 +1,76 @@
+package typing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvePostTypeKey(t *testing.T) {
+	testCases := []struct {
+		postTypeKey string
+		autoReturn  bool
+		expected    string
+	}{
+		{"", false, PostKeyNone},
+		{"", true, PostKeyEnter},
+		{"none", true, PostKeyNone},
+		{"tab", false, PostKeyTab},
+		{"TAB", true, PostKeyTab},
+		{"enter", false, PostKeyEnter},
+		{"shift_enter", false, PostKeyShiftEnter},
+		{"bogus", true, PostKeyEnter},
+		{"bogus", false, PostKeyNone},
+	}
+
+	for _, tc := range testCases {
+		if got := ResolvePostTypeKey(tc.postTypeKey, tc.autoReturn); got != tc.expected {
+			t.Errorf("ResolvePostTypeKey(%q, %v) = %q, expected %q", tc.postTypeKey, tc.autoReturn, got, tc.expected)
+		}
+	}
+}
+
+func TestPostKeyForTextSmartEnter(t *testing.T) {
+	testCases := []struct {
+		text        string
+		postTypeKey string
+		autoReturn  bool
+		smartEnter  bool
+		expected    string
+	}{
+		{"hello world", "", true, true, PostKeyEnter},
+		{"first line\nsecond line", "", true, true, PostKeyNone},
+		{"first line\r\nsecond line", "", true, true, PostKeyNone},
+		{"trailing newline only\n", "", true, true, PostKeyEnter},
+		{"first line\nsecond line", "", true, false, PostKeyEnter},
+		{"first line\nsecond line", "enter", false, true, PostKeyEnter},
+		{"first line\nsecond line", "tab", false, true, PostKeyTab},
+		{"first line\nsecond line", "", false, true, PostKeyNone},
+	}
+
+	for _, tc := range testCases {
+		got := PostKeyForText(tc.text, tc.postTypeKey, tc.autoReturn, tc.smartEnter)
+		if got != tc.expected {
+			t.Errorf("PostKeyForText(%q, %q, %v, %v) = %q, expected %q", tc.text, tc.postTypeKey, tc.autoReturn, tc.smartEnter, got, tc.expected)
+		}
+	}
+}
+
+func TestPostKeyArgs(t *testing.T) {
+	testCases := []struct {
+		tool     string
+		key      string
+		expected []string
+	}{
+		{"xdotool", PostKeyTab, []string{"key", "Tab"}},
+		{"xdotool", PostKeyShiftEnter, []string{"key", "shift+Return"}},
+		{"wtype", PostKeyEnter, []string{"-k", "Return"}},
+		{"ydotool", PostKeyTab, []string{"key", "15:1", "15:0"}},
+		{"ydotool", PostKeyNone, nil},
+	}
+
+	for _, tc := range testCases {
+		if got := postKeyArgs(tc.tool, tc.key); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("postKeyArgs(%q, %q) = %v, expected %v", tc.tool, tc.key, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package typing
+
+import "strings"
+
+// FieldKind describes the focused input, as far as it can be detected
+type FieldKind int
+
+const (
+	// FieldUnknown means detection was not possible
+	FieldUnknown FieldKind = iota
+	// FieldText is a regular input
+	FieldText
+	// FieldPassword is a password or passphrase prompt
+	FieldPassword
+)
+
+// passwordPromptClasses are window class fragments of dedicated secret
+// prompts: GnuPG's pinentry, polkit agents, ssh-askpass and the GNOME and KDE
+// keyring prompts. Titles aren't matched, as any document or web page can
+// have "password" in its title.
+var passwordPromptClasses = []string{
+	"pinentry",
+	"polkit",
+	"askpass",
+	"gcr-prompter",
+	"kwalletd",
+}
+
+// DetectFieldKind makes a best-effort guess at whether the focused window is a
+// password prompt, from its class. Browsers and most toolkits don't expose the
+// field type, so this only catches dedicated prompts.
+func (s *System) DetectFieldKind() FieldKind {
+	class, err := s.GetActiveWindowClass()
+	if err != nil {
+		return FieldUnknown
+	}
+	return classifyWindowClass(class)
+}
+
+// classifyWindowClass maps a window class to a field kind
+func classifyWindowClass(class string) FieldKind {
+	class = strings.ToLower(strings.TrimSpace(class))
+	if class == "" {
+		return FieldUnknown
+	}
+	for _, prompt := range passwordPromptClasses {
+		if strings.Contains(class, prompt) {
+			return FieldPassword
+		}
+	}
+	return FieldText
+}
+
+// ShouldAvoidTyping reports whether text must not be auto-typed into the field.
+// Unknown fields are typed into, since detection is best-effort.
+func ShouldAvoidTyping(kind FieldKind, avoidPasswordFields bool) bool {
+	return avoidPasswordFields && kind == FieldPassword
+}
@@ -0,0 +1,46 @@
+package typing
+
+import "testing"
+
+func TestClassifyWindowClass(t *testing.T) {
+	testCases := []struct {
+		class    string
+		expected FieldKind
+	}{
+		{"", FieldUnknown},
+		{"gedit", FieldText},
+		{"firefox", FieldText},
+		{"pinentry-gtk-2", FieldPassword},
+		{"Pinentry-qt", FieldPassword},
+		{"polkit-gnome-authentication-agent-1", FieldPassword},
+		{"lxpolkit", FieldPassword},
+		{"ssh-askpass", FieldPassword},
+		{"gcr-prompter", FieldPassword},
+		{"kwalletd5", FieldPassword},
+	}
+
+	for _, tc := range testCases {
+		if got := classifyWindowClass(tc.class); got != tc.expected {
+			t.Errorf("classifyWindowClass(%q) = %v, expected %v", tc.class, got, tc.expected)
+		}
+	}
+}
+
+func TestShouldAvoidTyping(t *testing.T) {
+	testCases := []struct {
+		kind     FieldKind
+		enabled  bool
+		expected bool
+	}{
+		{FieldPassword, true, true},
+		{FieldPassword, false, false},
+		{FieldText, true, false},
+		{FieldUnknown, true, false},
+	}
+
+	for _, tc := range testCases {
+		if got := ShouldAvoidTyping(tc.kind, tc.enabled); got != tc.expected {
+			t.Errorf("ShouldAvoidTyping(%v, %v) = %v, expected %v", tc.kind, tc.enabled, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package typing
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+)
+
+// keyTool picks the tool that types the placeholder and later selects it
+// back, as both steps must use the same one, and presses BackSpace to undo
+func (s *System) keyTool() (string, error) {
+	if session.IsWayland() && toolpath.AvailableWith(lookPath, "wtype") {
+		return "wtype", nil
+	}
+	for _, tool := range []string{"xdotool", "ydotool"} {
+		if toolpath.AvailableWith(lookPath, tool) {
+			return tool, nil
+		}
+	}
+	return "", fmt.Errorf("no tool available to press keys")
+}
+
+// selectBackArgs returns the arguments for tool to extend the selection n
+// characters to the left of the cursor, or nil when there is nothing to select
+func selectBackArgs(tool string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	switch tool {
+	case "xdotool":
+		return []string{"key", "--clearmodifiers", "--repeat", strconv.Itoa(n), "--delay", "0", "shift+Left"}
+	case "wtype":
+		args := []string{"-M", "shift"}
+		for i := 0; i < n; i++ {
+			args = append(args, "-k", "Left")
+		}
+		return append(args, "-m", "shift")
+	case "ydotool":
+		// Linux input event codes: 42 = KEY_LEFTSHIFT, 105 = KEY_LEFT
+		args := []string{"key", "42:1"}
+		for i := 0; i < n; i++ {
+			args = append(args, "105:1", "105:0")
+		}
+		return append(args, "42:0")
+	}
+	return nil
+}
+
+// deleteArgs returns the arguments for tool to press BackSpace once
+func deleteArgs(tool string) []string {
+	switch tool {
+	case "xdotool":
+		return []string{"key", "--clearmodifiers", "BackSpace"}
+	case "wtype":
+		return []string{"-k", "BackSpace"}
+	case "ydotool":
+		// 14 = KEY_BACKSPACE
+		return []string{"key", "14:1", "14:0"}
+	}
+	return nil
+}
+
+// ShowPlaceholder types placeholder at the cursor as instant feedback while
+// the transcription is in flight. It is typed directly rather than pasted so
+// the clipboard is left alone.
+func (s *System) ShowPlaceholder(ctx context.Context, placeholder string) error {
+	if placeholder == "" {
+		return nil
+	}
+	tool, err := s.keyTool()
+	if err != nil {
+		return err
+	}
+
+	tCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return exec.CommandContext(tCtx, tool, typeArgs(tool, placeholder, s.typeDelay(tool))...).Run()
+}
+
+// SelectPlaceholder selects the placeholder typed by ShowPlaceholder so the
+// transcription pasted next replaces it
+func (s *System) SelectPlaceholder(ctx context.Context, placeholder string) error {
+	if placeholder == "" {
+		return nil
+	}
+	tool, err := s.keyTool()
+	if err != nil {
+		return err
+	}
+	args := selectBackArgs(tool, utf8.RuneCountInString(placeholder))
+
+	tCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return exec.CommandContext(tCtx, tool, args...).Run()
+}
+
+// ClearPlaceholder removes the placeholder when there is nothing to replace
+// it with, e.g. the transcription failed or was empty
+func (s *System) ClearPlaceholder(ctx context.Context, placeholder string) error {
+	if placeholder == "" {
+		return nil
+	}
+	if err := s.SelectPlaceholder(ctx, placeholder); err != nil {
+		return err
+	}
+	tool, err := s.keyTool()
+	if err != nil {
+		return err
+	}
+
+	tCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return exec.CommandContext(tCtx, tool, deleteArgs(tool)...).Run()
+}
@@ -0,0 +1,73 @@
+package typing
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSelectBackArgs(t *testing.T) {
+	testCases := []struct {
+		tool     string
+		n        int
+		expected []string
+	}{
+		{"xdotool", 3, []string{"key", "--clearmodifiers", "--repeat", "3", "--delay", "0", "shift+Left"}},
+		{"wtype", 2, []string{"-M", "shift", "-k", "Left", "-k", "Left", "-m", "shift"}},
+		{"ydotool", 2, []string{"key", "42:1", "105:1", "105:0", "105:1", "105:0", "42:0"}},
+		{"xdotool", 0, nil},
+		{"unknown", 3, nil},
+	}
+
+	for _, tc := range testCases {
+		got := selectBackArgs(tc.tool, tc.n)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("selectBackArgs(%q, %d) = %v, want %v", tc.tool, tc.n, got, tc.expected)
+		}
+	}
+}
+
+func TestPlaceholderInsertAndReplace(t *testing.T) {
+	// The ellipsis is one character but three bytes; selecting back by bytes
+	// would eat into the user's own text
+	placeholder := "…"
+
+	testCases := []struct {
+		tool    string
+		insert  []string
+		replace []string
+		remove  []string
+	}{
+		{
+			"xdotool",
+			[]string{"type", "--clearmodifiers", "--delay", "2", "…"},
+			[]string{"key", "--clearmodifiers", "--repeat", "1", "--delay", "0", "shift+Left"},
+			[]string{"key", "--clearmodifiers", "BackSpace"},
+		},
+		{
+			"wtype",
+			[]string{"…"},
+			[]string{"-M", "shift", "-k", "Left", "-m", "shift"},
+			[]string{"-k", "BackSpace"},
+		},
+		{
+			"ydotool",
+			[]string{"type", "…"},
+			[]string{"key", "42:1", "105:1", "105:0", "42:0"},
+			[]string{"key", "14:1", "14:0"},
+		},
+	}
+
+	s := NewSystem()
+	for _, tc := range testCases {
+		if got := typeArgs(tc.tool, placeholder, s.typeDelay(tc.tool)); !reflect.DeepEqual(got, tc.insert) {
+			t.Errorf("%s insert = %v, want %v", tc.tool, got, tc.insert)
+		}
+		if got := selectBackArgs(tc.tool, utf8.RuneCountInString(placeholder)); !reflect.DeepEqual(got, tc.replace) {
+			t.Errorf("%s replace = %v, want %v", tc.tool, got, tc.replace)
+		}
+		if got := deleteArgs(tc.tool); !reflect.DeepEqual(got, tc.remove) {
+			t.Errorf("%s remove = %v, want %v", tc.tool, got, tc.remove)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package typing
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RegexPrefix marks a replacement key as a regular expression rather than a
+// spoken phrase, e.g. "re:\\bsmiley( face)?\\b"
+const RegexPrefix = "re:"
+
+// replaceRule is one compiled replacement
+type replaceRule struct {
+	re      *regexp.Regexp
+	repl    string
+	literal bool // repl is inserted as is, without $1 expansion
+}
+
+// Replacer rewrites spoken phrases in a transcription, e.g. "new line" to a
+// line break or "open paren" to "(", and applies regular expression rules
+type Replacer struct {
+	rules []replaceRule
+}
+
+// NewReplacer compiles replacement rules. A key is a phrase matched as whole
+// words, so "period" leaves "periodically" alone, or with RegexPrefix a
+// regular expression whose replacement may use $1. Both match case
+// insensitively. A phrase replaced by punctuation or a line break takes the
+// spaces that would look wrong with it: "end period" becomes "end." and
+// "open paren x close paren" becomes "(x)". Phrases apply first, longest
+// first so "new paragraph" wins over "new", then expressions in key order.
+func NewReplacer(rules map[string]string) (*Replacer, error) {
+	var phrases, patterns []string
+	for key := range rules {
+		if strings.HasPrefix(key, RegexPrefix) {
+			patterns = append(patterns, key)
+		} else if strings.TrimSpace(key) != "" {
+			phrases = append(phrases, key)
+		}
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if len(phrases[i]) != len(phrases[j]) {
+			return len(phrases[i]) > len(phrases[j])
+		}
+		return phrases[i] < phrases[j]
+	})
+	sort.Strings(patterns)
+
+	r := &Replacer{}
+	for _, phrase := range phrases {
+		r.rules = append(r.rules, replaceRule{re: phraseRegexp(phrase, rules[phrase]), repl: rules[phrase], literal: true})
+	}
+	for _, key := range patterns {
+		re, err := regexp.Compile("(?i)" + strings.TrimPrefix(key, RegexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid replacement %q: %w", key, err)
+		}
+		r.rules = append(r.rules, replaceRule{re: re, repl: rules[key]})
+	}
+	return r, nil
+}
+
+// phraseRegexp matches phrase case-insensitively as whole words, with any
+// run of spaces between its words. Edges that are punctuation need no
+// word boundary, so a phrase like "?!" still matches. The spaces before a
+// replacement that attaches to the previous word, and after one that
+// attaches to the next, are matched too so they are dropped.
+func phraseRegexp(phrase, repl string) *regexp.Regexp {
+	words := strings.Fields(phrase)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	pattern := strings.Join(words, `\s+`)
+	trimmed := strings.TrimSpace(phrase)
+	if first, _ := utf8.DecodeRuneInString(trimmed); isWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(trimmed); isWordRune(last) {
+		pattern += `\b`
+	}
+	if first, _ := utf8.DecodeRuneInString(repl); repl != "" && strings.ContainsRune(".,;:!?)]}\n", first) {
+		pattern = `[ \t]*` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(repl); repl != "" && strings.ContainsRune("([{\n", last) {
+		pattern += `[ \t]*`
+	}
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+// isWordRune reports whether r is a character \b treats as part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// Apply rewrites text with every rule in order
+func (r *Replacer) Apply(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		if rule.literal {
+			text = rule.re.ReplaceAllLiteralString(text, rule.repl)
+		} else {
+			text = rule.re.ReplaceAllString(text, rule.repl)
+		}
+	}
+	return text
+}
+
+// SetReplacements compiles the replacements applied by ApplyReplacements:
+// rules for every app and, keyed by WM_CLASS as in ResolveDelivery, rules
+// for particular apps, which apply before the general ones. An invalid
+// rule set is reported and left out.
+func (s *System) SetReplacements(rules map[string]string, appRules map[string]map[string]string) error {
+	var errs []string
+	general, err := NewReplacer(rules)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	apps := make(map[string]*Replacer, len(appRules))
+	for class, rules := range appRules {
+		replacer, err := NewReplacer(rules)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", class, err))
+			continue
+		}
+		apps[class] = replacer
+	}
+
+	s.mu.Lock()
+	s.replacer, s.appReplacers = general, apps
+	s.mu.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ApplyReplacements rewrites a transcription with the rules for the focused
+// app, then the general ones. The window is only queried when some app has
+// rules of its own.
+func (s *System) ApplyReplacements(text string) string {
+	s.mu.Lock()
+	general, apps := s.replacer, s.appReplacers
+	s.mu.Unlock()
+
+	if len(apps) > 0 {
+		if class, err := s.GetActiveWindowClass(); err == nil {
+			if key, ok := matchClass(class, apps); ok {
+				log.Printf("[Typing] Applying replacements for %s", class)
+				text = apps[key].Apply(text)
+			}
+		}
+	}
+	return general.Apply(text)
+}
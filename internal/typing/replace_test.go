@@ -0,0 +1,98 @@
+package typing
+
+import "testing"
+
+func TestReplacer(t *testing.T) {
+	r, err := NewReplacer(map[string]string{
+		"new line":                "\n",
+		"new paragraph":           "\n\n",
+		"open paren":              "(",
+		"close paren":             ")",
+		"period":                  ".",
+		"dollar sign":             "$1",
+		"re:\\b(\\d+) percent\\b": "$1%",
+		"re:\\bum+\\b,? ?":        "",
+	})
+	if err != nil {
+		t.Fatalf("NewReplacer failed: %v", err)
+	}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"first new line second", "first\nsecond"},
+		{"New Line", "\n"},
+		{"new  paragraph", "\n\n"},
+		{"call open paren x close paren", "call (x)"},
+		{"end of sentence period", "end of sentence."},
+		{"we meet periodically", "we meet periodically"},
+		{"the periods are long", "the periods are long"},
+		{"costs a dollar sign", "costs a $1"},
+		{"about 50 percent done", "about 50% done"},
+		{"um, so umm yes", "so yes"},
+		{"newline stays", "newline stays"},
+	}
+
+	for _, tc := range testCases {
+		if got := r.Apply(tc.input); got != tc.expected {
+			t.Errorf("Apply(%q) = %q, expected %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestReplacerRejectsBadRegex(t *testing.T) {
+	if _, err := NewReplacer(map[string]string{"re:(unclosed": "x"}); err == nil {
+		t.Error("Expected an error for an invalid expression")
+	}
+}
+
+func TestReplacerPunctuationPhrase(t *testing.T) {
+	r, err := NewReplacer(map[string]string{"?!": "‽"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Apply("really?! yes"); got != "really‽ yes" {
+		t.Errorf("Expected the interrobang, got %q", got)
+	}
+}
+
+func TestSetReplacementsKeepsValidRules(t *testing.T) {
+	s := NewSystem()
+	err := s.SetReplacements(map[string]string{"period": "."}, map[string]map[string]string{
+		"code":  {"open paren": "("},
+		"slack": {"re:[": "x"},
+	})
+	if err == nil {
+		t.Error("Expected the invalid slack rule to be reported")
+	}
+	if _, ok := s.appReplacers["code"]; !ok {
+		t.Error("Expected the valid app rules to be kept")
+	}
+	if got := s.replacer.Apply("done period"); got != "done." {
+		t.Errorf("Expected the general rules to apply, got %q", got)
+	}
+}
+
+func TestMatchClass(t *testing.T) {
+	rules := map[string]bool{"terminal": true, "gnome-terminal": true, "Code": true}
+
+	testCases := []struct {
+		class    string
+		expected string
+		ok       bool
+	}{
+		{"code", "Code", true},
+		{"gnome-terminal-server", "gnome-terminal", true},
+		{"xfce4-terminal", "terminal", true},
+		{"firefox", "", false},
+		{"", "", false},
+	}
+
+	for _, tc := range testCases {
+		key, ok := matchClass(tc.class, rules)
+		if key != tc.expected || ok != tc.ok {
+			t.Errorf("matchClass(%q) = %q, %v; expected %q, %v", tc.class, key, ok, tc.expected, tc.ok)
+		}
+	}
+}
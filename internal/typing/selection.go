@@ -0,0 +1,38 @@
+package typing
+
+import (
+	"log"
+	"os/exec"
+)
+
+// servePrimary serves text as the Wayland primary selection from a wl-copy
+// process owned by this one. It runs in the foreground and exits once a
+// client has read the selection, which SelectionTaken reports.
+func (s *System) servePrimary(text string) error {
+	cmd := exec.Command("wl-copy", "--primary", "--foreground", "--paste-once", text)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	taken := make(chan struct{})
+	s.mu.Lock()
+	s.selectionTaken = taken
+	s.mu.Unlock()
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Printf("[Typing] wl-copy serving the primary selection exited: %v", err)
+		}
+		close(taken)
+	}()
+	return nil
+}
+
+// SelectionTaken returns a channel closed once the primary selection set by
+// the last delivery has been read, or nil when this process isn't serving one
+// (X11, or no wl-copy)
+func (s *System) SelectionTaken() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.selectionTaken
+}
@@ -5,141 +5,211 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
+
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+)
+
+// lookPath and run are swapped in tests to fake the installed tools
+var (
+	lookPath = exec.LookPath
+	run      = runTool
 )
 
+// runTool runs tool with args to completion
+func runTool(ctx context.Context, tool string, args ...string) error {
+	return exec.CommandContext(ctx, tool, args...).Run()
+}
+
 // System handles direct keyboard input
-type System struct{}
+type System struct {
+	typeDelays map[string]int // per-character delay in ms by tool
+
+	mu             sync.Mutex
+	selectionTaken chan struct{}
+	replacer       *Replacer            // nil until SetReplacements
+	appReplacers   map[string]*Replacer // keyed by WM_CLASS
+	undo           bool                 // remember deliveries for UndoLast
+	last           *insertion           // for UndoLast; nil once undone
+	onManualPaste  func()
+}
+
+// ErrLeftOnClipboard is returned when no method could type or paste the
+// text, which was left on the clipboard for the user to paste
+var ErrLeftOnClipboard = fmt.Errorf("couldn't type or paste the text; it was left on the clipboard")
 
 // NewSystem creates a new typing system
 func NewSystem() *System {
-	return &System{}
+	return &System{
+		typeDelays: map[string]int{"xdotool": defaultXdotoolDelayMs},
+	}
 }
 
-// TypeText simulates typing text directly at the cursor position
-func (s *System) TypeText(ctx context.Context, text string, pressEnter bool) error {
+// TypeText simulates typing text directly at the cursor position, then presses
+// postKey (one of the PostKey constants) with the same tool that delivered the text
+func (s *System) TypeText(ctx context.Context, text string, postKey string) error {
 	log.Printf("[Typing] Delivering transcription (%d chars) via Multi-Buffer Paste...", len(text))
 
 	tCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
-	if err := s.SetPrimarySelection(tCtx, text); err != nil {
-		log.Printf("[Typing] Clipboard set warning: %v", err)
+	if err := s.pasteThrough(tCtx, text, postKey); err == nil {
+		return nil
 	}
 
-	time.Sleep(100 * time.Millisecond)
+	// 5. Fallback: Direct Typing (Only if separate buffer paste fails)
+	log.Printf("[Typing] Auto-paste failed, falling back to direct typing")
 
-	if err := s.PasteText(tCtx); err == nil {
-		if pressEnter {
-			time.Sleep(100 * time.Millisecond)
-			_ = s.PressEnter(tCtx)
-		}
+	if err := s.typeDirect(tCtx, text, postKey); err == nil {
 		return nil
 	}
 
-	// 5. Fallback: Direct Typing (Only if separate buffer paste fails)
-	log.Printf("[Typing] Auto-paste failed, falling back to direct typing")
+	return s.leaveOnClipboard(ctx, text)
+}
 
-	if s.isToolAvailable("ydotool") {
-		if err := exec.CommandContext(tCtx, "ydotool", "type", text).Run(); err == nil {
-			if pressEnter {
-				time.Sleep(100 * time.Millisecond)
-				_ = exec.CommandContext(tCtx, "ydotool", "key", "28:1", "28:0").Run()
-			}
-			return nil
-		}
+// OnManualPaste registers a callback for when the text couldn't be typed or
+// pasted and was left on the clipboard, e.g. to tell the user to paste it
+func (s *System) OnManualPaste(callback func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onManualPaste = callback
+}
+
+// leaveOnClipboard is the last resort once every typing and paste method
+// failed: the text is copied to the clipboard again, as the failed attempts
+// may have left it elsewhere or never got it there, and ErrLeftOnClipboard
+// returned. It goes ahead even when ctx is done, so the text survives a
+// shutdown that interrupted typing.
+func (s *System) leaveOnClipboard(ctx context.Context, text string) error {
+	if err := s.SetPrimarySelection(context.WithoutCancel(ctx), text); err != nil {
+		return fmt.Errorf("all typing/pasting methods failed, and so did copying to the clipboard: %w", err)
 	}
+	log.Printf("[Typing] All typing/pasting methods failed; the text is on the clipboard")
 
-	if s.isToolAvailable("wtype") {
-		if err := exec.CommandContext(tCtx, "wtype", text).Run(); err == nil {
-			if pressEnter {
-				time.Sleep(100 * time.Millisecond)
-				_ = exec.CommandContext(tCtx, "wtype", "-k", "Return").Run()
-			}
-			return nil
-		}
+	s.mu.Lock()
+	callback := s.onManualPaste
+	s.mu.Unlock()
+	if callback != nil {
+		callback()
 	}
+	return ErrLeftOnClipboard
+}
 
-	if s.isToolAvailable("xdotool") {
-		cmd := exec.CommandContext(tCtx, "xdotool", "type", "--clearmodifiers", "--delay", "2", text)
-		if err := cmd.Run(); err == nil {
-			if pressEnter {
-				time.Sleep(100 * time.Millisecond)
-				_ = s.PressEnter(tCtx)
-			}
-			return nil
-		}
+// pasteThrough puts text on the clipboard and triggers a paste, then presses postKey
+func (s *System) pasteThrough(ctx context.Context, text, postKey string) error {
+	if err := s.SetPrimarySelection(ctx, text); err != nil {
+		log.Printf("[Typing] Clipboard set warning: %v", err)
 	}
 
-	return fmt.Errorf("all typing/pasting methods failed")
+	time.Sleep(100 * time.Millisecond)
+
+	tool, err := s.paste(ctx)
+	if err != nil {
+		return err
+	}
+	s.pressPostKeyAfter(ctx, tool, postKey)
+	return nil
 }
 
-// PasteText tries various methods to trigger a paste event
-func (s *System) PasteText(ctx context.Context) error {
-	isWayland := strings.Contains(os.Getenv("WAYLAND_DISPLAY"), "wayland")
+// pasteStep is one way to trigger a paste: a tool and its arguments
+type pasteStep struct {
+	tool string
+	args []string
+}
 
-	// Priority 1: Ctrl+V (Standard for most GUI apps)
-	if isWayland && s.isToolAvailable("wtype") {
-		if err := exec.CommandContext(ctx, "wtype", "-M", "ctrl", "-k", "v").Run(); err == nil {
-			return nil
-		}
-	}
-	if s.isToolAvailable("xdotool") {
-		if err := exec.CommandContext(ctx, "xdotool", "key", "--clearmodifiers", "ctrl+v").Run(); err == nil {
-			return nil
+// pasteSteps lists the ways to trigger a paste in the order they are tried:
+// Ctrl+V, the standard for most GUI apps, then Shift+Insert, the standard
+// for terminals and many X11 apps. wtype only works under Wayland.
+func pasteSteps(wayland bool) []pasteStep {
+	var steps []pasteStep
+	for _, keys := range []struct{ wtype, xdotool []string }{
+		{[]string{"-M", "ctrl", "-k", "v"}, []string{"key", "--clearmodifiers", "ctrl+v"}},
+		{[]string{"-M", "shift", "-k", "Insert"}, []string{"key", "--clearmodifiers", "shift+Insert"}},
+	} {
+		if wayland {
+			steps = append(steps, pasteStep{"wtype", keys.wtype})
 		}
+		steps = append(steps, pasteStep{"xdotool", keys.xdotool})
 	}
+	return steps
+}
 
-	// Priority 2: Shift+Insert (Standard for terminals and many X11 apps)
-	if isWayland && s.isToolAvailable("wtype") {
-		if err := exec.CommandContext(ctx, "wtype", "-M", "shift", "-k", "Insert").Run(); err == nil {
-			return nil
-		}
-	}
-	if s.isToolAvailable("xdotool") {
-		if err := exec.CommandContext(ctx, "xdotool", "key", "--clearmodifiers", "shift+Insert").Run(); err == nil {
-			return nil
+// PasteText tries various methods to trigger a paste event
+func (s *System) PasteText(ctx context.Context) error {
+	_, err := s.paste(ctx)
+	return err
+}
+
+// paste triggers a paste and returns the tool that did it
+func (s *System) paste(ctx context.Context) (string, error) {
+	for _, step := range pasteSteps(session.IsWayland()) {
+		if toolpath.AvailableWith(lookPath, step.tool) && run(ctx, step.tool, step.args...) == nil {
+			return step.tool, nil
 		}
 	}
-
-	return fmt.Errorf("no paste trigger tool found or all failed")
+	return "", fmt.Errorf("no paste trigger tool found or all failed")
 }
 
-// SetPrimarySelection copies text to BOTH Primary and Clipboard selections
+// SetPrimarySelection copies text to BOTH Primary and Clipboard selections.
+// Each write is bounded by clipboardTimeout, so a tool that hangs (e.g. no
+// clipboard manager running) can't stall typing. A failed primary write is
+// only logged; the error reports the clipboard write.
 func (s *System) SetPrimarySelection(ctx context.Context, text string) error {
-	tCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	isWayland := session.IsWayland()
 
-	isWayland := strings.Contains(os.Getenv("WAYLAND_DISPLAY"), "wayland")
-
-	if isWayland && s.isToolAvailable("wl-copy") {
+	if isWayland && toolpath.AvailableWith(lookPath, "wl-copy") {
 		// Set both for Wayland
-		_ = exec.CommandContext(tCtx, "wl-copy", text).Run()
-		_ = exec.CommandContext(tCtx, "wl-copy", "--primary", text).Run()
-		return nil
+		err := writeClipboard(ctx, text, "wl-copy")
+		if s.servePrimary(text) != nil {
+			logPrimaryError("wl-copy", writeClipboard(ctx, text, "wl-copy", "--primary"))
+		}
+		return err
 	}
 
-	// X11 / XWayland
-	if s.isToolAvailable("xclip") {
-		_ = exec.CommandContext(tCtx, "xclip", "-selection", "clipboard", text).Run()
-		_ = exec.CommandContext(tCtx, "xclip", "-selection", "primary", text).Run()
-		return nil
+	// X11 / XWayland; both read the text from stdin
+	if toolpath.AvailableWith(lookPath, "xclip") {
+		err := writeClipboard(ctx, text, "xclip", "-selection", "clipboard")
+		logPrimaryError("xclip", writeClipboard(ctx, text, "xclip", "-selection", "primary"))
+		return err
 	}
-	if s.isToolAvailable("xsel") {
-		_ = exec.CommandContext(tCtx, "xsel", "--clipboard", "--input", text).Run()
-		_ = exec.CommandContext(tCtx, "xsel", "--primary", "--input", text).Run()
-		return nil
+	if toolpath.AvailableWith(lookPath, "xsel") {
+		err := writeClipboard(ctx, text, "xsel", "--clipboard", "--input")
+		logPrimaryError("xsel", writeClipboard(ctx, text, "xsel", "--primary", "--input"))
+		return err
 	}
 
 	return fmt.Errorf("no primary/clipboard selection tool found")
 }
 
+// GetClipboard returns the text on the clipboard, each read bounded by
+// clipboardTimeout like the writes
+func (s *System) GetClipboard(ctx context.Context) (string, error) {
+	if session.IsWayland() && toolpath.AvailableWith(lookPath, "wl-paste") {
+		return readClipboard(ctx, "wl-paste", "--no-newline")
+	}
+	if toolpath.AvailableWith(lookPath, "xclip") {
+		return readClipboard(ctx, "xclip", "-selection", "clipboard", "-o")
+	}
+	if toolpath.AvailableWith(lookPath, "xsel") {
+		return readClipboard(ctx, "xsel", "--clipboard", "--output")
+	}
+	return "", fmt.Errorf("no clipboard tool found")
+}
+
+// logPrimaryError logs a failed primary selection write
+func logPrimaryError(tool string, err error) {
+	if err != nil {
+		log.Printf("[Typing] Primary selection via %s failed: %v", tool, err)
+	}
+}
+
 // WaitForFocus waits until the focus is no longer on a VoiceType window
 func (s *System) WaitForFocus(ctx context.Context) {
-	if !s.isToolAvailable("xdotool") {
+	if !toolpath.AvailableWith(lookPath, "xdotool") {
 		// Fallback to simple sleep if we can't verify focus
 		time.Sleep(600 * time.Millisecond)
 		return
@@ -168,7 +238,7 @@ func (s *System) WaitForFocus(ctx context.Context) {
 
 // GetActiveWindowID returns the ID of the currently active window
 func (s *System) GetActiveWindowID() string {
-	if !s.isToolAvailable("xdotool") {
+	if !toolpath.AvailableWith(lookPath, "xdotool") {
 		return ""
 	}
 	out, err := exec.Command("xdotool", "getactivewindow").Output()
@@ -180,7 +250,7 @@ func (s *System) GetActiveWindowID() string {
 
 // ActivateWindow restores focus to a specific window
 func (s *System) ActivateWindow(id string) {
-	if id == "" || !s.isToolAvailable("xdotool") {
+	if id == "" || !toolpath.AvailableWith(lookPath, "xdotool") {
 		return
 	}
 	_ = exec.Command("xdotool", "windowactivate", "--sync", id).Run()
@@ -188,20 +258,5 @@ func (s *System) ActivateWindow(id string) {
 
 // PressEnter simulates pressing the Enter key
 func (s *System) PressEnter(ctx context.Context) error {
-	tCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-	defer cancel()
-
-	if strings.Contains(os.Getenv("WAYLAND_DISPLAY"), "wayland") && s.isToolAvailable("wtype") {
-		return exec.CommandContext(tCtx, "wtype", "-k", "Return").Run()
-	}
-	if s.isToolAvailable("xdotool") {
-		return exec.CommandContext(tCtx, "xdotool", "key", "Return").Run()
-	}
-	return fmt.Errorf("no tool available to press Enter")
-}
-
-// isToolAvailable checks if a command-line tool exists
-func (s *System) isToolAvailable(tool string) bool {
-	_, err := exec.LookPath(tool)
-	return err == nil
+	return s.PressPostKey(ctx, PostKeyEnter)
 }
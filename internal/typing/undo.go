@@ -0,0 +1,148 @@
+package typing
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+	"speek_to_text_linux/pkg/errors"
+)
+
+// insertion is what the last successful delivery put at the cursor
+type insertion struct {
+	text      string
+	postKey   string
+	window    string // active window ID, "" when unknown
+	selection string // primary selection right after delivering
+	selErr    error
+}
+
+// undoLength returns how many BackSpace presses remove text and the Enter
+// pressed after it. A CRLF line break is one character in a text field. It
+// fails for a Tab, which moved focus away from the text.
+func undoLength(text, postKey string) (int, error) {
+	n := utf8.RuneCountInString(strings.ReplaceAll(text, "\r\n", "\n"))
+	switch postKey {
+	case PostKeyEnter, PostKeyShiftEnter:
+		n++
+	case PostKeyTab:
+		return 0, errors.NewError(errors.ErrorTypeTyping, "the Tab pressed after the text moved focus away from it", nil)
+	}
+	return n, nil
+}
+
+// backspaceArgs returns the arguments for tool to press BackSpace n times,
+// or nil when there is nothing to delete
+func backspaceArgs(tool string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	switch tool {
+	case "xdotool":
+		return []string{"key", "--clearmodifiers", "--repeat", strconv.Itoa(n), "--delay", "0", "BackSpace"}
+	case "wtype":
+		var args []string
+		for i := 0; i < n; i++ {
+			args = append(args, "-k", "BackSpace")
+		}
+		return args
+	case "ydotool":
+		// 14 = KEY_BACKSPACE
+		args := []string{"key"}
+		for i := 0; i < n; i++ {
+			args = append(args, "14:1", "14:0")
+		}
+		return args
+	}
+	return nil
+}
+
+// readPrimary returns the primary selection, i.e. the last selected text
+func (s *System) readPrimary(ctx context.Context) (string, error) {
+	if session.IsWayland() && toolpath.AvailableWith(lookPath, "wl-paste") {
+		return readClipboard(ctx, "wl-paste", "--primary", "--no-newline")
+	}
+	if toolpath.AvailableWith(lookPath, "xclip") {
+		return readClipboard(ctx, "xclip", "-selection", "primary", "-o")
+	}
+	if toolpath.AvailableWith(lookPath, "xsel") {
+		return readClipboard(ctx, "xsel", "--primary", "--output")
+	}
+	return "", errors.NewError(errors.ErrorTypeTyping, "no selection tool found", nil)
+}
+
+// EnableUndo makes DeliverText remember each delivery for UndoLast. It is
+// off by default, as remembering reads the primary selection every time.
+func (s *System) EnableUndo() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.undo = true
+}
+
+// remember records a delivery for UndoLast, with the window it went to and
+// the primary selection, so a later undo can tell if the user moved on. A
+// primary selection this process serves is left unread, as reading it would
+// use up its one paste. Nothing is recorded unless EnableUndo was called.
+func (s *System) remember(ctx context.Context, text, postKey string) {
+	s.mu.Lock()
+	undo := s.undo
+	s.mu.Unlock()
+	if !undo {
+		return
+	}
+
+	last := &insertion{text: text, postKey: postKey, window: s.GetActiveWindowID()}
+	if s.SelectionTaken() == nil {
+		last.selection, last.selErr = s.readPrimary(ctx)
+	} else {
+		last.selErr = errors.NewError(errors.ErrorTypeTyping, "primary selection is served by this process", nil)
+	}
+
+	s.mu.Lock()
+	s.last = last
+	s.mu.Unlock()
+}
+
+// UndoLast deletes what the last delivery inserted, including the Enter
+// pressed after it, by pressing BackSpace once per character. It can only
+// count on the cursor still being at the end of the text, so it is best
+// effort: a change of the primary selection since, which selecting or
+// clicking elsewhere usually causes, is logged and the undo goes ahead. It
+// refuses when another window has focus, where the keys would land in the
+// wrong app. Each delivery can be undone once.
+func (s *System) UndoLast(ctx context.Context) error {
+	s.mu.Lock()
+	last := s.last
+	s.last = nil
+	s.mu.Unlock()
+	if last == nil {
+		return errors.ErrNothingToUndo
+	}
+
+	n, err := undoLength(last.text, last.postKey)
+	if err != nil {
+		return err
+	}
+	if window := s.GetActiveWindowID(); window != "" && last.window != "" && window != last.window {
+		return errors.NewError(errors.ErrorTypeTyping, "focus moved to another window since the text was inserted", nil)
+	}
+	if sel, err := s.readPrimary(ctx); err == nil && last.selErr == nil && sel != last.selection {
+		log.Printf("[Typing] Selection changed since the last insertion; the cursor may have moved, undoing anyway")
+	}
+
+	tool, err := s.keyTool()
+	if err != nil {
+		return err
+	}
+	log.Printf("[Typing] Undoing last insertion (%d characters) via %s", n, tool)
+
+	tCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return exec.CommandContext(tCtx, tool, backspaceArgs(tool, n)...).Run()
+}
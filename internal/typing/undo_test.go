@@ -0,0 +1,85 @@
+package typing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+func TestUndoLength(t *testing.T) {
+	testCases := []struct {
+		text     string
+		postKey  string
+		expected int
+		valid    bool
+	}{
+		{"hello", PostKeyNone, 5, true},
+		{"hello", "", 5, true},
+		{"hello", PostKeyEnter, 6, true},
+		{"hello", PostKeyShiftEnter, 6, true},
+		{"héllo wörld", PostKeyNone, 11, true},
+		{"👍 ok", PostKeyNone, 4, true},
+		{"one\ntwo", PostKeyNone, 7, true},
+		{"one\r\ntwo", PostKeyEnter, 8, true},
+		{"", PostKeyNone, 0, true},
+		{"hello", PostKeyTab, 0, false},
+	}
+
+	for _, tc := range testCases {
+		got, err := undoLength(tc.text, tc.postKey)
+		if tc.valid != (err == nil) {
+			t.Errorf("undoLength(%q, %q): valid=%v, got error %v", tc.text, tc.postKey, tc.valid, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("undoLength(%q, %q) = %d, expected %d", tc.text, tc.postKey, got, tc.expected)
+		}
+	}
+}
+
+func TestBackspaceArgs(t *testing.T) {
+	testCases := []struct {
+		tool     string
+		n        int
+		expected []string
+	}{
+		{"xdotool", 4, []string{"key", "--clearmodifiers", "--repeat", "4", "--delay", "0", "BackSpace"}},
+		{"wtype", 2, []string{"-k", "BackSpace", "-k", "BackSpace"}},
+		{"ydotool", 2, []string{"key", "14:1", "14:0", "14:1", "14:0"}},
+		{"xdotool", 0, nil},
+		{"unknown", 3, nil},
+	}
+
+	for _, tc := range testCases {
+		got := backspaceArgs(tc.tool, tc.n)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("backspaceArgs(%q, %d) = %v, want %v", tc.tool, tc.n, got, tc.expected)
+		}
+	}
+}
+
+func TestUndoLastOnce(t *testing.T) {
+	s := NewSystem()
+	if err := s.UndoLast(context.Background()); !errors.Is(err, errors.ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo before any delivery, got %v", err)
+	}
+
+	// A Tab can't be undone, but the insertion is still used up
+	s.last = &insertion{text: "hello", postKey: PostKeyTab}
+	if err := s.UndoLast(context.Background()); err == nil || errors.Is(err, errors.ErrNothingToUndo) {
+		t.Errorf("Expected undoing past a Tab to fail, got %v", err)
+	}
+	if err := s.UndoLast(context.Background()); !errors.Is(err, errors.ErrNothingToUndo) {
+		t.Errorf("Expected a second undo to have nothing to undo, got %v", err)
+	}
+}
+
+func TestRememberNeedsEnableUndo(t *testing.T) {
+	s := NewSystem()
+	s.remember(context.Background(), "hello", PostKeyNone)
+	if s.last != nil {
+		t.Error("Expected nothing remembered without EnableUndo")
+	}
+}
@@ -0,0 +1,115 @@
+package typing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
+)
+
+// GetActiveWindowClass returns the focused window's class (WM_CLASS on X11,
+// app_id/class on Wayland compositors), lowercased. This is the single place
+// features such as per-app profiles and terminal detection should query.
+func (s *System) GetActiveWindowClass() (string, error) {
+	if session.IsWayland() {
+		if toolpath.AvailableWith(lookPath, "hyprctl") {
+			out, err := exec.Command("hyprctl", "activewindow", "-j").Output()
+			if err == nil {
+				return parseHyprctlClass(out)
+			}
+		}
+		if toolpath.AvailableWith(lookPath, "swaymsg") {
+			out, err := exec.Command("swaymsg", "-t", "get_tree").Output()
+			if err == nil {
+				return parseSwayClass(out)
+			}
+		}
+		// XWayland windows are still visible to xdotool
+	}
+
+	if !toolpath.AvailableWith(lookPath, "xdotool") {
+		return "", fmt.Errorf("no tool available to query the active window class")
+	}
+	out, err := exec.Command("xdotool", "getactivewindow", "getwindowclassname").Output()
+	if err != nil {
+		return "", fmt.Errorf("xdotool getwindowclassname failed: %w", err)
+	}
+	return parseXdotoolClass(out)
+}
+
+// parseXdotoolClass normalizes `xdotool getwindowclassname` output
+func parseXdotoolClass(out []byte) (string, error) {
+	class := normalizeClass(string(out))
+	if class == "" {
+		return "", fmt.Errorf("active window has no class")
+	}
+	return class, nil
+}
+
+// parseHyprctlClass extracts the class from `hyprctl activewindow -j`
+func parseHyprctlClass(out []byte) (string, error) {
+	var win struct {
+		Class string `json:"class"`
+	}
+	if err := json.Unmarshal(out, &win); err != nil {
+		return "", fmt.Errorf("failed to parse hyprctl output: %w", err)
+	}
+	class := normalizeClass(win.Class)
+	if class == "" {
+		return "", fmt.Errorf("active window has no class")
+	}
+	return class, nil
+}
+
+// swayNode is the subset of a sway tree node needed to find the focused window
+type swayNode struct {
+	Focused          bool       `json:"focused"`
+	AppID            string     `json:"app_id"`
+	Nodes            []swayNode `json:"nodes"`
+	FloatingNodes    []swayNode `json:"floating_nodes"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+}
+
+// parseSwayClass finds the focused node in `swaymsg -t get_tree` output
+func parseSwayClass(out []byte) (string, error) {
+	var root swayNode
+	if err := json.Unmarshal(out, &root); err != nil {
+		return "", fmt.Errorf("failed to parse swaymsg output: %w", err)
+	}
+	if node := findFocused(&root); node != nil {
+		class := node.AppID
+		if class == "" {
+			class = node.WindowProperties.Class
+		}
+		if class = normalizeClass(class); class != "" {
+			return class, nil
+		}
+	}
+	return "", fmt.Errorf("no focused window found")
+}
+
+func findFocused(n *swayNode) *swayNode {
+	if n.Focused {
+		return n
+	}
+	for i := range n.Nodes {
+		if f := findFocused(&n.Nodes[i]); f != nil {
+			return f
+		}
+	}
+	for i := range n.FloatingNodes {
+		if f := findFocused(&n.FloatingNodes[i]); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func normalizeClass(class string) string {
+	return strings.ToLower(strings.TrimSpace(class))
+}
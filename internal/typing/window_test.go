@@ -0,0 +1,47 @@
+package typing
+
+import "testing"
+
+func TestParseXdotoolClass(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected string
+		wantErr  bool
+	}{
+		{"Gnome-terminal\n", "gnome-terminal", false},
+		{"firefox\n", "firefox", false},
+		{"  Code  \n", "code", false},
+		{"\n", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseXdotoolClass([]byte(tc.output))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseXdotoolClass(%q) error = %v, wantErr %v", tc.output, err, tc.wantErr)
+		}
+		if got != tc.expected {
+			t.Errorf("parseXdotoolClass(%q) = %q, expected %q", tc.output, got, tc.expected)
+		}
+	}
+}
+
+func TestParseHyprctlClass(t *testing.T) {
+	got, err := parseHyprctlClass([]byte(`{"address": "0x1", "class": "Alacritty", "title": "~"}`))
+	if err != nil || got != "alacritty" {
+		t.Errorf("Expected alacritty, got %q (%v)", got, err)
+	}
+}
+
+func TestParseSwayClass(t *testing.T) {
+	tree := `{"focused": false, "nodes": [
+		{"focused": false, "nodes": [
+			{"focused": false, "app_id": "foot", "nodes": []},
+			{"focused": true, "app_id": null, "window_properties": {"class": "Slack"}, "nodes": []}
+		]}
+	]}`
+
+	got, err := parseSwayClass([]byte(tree))
+	if err != nil || got != "slack" {
+		t.Errorf("Expected slack, got %q (%v)", got, err)
+	}
+}
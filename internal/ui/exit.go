@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"time"
+)
+
+// DefaultSelectionTimeout bounds how long a one-shot run stays alive waiting
+// for the target app to read a selection it serves
+const DefaultSelectionTimeout = 5 * time.Second
+
+// WaitBeforeExit blocks for the grace period between delivering text and
+// quitting: at least delay, and while taken is non-nil, until it is closed or
+// timeout has passed since the call. Some clipboard managers and Wayland
+// compositors read the selection late, and quitting first leaves the paste
+// empty. It reports whether the selection was taken.
+func WaitBeforeExit(delay time.Duration, taken <-chan struct{}, timeout time.Duration) bool {
+	start := time.Now()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if taken == nil {
+		return false
+	}
+
+	remaining := timeout - time.Since(start)
+	if remaining <= 0 {
+		select {
+		case <-taken:
+			return true
+		default:
+			return false
+		}
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-taken:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitBeforeExit(t *testing.T) {
+	closed := make(chan struct{})
+	close(closed)
+
+	testCases := []struct {
+		name    string
+		delay   time.Duration
+		taken   func() <-chan struct{}
+		timeout time.Duration
+		want    bool
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"delay only", 30 * time.Millisecond, func() <-chan struct{} { return nil }, time.Second, false, 30 * time.Millisecond, 500 * time.Millisecond},
+		{"no delay", 0, func() <-chan struct{} { return nil }, time.Second, false, 0, 20 * time.Millisecond},
+		{"taken before delay ends", 30 * time.Millisecond, func() <-chan struct{} { return closed }, time.Second, true, 30 * time.Millisecond, 500 * time.Millisecond},
+		{"taken after delay", 0, func() <-chan struct{} {
+			ch := make(chan struct{})
+			time.AfterFunc(40*time.Millisecond, func() { close(ch) })
+			return ch
+		}, time.Second, true, 40 * time.Millisecond, 500 * time.Millisecond},
+		{"never taken", 0, func() <-chan struct{} { return make(chan struct{}) }, 50 * time.Millisecond, false, 50 * time.Millisecond, 500 * time.Millisecond},
+		{"delay outlasts timeout", 60 * time.Millisecond, func() <-chan struct{} { return make(chan struct{}) }, 10 * time.Millisecond, false, 60 * time.Millisecond, 500 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			got := WaitBeforeExit(tc.delay, tc.taken(), tc.timeout)
+			elapsed := time.Since(start)
+			if got != tc.want {
+				t.Errorf("Expected taken=%v, got %v", tc.want, got)
+			}
+			if elapsed < tc.min || elapsed > tc.max {
+				t.Errorf("Expected to wait between %v and %v, waited %v", tc.min, tc.max, elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// Fader runs cancellable opacity ramps for the pill window
+type Fader struct {
+	mu  sync.Mutex
+	gen uint64
+}
+
+// FadeOut ramps opacity from 1 to 0 over duration, calling apply for each step.
+// It returns false if the fade was cancelled (e.g. a new recording started)
+// before it completed, in which case the caller must not hide the window.
+func (f *Fader) FadeOut(duration time.Duration, steps int, apply func(opacity float64)) bool {
+	if steps < 1 {
+		steps = 1
+	}
+
+	f.mu.Lock()
+	f.gen++
+	gen := f.gen
+	f.mu.Unlock()
+
+	stepDuration := duration / time.Duration(steps)
+	for i := steps; i >= 0; i-- {
+		if !f.isCurrent(gen) {
+			return false
+		}
+		apply(float64(i) / float64(steps))
+		if i > 0 {
+			time.Sleep(stepDuration)
+		}
+	}
+	return f.isCurrent(gen)
+}
+
+// Cancel aborts any fade in progress
+func (f *Fader) Cancel() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gen++
+}
+
+func (f *Fader) isCurrent(gen uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.gen == gen
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFaderCompletes(t *testing.T) {
+	var f Fader
+	var got []float64
+
+	ok := f.FadeOut(0, 4, func(opacity float64) {
+		got = append(got, opacity)
+	})
+
+	if !ok {
+		t.Fatal("Expected fade to complete")
+	}
+	if len(got) != 5 || got[0] != 1.0 || got[4] != 0.0 {
+		t.Errorf("Expected 5 steps from 1.0 to 0.0, got %v", got)
+	}
+}
+
+func TestFaderCancelledByNewRecording(t *testing.T) {
+	var f Fader
+	var mu sync.Mutex
+	var last float64
+	started := make(chan struct{})
+	done := make(chan bool)
+
+	go func() {
+		first := true
+		done <- f.FadeOut(200*time.Millisecond, 10, func(opacity float64) {
+			mu.Lock()
+			last = opacity
+			mu.Unlock()
+			if first {
+				first = false
+				close(started)
+			}
+		})
+	}()
+
+	<-started
+	f.Cancel()
+
+	if <-done {
+		t.Fatal("Expected cancelled fade to report incomplete")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last == 0.0 {
+		t.Error("Expected cancelled fade to stop before reaching zero opacity")
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+	"sync"
+)
+
+// Which window snapshot focus is restored to before typing
+const (
+	// FocusStart prefers the window focused when recording started, since
+	// the user may alt-tab away during a long dictation
+	FocusStart = "start"
+	// FocusStop prefers the window focused when recording stopped
+	FocusStop = "stop"
+)
+
+// FocusSnapshot remembers the target window captured when recording starts
+type FocusSnapshot struct {
+	mu      sync.Mutex
+	startID string
+}
+
+// Capture records the window focused as recording starts
+func (f *FocusSnapshot) Capture(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.startID = strings.TrimSpace(id)
+}
+
+// Take returns the window to restore focus to and clears the snapshot.
+// prefer picks which of the start-time and stop-time windows wins (FocusStart
+// unless it is FocusStop); the other is the fallback when the preferred one
+// wasn't captured.
+func (f *FocusSnapshot) Take(stopID, prefer string) string {
+	f.mu.Lock()
+	startID := f.startID
+	f.startID = ""
+	f.mu.Unlock()
+
+	stopID = strings.TrimSpace(stopID)
+	first, second := startID, stopID
+	if strings.EqualFold(strings.TrimSpace(prefer), FocusStop) {
+		first, second = stopID, startID
+	}
+	if first != "" {
+		return first
+	}
+	return second
+}
@@ -0,0 +1,42 @@
+package ui
+
+import "testing"
+
+func TestFocusSnapshotAtStart(t *testing.T) {
+	testCases := []struct {
+		name     string
+		startID  string
+		stopID   string
+		prefer   string
+		expected string
+	}{
+		{"start wins by default", "111", "222", "", "111"},
+		{"start wins", "111", "222", FocusStart, "111"},
+		{"stop wins", "111", "222", FocusStop, "222"},
+		{"stop fallback when start missing", "", "222", FocusStart, "222"},
+		{"start fallback when stop missing", "111", "", FocusStop, "111"},
+		{"nothing captured", "", "", FocusStart, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f FocusSnapshot
+			f.Capture(tc.startID)
+			if got := f.Take(tc.stopID, tc.prefer); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFocusSnapshotIsConsumed(t *testing.T) {
+	var f FocusSnapshot
+	f.Capture("111\n")
+	if got := f.Take("222", FocusStart); got != "111" {
+		t.Fatalf("Expected start-time window, got %q", got)
+	}
+	// A re-transcription has no recording start; the stale window must not return
+	if got := f.Take("333", FocusStart); got != "333" {
+		t.Errorf("Expected stop-time window once the snapshot was used, got %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"os/exec"
+)
+
+// Hint values that give the pill its undecorated, always-on-top look
+const (
+	motifNoDecorations = "0x2, 0x0, 0x0, 0x0, 0x0"
+	windowTypeHint     = "_NET_WM_WINDOW_TYPE_NOTIFICATION"
+	windowStateHint    = "_NET_WM_STATE_SKIP_TASKBAR,_NET_WM_STATE_SKIP_PAGER,_NET_WM_STATE_ABOVE,_NET_WM_STATE_STAY_ON_TOP"
+)
+
+// WindowHintArgs returns the xprop argument lists that strip decorations from
+// a window. When id is set the window is addressed directly and the state and
+// allowed-actions hints are included; otherwise it is looked up by title, and
+// only the decoration and type hints are set.
+func WindowHintArgs(id, title string) [][]string {
+	target := []string{"-name", title}
+	if id != "" {
+		target = []string{"-id", id}
+	}
+	set := func(prop, format, value string) []string {
+		args := append([]string{}, target...)
+		return append(args, "-f", prop, format, "-set", prop, value)
+	}
+
+	// The type hint goes first so the WM classifies the window before it
+	// decides whether to draw a title bar
+	args := [][]string{
+		set("_NET_WM_WINDOW_TYPE", "32a", windowTypeHint),
+		set("_MOTIF_WM_HINTS", "32c", motifNoDecorations),
+	}
+	if id != "" {
+		args = append(args,
+			set("_NET_WM_STATE", "32a", windowStateHint),
+			set("_NET_WM_ALLOWED_ACTIONS", "32a", ""),
+		)
+	}
+	return args
+}
+
+// ApplyWindowHints runs xprop with each of WindowHintArgs
+func ApplyWindowHints(id, title string) {
+	for _, args := range WindowHintArgs(id, title) {
+		exec.Command("xprop", args...).Run()
+	}
+}
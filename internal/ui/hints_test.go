@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindowHintArgsByID(t *testing.T) {
+	args := WindowHintArgs("0x1234", "VoiceType")
+
+	if len(args) != 4 {
+		t.Fatalf("Expected 4 xprop calls, got %d", len(args))
+	}
+	want := []string{"-id", "0x1234", "-f", "_NET_WM_WINDOW_TYPE", "32a", "-set", "_NET_WM_WINDOW_TYPE", "_NET_WM_WINDOW_TYPE_NOTIFICATION"}
+	if !reflect.DeepEqual(args[0], want) {
+		t.Errorf("Expected window type hint first:\n got %v\nwant %v", args[0], want)
+	}
+	want = []string{"-id", "0x1234", "-f", "_MOTIF_WM_HINTS", "32c", "-set", "_MOTIF_WM_HINTS", "0x2, 0x0, 0x0, 0x0, 0x0"}
+	if !reflect.DeepEqual(args[1], want) {
+		t.Errorf("Expected motif hint:\n got %v\nwant %v", args[1], want)
+	}
+	if args[3][len(args[3])-1] != "" {
+		t.Errorf("Expected empty allowed actions, got %q", args[3][len(args[3])-1])
+	}
+}
+
+func TestWindowHintArgsByName(t *testing.T) {
+	args := WindowHintArgs("", "VoiceType")
+
+	if len(args) != 2 {
+		t.Fatalf("Expected 2 xprop calls, got %d", len(args))
+	}
+	for _, a := range args {
+		if a[0] != "-name" || a[1] != "VoiceType" {
+			t.Errorf("Expected window addressed by name, got %v", a[:2])
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds how long shutdown waits for a transcription
+// in flight to finish typing
+const DefaultShutdownTimeout = 10 * time.Second
+
+// InFlight tracks goroutines that should finish before the process exits,
+// such as the one typing a transcription; exiting under it leaves partial
+// text in the target app
+type InFlight struct {
+	wg sync.WaitGroup
+}
+
+// Go runs fn on a new goroutine, tracked until it returns
+func (f *InFlight) Go(fn func()) {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		fn()
+	}()
+}
+
+// Wait blocks until every tracked goroutine has returned or timeout has
+// passed, and reports whether they all returned
+func (f *InFlight) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
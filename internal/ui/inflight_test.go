@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInFlightWaitBlocksUntilDone(t *testing.T) {
+	var f InFlight
+	var finished atomic.Bool
+	release := make(chan struct{})
+	f.Go(func() {
+		<-release
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	if !f.Wait(2 * time.Second) {
+		t.Fatal("Expected Wait to report the goroutine finished")
+	}
+	if !finished.Load() {
+		t.Error("Expected Wait to return only after the goroutine completed")
+	}
+}
+
+func TestInFlightWaitTimesOut(t *testing.T) {
+	var f InFlight
+	release := make(chan struct{})
+	defer close(release)
+	f.Go(func() { <-release })
+
+	start := time.Now()
+	if f.Wait(30 * time.Millisecond) {
+		t.Fatal("Expected Wait to time out on a stuck goroutine")
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected Wait to give up after about 30ms, took %v", elapsed)
+	}
+}
+
+func TestInFlightWaitWithNothingTracked(t *testing.T) {
+	var f InFlight
+	if !f.Wait(time.Second) {
+		t.Error("Expected Wait to return immediately with nothing in flight")
+	}
+}
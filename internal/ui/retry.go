@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"sync"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+// ShortRetry decides when a recording that came out too short, e.g. a tap
+// of the hotkey that let go too soon, starts listening again on its own
+// instead of ending, as the user will most likely try again. It allows a
+// limited number of retries in a row.
+type ShortRetry struct {
+	mu   sync.Mutex
+	max  int
+	used int
+}
+
+// NewShortRetry allows up to max automatic retries in a row; 0 or less
+// turns them off
+func NewShortRetry(max int) *ShortRetry {
+	return &ShortRetry{max: max}
+}
+
+// Retry reports whether the recording that ended with err should start
+// again, counting the retry when it should. Only errors.ErrAudioTooShort
+// retries, and only until the cap is used up.
+func (r *ShortRetry) Retry(err error) bool {
+	if r == nil || !errors.Is(err, errors.ErrAudioTooShort) {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used >= r.max {
+		return false
+	}
+	r.used++
+	return true
+}
+
+// Reset starts the count again once a recording was long enough to
+// transcribe
+func (r *ShortRetry) Reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.used = 0
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"speek_to_text_linux/pkg/errors"
+)
+
+func TestShortRetry(t *testing.T) {
+	tooShort := errors.ErrAudioTooShort
+	wrapped := fmt.Errorf("stop: %w", errors.ErrAudioTooShort)
+
+	testCases := []struct {
+		name  string
+		max   int
+		steps []error // nil stands for a recording long enough, which resets
+		want  []bool
+	}{
+		{"retries once", 1, []error{tooShort, tooShort}, []bool{true, false}},
+		{"cap of three", 3, []error{tooShort, wrapped, tooShort, tooShort}, []bool{true, true, true, false}},
+		{"a good recording resets the cap", 1, []error{tooShort, nil, tooShort, tooShort}, []bool{true, false, true, false}},
+		{"other errors don't retry", 2, []error{fmt.Errorf("device busy"), errors.ErrDeviceNotFound}, []bool{false, false}},
+		{"off", 0, []error{tooShort}, []bool{false}},
+	}
+
+	for _, tc := range testCases {
+		r := NewShortRetry(tc.max)
+		for i, err := range tc.steps {
+			if err == nil {
+				r.Reset()
+				if tc.want[i] {
+					t.Fatalf("%s: step %d: a reset can't retry", tc.name, i)
+				}
+				continue
+			}
+			if got := r.Retry(err); got != tc.want[i] {
+				t.Errorf("%s: step %d: Retry(%v) = %v, expected %v", tc.name, i, err, got, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestShortRetryNil(t *testing.T) {
+	var r *ShortRetry
+	if r.Retry(errors.ErrAudioTooShort) {
+		t.Error("Expected a nil ShortRetry never to retry")
+	}
+	r.Reset()
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SessionState is a stage of the recording lifecycle
+type SessionState int
+
+const (
+	// StateIdle means nothing is happening and a recording may start
+	StateIdle SessionState = iota
+	// StateRecording means audio is being captured
+	StateRecording
+	// StateProcessing means a recording is being transcribed and delivered
+	StateProcessing
+	// StateError means the last recording failed; a new one may start
+	StateError
+)
+
+// String returns the state name
+func (s SessionState) String() string {
+	switch s {
+	case StateIdle:
+		return "idle"
+	case StateRecording:
+		return "recording"
+	case StateProcessing:
+		return "processing"
+	case StateError:
+		return "error"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int(s))
+	}
+}
+
+// Session tracks the recording lifecycle. Every change goes through a
+// transition that checks the current state atomically, so two callers racing
+// (e.g. the hotkey and the fixed-duration timer) can't both stop a recording.
+type Session struct {
+	mu    sync.Mutex
+	state SessionState
+}
+
+// State returns the current state
+func (s *Session) State() SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Start moves to recording; only allowed from idle or after an error
+func (s *Session) Start() error {
+	return s.transition(StateRecording, StateIdle, StateError)
+}
+
+// Stop moves a recording to processing
+func (s *Session) Stop() error {
+	return s.transition(StateProcessing, StateRecording)
+}
+
+// Done returns to idle once processing finishes, including when there was
+// nothing to transcribe
+func (s *Session) Done() error {
+	return s.transition(StateIdle, StateProcessing)
+}
+
+// Reprocess moves straight to processing to transcribe a retained recording
+// again; allowed whenever a recording could start
+func (s *Session) Reprocess() error {
+	return s.transition(StateProcessing, StateIdle, StateError)
+}
+
+// Fail moves a recording or transcription that went wrong to the error state
+func (s *Session) Fail() error {
+	return s.transition(StateError, StateRecording, StateProcessing)
+}
+
+func (s *Session) transition(to SessionState, from ...SessionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range from {
+		if s.state == f {
+			s.state = to
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid session transition from %s to %s", s.state, to)
+}
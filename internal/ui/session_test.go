@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSessionTransitions(t *testing.T) {
+	all := []SessionState{StateIdle, StateRecording, StateProcessing, StateError}
+	transitions := []struct {
+		name  string
+		do    func(*Session) error
+		valid map[SessionState]SessionState // from -> to
+	}{
+		{"Start", (*Session).Start, map[SessionState]SessionState{StateIdle: StateRecording, StateError: StateRecording}},
+		{"Stop", (*Session).Stop, map[SessionState]SessionState{StateRecording: StateProcessing}},
+		{"Reprocess", (*Session).Reprocess, map[SessionState]SessionState{StateIdle: StateProcessing, StateError: StateProcessing}},
+		{"Done", (*Session).Done, map[SessionState]SessionState{StateProcessing: StateIdle}},
+		{"Fail", (*Session).Fail, map[SessionState]SessionState{StateRecording: StateError, StateProcessing: StateError}},
+	}
+
+	for _, tr := range transitions {
+		for _, from := range all {
+			s := &Session{state: from}
+			err := tr.do(s)
+			to, ok := tr.valid[from]
+			if ok {
+				if err != nil {
+					t.Errorf("%s from %s: unexpected error %v", tr.name, from, err)
+				}
+				if s.State() != to {
+					t.Errorf("%s from %s: expected %s, got %s", tr.name, from, to, s.State())
+				}
+			} else {
+				if err == nil {
+					t.Errorf("%s from %s: expected invalid transition", tr.name, from)
+				}
+				if s.State() != from {
+					t.Errorf("%s from %s: state changed to %s on invalid transition", tr.name, from, s.State())
+				}
+			}
+		}
+	}
+}
+
+func TestSessionCannotStartWhileProcessing(t *testing.T) {
+	var s Session
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err == nil {
+		t.Error("Expected start to be rejected while processing")
+	}
+}
+
+func TestSessionConcurrentStopOnlyOnce(t *testing.T) {
+	s := &Session{state: StateRecording}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stopped := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.Stop() == nil {
+				mu.Lock()
+				stopped++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stopped != 1 {
+		t.Errorf("Expected exactly one stop to win, got %d", stopped)
+	}
+}
@@ -4,11 +4,11 @@ package ui
 import (
 	"fmt"
 	"log"
-	"os"
 	"os/exec"
-	"strings"
 	"time"
 
+	"speek_to_text_linux/internal/session"
+	"speek_to_text_linux/internal/toolpath"
 	"speek_to_text_linux/pkg/errors"
 )
 
@@ -59,11 +59,10 @@ func (u *UI) Hide() {
 // showIndicator shows the recording indicator window
 func (u *UI) showIndicator() error {
 	// Try different methods based on desktop environment
-	if strings.Contains(os.Getenv("WAYLAND_DISPLAY"), "wayland") {
+	switch session.Current() {
+	case session.Wayland:
 		return u.showWaylandIndicator()
-	}
-
-	if strings.Contains(os.Getenv("DISPLAY"), ":") {
+	case session.X11:
 		return u.showX11Indicator()
 	}
 
@@ -75,11 +74,11 @@ func (u *UI) showIndicator() error {
 // showX11Indicator shows an X11-based recording indicator
 func (u *UI) showX11Indicator() error {
 	// Try to use xdotool to create a small window or use yad/zenity
-	if u.isToolAvailable("yad") {
+	if toolpath.Available("yad") {
 		return u.showYadIndicator()
 	}
 
-	if u.isToolAvailable("zenity") {
+	if toolpath.Available("zenity") {
 		return u.showZenityIndicator()
 	}
 
@@ -165,7 +164,7 @@ func (u *UI) hideIndicator() error {
 	}
 
 	// Try to clean up any remaining processes
-	if u.isToolAvailable("pkill") {
+	if toolpath.Available("pkill") {
 		exec.Command("pkill", "-f", "yad.*VoiceType").Run()
 		exec.Command("pkill", "-f", "zenity.*VoiceType").Run()
 	}
@@ -173,12 +172,6 @@ func (u *UI) hideIndicator() error {
 	return nil
 }
 
-// isToolAvailable checks if a tool is available
-func (u *UI) isToolAvailable(tool string) bool {
-	_, err := exec.LookPath(tool)
-	return err == nil
-}
-
 // Toggle toggles the recording indicator visibility
 func (u *UI) Toggle() {
 	if u.isVisible {
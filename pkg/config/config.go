@@ -1,38 +1,193 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
 )
 
+// DefaultTranscriptionPrompt asks the model for clean, punctuated dictation
+// without filler words; it is also the API client's built-in English prompt
+const DefaultTranscriptionPrompt = "Transcribe the audio accurately. Add appropriate punctuation and capitalization. Remove filler words like 'um', 'uh', 'ah'. Ensure the output is natural and professional."
+
+// DefaultAPIRetries is how many times a failed transcription request is
+// retried, here and in the API client
+const DefaultAPIRetries = 3
+
 // Config represents the application configuration
 type Config struct {
 	GROQ_API_KEY         string  `json:"groq_api_key"`
+	Provider             string  `json:"provider"`
+	LocalURL             string  `json:"local_url"`
 	Hotkey               string  `json:"hotkey"`
+	HotkeyMode           string  `json:"hotkey_mode"`
+	UndoHotkey           string  `json:"undo_hotkey"`
 	AudioDevice          string  `json:"audio_device"`
+	PulseSource          string  `json:"pulse_source"`
 	DisableNotifications bool    `json:"disable_notifications"`
+	NotifyMaxChars       int     `json:"notify_max_chars"`
+	NotifyShowCount      bool    `json:"notify_show_count"`
+	ErrorSound           bool    `json:"error_sound"`
 	Verbose              bool    `json:"verbose"`
 	Model                string  `json:"model"`
 	Temperature          float64 `json:"temperature"`
 	AutoReturn           bool    `json:"auto_return"`
+	SmartEnter           bool    `json:"smart_enter"`
+	TypingPlaceholder    string  `json:"typing_placeholder"`
+	PostTypeKey          string  `json:"post_type_key"`
+	DeliveryMethod       string  `json:"delivery_method"`
+	XdotoolTypeDelayMs   int     `json:"xdotool_type_delay_ms"`
+	YdotoolTypeDelayMs   int     `json:"ydotool_type_delay_ms"`
+	WtypeTypeDelayMs     int     `json:"wtype_type_delay_ms"`
+	Language             string  `json:"language"`
+	Translate            bool    `json:"translate"`
+	FadeOutMs            int     `json:"fade_out_ms"`
+	StripModelArtifacts  bool    `json:"strip_model_artifacts"`
+	StripLabels          bool    `json:"strip_labels"`
+	ForceSentenceCase    bool    `json:"force_sentence_case"`
+	FixEnglish           bool    `json:"fix_english"`
+	ReadBack             bool    `json:"read_back"`
+	EmojiShortcodes      bool    `json:"emoji_shortcodes"`
+	CooldownMs           int     `json:"cooldown_ms"`
+	ToggleDebounceMs     int     `json:"toggle_debounce_ms"`
+	IgnoreLaunchPress    bool    `json:"ignore_launch_press"`
+	ExitDelayMs          int     `json:"exit_delay_ms"`
+	RetryOnShort         bool    `json:"retry_on_short"`
+	RetryOnShortMax      int     `json:"retry_on_short_max"`
+	MinRecordingMs       int     `json:"min_recording_ms"`
+	CaptureFormat        string  `json:"capture_format"`
+	CaptureBackend       string  `json:"capture_backend"`
+	SampleRate           int     `json:"sample_rate"`
+	Channels             int     `json:"channels"`
+	PeriodSize           int     `json:"period_size"`
+	BufferSize           int     `json:"buffer_size"`
+	LocalMetrics         bool    `json:"local_metrics"`
+	AvoidPasswordFields  bool    `json:"avoid_password_fields"`
+	FocusTarget          string  `json:"focus_target"`
+	RawTranscription     bool    `json:"raw_transcription"`
+	TranscriptionPrompt  string  `json:"transcription_prompt"`
+	PrerollMs            int     `json:"preroll_ms"`
+	LookbackSeconds      int     `json:"lookback_seconds"`
+	AutoStopSilenceMs    int     `json:"auto_stop_silence_ms"`
+	AutoStopThreshold    float64 `json:"auto_stop_threshold"`
+	NoiseFloor           float64 `json:"noise_floor"`
+	NewlineStyle         string  `json:"newline_style"`
+	OnEmpty              string  `json:"on_empty"`
+	RespectDND           bool    `json:"respect_dnd"`
+	APIRetries           int     `json:"api_retries"`
+	APIRetryDelayMs      int     `json:"api_retry_delay_ms"`
+	APIRetryTimeouts     bool    `json:"api_retry_timeouts"`
+	RequestTimeoutSecs   int     `json:"request_timeout_seconds"`
+	MaxConcurrentReqs    int     `json:"max_concurrent_requests"`
+	KeepWarmInterval     int     `json:"keep_warm_interval"`
+	KeepWarmIdleMinutes  int     `json:"keep_warm_idle_minutes"`
+	StreamTranscription  bool    `json:"stream_transcription"`
+	StreamUpload         bool    `json:"stream_upload"`
+	JournalDir           string  `json:"journal_dir"`
+	JournalOnly          bool    `json:"journal_only"`
+	DatasetDir           string  `json:"dataset_dir"`
+	SaveRecordings       bool    `json:"save_recordings"`
+	HistoryDir           string  `json:"history_dir"`
+	HistoryMaxAgeDays    int     `json:"history_max_age_days"`
+	RetentionMaxEntries  int     `json:"retention_max_entries"`
+	RetentionMaxAgeDays  int     `json:"retention_max_age_days"`
+	RetentionMaxBytes    int64   `json:"retention_max_bytes"`
+	MinFreeSpaceMB       int     `json:"min_free_space_mb"`
+	// Glossary lists names and jargon appended to the transcription prompt
+	Glossary []string `json:"glossary,omitempty"`
+	// LabelPatterns are regexes removed when StripLabels is on; empty uses
+	// the built-in timestamp and speaker-label patterns
+	LabelPatterns []string `json:"label_patterns,omitempty"`
+	// Acronyms keep their exact spelling when ForceSentenceCase capitalizes
+	Acronyms []string `json:"acronyms,omitempty"`
+	// ChannelLabels name the speaker on each channel when Channels is 2;
+	// empty uses Left and Right
+	ChannelLabels []string `json:"channel_labels,omitempty"`
+	// EmojiMap adds to or overrides the built-in emoji phrases; keys wrapped
+	// in colons are :shortcodes:, and an empty value removes an entry
+	EmojiMap map[string]string `json:"emoji_map,omitempty"`
+	// AppDelivery overrides DeliveryMethod per app, keyed by WM_CLASS
+	AppDelivery map[string]string `json:"app_delivery,omitempty"`
+	// Replacements rewrite spoken phrases before typing, e.g. "new line" to
+	// a line break; keys starting with re: are regular expressions
+	Replacements map[string]string `json:"replacements,omitempty"`
+	// AppReplacements are Replacements for particular apps, keyed by WM_CLASS
+	AppReplacements map[string]map[string]string `json:"app_replacements,omitempty"`
+	// PromptOverrides replace the transcription prompt for a model or
+	// language, keyed by model name or language; an empty prompt sends none
+	PromptOverrides map[string]string `json:"prompt_overrides,omitempty"`
+	// OutputMetadataTemplate is added to typed text, e.g. "[{model}, {duration}]",
+	// before it or, when OutputMetadataPosition is suffix, after it
+	OutputMetadataTemplate string `json:"output_metadata_template"`
+	OutputMetadataPosition string `json:"output_metadata_position"`
+	// DeviceHistory maps capture devices, keyed by audio.DeviceID, to the Unix
+	// time they were last explicitly chosen
+	DeviceHistory map[string]int64 `json:"device_history,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Hotkey:      "ctrl+space",
-		AudioDevice: "",
-		Model:       "whisper-large-v3",
-		Temperature: 0.0,
-		AutoReturn:  false,
+		Hotkey:              "ctrl+space",
+		HotkeyMode:          "toggle",
+		AudioDevice:         "",
+		Provider:            "groq",
+		Model:               "whisper-large-v3",
+		Temperature:         0.0,
+		AutoReturn:          false,
+		SmartEnter:          true,
+		XdotoolTypeDelayMs:  2,
+		YdotoolTypeDelayMs:  -1,
+		WtypeTypeDelayMs:    -1,
+		FadeOutMs:           200,
+		CooldownMs:          800,
+		NotifyMaxChars:      100,
+		ToggleDebounceMs:    600,
+		IgnoreLaunchPress:   true,
+		ExitDelayMs:         600,
+		RetryOnShortMax:     1,
+		MinRecordingMs:      250,
+		DeliveryMethod:      "paste",
+		CaptureBackend:      "auto",
+		SampleRate:          16000,
+		Channels:            1,
+		AvoidPasswordFields: true,
+		FocusTarget:         "start",
+		NewlineStyle:        "lf",
+		OnEmpty:             "ignore",
+		RespectDND:          true,
+		APIRetries:          DefaultAPIRetries,
+		APIRetryDelayMs:     1000,
+		MaxConcurrentReqs:   3,
+		KeepWarmIdleMinutes: 30,
+		MinFreeSpaceMB:      50,
+		AutoStopThreshold:   0.01,
+		TranscriptionPrompt: DefaultTranscriptionPrompt,
 	}
 }
 
-// Load loads configuration from environment and files
+// Load loads configuration from environment and files. A config file that
+// isn't valid JSON is backed up and replaced by the defaults; the config is
+// still returned then, with an error wrapping errors.ErrConfigReset for the
+// caller to tell the user.
 func Load() (*Config, error) {
+	return load(true)
+}
+
+// Inspect loads configuration like Load but never touches the file: a config
+// file that isn't valid JSON is reported as an error instead
+func Inspect() (*Config, error) {
+	return load(false)
+}
+
+func load(repair bool) (*Config, error) {
 	cfg := DefaultConfig()
+	var resetErr error
 
 	// 1. Try to load from file
 	path, err := GetConfigPath()
@@ -40,34 +195,342 @@ func Load() (*Config, error) {
 		if data, err := os.ReadFile(path); err == nil {
 			// Use a map to check if field exists in JSON
 			var raw map[string]interface{}
-			if err := json.Unmarshal(data, &raw); err == nil {
+			if err := json.Unmarshal(data, &raw); err != nil {
+				if !repair {
+					return nil, fmt.Errorf("config file %s is not valid JSON: %w", path, err)
+				}
+				// Keep the broken file so the user can recover their API key and settings
+				resetErr = backupCorruptConfig(path, err)
+			} else {
 				if val, ok := raw["auto_return"]; ok {
 					if b, ok := val.(bool); ok {
 						cfg.AutoReturn = b
 					}
 				}
+				if val, ok := raw["smart_enter"].(bool); ok {
+					cfg.SmartEnter = val
+				}
 				// Also merge other fields safely
 				if val, ok := raw["groq_api_key"].(string); ok && val != "" {
 					cfg.GROQ_API_KEY = val
 				}
+				if val, ok := raw["provider"].(string); ok && val != "" {
+					cfg.Provider = val
+				}
+				if val, ok := raw["local_url"].(string); ok {
+					cfg.LocalURL = val
+				}
 				if val, ok := raw["hotkey"].(string); ok && val != "" {
 					cfg.Hotkey = val
 				}
+				if val, ok := raw["undo_hotkey"].(string); ok {
+					cfg.UndoHotkey = val
+				}
+				if val, ok := raw["hotkey_mode"].(string); ok && val != "" {
+					cfg.HotkeyMode = val
+				}
 				if val, ok := raw["audio_device"].(string); ok && val != "" {
 					cfg.AudioDevice = val
 				}
+				if val, ok := raw["pulse_source"].(string); ok {
+					cfg.PulseSource = val
+				}
 				if val, ok := raw["model"].(string); ok && val != "" {
 					cfg.Model = val
 				}
 				if val, ok := raw["disable_notifications"].(bool); ok {
 					cfg.DisableNotifications = val
 				}
+				if val, ok := raw["notify_max_chars"].(float64); ok && validNumber("notify_max_chars", val) {
+					cfg.NotifyMaxChars = int(val)
+				}
+				if val, ok := raw["notify_show_count"].(bool); ok {
+					cfg.NotifyShowCount = val
+				}
+				if val, ok := raw["error_sound"].(bool); ok {
+					cfg.ErrorSound = val
+				}
 				if val, ok := raw["verbose"].(bool); ok {
 					cfg.Verbose = val
 				}
 				if val, ok := raw["temperature"].(float64); ok {
 					cfg.Temperature = val
 				}
+				if val, ok := raw["language"].(string); ok {
+					cfg.Language = val
+				}
+				if val, ok := raw["translate"].(bool); ok {
+					cfg.Translate = val
+				}
+				if val, ok := raw["glossary"].([]interface{}); ok {
+					cfg.Glossary = nil
+					for _, term := range val {
+						if s, ok := term.(string); ok {
+							cfg.Glossary = append(cfg.Glossary, s)
+						}
+					}
+				}
+				if val, ok := raw["fade_out_ms"].(float64); ok && validNumber("fade_out_ms", val) {
+					cfg.FadeOutMs = int(val)
+				}
+				if val, ok := raw["strip_model_artifacts"].(bool); ok {
+					cfg.StripModelArtifacts = val
+				}
+				if val, ok := raw["strip_labels"].(bool); ok {
+					cfg.StripLabels = val
+				}
+				if val, ok := raw["emoji_shortcodes"].(bool); ok {
+					cfg.EmojiShortcodes = val
+				}
+				if val, ok := raw["fix_english"].(bool); ok {
+					cfg.FixEnglish = val
+				}
+				if val, ok := raw["read_back"].(bool); ok {
+					cfg.ReadBack = val
+				}
+				if val, ok := raw["force_sentence_case"].(bool); ok {
+					cfg.ForceSentenceCase = val
+				}
+				if val, ok := raw["sample_rate"].(float64); ok && validNumber("sample_rate", val) {
+					cfg.SampleRate = int(val)
+				}
+				if val, ok := raw["channels"].(float64); ok && validNumber("channels", val) {
+					cfg.Channels = int(val)
+				}
+				if val, ok := raw["channel_labels"].([]interface{}); ok {
+					cfg.ChannelLabels = nil
+					for _, label := range val {
+						if s, ok := label.(string); ok {
+							cfg.ChannelLabels = append(cfg.ChannelLabels, s)
+						}
+					}
+				}
+				if val, ok := raw["acronyms"].([]interface{}); ok {
+					cfg.Acronyms = nil
+					for _, acronym := range val {
+						if s, ok := acronym.(string); ok {
+							cfg.Acronyms = append(cfg.Acronyms, s)
+						}
+					}
+				}
+				if val, ok := raw["label_patterns"].([]interface{}); ok {
+					cfg.LabelPatterns = nil
+					for _, pattern := range val {
+						if s, ok := pattern.(string); ok {
+							cfg.LabelPatterns = append(cfg.LabelPatterns, s)
+						}
+					}
+				}
+				if val, ok := raw["cooldown_ms"].(float64); ok && validNumber("cooldown_ms", val) {
+					cfg.CooldownMs = int(val)
+				}
+				if val, ok := raw["toggle_debounce_ms"].(float64); ok && validNumber("toggle_debounce_ms", val) {
+					cfg.ToggleDebounceMs = int(val)
+				}
+				if val, ok := raw["ignore_launch_press"].(bool); ok {
+					cfg.IgnoreLaunchPress = val
+				}
+				if val, ok := raw["exit_delay_ms"].(float64); ok && validNumber("exit_delay_ms", val) {
+					cfg.ExitDelayMs = int(val)
+				}
+				if val, ok := raw["retry_on_short"].(bool); ok {
+					cfg.RetryOnShort = val
+				}
+				if val, ok := raw["retry_on_short_max"].(float64); ok && validNumber("retry_on_short_max", val) {
+					cfg.RetryOnShortMax = int(val)
+				}
+				if val, ok := raw["min_recording_ms"].(float64); ok && validNumber("min_recording_ms", val) {
+					cfg.MinRecordingMs = int(val)
+				}
+				if val, ok := raw["capture_format"].(string); ok {
+					cfg.CaptureFormat = val
+				}
+				if val, ok := raw["capture_backend"].(string); ok && val != "" {
+					cfg.CaptureBackend = val
+				}
+				if val, ok := raw["period_size"].(float64); ok && validNumber("period_size", val) {
+					cfg.PeriodSize = int(val)
+				}
+				if val, ok := raw["buffer_size"].(float64); ok && validNumber("buffer_size", val) {
+					cfg.BufferSize = int(val)
+				}
+				if val, ok := raw["local_metrics"].(bool); ok {
+					cfg.LocalMetrics = val
+				}
+				if val, ok := raw["avoid_password_fields"].(bool); ok {
+					cfg.AvoidPasswordFields = val
+				}
+				if val, ok := raw["focus_target"].(string); ok && val != "" {
+					cfg.FocusTarget = val
+				}
+				if val, ok := raw["raw_transcription"].(bool); ok {
+					cfg.RawTranscription = val
+				}
+				// Unlike most strings an empty prompt is meaningful: send none
+				if val, ok := raw["transcription_prompt"].(string); ok {
+					cfg.TranscriptionPrompt = val
+				}
+				if val, ok := raw["preroll_ms"].(float64); ok && validNumber("preroll_ms", val) {
+					cfg.PrerollMs = int(val)
+				}
+				if val, ok := raw["lookback_seconds"].(float64); ok && validNumber("lookback_seconds", val) {
+					cfg.LookbackSeconds = int(val)
+				}
+				if val, ok := raw["newline_style"].(string); ok && val != "" {
+					cfg.NewlineStyle = val
+				}
+				if val, ok := raw["output_metadata_template"].(string); ok {
+					cfg.OutputMetadataTemplate = val
+				}
+				if val, ok := raw["output_metadata_position"].(string); ok {
+					cfg.OutputMetadataPosition = val
+				}
+				if val, ok := raw["on_empty"].(string); ok && val != "" {
+					cfg.OnEmpty = val
+				}
+				if val, ok := raw["respect_dnd"].(bool); ok {
+					cfg.RespectDND = val
+				}
+				if val, ok := raw["typing_placeholder"].(string); ok {
+					cfg.TypingPlaceholder = val
+				}
+				if val, ok := raw["post_type_key"].(string); ok {
+					cfg.PostTypeKey = val
+				}
+				// Negative typing delays mean "use the tool's own default"
+				if val, ok := raw["xdotool_type_delay_ms"].(float64); ok {
+					cfg.XdotoolTypeDelayMs = int(val)
+				}
+				if val, ok := raw["ydotool_type_delay_ms"].(float64); ok {
+					cfg.YdotoolTypeDelayMs = int(val)
+				}
+				if val, ok := raw["wtype_type_delay_ms"].(float64); ok {
+					cfg.WtypeTypeDelayMs = int(val)
+				}
+				if val, ok := raw["auto_stop_silence_ms"].(float64); ok && validNumber("auto_stop_silence_ms", val) {
+					cfg.AutoStopSilenceMs = int(val)
+				}
+				if val, ok := raw["auto_stop_threshold"].(float64); ok && validNumber("auto_stop_threshold", val) {
+					cfg.AutoStopThreshold = val
+				}
+				if val, ok := raw["noise_floor"].(float64); ok && validNumber("noise_floor", val) {
+					cfg.NoiseFloor = val
+				}
+				if val, ok := raw["min_free_space_mb"].(float64); ok && validNumber("min_free_space_mb", val) {
+					cfg.MinFreeSpaceMB = int(val)
+				}
+				if val, ok := raw["api_retries"].(float64); ok && validNumber("api_retries", val) {
+					cfg.APIRetries = int(val)
+				}
+				if val, ok := raw["api_retry_delay_ms"].(float64); ok && validNumber("api_retry_delay_ms", val) {
+					cfg.APIRetryDelayMs = int(val)
+				}
+				if val, ok := raw["api_retry_timeouts"].(bool); ok {
+					cfg.APIRetryTimeouts = val
+				}
+				if val, ok := raw["request_timeout_seconds"].(float64); ok && validNumber("request_timeout_seconds", val) {
+					cfg.RequestTimeoutSecs = int(val)
+				}
+				if val, ok := raw["max_concurrent_requests"].(float64); ok && validNumber("max_concurrent_requests", val) {
+					cfg.MaxConcurrentReqs = int(val)
+				}
+				if val, ok := raw["keep_warm_interval"].(float64); ok && validNumber("keep_warm_interval", val) {
+					cfg.KeepWarmInterval = int(val)
+				}
+				if val, ok := raw["keep_warm_idle_minutes"].(float64); ok && validNumber("keep_warm_idle_minutes", val) {
+					cfg.KeepWarmIdleMinutes = int(val)
+				}
+				if val, ok := raw["stream_transcription"].(bool); ok {
+					cfg.StreamTranscription = val
+				}
+				if val, ok := raw["stream_upload"].(bool); ok {
+					cfg.StreamUpload = val
+				}
+				if val, ok := raw["journal_dir"].(string); ok {
+					cfg.JournalDir = val
+				}
+				if val, ok := raw["journal_only"].(bool); ok {
+					cfg.JournalOnly = val
+				}
+				if val, ok := raw["dataset_dir"].(string); ok {
+					cfg.DatasetDir = val
+				}
+				if val, ok := raw["save_recordings"].(bool); ok {
+					cfg.SaveRecordings = val
+				}
+				if val, ok := raw["history_dir"].(string); ok {
+					cfg.HistoryDir = val
+				}
+				if val, ok := raw["history_max_age_days"].(float64); ok && validNumber("history_max_age_days", val) {
+					cfg.HistoryMaxAgeDays = int(val)
+				}
+				if val, ok := raw["retention_max_entries"].(float64); ok && validNumber("retention_max_entries", val) {
+					cfg.RetentionMaxEntries = int(val)
+				}
+				if val, ok := raw["retention_max_age_days"].(float64); ok && validNumber("retention_max_age_days", val) {
+					cfg.RetentionMaxAgeDays = int(val)
+				}
+				if val, ok := raw["retention_max_bytes"].(float64); ok && validNumber("retention_max_bytes", val) {
+					cfg.RetentionMaxBytes = int64(val)
+				}
+				if val, ok := raw["delivery_method"].(string); ok && val != "" {
+					cfg.DeliveryMethod = val
+				}
+				if val, ok := raw["emoji_map"].(map[string]interface{}); ok {
+					cfg.EmojiMap = make(map[string]string, len(val))
+					for phrase, emoji := range val {
+						if s, ok := emoji.(string); ok {
+							cfg.EmojiMap[phrase] = s
+						}
+					}
+				}
+				if val, ok := raw["app_delivery"].(map[string]interface{}); ok {
+					cfg.AppDelivery = make(map[string]string, len(val))
+					for class, method := range val {
+						if s, ok := method.(string); ok {
+							cfg.AppDelivery[class] = s
+						}
+					}
+				}
+				if val, ok := raw["prompt_overrides"].(map[string]interface{}); ok {
+					cfg.PromptOverrides = make(map[string]string, len(val))
+					for key, prompt := range val {
+						if s, ok := prompt.(string); ok {
+							cfg.PromptOverrides[key] = s
+						}
+					}
+				}
+				if val, ok := raw["replacements"].(map[string]interface{}); ok {
+					cfg.Replacements = make(map[string]string, len(val))
+					for phrase, repl := range val {
+						if s, ok := repl.(string); ok {
+							cfg.Replacements[phrase] = s
+						}
+					}
+				}
+				if val, ok := raw["app_replacements"].(map[string]interface{}); ok {
+					cfg.AppReplacements = make(map[string]map[string]string, len(val))
+					for class, rules := range val {
+						rules, ok := rules.(map[string]interface{})
+						if !ok {
+							continue
+						}
+						cfg.AppReplacements[class] = make(map[string]string, len(rules))
+						for phrase, repl := range rules {
+							if s, ok := repl.(string); ok {
+								cfg.AppReplacements[class][phrase] = s
+							}
+						}
+					}
+				}
+				if val, ok := raw["device_history"].(map[string]interface{}); ok {
+					cfg.DeviceHistory = make(map[string]int64, len(val))
+					for device, ts := range val {
+						if f, ok := ts.(float64); ok {
+							cfg.DeviceHistory[device] = int64(f)
+						}
+					}
+				}
 			}
 		}
 	}
@@ -89,6 +552,10 @@ func Load() (*Config, error) {
 		cfg.Model = model
 	}
 
+	if language := os.Getenv("VOICE_TYPE_LANGUAGE"); language != "" {
+		cfg.Language = language
+	}
+
 	if tempStr := os.Getenv("VOICE_TYPE_TEMPERATURE"); tempStr != "" {
 		var temp float64
 		if _, err := fmt.Sscanf(tempStr, "%f", &temp); err == nil {
@@ -104,7 +571,30 @@ func Load() (*Config, error) {
 		cfg.Verbose = true
 	}
 
-	return cfg, nil
+	return cfg, resetErr
+}
+
+// backupCorruptConfig moves an unparseable config file aside to a timestamped
+// config.json.<time>.bak, never over an earlier backup, and returns the
+// errors.ErrConfigReset describing what happened
+func backupCorruptConfig(path string, parseErr error) error {
+	backup := backupPath(path, time.Now())
+	if err := os.Rename(path, backup); err != nil {
+		return fmt.Errorf("%w: %s is not valid JSON (%v) and could not be backed up: %v", errors.ErrConfigReset, path, parseErr, err)
+	}
+	return fmt.Errorf("%w: %s is not valid JSON (%v), backed up to %s", errors.ErrConfigReset, path, parseErr, backup)
+}
+
+// backupPath returns a backup name for path at now that no file has yet
+func backupPath(path string, now time.Time) string {
+	base := path + "." + now.Format("20060102-150405")
+	backup := base + ".bak"
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(backup); err != nil {
+			return backup
+		}
+		backup = fmt.Sprintf("%s-%d.bak", base, i)
+	}
 }
 
 // Save saves configuration to a file
@@ -125,6 +615,62 @@ func (c *Config) Save(path string) error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// SaveKeys sets values, by JSON key, in the config file at path (or the
+// default one when path is empty) and leaves the rest of the file as it is.
+// Unlike Save it never writes what only came from the environment or a
+// launch override, such as GROQ_API_KEY, into the file.
+func SaveKeys(path string, values map[string]interface{}) error {
+	if path == "" {
+		var err error
+		path, err = GetConfigPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	raw := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		// Keep numbers such as retention_max_bytes exactly as written
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("config file %s is corrupt, not overwriting it: %w", path, err)
+		}
+		if raw == nil {
+			raw = make(map[string]interface{})
+		}
+	}
+	for key, value := range values {
+		raw[key] = value
+	}
+
+	data, err = json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Replace the file whole, so a crash can't lose the settings it held
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RememberDevice records the device with the given id as the most recently chosen
+func (c *Config) RememberDevice(device string) {
+	if device == "" {
+		return
+	}
+	if c.DeviceHistory == nil {
+		c.DeviceHistory = make(map[string]int64)
+	}
+	c.DeviceHistory[device] = time.Now().Unix()
+}
+
 // GetConfigPath returns the path to the config file
 func GetConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
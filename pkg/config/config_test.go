@@ -1,8 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"speek_to_text_linux/pkg/errors"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -91,3 +98,160 @@ func TestLoadWithEnvironment(t *testing.T) {
 		t.Error("Expected Verbose to be true")
 	}
 }
+
+func TestLoadCorruptConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GROQ_API_KEY", "")
+
+	dir := filepath.Join(home, ".config", "voicetype")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	corrupt := []byte(`{"groq_api_key": "gsk_secret", "hotkey": `)
+	if err := os.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A backup from an earlier reset must survive this one
+	earlier := filepath.Join(dir, "config.json.bak")
+	if err := os.WriteFile(earlier, []byte("earlier"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, loadErr := Load()
+	if !errors.Is(loadErr, errors.ErrConfigReset) {
+		t.Fatalf("Expected ErrConfigReset, got %v", loadErr)
+	}
+
+	if cfg.Hotkey != DefaultConfig().Hotkey {
+		t.Errorf("Expected default hotkey after corruption, got '%s'", cfg.Hotkey)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "config.json.*.bak"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected one timestamped backup, got %v (%v)", backups, err)
+	}
+	backup, err := os.ReadFile(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(backup, corrupt) {
+		t.Errorf("Expected backup to preserve corrupt contents, got %q", backup)
+	}
+	if data, _ := os.ReadFile(earlier); string(data) != "earlier" {
+		t.Errorf("Expected the earlier backup untouched, got %q", data)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected corrupt config to be moved aside")
+	}
+
+	if !strings.Contains(loadErr.Error(), backups[0]) {
+		t.Errorf("Expected the error to name the backup, got %q", loadErr)
+	}
+}
+
+func TestBackupPathSkipsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.Local)
+
+	first := backupPath(path, now)
+	if err := os.WriteFile(first, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if second := backupPath(path, now); second == first {
+		t.Errorf("Expected a new name beside %s", first)
+	}
+}
+
+func TestInspectCorruptConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".config", "voicetype")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	corrupt := []byte(`{"hotkey": `)
+	if err := os.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Inspect(); err == nil {
+		t.Error("Expected Inspect to report the corrupt config")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the corrupt config left in place: %v", err)
+	}
+	if !bytes.Equal(data, corrupt) {
+		t.Errorf("Expected the corrupt config unchanged, got %q", data)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("Expected no backup from Inspect")
+	}
+}
+
+func TestSaveKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{"groq_api_key": "gsk_file", "retention_max_bytes": 9007199254740993, "custom": [1, 2]}`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveKeys(path, map[string]interface{}{"model": "whisper-large-v3", "auto_return": true}); err != nil {
+		t.Fatalf("SaveKeys failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]json.RawMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, data)
+	}
+	expected := map[string]string{
+		"groq_api_key":        `"gsk_file"`,
+		"retention_max_bytes": "9007199254740993",
+		"custom":              "[\n    1,\n    2\n  ]",
+		"model":               `"whisper-large-v3"`,
+		"auto_return":         "true",
+	}
+	if len(saved) != len(expected) {
+		t.Errorf("Expected only the given keys added, got %s", data)
+	}
+	for key, want := range expected {
+		if got := string(saved[key]); got != want {
+			t.Errorf("%s: expected %s, got %s", key, want, got)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be private, got %v", info.Mode())
+	}
+}
+
+func TestSaveKeysMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "new.json")
+	if err := SaveKeys(missing, map[string]interface{}{"hotkey": "F9"}); err != nil {
+		t.Fatalf("SaveKeys on a missing file failed: %v", err)
+	}
+	if data, _ := os.ReadFile(missing); !strings.Contains(string(data), `"hotkey": "F9"`) {
+		t.Errorf("Expected the key in a new file, got %s", data)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"hotkey": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveKeys(corrupt, map[string]interface{}{"hotkey": "F9"}); err == nil {
+		t.Error("Expected a corrupt file to be left alone with an error")
+	}
+	if data, _ := os.ReadFile(corrupt); string(data) != `{"hotkey": ` {
+		t.Errorf("Expected the corrupt file unchanged, got %s", data)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Sets collects repeated --set key=value flags; it implements flag.Value
+type Sets []string
+
+func (s *Sets) String() string {
+	return strings.Join(*s, " ")
+}
+
+// Set adds one key=value pair
+func (s *Sets) Set(value string) error {
+	if _, _, ok := strings.Cut(value, "="); !ok {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+// ApplyOverrides changes the config for this run only, for scripting: first
+// the keys of jsonObject, e.g. `{"language": "es"}`, then each key=value of
+// sets in order, e.g. "auto_return=true". Keys are the config file's.
+// Values are coerced to the key's type, so "true", "1.5" and "a,b" fill a
+// bool, a number and a list. Nothing is written to the config file. It
+// fails on an unknown key, a value of the wrong type or a number out of
+// range, leaving the config as it was.
+func (c *Config) ApplyOverrides(jsonObject string, sets []string) error {
+	next := *c
+	if strings.TrimSpace(jsonObject) != "" {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(jsonObject), &raw); err != nil {
+			return fmt.Errorf("invalid config JSON: %w", err)
+		}
+		for key, value := range raw {
+			if err := next.setJSON(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", set)
+		}
+		if err := next.Set(strings.TrimSpace(key), value); err != nil {
+			return err
+		}
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	*c = next
+	return nil
+}
+
+// field returns the struct field for a config key
+func (c *Config) field(key string) (reflect.Value, error) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonKey(t.Field(i)) == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+}
+
+// Set sets one config key from its text form, coerced to the key's type.
+// Lists take a JSON array or comma-separated items and maps a JSON object.
+func (c *Config) Set(key, value string) error {
+	f, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	if err := setValue(f, strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// setJSON sets one config key from a JSON value. A string given for a key
+// of another type is coerced as by Set, so {"auto_return": "true"} works.
+func (c *Config) setJSON(key string, raw json.RawMessage) error {
+	f, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	ptr := reflect.New(f.Type())
+	if err := json.Unmarshal(raw, ptr.Interface()); err == nil {
+		f.Set(ptr.Elem())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("%s: expected %s, got %s", key, schemaType(f.Type()).Type, raw)
+	}
+	return c.Set(key, s)
+}
+
+// setValue parses text into f according to f's kind
+func setValue(f reflect.Value, text string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", text)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", text)
+		}
+		f.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(text, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", text)
+		}
+		f.SetFloat(x)
+	case reflect.Slice:
+		if strings.HasPrefix(text, "[") {
+			return unmarshalInto(f, text)
+		}
+		items := reflect.MakeSlice(f.Type(), 0, 0)
+		if text != "" {
+			for _, item := range strings.Split(text, ",") {
+				elem := reflect.New(f.Type().Elem()).Elem()
+				if err := setValue(elem, strings.TrimSpace(item)); err != nil {
+					return err
+				}
+				items = reflect.Append(items, elem)
+			}
+		}
+		f.Set(items)
+	case reflect.Map:
+		return unmarshalInto(f, text)
+	default:
+		return fmt.Errorf("can't be set from the command line")
+	}
+	return nil
+}
+
+// unmarshalInto parses JSON text into f
+func unmarshalInto(f reflect.Value, text string) error {
+	ptr := reflect.New(f.Type())
+	if err := json.Unmarshal([]byte(text), ptr.Interface()); err != nil {
+		return fmt.Errorf("expected JSON %s: %v", schemaType(f.Type()).Type, err)
+	}
+	f.Set(ptr.Elem())
+	return nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetCoercesTypes(t *testing.T) {
+	testCases := []struct {
+		key   string
+		value string
+		check func(c *Config) interface{}
+		want  interface{}
+	}{
+		{"auto_return", "true", func(c *Config) interface{} { return c.AutoReturn }, true},
+		{"auto_return", "0", func(c *Config) interface{} { return c.AutoReturn }, false},
+		{"language", "es", func(c *Config) interface{} { return c.Language }, "es"},
+		{"language", "", func(c *Config) interface{} { return c.Language }, ""},
+		{"cooldown_ms", " 250 ", func(c *Config) interface{} { return c.CooldownMs }, 250},
+		{"retention_max_bytes", "1048576", func(c *Config) interface{} { return c.RetentionMaxBytes }, int64(1 << 20)},
+		{"temperature", "0.4", func(c *Config) interface{} { return c.Temperature }, 0.4},
+		{"glossary", "Kubernetes, gRPC", func(c *Config) interface{} { return c.Glossary }, []string{"Kubernetes", "gRPC"}},
+		{"glossary", `["a,b", "c"]`, func(c *Config) interface{} { return c.Glossary }, []string{"a,b", "c"}},
+		{"glossary", "", func(c *Config) interface{} { return c.Glossary }, []string{}},
+		{"app_delivery", `{"slack": "type"}`, func(c *Config) interface{} { return c.AppDelivery }, map[string]string{"slack": "type"}},
+	}
+
+	for _, tc := range testCases {
+		c := DefaultConfig()
+		if err := c.Set(tc.key, tc.value); err != nil {
+			t.Errorf("Set(%q, %q) failed: %v", tc.key, tc.value, err)
+			continue
+		}
+		if got := tc.check(c); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Set(%q, %q) gave %#v, expected %#v", tc.key, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestSetRejectsBadValues(t *testing.T) {
+	testCases := []struct {
+		key   string
+		value string
+	}{
+		{"no_such_key", "1"},
+		{"auto_return", "maybe"},
+		{"cooldown_ms", "1.5"},
+		{"cooldown_ms", "fast"},
+		{"temperature", "warm"},
+		{"app_delivery", "slack=type"},
+	}
+
+	for _, tc := range testCases {
+		if err := DefaultConfig().Set(tc.key, tc.value); err == nil {
+			t.Errorf("Expected Set(%q, %q) to fail", tc.key, tc.value)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	c := DefaultConfig()
+	err := c.ApplyOverrides(`{"language": "fr", "auto_return": "yes-ish", "cooldown_ms": 100}`, nil)
+	if err == nil {
+		t.Fatal("Expected a bad bool in the JSON to fail")
+	}
+	if c.Language != "" || c.CooldownMs != DefaultConfig().CooldownMs {
+		t.Error("Expected a failed override to leave the config unchanged")
+	}
+
+	err = c.ApplyOverrides(`{"language": "fr", "auto_return": "true", "cooldown_ms": 100, "glossary": ["VoiceType"]}`,
+		[]string{"language=es", "acronyms=NASA,GPU"})
+	if err != nil {
+		t.Fatalf("ApplyOverrides failed: %v", err)
+	}
+	// --set wins over --config-json
+	if c.Language != "es" {
+		t.Errorf("Expected language es, got %q", c.Language)
+	}
+	if !c.AutoReturn || c.CooldownMs != 100 || !reflect.DeepEqual(c.Glossary, []string{"VoiceType"}) {
+		t.Errorf("JSON overrides not applied: auto_return=%v cooldown_ms=%d glossary=%v", c.AutoReturn, c.CooldownMs, c.Glossary)
+	}
+	if !reflect.DeepEqual(c.Acronyms, []string{"NASA", "GPU"}) {
+		t.Errorf("Expected acronyms [NASA GPU], got %v", c.Acronyms)
+	}
+
+	testCases := []struct {
+		json string
+		sets []string
+	}{
+		{`{"language": `, nil},
+		{`["language"]`, nil},
+		{`{"cooldown_ms": "soon"}`, nil},
+		{`{"glossary": 3}`, nil},
+		{`{"nope": 1}`, nil},
+		{"", []string{"language"}},
+		{"", []string{"nope=1"}},
+	}
+	for _, tc := range testCases {
+		if err := DefaultConfig().ApplyOverrides(tc.json, tc.sets); err == nil {
+			t.Errorf("Expected ApplyOverrides(%q, %q) to fail", tc.json, tc.sets)
+		}
+	}
+}
+
+func TestApplyOverridesRejectsOutOfRange(t *testing.T) {
+	for _, set := range []string{"notify_max_chars=-1", "retry_on_short_max=-3", "cooldown_ms=-5"} {
+		c := DefaultConfig()
+		if err := c.ApplyOverrides(`{"language": "es"}`, []string{set}); err == nil {
+			t.Errorf("Expected %s to be rejected", set)
+		}
+		if !reflect.DeepEqual(c, DefaultConfig()) {
+			t.Errorf("Expected a rejected %s to leave the config unchanged", set)
+		}
+	}
+}
+
+func TestSetsFlag(t *testing.T) {
+	var s Sets
+	if err := s.Set("language=es"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("auto_return=true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("language"); err == nil {
+		t.Error("Expected a value without = to be rejected")
+	}
+	if !reflect.DeepEqual([]string(s), []string{"language=es", "auto_return=true"}) {
+		t.Errorf("Unexpected sets %q", s)
+	}
+}
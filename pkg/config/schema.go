@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// descriptions documents each config key for the exported schema. Every
+// json key of Config needs an entry; the schema test enforces it.
+var descriptions = map[string]string{
+	"groq_api_key":            "Groq API key; the GROQ_API_KEY environment variable takes precedence",
+	"provider":                "Transcription provider: groq, or local for an OpenAI-compatible server such as whisper.cpp (no API key needed)",
+	"local_url":               "Base URL of the local provider's API, before /audio/transcriptions; empty uses http://127.0.0.1:8080/v1",
+	"hotkey":                  "Global hotkey that toggles recording: modifiers (ctrl, alt, shift, super) and a key joined by +, e.g. ctrl+space, alt+shift+d or F9",
+	"hotkey_mode":             "toggle (press to start, press again to stop) or push_to_talk (record while the hotkey is held)",
+	"undo_hotkey":             "Hotkey that deletes the text the terminal app typed last, e.g. ctrl+alt+z; empty disables it",
+	"audio_device":            "ALSA capture device; empty picks the most recently used one, then default",
+	"pulse_source":            "PulseAudio/PipeWire source used when capturing from default; empty detects it, \"off\" keeps ALSA's default",
+	"disable_notifications":   "Turn off desktop notifications",
+	"notify_max_chars":        "Longest transcription preview shown in a notification, cut at a whole character; 0 shows it all",
+	"notify_show_count":       "Add the transcription's length in characters to notifications that quote it",
+	"error_sound":             "Play a falling two-note tone when transcription or typing fails",
+	"verbose":                 "Log extra detail",
+	"model":                   "Whisper model used for transcription",
+	"temperature":             "Sampling temperature sent to the model",
+	"auto_return":             "Press Enter after typing (legacy; see post_type_key)",
+	"smart_enter":             "Skip the Enter implied by auto_return for multi-line text",
+	"typing_placeholder":      "Text typed at the cursor while transcribing and replaced by the result; empty disables it",
+	"post_type_key":           "Key pressed after typing: none, enter, tab or shift_enter",
+	"delivery_method":         "How text reaches the focused app: paste (clipboard, typing as fallback) or type (key by key, pasting as fallback)",
+	"xdotool_type_delay_ms":   "Per-character delay for xdotool typing; negative uses the tool default",
+	"ydotool_type_delay_ms":   "Per-character delay for ydotool typing; negative uses the tool default",
+	"wtype_type_delay_ms":     "Per-character delay for wtype typing; negative uses the tool default",
+	"language":                "ISO-639-1 language hint, e.g. en; empty auto-detects",
+	"translate":               "Translate speech in any language to English text instead of transcribing it as spoken",
+	"fade_out_ms":             "Duration of the pill fade-out animation",
+	"strip_model_artifacts":   "Remove quotes, bullets and code fences the model wraps around the text",
+	"strip_labels":            "Remove timestamps and speaker labels from the text",
+	"force_sentence_case":     "Capitalize the first letter of every sentence",
+	"fix_english":             "Capitalize a standalone \"i\" and restore apostrophes in contractions like \"dont\"; English text only",
+	"read_back":               "Read each transcription aloud (espeak-ng or spd-say) before typing it",
+	"emoji_shortcodes":        "Replace spoken phrases like \"smiley face\" or \"thumbs up emoji\" and :shortcodes: like :thumbsup: with emoji, pasted rather than typed",
+	"cooldown_ms":             "Ignore the hotkey for this long after typing",
+	"toggle_debounce_ms":      "Ignore a toggle this soon after the previous one, from the hotkey or --toggle; push-to-talk is not debounced",
+	"ignore_launch_press":     "Ignore a hotkey already held when the app starts, e.g. from the shortcut that launched it, until it is released",
+	"exit_delay_ms":           "How long a one-shot run stays alive after typing so the selection can be read",
+	"retry_on_short":          "When a recording is too short to transcribe, e.g. a tap that let go too soon, start listening again instead of ending; not in push-to-talk",
+	"retry_on_short_max":      "How many times in a row retry_on_short listens again",
+	"min_recording_ms":        "Recordings shorter than this are too short to transcribe and end without typing, or listen again with retry_on_short",
+	"capture_format":          "arecord sample format: S16_LE, S24_3LE, S32_LE or FLOAT_LE; empty uses S16_LE",
+	"capture_backend":         "Capture tool: auto, alsa (arecord), pulse (parec) or pipewire (pw-record); a missing tool, or auto with an audio_device other than default, uses arecord",
+	"sample_rate":             "Capture sample rate in Hz, e.g. 44100 for mics that distort at 16000",
+	"channels":                "Capture channels: 1, or 2 to transcribe a stereo interface's left and right inputs separately into a labeled transcript",
+	"period_size":             "arecord period size in frames; 0 uses the ALSA default",
+	"buffer_size":             "arecord buffer size in frames; 0 uses the ALSA default",
+	"local_metrics":           "Keep local success/error counters (see --stats)",
+	"avoid_password_fields":   "Copy instead of typing when the focused window is a password prompt such as pinentry or a polkit agent",
+	"focus_target":            "Window focused before typing: start (when recording began) or stop",
+	"transcription_prompt":    "Instructions sent with English or auto-detected speech to set the dictation style; empty sends none, e.g. to keep filler words",
+	"raw_transcription":       "Verbatim transcription with no prompt and no cleanup",
+	"preroll_ms":              "Audio kept from just before the hotkey was pressed; 0 disables pre-roll",
+	"lookback_seconds":        "Keep this many seconds of audio always captured so \"last\" in the terminal app transcribes what was just said; 0 disables it",
+	"auto_stop_silence_ms":    "Stop recording after this much continuous silence; 0 disables it",
+	"auto_stop_threshold":     "RMS level (0 to 1) below which audio counts as silence for auto_stop_silence_ms",
+	"noise_floor":             "Ambient RMS level (0 to 1) measured by --calibrate; auto-stop raises its threshold to clear it, and a recording that never rises above it isn't transcribed. 0 is uncalibrated",
+	"newline_style":           "Line endings of typed text: lf, crlf or platform",
+	"on_empty":                "What to do when nothing was said: ignore or notify",
+	"respect_dnd":             "Suppress notifications while Do Not Disturb is on",
+	"api_retries":             "Retries for transcription requests that failed with a network error, 429 or 5xx",
+	"request_timeout_seconds": "Timeout for each transcription request; 0 allows 30 seconds plus two seconds per second of audio",
+	"api_retry_delay_ms":      "Delay before the first retry, doubled on each retry after; a 429's Retry-After takes precedence",
+	"api_retry_timeouts":      "Also retry requests that timed out, which the server may have transcribed and billed already",
+	"max_concurrent_requests": "Most transcription requests in flight at once, e.g. chunks of a long recording; 0 is unlimited",
+	"keep_warm_interval":      "Seconds between keep-warm pings from the terminal app, so the first transcription after a pause starts fast; 0 disables them",
+	"keep_warm_idle_minutes":  "Stop keep-warm pings after this many minutes without a recording; 0 never stops",
+	"stream_transcription":    "Stream partial transcription text where supported",
+	"stream_upload":           "Upload audio while recording so long dictations transcribe sooner after stopping; mono only",
+	"journal_dir":             "Append every transcription to a dated file in this directory; empty disables it",
+	"journal_only":            "Only journal transcriptions, don't type them",
+	"dataset_dir":             "Save each recording and its transcription as a WAV/text pair in this directory",
+	"save_recordings":         "Keep every recording as <timestamp>.wav in history_dir, with its transcription in <timestamp>.txt",
+	"history_dir":             "Where save_recordings keeps recordings; empty uses ~/.config/voicetype/history",
+	"history_max_age_days":    "Delete saved recordings older than this many days on startup; 0 keeps them forever",
+	"retention_max_entries":   "Keep at most this many journal entries; 0 is unlimited",
+	"retention_max_age_days":  "Delete journal days older than this many days; 0 is unlimited",
+	"retention_max_bytes":     "Keep journal entries within this many bytes in total; 0 is unlimited",
+	"min_free_space_mb":       "Skip saving journal entries, dataset pairs, saved recordings and the debug log when less than this many MB are free; 0 disables the check",
+	"glossary":                "Names and jargon appended to the transcription prompt to bias recognition",
+	"prompt_overrides":        "Transcription prompts for particular models or languages, e.g. {\"ja\": \"\", \"spanish\": \"...\"}; an empty prompt sends none, and a model's wins over a language's",
+	"label_patterns":          "Regexes removed when strip_labels is on; empty uses the built-in patterns",
+	"acronyms":                "Words force_sentence_case never recapitalizes, e.g. iOS or npm",
+	"channel_labels":          "Speaker labels for the left and right channels when channels is 2; empty uses Left and Right",
+	"emoji_map":               "Extra or overriding emoji phrases, e.g. {\"coffee emoji\": \"☕\", \":shipit:\": \"🚢\"}; an empty value removes a built-in one",
+	"app_delivery":            "Per-app delivery_method keyed by WM_CLASS, e.g. {\"slack\": \"paste\", \"terminal\": \"type\"}; a key also matches classes containing it",
+	"replacements":            "Spoken phrases to replace before typing, matched as whole words in any case, e.g. {\"new line\": \"\\n\", \"open paren\": \"(\"}; keys starting with re: are regular expressions",
+	"app_replacements":        "Per-app replacements keyed by WM_CLASS, applied before the general ones, e.g. {\"code\": {\"arrow\": \"=>\"}}",
+	"device_history":          "Capture devices (USB id or ALSA name) and the Unix time they were last chosen; maintained automatically",
+
+	"output_metadata_template": "Added to each typed transcription, e.g. \"[{model}, {duration}]\"; {timestamp} is the delivery time. Empty adds nothing",
+	"output_metadata_position": "Where output_metadata_template goes: prefix (the default) or suffix",
+}
+
+// SchemaProperty describes one config key
+type SchemaProperty struct {
+	Type                 string          `json:"type"`
+	Description          string          `json:"description,omitempty"`
+	Default              interface{}     `json:"default,omitempty"`
+	Items                *SchemaProperty `json:"items,omitempty"`
+	AdditionalProperties *SchemaProperty `json:"additionalProperties,omitempty"`
+}
+
+// Schema is a JSON Schema for config.json, usable by editors for autocomplete
+type Schema struct {
+	Schema               string                    `json:"$schema"`
+	Title                string                    `json:"title"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]SchemaProperty `json:"properties"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+// GenerateSchema builds the schema from the Config struct's json tags, with
+// defaults taken from DefaultConfig
+func GenerateSchema() *Schema {
+	s := &Schema{
+		Schema:     "http://json-schema.org/draft-07/schema#",
+		Title:      "VoiceType configuration",
+		Type:       "object",
+		Properties: make(map[string]SchemaProperty),
+	}
+
+	defaults := reflect.ValueOf(DefaultConfig()).Elem()
+	t := defaults.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := jsonKey(field)
+		if key == "" {
+			continue
+		}
+		prop := schemaType(field.Type)
+		prop.Description = descriptions[key]
+		if v := defaults.Field(i); !v.IsZero() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Map) {
+			prop.Default = v.Interface()
+		}
+		s.Properties[key] = prop
+	}
+	return s
+}
+
+// JSON returns the schema as indented JSON
+func (s *Schema) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// jsonKey returns the config key of a struct field, or "" if it isn't serialized
+func jsonKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// schemaType maps a Go type to its JSON Schema type
+func schemaType(t reflect.Type) SchemaProperty {
+	switch t.Kind() {
+	case reflect.Bool:
+		return SchemaProperty{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return SchemaProperty{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return SchemaProperty{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		items := schemaType(t.Elem())
+		return SchemaProperty{Type: "array", Items: &items}
+	case reflect.Map:
+		values := schemaType(t.Elem())
+		return SchemaProperty{Type: "object", AdditionalProperties: &values}
+	}
+	return SchemaProperty{Type: "string"}
+}
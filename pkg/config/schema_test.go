@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaListsAllFields(t *testing.T) {
+	schema := GenerateSchema()
+
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := jsonKey(field)
+		prop, ok := schema.Properties[key]
+		if !ok {
+			t.Errorf("Field %s (%q) missing from schema", field.Name, key)
+			continue
+		}
+		if prop.Description == "" {
+			t.Errorf("Field %s (%q) has no description", field.Name, key)
+		}
+	}
+	if len(schema.Properties) != typ.NumField() {
+		t.Errorf("Expected %d properties, got %d", typ.NumField(), len(schema.Properties))
+	}
+	for key := range descriptions {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("Description for unknown key %q", key)
+		}
+	}
+}
+
+func TestSchemaTypesAndDefaults(t *testing.T) {
+	schema := GenerateSchema()
+
+	testCases := []struct {
+		key      string
+		typ      string
+		expected interface{}
+	}{
+		{"hotkey", "string", DefaultConfig().Hotkey},
+		{"temperature", "number", 0.0},
+		{"smart_enter", "boolean", true},
+		{"cooldown_ms", "integer", 800},
+		{"toggle_debounce_ms", "integer", 600},
+		{"glossary", "array", nil},
+		{"device_history", "object", nil},
+	}
+
+	for _, tc := range testCases {
+		prop := schema.Properties[tc.key]
+		if prop.Type != tc.typ {
+			t.Errorf("%s: expected type %s, got %s", tc.key, tc.typ, prop.Type)
+		}
+		if !reflect.DeepEqual(prop.Default, tc.expected) {
+			t.Errorf("%s: expected default %v, got %v", tc.key, tc.expected, prop.Default)
+		}
+	}
+	if items := schema.Properties["glossary"].Items; items == nil || items.Type != "string" {
+		t.Errorf("Expected glossary items to be strings, got %+v", items)
+	}
+	if values := schema.Properties["device_history"].AdditionalProperties; values == nil || values.Type != "integer" {
+		t.Errorf("Expected device_history values to be integers, got %+v", values)
+	}
+
+	data, err := schema.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// nonNegative accepts zero and up, for counts, sizes and durations where 0
+// means off or the default
+func nonNegative(v float64) bool { return v >= 0 }
+
+// positive accepts values above zero
+func positive(v float64) bool { return v > 0 }
+
+// numberChecks are the allowed values of numeric settings, shared by Load,
+// which ignores a value out of range, and Validate
+var numberChecks = map[string]func(float64) bool{
+	"notify_max_chars":        nonNegative,
+	"fade_out_ms":             nonNegative,
+	"sample_rate":             positive,
+	"channels":                func(v float64) bool { return v == 1 || v == 2 },
+	"cooldown_ms":             nonNegative,
+	"toggle_debounce_ms":      nonNegative,
+	"exit_delay_ms":           nonNegative,
+	"retry_on_short_max":      nonNegative,
+	"min_recording_ms":        nonNegative,
+	"period_size":             nonNegative,
+	"buffer_size":             nonNegative,
+	"preroll_ms":              nonNegative,
+	"lookback_seconds":        nonNegative,
+	"auto_stop_silence_ms":    nonNegative,
+	"auto_stop_threshold":     nonNegative,
+	"noise_floor":             nonNegative,
+	"min_free_space_mb":       nonNegative,
+	"api_retries":             nonNegative,
+	"api_retry_delay_ms":      positive,
+	"request_timeout_seconds": nonNegative,
+	"max_concurrent_requests": nonNegative,
+	"keep_warm_interval":      nonNegative,
+	"keep_warm_idle_minutes":  nonNegative,
+	"history_max_age_days":    nonNegative,
+	"retention_max_entries":   nonNegative,
+	"retention_max_age_days":  nonNegative,
+	"retention_max_bytes":     nonNegative,
+}
+
+// validNumber reports whether val is allowed for key
+func validNumber(key string, val float64) bool {
+	check, ok := numberChecks[key]
+	return !ok || check(val)
+}
+
+// Validate reports the first numeric setting out of its allowed range, e.g. a
+// negative cooldown_ms
+func (c *Config) Validate() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := jsonKey(t.Field(i))
+		if _, ok := numberChecks[key]; !ok {
+			continue
+		}
+		var val float64
+		switch f := v.Field(i); f.Kind() {
+		case reflect.Int, reflect.Int64:
+			val = float64(f.Int())
+		case reflect.Float64:
+			val = f.Float()
+		default:
+			continue
+		}
+		if !validNumber(key, val) {
+			return fmt.Errorf("%s: %v is out of range", key, val)
+		}
+	}
+	return nil
+}
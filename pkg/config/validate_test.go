@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumberChecksNameRealKeys(t *testing.T) {
+	keys := make(map[string]bool)
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		keys[jsonKey(typ.Field(i))] = true
+	}
+	for key := range numberChecks {
+		if !keys[key] {
+			t.Errorf("numberChecks has %q, which is not a config key", key)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(c *Config)
+	}{
+		{"negative count", func(c *Config) { c.NotifyMaxChars = -1 }},
+		{"negative retries", func(c *Config) { c.RetryOnShortMax = -3 }},
+		{"negative duration", func(c *Config) { c.CooldownMs = -5 }},
+		{"zero sample rate", func(c *Config) { c.SampleRate = 0 }},
+		{"three channels", func(c *Config) { c.Channels = 3 }},
+		{"negative float", func(c *Config) { c.NoiseFloor = -0.1 }},
+	}
+
+	for _, tc := range testCases {
+		c := DefaultConfig()
+		tc.modify(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"log"
 	"runtime"
@@ -57,10 +58,16 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // Handler provides centralized error handling
 type Handler struct {
 	mu        sync.Mutex
 	callbacks []func(*Error)
+	typed     map[ErrorType][]func(*Error)
 	logger    *log.Logger
 }
 
@@ -71,28 +78,47 @@ func NewHandler() *Handler {
 	}
 }
 
-// OnError registers a callback for error events
+// OnError registers a callback for error events of every type
 func (h *Handler) OnError(callback func(*Error)) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.callbacks = append(h.callbacks, callback)
 }
 
-// Handle processes an error
+// OnErrorType registers a callback for errors of type t only, e.g. to point
+// the user at their microphone for ErrorTypeAudio
+func (h *Handler) OnErrorType(t ErrorType, callback func(*Error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.typed == nil {
+		h.typed = make(map[ErrorType][]func(*Error))
+	}
+	h.typed[t] = append(h.typed[t], callback)
+}
+
+// Handle processes an error. An *Error anywhere in err's chain reaches the
+// callbacks as is, keeping its type; any other error is reported as
+// ErrorTypeUnknown.
 func (h *Handler) Handle(err error) {
 	if err == nil {
 		return
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
 	// Log the error
 	h.logger.Print(err)
 
+	var e *Error
+	if !stderrors.As(err, &e) {
+		e = NewError(ErrorTypeUnknown, "Error occurred", err)
+	}
+
+	h.mu.Lock()
+	callbacks := append(append([]func(*Error){}, h.callbacks...), h.typed[e.Type]...)
+	h.mu.Unlock()
+
 	// Notify callbacks
-	for _, callback := range h.callbacks {
-		go callback(NewError(ErrorTypeUnknown, "Error occurred", err))
+	for _, callback := range callbacks {
+		go callback(e)
 	}
 }
 
@@ -132,6 +158,11 @@ func IsType(err error, errType ErrorType) bool {
 	return false
 }
 
+// Is reports whether any error in err's chain matches target
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
 // Wrap wraps an error with additional context
 func Wrap(err error, errType ErrorType, message string) *Error {
 	if err == nil {
@@ -154,4 +185,9 @@ var (
 	ErrAudioTooShort    = fmt.Errorf("audio recording is too short")
 	ErrNoMicrophone     = fmt.Errorf("no microphone found")
 	ErrTypingFailed     = fmt.Errorf("typing operation failed")
+	ErrAlreadyRecording = fmt.Errorf("already recording")
+	ErrNothingToRetry   = fmt.Errorf("no recording to re-transcribe")
+	ErrNothingToUndo    = fmt.Errorf("nothing to undo")
+	ErrNoHotkeyTool     = fmt.Errorf("no hotkey detection tool available")
+	ErrConfigReset      = fmt.Errorf("config file was reset to defaults")
 )
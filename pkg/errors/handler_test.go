@@ -2,7 +2,10 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewError(t *testing.T) {
@@ -60,6 +63,17 @@ func TestIsType(t *testing.T) {
 	}
 }
 
+func TestIsUnwrapsError(t *testing.T) {
+	err := Wrap(ErrAlreadyRecording, ErrorTypeAudio, "cannot start recording")
+
+	if !Is(err, ErrAlreadyRecording) {
+		t.Error("Expected Is to match the wrapped sentinel")
+	}
+	if Is(err, ErrAudioTooShort) {
+		t.Error("Expected Is to not match an unrelated sentinel")
+	}
+}
+
 func TestHandler_Error(t *testing.T) {
 	handler := NewHandler()
 
@@ -88,3 +102,72 @@ func TestHandler_Info(t *testing.T) {
 	// This should not panic
 	handler.Info("info: %v", "test")
 }
+
+func TestHandlerKeepsErrorType(t *testing.T) {
+	handler := NewHandler()
+	got := make(chan *Error, 1)
+	handler.OnError(func(e *Error) { got <- e })
+
+	original := Wrap(ErrNoMicrophone, ErrorTypeAudio, "cannot record")
+	handler.Handle(fmt.Errorf("starting: %w", original))
+	if e := receive(t, got); e != original {
+		t.Errorf("Expected the typed error itself, got %v (type %v)", e, e.Type)
+	}
+
+	handler.Handle(errors.New("plain"))
+	if e := receive(t, got); e.Type != ErrorTypeUnknown || e.Err.Error() != "plain" {
+		t.Errorf("Expected an untyped error to be reported as unknown, got %v (type %v)", e, e.Type)
+	}
+}
+
+func TestOnErrorTypeFiresOnlyForMatchingType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected []ErrorType
+	}{
+		{"audio", NewError(ErrorTypeAudio, "mic unplugged", nil), []ErrorType{ErrorTypeAudio}},
+		{"api", Wrap(ErrAPIKeyInvalid, ErrorTypeAPI, "request rejected"), []ErrorType{ErrorTypeAPI}},
+		{"wrapped api", fmt.Errorf("transcribe: %w", NewError(ErrorTypeAPI, "bad key", nil)), []ErrorType{ErrorTypeAPI}},
+		{"network", NewError(ErrorTypeNetwork, "offline", nil), nil},
+		{"untyped", errors.New("plain"), nil},
+	}
+
+	for _, tc := range testCases {
+		handler := NewHandler()
+		fired := make(chan ErrorType, 4)
+		for _, typ := range []ErrorType{ErrorTypeAudio, ErrorTypeAPI} {
+			typ := typ
+			handler.OnErrorType(typ, func(*Error) { fired <- typ })
+		}
+
+		handler.Handle(tc.err)
+
+		// Callbacks run asynchronously; collect until none arrive for a while
+		var types []ErrorType
+	collect:
+		for {
+			select {
+			case typ := <-fired:
+				types = append(types, typ)
+			case <-time.After(50 * time.Millisecond):
+				break collect
+			}
+		}
+		if !reflect.DeepEqual(types, tc.expected) {
+			t.Errorf("%s: expected callbacks for %v, got %v", tc.name, tc.expected, types)
+		}
+	}
+}
+
+// receive waits for a callback's error
+func receive(t *testing.T, ch <-chan *Error) *Error {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Expected the callback to fire")
+		return nil
+	}
+}
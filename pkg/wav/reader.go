@@ -0,0 +1,180 @@
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Format describes the PCM layout of a WAV file
+type Format struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// frameSize returns the bytes in one sample of every channel
+func (f Format) frameSize() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// File is a WAV file read by Read
+type File struct {
+	Format
+	Data []byte // PCM samples
+}
+
+// Duration returns how long the samples play for
+func (f *File) Duration() time.Duration {
+	bytesPerSecond := f.SampleRate * f.frameSize()
+	if bytesPerSecond == 0 {
+		return 0
+	}
+	return time.Duration(len(f.Data)) * time.Second / time.Duration(bytesPerSecond)
+}
+
+// Decode parses a PCM WAV file, returning its format and sample data. It is
+// strict about the header so a malformed encode is caught before upload:
+// sizes must match the file, the fmt chunk must describe PCM, and the data
+// must be a whole number of frames.
+func Decode(data []byte) (Format, []byte, error) {
+	var f Format
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return f, nil, fmt.Errorf("missing RIFF/WAVE header")
+	}
+	if riffSize := binary.LittleEndian.Uint32(data[4:8]); int(riffSize) != len(data)-8 {
+		return f, nil, fmt.Errorf("RIFF size %d doesn't match file size %d", riffSize, len(data)-8)
+	}
+
+	haveFmt := false
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		if size < 0 || body+size > len(data) {
+			return f, nil, fmt.Errorf("%q chunk size %d runs past the end of the file", id, size)
+		}
+
+		switch id {
+		case "fmt ":
+			parsed, err := parseFmt(data[body : body+size])
+			if err != nil {
+				return f, nil, err
+			}
+			f, haveFmt = parsed, true
+		case "data":
+			if !haveFmt {
+				return f, nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			if frame := f.frameSize(); size%frame != 0 {
+				return f, nil, fmt.Errorf("data size %d is not a whole number of %d-byte frames", size, frame)
+			}
+			return f, data[body : body+size], nil
+		}
+
+		// Chunks are padded to an even size
+		pos = body + size + size%2
+	}
+	return f, nil, fmt.Errorf("no data chunk")
+}
+
+// maxFmtSize bounds the fmt chunk Read buffers; a PCM one is 16 to 40 bytes
+const maxFmtSize = 1 << 10
+
+// unknownSize is the data size a recorder streaming to a pipe writes, as it
+// can't go back to fill in the real one
+const unknownSize = 0xFFFFFFFF
+
+// Read parses a PCM WAV file from r, e.g. one recorded by arecord or sox.
+// Unlike Decode it takes files as recorders leave them: the RIFF size isn't
+// checked, chunks other than fmt and data, such as LIST or fact, are
+// skipped, and a data chunk of unknown size runs to the end of the input.
+func Read(r io.Reader) (*File, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("missing RIFF/WAVE header")
+	}
+
+	var f File
+	haveFmt := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("no data chunk")
+			}
+			return nil, fmt.Errorf("reading chunk header: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size > maxFmtSize {
+				return nil, fmt.Errorf("fmt chunk too long (%d bytes)", size)
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("reading fmt chunk: %w", err)
+			}
+			parsed, err := parseFmt(body[:size])
+			if err != nil {
+				return nil, err
+			}
+			f.Format, haveFmt = parsed, true
+			continue
+		case "data":
+			if !haveFmt {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			var err error
+			if size == unknownSize {
+				f.Data, err = io.ReadAll(r)
+				// Drop a partial frame left by a recording cut off mid-write
+				f.Data = f.Data[:len(f.Data)-len(f.Data)%f.frameSize()]
+			} else {
+				f.Data, err = io.ReadAll(io.LimitReader(r, int64(size)))
+				if err == nil && len(f.Data) < int(size) {
+					err = fmt.Errorf("data chunk is %d bytes, expected %d", len(f.Data), size)
+				}
+				if frame := f.frameSize(); err == nil && len(f.Data)%frame != 0 {
+					err = fmt.Errorf("data size %d is not a whole number of %d-byte frames", size, frame)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading data chunk: %w", err)
+			}
+			return &f, nil
+		}
+
+		// Chunks are padded to an even size
+		skip := int64(size) + int64(size%2)
+		if n, err := io.CopyN(io.Discard, r, skip); err != nil {
+			return nil, fmt.Errorf("%q chunk size %d runs past the end of the file (%d bytes left)", id, size, n)
+		}
+	}
+}
+
+// parseFmt parses the body of a fmt chunk, which must describe PCM
+func parseFmt(chunk []byte) (Format, error) {
+	var f Format
+	if len(chunk) < 16 {
+		return f, fmt.Errorf("fmt chunk too short (%d bytes)", len(chunk))
+	}
+	if format := binary.LittleEndian.Uint16(chunk[0:2]); format != 1 {
+		return f, fmt.Errorf("audio format %d is not PCM", format)
+	}
+	f.Channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+	f.SampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+	byteRate := int(binary.LittleEndian.Uint32(chunk[8:12]))
+	blockAlign := int(binary.LittleEndian.Uint16(chunk[12:14]))
+	f.BitsPerSample = int(binary.LittleEndian.Uint16(chunk[14:16]))
+	if f.Channels == 0 || f.SampleRate == 0 || f.frameSize() == 0 {
+		return f, fmt.Errorf("fmt chunk has zero channels, rate or sample size")
+	}
+	if blockAlign != f.frameSize() || byteRate != f.SampleRate*blockAlign {
+		return f, fmt.Errorf("fmt chunk byte rate %d or block align %d is inconsistent", byteRate, blockAlign)
+	}
+	return f, nil
+}
@@ -0,0 +1,173 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestDecodeRoundTrip(t *testing.T) {
+	audioData := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	wavData, err := Encode(audioData, 16000, 2, 16)
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	f, pcm, err := Decode(wavData)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if f != (Format{SampleRate: 16000, Channels: 2, BitsPerSample: 16}) {
+		t.Errorf("Unexpected format %+v", f)
+	}
+	if !bytes.Equal(pcm, audioData) {
+		t.Errorf("Expected %v, got %v", audioData, pcm)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	valid, err := Encode(make([]byte, 64), 16000, 1, 16)
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		corrupt func(b []byte) []byte
+	}{
+		{"not RIFF", func(b []byte) []byte { copy(b[0:4], "RIFX"); return b }},
+		{"wrong RIFF size", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[4:8], 36); return b }},
+		{"data size past end", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[40:44], 1000); return b }},
+		{"not PCM", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[20:22], 3); return b }},
+		{"bad block align", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[32:34], 4); return b }},
+		{"zero channels", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[22:24], 0); return b }},
+		{"no data chunk", func(b []byte) []byte {
+			copy(b[36:40], "LIST")
+			return b
+		}},
+		{"truncated", func(b []byte) []byte { return b[:8] }},
+	}
+
+	for _, tc := range testCases {
+		b := tc.corrupt(append([]byte(nil), valid...))
+		if _, _, err := Decode(b); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+// chunk returns a RIFF chunk with its header and pad byte
+func chunk(id string, body []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:8], uint32(len(body)))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func TestRead(t *testing.T) {
+	pcm := make([]byte, 32000) // one second of 16 kHz mono 16-bit
+	for i := range pcm {
+		pcm[i] = byte(i)
+	}
+	encoded, err := Encode(pcm, 16000, 1, 16)
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+	fmtChunk, dataChunk := encoded[12:36], encoded[36:]
+
+	// riff assembles a file from chunks; the RIFF size is left wrong on
+	// purpose, as Read doesn't check it
+	riff := func(chunks ...[]byte) []byte {
+		b := []byte("RIFF\x00\x00\x00\x00WAVE")
+		for _, c := range chunks {
+			b = append(b, c...)
+		}
+		return b
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"encoded", encoded, pcm},
+		{"LIST before fmt", riff(chunk("LIST", []byte("INFOISFT\x05\x00\x00\x00sox\x00\x00")), fmtChunk, dataChunk), pcm},
+		{"odd-sized fact after fmt", riff(fmtChunk, chunk("fact", []byte{1, 2, 3}), dataChunk), pcm},
+		{"chunk after data", riff(fmtChunk, dataChunk, chunk("LIST", []byte("INFO"))), pcm},
+		{"streamed", append(StreamHeader(16000, 1, 16), pcm...), pcm},
+		{"streamed, cut mid-frame", append(StreamHeader(16000, 1, 16), pcm[:101]...), pcm[:100]},
+	}
+
+	for _, tc := range testCases {
+		f, err := Read(bytes.NewReader(tc.data))
+		if err != nil {
+			t.Errorf("%s: Read() failed: %v", tc.name, err)
+			continue
+		}
+		if f.Format != (Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16}) {
+			t.Errorf("%s: unexpected format %+v", tc.name, f.Format)
+		}
+		if !bytes.Equal(f.Data, tc.want) {
+			t.Errorf("%s: got %d bytes of samples, expected %d", tc.name, len(f.Data), len(tc.want))
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	encoded, err := Encode(make([]byte, 64), 16000, 1, 16)
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		corrupt func(b []byte) []byte
+	}{
+		{"empty", func(b []byte) []byte { return nil }},
+		{"not WAVE", func(b []byte) []byte { copy(b[8:12], "AVI "); return b }},
+		{"not PCM", func(b []byte) []byte { binary.LittleEndian.PutUint16(b[20:22], 3); return b }},
+		{"4-bit samples", func(b []byte) []byte {
+			binary.LittleEndian.PutUint16(b[32:34], 0)
+			binary.LittleEndian.PutUint32(b[28:32], 0)
+			binary.LittleEndian.PutUint16(b[34:36], 4)
+			return b
+		}},
+		{"data before fmt", func(b []byte) []byte { return append(b[:12], b[36:]...) }},
+		{"no data chunk", func(b []byte) []byte { return b[:36] }},
+		{"data cut short", func(b []byte) []byte { return b[:len(b)-10] }},
+		{"partial frame", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[40:44], 63); return b }},
+		{"data chunk renamed", func(b []byte) []byte { copy(b[36:40], "LIST"); return b }},
+		{"chunk past end", func(b []byte) []byte { copy(b[36:40], "LIST"); return b[:len(b)-1] }},
+	}
+
+	for _, tc := range testCases {
+		b := tc.corrupt(append([]byte(nil), encoded...))
+		if _, err := Read(bytes.NewReader(b)); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
+
+func TestFileDuration(t *testing.T) {
+	testCases := []struct {
+		format Format
+		bytes  int
+		want   time.Duration
+	}{
+		{Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16}, 32000, time.Second},
+		{Format{SampleRate: 44100, Channels: 2, BitsPerSample: 16}, 44100, 250 * time.Millisecond},
+		{Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16}, 0, 0},
+		{Format{}, 100, 0},
+	}
+
+	for _, tc := range testCases {
+		f := &File{Format: tc.format, Data: make([]byte, tc.bytes)}
+		if got := f.Duration(); got != tc.want {
+			t.Errorf("Duration() of %d bytes at %+v = %v, expected %v", tc.bytes, tc.format, got, tc.want)
+		}
+	}
+}
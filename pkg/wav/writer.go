@@ -7,9 +7,10 @@ import (
 	"io"
 )
 
-// Writer represents a WAV file writer
+// Writer represents a WAV file writer. The sizes in the header aren't known
+// until the last sample, so Close seeks back to fill them in.
 type Writer struct {
-	w             io.Writer
+	w             io.WriteSeeker
 	sampleRate    int
 	channels      int
 	bitsPerSample int
@@ -17,8 +18,8 @@ type Writer struct {
 	headerWritten bool
 }
 
-// NewWriter creates a new WAV writer
-func NewWriter(w io.Writer, sampleRate, channels, bitsPerSample int) *Writer {
+// NewWriter creates a new WAV writer, e.g. on an *os.File
+func NewWriter(w io.WriteSeeker, sampleRate, channels, bitsPerSample int) *Writer {
 	return &Writer{
 		w:             w,
 		sampleRate:    sampleRate,
@@ -44,6 +45,12 @@ func (w *Writer) Write(p []byte) (int, error) {
 
 // writeHeader writes the WAV file header
 func (w *Writer) writeHeader() error {
+	_, err := w.w.Write(w.header())
+	return err
+}
+
+// header returns the WAV file header for the data written so far
+func (w *Writer) header() []byte {
 	// WAV file format:
 	// RIFF header (12 bytes)
 	// fmt chunk (24 bytes for PCM)
@@ -94,53 +101,56 @@ func (w *Writer) writeHeader() error {
 	// data chunk size
 	binary.LittleEndian.PutUint32(header[40:44], uint32(w.dataSize))
 
-	_, err := w.w.Write(header)
-	return err
+	return header
 }
 
-// Close finalizes the WAV file
+// Close finalizes the WAV file by patching the RIFF size (bytes 4-8) and
+// the data size (bytes 40-44) in the header, leaving the position at the
+// end. It doesn't close the underlying writer.
 func (w *Writer) Close() error {
 	if !w.headerWritten {
-		// Write header with 0 data size
 		w.dataSize = 0
 		if err := w.writeHeader(); err != nil {
 			return err
 		}
+		w.headerWritten = true
 	}
 
-	// Rewrite the header with the correct data size
-	return nil
+	header := w.header()
+	for _, field := range [][2]int{{4, 8}, {40, 44}} {
+		if _, err := w.w.Seek(int64(field[0]), io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(header[field[0]:field[1]]); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.Seek(0, io.SeekEnd)
+	return err
 }
 
 // Encode audio data to WAV format in memory
 func Encode(audioData []byte, sampleRate, channels, bitsPerSample int) ([]byte, error) {
-	buf := &bufferWriter{
-		data: make([]byte, 0, len(audioData)+44),
-	}
-
-	writer := NewWriter(buf, sampleRate, channels, bitsPerSample)
-
-	// Write header first
-	if err := writer.writeHeader(); err != nil {
-		return nil, err
-	}
-
-	// Write audio data
-	if _, err := buf.Write(audioData); err != nil {
-		return nil, err
+	writer := &Writer{
+		sampleRate:    sampleRate,
+		channels:      channels,
+		bitsPerSample: bitsPerSample,
+		dataSize:      len(audioData),
 	}
-
-	return buf.data, nil
-}
-
-// bufferWriter is a helper for writing to a byte slice
-type bufferWriter struct {
-	data []byte
+	data := make([]byte, 0, len(audioData)+44)
+	data = append(data, writer.header()...)
+	return append(data, audioData...), nil
 }
 
-func (b *bufferWriter) Write(p []byte) (int, error) {
-	b.data = append(b.data, p...)
-	return len(p), nil
+// StreamHeader returns a WAV header for audio whose length isn't known yet,
+// e.g. one uploaded while it is still being recorded. The RIFF and data sizes
+// are set to 0xFFFFFFFF, which decoders read as "until end of stream".
+func StreamHeader(sampleRate, channels, bitsPerSample int) []byte {
+	writer := &Writer{sampleRate: sampleRate, channels: channels, bitsPerSample: bitsPerSample}
+	header := writer.header()
+	binary.LittleEndian.PutUint32(header[4:8], 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(header[40:44], 0xFFFFFFFF)
+	return header
 }
 
 // GetWAVHeaderSize returns the size of a WAV header
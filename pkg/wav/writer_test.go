@@ -2,12 +2,13 @@ package wav
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestNewWriter(t *testing.T) {
-	buf := &bytes.Buffer{}
-	writer := NewWriter(buf, 16000, 1, 16)
+	writer := NewWriter(tempFile(t), 16000, 1, 16)
 
 	if writer.sampleRate != 16000 {
 		t.Errorf("Expected sample rate 16000, got %d", writer.sampleRate)
@@ -70,8 +71,7 @@ func TestCalculateWAVSize(t *testing.T) {
 }
 
 func TestWriterToString(t *testing.T) {
-	buf := &bytes.Buffer{}
-	writer := NewWriter(buf, 16000, 1, 16)
+	writer := NewWriter(tempFile(t), 16000, 1, 16)
 
 	str := writer.ToString()
 	if str == "" {
@@ -111,3 +111,87 @@ func TestEncodeDifferentSampleRates(t *testing.T) {
 		_ = expectedRate
 	}
 }
+
+func TestStreamHeader(t *testing.T) {
+	header := StreamHeader(16000, 1, 16)
+	if len(header) != GetWAVHeaderSize() {
+		t.Fatalf("Expected a %d-byte header, got %d", GetWAVHeaderSize(), len(header))
+	}
+
+	full, _ := Encode(nil, 16000, 1, 16)
+	// Identical to a regular header apart from the two sizes
+	if !bytes.Equal(header[8:40], full[8:40]) {
+		t.Errorf("Expected the fmt chunk of a regular header, got %x", header[8:40])
+	}
+	for _, off := range []int{4, 40} {
+		if !bytes.Equal(header[off:off+4], []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+			t.Errorf("Expected an unknown size at offset %d, got %x", off, header[off:off+4])
+		}
+	}
+}
+
+// tempFile returns a file removed when the test ends
+func tempFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestWriterPatchesSizesOnClose(t *testing.T) {
+	audioData := make([]byte, 3200)
+	for i := range audioData {
+		audioData[i] = byte(i)
+	}
+
+	testCases := []struct {
+		name   string
+		chunks [][]byte
+	}{
+		{"one write", [][]byte{audioData}},
+		{"many writes", [][]byte{audioData[:1000], audioData[1000:1002], audioData[1002:]}},
+		{"empty", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := tempFile(t)
+			writer := NewWriter(f, 16000, 1, 16)
+			var want []byte
+			for _, chunk := range tc.chunks {
+				if _, err := writer.Write(chunk); err != nil {
+					t.Fatalf("Write() failed: %v", err)
+				}
+				want = append(want, chunk...)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() failed: %v", err)
+			}
+
+			data, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != CalculateWAVSize(len(want)) {
+				t.Fatalf("Expected %d bytes, got %d", CalculateWAVSize(len(want)), len(data))
+			}
+			format, pcm, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode() failed: %v", err)
+			}
+			if format != (Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16}) {
+				t.Errorf("Unexpected format %+v", format)
+			}
+			if !bytes.Equal(pcm, want) {
+				t.Errorf("Expected %d bytes of audio back, got %d", len(want), len(pcm))
+			}
+			encoded, _ := Encode(want, 16000, 1, 16)
+			if !bytes.Equal(data, encoded) {
+				t.Error("Expected the streamed file to match Encode")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+//go:build buildcheck
+
+// Package test holds checks on the module as a whole. Run them with
+// "go test -tags buildcheck ./test/", as "make build-check" does.
+package test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommandsBuild compiles every command, so a change to a shared package
+// can't leave the CLI or the GUI unbuildable
+func TestCommandsBuild(t *testing.T) {
+	gomod, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		t.Fatalf("go env GOMOD failed: %v", err)
+	}
+
+	cmd := exec.Command("go", "build", "./cmd/...")
+	cmd.Dir = filepath.Dir(strings.TrimSpace(string(gomod)))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build ./cmd/... failed: %v\n%s", err, out)
+	}
+}